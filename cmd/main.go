@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"rijig/config"
 	"rijig/internal/cart"
+	"rijig/internal/geoindex"
+	"rijig/internal/requestpickup"
 	"rijig/internal/trash"
 	"rijig/internal/worker"
 	"time"
@@ -19,22 +22,72 @@ import (
 
 func main() {
 	config.SetupConfig()
+
+	if err := geoindex.Rebuild(context.Background(), config.DB); err != nil {
+		log.Printf("Geo index rebuild error: %v", err)
+	}
+
 	cartRepo := cart.NewCartRepository()
 	trashRepo := trash.NewTrashRepository(config.DB)
-	cartService := cart.NewCartService(cartRepo, trashRepo)
-	worker := worker.NewCartWorker(cartService, cartRepo, trashRepo)
+	cartService := cart.NewCartService(cartRepo, trashRepo, trash.NewDefaultTrashRegionalPriceService(config.DB))
+	cartWorker := worker.NewCartWorker(cartService)
+
+	if indexed, err := cart.BackfillCartExpiryIndex(context.Background()); err != nil {
+		log.Printf("Cart expiry index backfill error: %v", err)
+	} else if indexed > 0 {
+		log.Printf("Indexed expiry for %d existing carts", indexed)
+	}
 
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			if err := worker.AutoCommitExpiringCarts(); err != nil {
+			if err := cartWorker.AutoCommitExpiringCarts(); err != nil {
 				log.Printf("Auto-commit error: %v", err)
 			}
 		}
 	}()
 
+	dispatchWorker := worker.NewDispatchWorker(requestpickup.NewDefaultPickupDispatchService())
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := dispatchWorker.DispatchPendingPickups(); err != nil {
+				log.Printf("Dispatch error: %v", err)
+			}
+		}
+	}()
+
+	subscriptionWorker := worker.NewSubscriptionWorker(requestpickup.NewDefaultPickupSubscriptionService())
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := subscriptionWorker.MaterializeSubscriptions(); err != nil {
+				log.Printf("Subscription error: %v", err)
+			}
+		}
+	}()
+
+	trashPriceWorker := worker.NewTrashPriceWorker(trash.NewTrashPriceService(trashRepo, trash.NewTrashPriceRepository(config.DB)))
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := trashPriceWorker.ApplyScheduledPrices(); err != nil {
+				log.Printf("Trash price error: %v", err)
+			}
+		}
+	}()
+
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
@@ -25,6 +25,37 @@ func enableUUIDExtension(db *gorm.DB) error {
 	return nil
 }
 
+// dropDuplicateCarts keeps only the most recently updated cart of each user so the
+// unique index on carts.user_id can be built. Database carts are snapshots of the
+// Redis cart, so the newest one is the user's current cart and the others are stale.
+func dropDuplicateCarts(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Cart{}) {
+		return nil
+	}
+
+	stale := `SELECT id FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY updated_at DESC, created_at DESC, id DESC) AS position
+		FROM carts
+	) ranked WHERE position > 1`
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Migrator().HasTable(&model.CartItem{}) {
+			if err := tx.Exec("DELETE FROM cart_items WHERE cart_id IN (" + stale + ")").Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Exec("DELETE FROM carts WHERE id IN (" + stale + ")")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Removed %d duplicate cart(s) before indexing carts.user_id", result.RowsAffected)
+		}
+		return nil
+	})
+}
+
 func RunMigrations(db *gorm.DB) error {
 	log.Println("Starting database migration...")
 
@@ -33,6 +64,11 @@ func RunMigrations(db *gorm.DB) error {
 		return err
 	}
 
+	if err := dropDuplicateCarts(db); err != nil {
+		log.Printf("Error removing duplicate carts: %v", err)
+		return err
+	}
+
 	err := db.AutoMigrate(
 		// Location models
 		&model.Province{},
@@ -55,20 +91,48 @@ func RunMigrations(db *gorm.DB) error {
 		&model.RequestPickupItem{},
 		&model.PickupStatusHistory{},
 		&model.PickupRating{},
+		&model.MatchingConfig{},
+		&model.PickupDispatchOffer{},
+		&model.CollectorAvailabilitySlot{},
+		&model.CollectorSlotBooking{},
+		&model.PickupSubscription{},
+		&model.PickupSubscriptionItem{},
+		&model.PickupSubscriptionSkip{},
 
 		// Cart related models
 		&model.Cart{},
 		&model.CartItem{},
+		&model.CartQuote{},
+		&model.CartQuoteItem{},
 
 		// Store related models
 		&model.Store{},
 		&model.Product{},
 		&model.ProductImage{},
+		&model.StoreOrder{},
+		&model.StoreOrderItem{},
 
 		// Content models
 		&model.Article{},
 		&model.Banner{},
 		&model.InitialCoint{},
+		&model.PointAccount{},
+		&model.PointTransaction{},
+		&model.PointEntry{},
+		&model.Wallet{},
+		&model.WalletTransaction{},
+		&model.WalletEntry{},
+		&model.WalletDispute{},
+		&model.Payment{},
+		&model.PaymentEvent{},
+		&model.PaymentSimulatorCharge{},
+		&model.FacilityIntake{},
+		&model.FacilityIntakePickup{},
+		&model.TraceLot{},
+		&model.TraceLink{},
+		&model.TraceRecord{},
+		&model.ImpactRecord{},
+		&model.ImpactRollup{},
 		&model.About{},
 		&model.AboutDetail{},
 		&model.CoverageArea{},
@@ -76,6 +140,8 @@ func RunMigrations(db *gorm.DB) error {
 		// Trash related models
 		&model.TrashCategory{},
 		&model.TrashDetail{},
+		&model.TrashCategoryPrice{},
+		&model.TrashCategoryRegionalPrice{},
 	)
 
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"rijig/config"
+	"rijig/internal/geoindex"
 	"rijig/internal/wilayahindo"
 	"rijig/model"
 	"rijig/utils"
@@ -229,6 +231,9 @@ func (s *addressService) UpdateAddress(ctx context.Context, userID, id string, a
 	s.cacheAddress(responseDTO)
 	s.invalidateAddressCaches(userID, "")
 
+	// collectors and open pickups at this address must move with it in the geo index
+	geoindex.SyncAddress(ctx, config.DB, address.ID)
+
 	return responseDTO, nil
 }
 
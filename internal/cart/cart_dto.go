@@ -3,6 +3,7 @@ package cart
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type RequestCartItemDTO struct {
@@ -17,9 +18,12 @@ type RequestCartDTO struct {
 type ResponseCartDTO struct {
 	ID                  string                `json:"id"`
 	UserID              string                `json:"user_id"`
+	AddressID           string                `json:"address_id,omitempty"`
 	TotalAmount         float64               `json:"total_amount"`
 	EstimatedTotalPrice float64               `json:"estimated_total_price"`
 	CartItems           []ResponseCartItemDTO `json:"cart_items"`
+	ExpiresAt           *time.Time            `json:"expires_at,omitempty"`
+	ExpiresInSeconds    int64                 `json:"expires_in_seconds,omitempty"`
 }
 
 type ResponseCartItemDTO struct {
@@ -28,7 +32,9 @@ type ResponseCartItemDTO struct {
 	TrashName              string  `json:"trash_name"`
 	TrashIcon              string  `json:"trash_icon"`
 	TrashPrice             float64 `json:"trash_price"`
+	PriceRegion            string  `json:"price_region,omitempty"`
 	Amount                 float64 `json:"amount"`
+	Unit                   string  `json:"unit"`
 	SubTotalEstimatedPrice float64 `json:"subtotal_estimated_price"`
 }
 
@@ -47,3 +53,30 @@ func (r *RequestCartDTO) ValidateRequestCartDTO() (map[string][]string, bool) {
 
 	return nil, true
 }
+
+type RequestCartQuoteDTO struct {
+	AddressID string `json:"address_id"`
+}
+
+type CartQuoteItemDTO struct {
+	TrashID                string  `json:"trash_id"`
+	TrashName              string  `json:"trash_name"`
+	TrashIcon              string  `json:"trash_icon"`
+	Amount                 float64 `json:"amount"`
+	Unit                   string  `json:"unit"`
+	PricePerKg             float64 `json:"price_per_kg"`
+	PriceRegion            string  `json:"price_region,omitempty"`
+	SubTotalEstimatedPrice float64 `json:"subtotal_estimated_price"`
+}
+
+type CartQuoteResponseDTO struct {
+	ID                  string             `json:"id"`
+	UserID              string             `json:"user_id"`
+	AddressID           string             `json:"address_id,omitempty"`
+	TotalAmount         float64            `json:"total_amount"`
+	EstimatedTotalPrice float64            `json:"estimated_total_price"`
+	Items               []CartQuoteItemDTO `json:"items"`
+	ExpiresAt           time.Time          `json:"expires_at"`
+	ExpiresInSeconds    int64              `json:"expires_in_seconds"`
+	Used                bool               `json:"used"`
+}
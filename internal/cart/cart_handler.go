@@ -1,6 +1,8 @@
 package cart
 
 import (
+	"errors"
+	"rijig/internal/trash"
 	"rijig/middleware"
 	"rijig/utils"
 
@@ -8,11 +10,12 @@ import (
 )
 
 type CartHandler struct {
-	cartService CartService
+	cartService  CartService
+	quoteService CartQuoteService
 }
 
-func NewCartHandler(cartService CartService) *CartHandler {
-	return &CartHandler{cartService: cartService}
+func NewCartHandler(cartService CartService, quoteService CartQuoteService) *CartHandler {
+	return &CartHandler{cartService: cartService, quoteService: quoteService}
 }
 
 func (h *CartHandler) AddOrUpdateItem(c *fiber.Ctx) error {
@@ -42,6 +45,9 @@ func (h *CartHandler) AddOrUpdateItem(c *fiber.Ctx) error {
 	}
 
 	if err := h.cartService.AddOrUpdateItem(c.Context(), claims.UserID, req); err != nil {
+		if errors.Is(err, trash.ErrInvalidQuantity) {
+			return utils.BadRequest(c, err.Error())
+		}
 		return utils.InternalServerError(c, "Gagal menambahkan item ke keranjang")
 	}
 
@@ -54,8 +60,11 @@ func (h *CartHandler) GetCart(c *fiber.Ctx) error {
 		return err
 	}
 
-	cart, err := h.cartService.GetCart(c.Context(), claims.UserID)
+	cart, err := h.cartService.GetCartForAddress(c.Context(), claims.UserID, c.Query("address_id"))
 	if err != nil {
+		if errors.Is(err, trash.ErrPriceAddressNotFound) {
+			return utils.NotFound(c, "Alamat tidak ditemukan")
+		}
 		return utils.InternalServerError(c, "Gagal mengambil data keranjang")
 	}
 
@@ -106,3 +115,61 @@ func (h *CartHandler) ClearCart(c *fiber.Ctx) error {
 
 	return utils.Success(c, "Keranjang berhasil dikosongkan")
 }
+
+func (h *CartHandler) CreateQuote(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequestCartQuoteDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Payload tidak valid", map[string][]string{
+			"request": {"Payload tidak valid"},
+		})
+	}
+
+	quote, err := h.quoteService.CreateQuote(c.Context(), claims.UserID, req.AddressID)
+	if err != nil {
+		return HandleQuoteError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Penawaran harga berhasil dibuat", quote)
+}
+
+func (h *CartHandler) GetQuote(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	quote, err := h.quoteService.GetQuote(c.Context(), claims.UserID, c.Params("quote_id"))
+	if err != nil {
+		return HandleQuoteError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Berhasil mengambil penawaran harga", quote)
+}
+
+// HandleQuoteError maps quote errors to responses. An expired quote answers 410 with
+// a quote_id field error so clients know to request a fresh quote.
+func HandleQuoteError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrQuoteNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrQuoteExpired):
+		return utils.ResponseErrorData(c, fiber.StatusGone, err.Error(), map[string][]string{
+			"quote_id": {"expired"},
+		})
+	case errors.Is(err, trash.ErrPriceAddressNotFound):
+		return utils.NotFound(c, "Alamat tidak ditemukan")
+	case errors.Is(err, ErrQuoteUsed), errors.Is(err, ErrQuoteCartChanged), errors.Is(err, ErrQuoteAddressMismatch):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), map[string][]string{
+			"quote_id": {err.Error()},
+		})
+	case errors.Is(err, ErrCartEmpty), errors.Is(err, ErrQuoteAddressRequired):
+		return utils.BadRequest(c, err.Error())
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
@@ -0,0 +1,79 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartQuoteRepository interface {
+	CreateQuote(ctx context.Context, quote *model.CartQuote) error
+	GetQuoteByID(ctx context.Context, quoteID string) (*model.CartQuote, error)
+	GetQuoteForUpdate(ctx context.Context, quoteID string) (*model.CartQuote, error)
+	MarkQuoteUsed(ctx context.Context, quoteID string, usedAt time.Time) error
+
+	WithTx(tx *gorm.DB) CartQuoteRepository
+}
+
+type cartQuoteRepository struct {
+	db *gorm.DB
+}
+
+func NewCartQuoteRepository() CartQuoteRepository {
+	return &cartQuoteRepository{db: config.DB}
+}
+
+func (r *cartQuoteRepository) WithTx(tx *gorm.DB) CartQuoteRepository {
+	return &cartQuoteRepository{db: tx}
+}
+
+func (r *cartQuoteRepository) CreateQuote(ctx context.Context, quote *model.CartQuote) error {
+	return r.db.WithContext(ctx).Create(quote).Error
+}
+
+func (r *cartQuoteRepository) GetQuoteByID(ctx context.Context, quoteID string) (*model.CartQuote, error) {
+	var quote model.CartQuote
+	err := r.db.WithContext(ctx).
+		Preload("Items.TrashCategory").
+		Where("id = ?", quoteID).
+		First(&quote).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *cartQuoteRepository) GetQuoteForUpdate(ctx context.Context, quoteID string) (*model.CartQuote, error) {
+	var quote model.CartQuote
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", quoteID).
+		First(&quote).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Where("quote_id = ?", quoteID).Find(&quote.Items).Error; err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *cartQuoteRepository) MarkQuoteUsed(ctx context.Context, quoteID string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.CartQuote{}).
+		Where("id = ? AND used_at IS NULL", quoteID).
+		Update("used_at", usedAt).Error
+}
@@ -0,0 +1,206 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"rijig/model"
+
+	"gorm.io/gorm"
+)
+
+const defaultCartQuoteTTL = 15 * time.Minute
+
+var (
+	ErrCartEmpty        = errors.New("keranjang kosong")
+	ErrQuoteNotFound    = errors.New("penawaran harga tidak ditemukan")
+	ErrQuoteExpired     = errors.New("penawaran harga sudah kedaluwarsa, silakan minta penawaran baru")
+	ErrQuoteUsed        = errors.New("penawaran harga sudah digunakan")
+	ErrQuoteCartChanged = errors.New("isi keranjang berubah sejak penawaran harga dibuat, silakan minta penawaran baru")
+
+	ErrQuoteAddressRequired = errors.New("alamat harus dipilih untuk penawaran harga")
+	ErrQuoteAddressMismatch = errors.New("penawaran harga dibuat untuk alamat lain, silakan minta penawaran baru")
+)
+
+type CartQuoteService interface {
+	CreateQuote(ctx context.Context, userID, addressID string) (*CartQuoteResponseDTO, error)
+	GetQuote(ctx context.Context, userID, quoteID string) (*CartQuoteResponseDTO, error)
+	ClaimQuote(ctx context.Context, tx *gorm.DB, userID, quoteID, addressID string) (*model.CartQuote, error)
+}
+
+type cartQuoteService struct {
+	quoteRepo   CartQuoteRepository
+	cartService CartService
+}
+
+func NewCartQuoteService(quoteRepo CartQuoteRepository, cartService CartService) CartQuoteService {
+	return &cartQuoteService{
+		quoteRepo:   quoteRepo,
+		cartService: cartService,
+	}
+}
+
+// cartQuoteTTL reads CART_QUOTE_TTL, how long quoted prices stay valid.
+func cartQuoteTTL() time.Duration {
+	raw := os.Getenv("CART_QUOTE_TTL")
+	if raw == "" {
+		return defaultCartQuoteTTL
+	}
+
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl <= 0 {
+		log.Printf("[CART] invalid CART_QUOTE_TTL %q, using %s", raw, defaultCartQuoteTTL)
+		return defaultCartQuoteTTL
+	}
+	return ttl
+}
+
+// CreateQuote prices the cart for the address the pickup will be made from, since
+// regional prices depend on it.
+func (s *cartQuoteService) CreateQuote(ctx context.Context, userID, addressID string) (*CartQuoteResponseDTO, error) {
+	if addressID == "" {
+		return nil, ErrQuoteAddressRequired
+	}
+
+	cart, err := s.cartService.GetCartForAddress(ctx, userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.CartItems) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	quote := &model.CartQuote{
+		UserID:    userID,
+		AddressID: &addressID,
+		ExpiresAt: time.Now().Add(cartQuoteTTL()),
+	}
+	for _, item := range cart.CartItems {
+		quote.Items = append(quote.Items, model.CartQuoteItem{
+			TrashCategoryID:        item.TrashID,
+			Amount:                 item.Amount,
+			Unit:                   item.Unit,
+			PricePerKg:             item.TrashPrice,
+			PriceRegion:            item.PriceRegion,
+			SubTotalEstimatedPrice: item.SubTotalEstimatedPrice,
+		})
+		quote.TotalAmount += item.Amount
+		quote.EstimatedTotalPrice += item.SubTotalEstimatedPrice
+	}
+
+	if err := s.quoteRepo.CreateQuote(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	return s.GetQuote(ctx, userID, quote.ID)
+}
+
+func (s *cartQuoteService) GetQuote(ctx context.Context, userID, quoteID string) (*CartQuoteResponseDTO, error) {
+	quote, err := s.quoteRepo.GetQuoteByID(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.UserID != userID {
+		return nil, ErrQuoteNotFound
+	}
+
+	return toCartQuoteResponse(quote), nil
+}
+
+// ClaimQuote locks the quote inside tx and marks it used. It fails when the quote is
+// not the user's, has expired, was already used, was priced for another address, or
+// no longer matches the cart.
+func (s *cartQuoteService) ClaimQuote(ctx context.Context, tx *gorm.DB, userID, quoteID, addressID string) (*model.CartQuote, error) {
+	quoteRepo := s.quoteRepo.WithTx(tx)
+
+	quote, err := quoteRepo.GetQuoteForUpdate(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.UserID != userID {
+		return nil, ErrQuoteNotFound
+	}
+	if quote.UsedAt != nil {
+		return nil, ErrQuoteUsed
+	}
+	if quote.AddressID == nil || *quote.AddressID != addressID {
+		return nil, ErrQuoteAddressMismatch
+	}
+
+	now := time.Now()
+	if !now.Before(quote.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+
+	cart, err := s.cartService.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.CartItems) == 0 {
+		return nil, ErrCartEmpty
+	}
+	if !quoteMatchesCart(quote, cart) {
+		return nil, ErrQuoteCartChanged
+	}
+
+	if err := quoteRepo.MarkQuoteUsed(ctx, quote.ID, now); err != nil {
+		return nil, err
+	}
+	quote.UsedAt = &now
+
+	return quote, nil
+}
+
+func quoteMatchesCart(quote *model.CartQuote, cart *ResponseCartDTO) bool {
+	if len(quote.Items) != len(cart.CartItems) {
+		return false
+	}
+
+	amounts := make(map[string]float64, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		amounts[item.TrashID] = item.Amount
+	}
+
+	for _, item := range quote.Items {
+		amount, ok := amounts[item.TrashCategoryID]
+		if !ok || amount != item.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+func toCartQuoteResponse(quote *model.CartQuote) *CartQuoteResponseDTO {
+	items := make([]CartQuoteItemDTO, 0, len(quote.Items))
+	for _, item := range quote.Items {
+		items = append(items, CartQuoteItemDTO{
+			TrashID:                item.TrashCategoryID,
+			TrashName:              item.TrashCategory.Name,
+			TrashIcon:              item.TrashCategory.IconTrash,
+			Amount:                 item.Amount,
+			Unit:                   item.Unit,
+			PricePerKg:             item.PricePerKg,
+			PriceRegion:            item.PriceRegion,
+			SubTotalEstimatedPrice: item.SubTotalEstimatedPrice,
+		})
+	}
+
+	response := &CartQuoteResponseDTO{
+		ID:                  quote.ID,
+		UserID:              quote.UserID,
+		TotalAmount:         quote.TotalAmount,
+		EstimatedTotalPrice: quote.EstimatedTotalPrice,
+		Items:               items,
+		ExpiresAt:           quote.ExpiresAt,
+		ExpiresInSeconds:    int64(math.Max(0, time.Until(quote.ExpiresAt).Seconds())),
+		Used:                quote.UsedAt != nil,
+	}
+	if quote.AddressID != nil {
+		response.AddressID = *quote.AddressID
+	}
+	return response
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"rijig/config"
@@ -14,59 +15,170 @@ import (
 const CartTTL = 30 * time.Minute
 const CartKeyPrefix = "cart:"
 
+// CartExpiryIndexKey is a sorted set of user IDs scored by the unix time their cart
+// expires, so the worker can find expiring carts without scanning the keyspace.
+const CartExpiryIndexKey = "carts:expiry"
+
+// cartCommitGrace keeps the Redis key alive past the advertised expiry, so a cart is
+// never lost when the commit worker runs late.
+const cartCommitGrace = 10 * time.Minute
+
+// CachedCart is the cart as stored in Redis. Version increases on every change and is
+// what makes committing to the database idempotent.
+type CachedCart struct {
+	CartItems []RequestCartItemDTO `json:"cart_items"`
+	Version   int64                `json:"version"`
+	ExpiresAt time.Time            `json:"-"`
+}
+
+// deleteIfUnchangedScript removes the cart only if nobody changed it since it was read.
+var deleteIfUnchangedScript = redis.NewScript(`
+local raw = redis.call('GET', KEYS[1])
+if not raw then
+	redis.call('ZREM', KEYS[2], ARGV[2])
+	return 1
+end
+if (cjson.decode(raw).version or 0) == tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+	redis.call('ZREM', KEYS[2], ARGV[2])
+	return 1
+end
+return 0
+`)
+
 func buildCartKey(userID string) string {
 	return fmt.Sprintf("%s%s", CartKeyPrefix, userID)
 }
 
-func SetCartToRedis(ctx context.Context, userID string, cart RequestCartDTO) error {
+func SetCartToRedis(ctx context.Context, userID string, cart CachedCart) error {
+	_, err := writeCart(ctx, userID, cart, false)
+	return err
+}
+
+// setCartToRedisIfAbsent writes the cart only when the user has no Redis cart yet.
+func setCartToRedisIfAbsent(ctx context.Context, userID string, cart CachedCart) (bool, error) {
+	return writeCart(ctx, userID, cart, true)
+}
+
+func writeCart(ctx context.Context, userID string, cart CachedCart, onlyIfAbsent bool) (bool, error) {
 	data, err := json.Marshal(cart)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	key := buildCartKey(userID)
+	if onlyIfAbsent {
+		set, err := config.RedisClient.SetNX(ctx, key, data, CartTTL+cartCommitGrace).Result()
+		if err != nil || !set {
+			return false, err
+		}
+		return true, trackCartExpiry(ctx, config.RedisClient, userID)
 	}
 
-	return config.RedisClient.Set(ctx, buildCartKey(userID), data, CartTTL).Err()
+	_, err = config.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, CartTTL+cartCommitGrace)
+		return trackCartExpiry(ctx, pipe, userID)
+	})
+	return err == nil, err
 }
 
 func RefreshCartTTL(ctx context.Context, userID string) error {
-	return config.RedisClient.Expire(ctx, buildCartKey(userID), CartTTL).Err()
+	_, err := config.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, buildCartKey(userID), CartTTL+cartCommitGrace)
+		return trackCartExpiry(ctx, pipe, userID)
+	})
+	return err
 }
 
-func GetCartFromRedis(ctx context.Context, userID string) (*RequestCartDTO, error) {
-	val, err := config.RedisClient.Get(ctx, buildCartKey(userID)).Result()
+func trackCartExpiry(ctx context.Context, cmd redis.Cmdable, userID string) error {
+	return cmd.ZAdd(ctx, CartExpiryIndexKey, &redis.Z{
+		Score:  float64(time.Now().Add(CartTTL).Unix()),
+		Member: userID,
+	}).Err()
+}
+
+func GetCartFromRedis(ctx context.Context, userID string) (*CachedCart, error) {
+	pipe := config.RedisClient.Pipeline()
+	getCmd := pipe.Get(ctx, buildCartKey(userID))
+	scoreCmd := pipe.ZScore(ctx, CartExpiryIndexKey, userID)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	val, err := getCmd.Result()
 	if err == redis.Nil {
 		return nil, nil
 	} else if err != nil {
 		return nil, err
 	}
 
-	var cart RequestCartDTO
+	var cart CachedCart
 	if err := json.Unmarshal([]byte(val), &cart); err != nil {
 		return nil, err
 	}
+
+	if score, err := scoreCmd.Result(); err == nil {
+		cart.ExpiresAt = time.Unix(int64(score), 0)
+	} else {
+		cart.ExpiresAt = time.Now().Add(CartTTL)
+	}
 	return &cart, nil
 }
 
 func DeleteCartFromRedis(ctx context.Context, userID string) error {
-	return config.RedisClient.Del(ctx, buildCartKey(userID)).Err()
+	_, err := config.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, buildCartKey(userID))
+		pipe.ZRem(ctx, CartExpiryIndexKey, userID)
+		return nil
+	})
+	return err
 }
 
-func GetExpiringCartKeys(ctx context.Context, threshold time.Duration) ([]string, error) {
-	keys, err := config.RedisClient.Keys(ctx, CartKeyPrefix+"*").Result()
+// DeleteCartFromRedisIfUnchanged drops the cart after a commit, unless the user edited
+// it in the meantime; in that case the newer cart stays and is committed later.
+func DeleteCartFromRedisIfUnchanged(ctx context.Context, userID string, version int64) (bool, error) {
+	deleted, err := deleteIfUnchangedScript.Run(ctx, config.RedisClient,
+		[]string{buildCartKey(userID), CartExpiryIndexKey}, version, userID).Int()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
+	return deleted == 1, nil
+}
+
+// GetExpiringCartUserIDs returns users whose cart expires within threshold.
+func GetExpiringCartUserIDs(ctx context.Context, threshold time.Duration) ([]string, error) {
+	return config.RedisClient.ZRangeByScore(ctx, CartExpiryIndexKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Add(threshold).Unix(), 10),
+	}).Result()
+}
+
+// BackfillCartExpiryIndex indexes carts written before the expiry index existed. It
+// uses SCAN so it is safe to run against a live Redis at startup.
+func BackfillCartExpiryIndex(ctx context.Context) (int, error) {
+	indexed := 0
+	iter := config.RedisClient.Scan(ctx, 0, CartKeyPrefix+"*", 200).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		userID := key[len(CartKeyPrefix):]
+
+		if _, err := config.RedisClient.ZScore(ctx, CartExpiryIndexKey, userID).Result(); err == nil {
+			continue
+		}
 
-	var expiringKeys []string
-	for _, key := range keys {
 		ttl, err := config.RedisClient.TTL(ctx, key).Result()
-		if err != nil {
+		if err != nil || ttl <= 0 {
 			continue
 		}
 
-		if ttl > 0 && ttl <= threshold {
-			expiringKeys = append(expiringKeys, key)
+		err = config.RedisClient.ZAdd(ctx, CartExpiryIndexKey, &redis.Z{
+			Score:  float64(time.Now().Add(ttl).Unix()),
+			Member: userID,
+		}).Err()
+		if err != nil {
+			return indexed, err
 		}
+		indexed++
 	}
-
-	return expiringKeys, nil
+	return indexed, iter.Err()
 }
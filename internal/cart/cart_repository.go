@@ -9,6 +9,7 @@ import (
 	"rijig/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CartRepository interface {
@@ -19,7 +20,7 @@ type CartRepository interface {
 	UpdateCartTotals(ctx context.Context, cartID string) error
 	DeleteCart(ctx context.Context, userID string) error
 
-	CreateCartWithItems(ctx context.Context, cart *model.Cart) error
+	SaveCartSnapshot(ctx context.Context, userID string, version int64, items []model.CartItem) (bool, error)
 	HasExistingCart(ctx context.Context, userID string) (bool, error)
 }
 
@@ -131,22 +132,70 @@ func (r *cartRepository) UpdateCartTotals(ctx context.Context, cartID string) er
 
 func (r *cartRepository) DeleteCart(ctx context.Context, userID string) error {
 	db := config.DB.WithContext(ctx)
-	var cart model.Cart
-	if err := db.Where("user_id = ?", userID).First(&cart).Error; err != nil {
-		return err
-	}
-	return db.Delete(&cart).Error
+	return db.Where("user_id = ?", userID).Delete(&model.Cart{}).Error
 }
 
-func (r *cartRepository) CreateCartWithItems(ctx context.Context, cart *model.Cart) error {
-	db := config.DB.WithContext(ctx)
+// SaveCartSnapshot replaces the user's stored cart with items, in one transaction. A
+// snapshot whose version is not newer than the stored one is ignored, so committing
+// the same Redis cart twice is harmless. It reports whether anything was written.
+func (r *cartRepository) SaveCartSnapshot(ctx context.Context, userID string, version int64, items []model.CartItem) (bool, error) {
+	applied := false
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		insert := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoNothing: true,
+		}).Create(&model.Cart{UserID: userID})
+		if insert.Error != nil {
+			return fmt.Errorf("failed to create cart: %w", insert.Error)
+		}
+		created := insert.RowsAffected == 1
+
+		var cart model.Cart
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&cart).Error; err != nil {
+			return fmt.Errorf("failed to lock cart: %w", err)
+		}
+
+		if !created && cart.Version >= version {
+			return nil
+		}
 
-	return db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(cart).Error; err != nil {
-			return fmt.Errorf("failed to create cart: %w", err)
+		if err := tx.Where("cart_id = ?", cart.ID).Delete(&model.CartItem{}).Error; err != nil {
+			return fmt.Errorf("failed to clear cart items: %w", err)
 		}
+
+		totalAmount := 0.0
+		totalPrice := 0.0
+		for i := range items {
+			items[i].ID = ""
+			items[i].CartID = cart.ID
+			totalAmount += items[i].Amount
+			totalPrice += items[i].SubTotalEstimatedPrice
+		}
+
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return fmt.Errorf("failed to save cart items: %w", err)
+			}
+		}
+
+		if err := tx.Model(&model.Cart{}).
+			Where("id = ?", cart.ID).
+			Updates(map[string]interface{}{
+				"total_amount":          totalAmount,
+				"estimated_total_price": totalPrice,
+				"version":               version,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update cart totals: %w", err)
+		}
+
+		applied = true
 		return nil
 	})
+
+	return applied, err
 }
 
 func (r *cartRepository) HasExistingCart(ctx context.Context, userID string) (bool, error) {
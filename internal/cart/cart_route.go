@@ -11,8 +11,9 @@ import (
 func TrashCartRouter(api fiber.Router) {
 	repo := NewCartRepository()
 	trashRepo := trash.NewTrashRepository(config.DB)
-	cartService := NewCartService(repo, trashRepo)
-	cartHandler := NewCartHandler(cartService)
+	cartService := NewCartService(repo, trashRepo, trash.NewDefaultTrashRegionalPriceService(config.DB))
+	quoteService := NewCartQuoteService(NewCartQuoteRepository(), cartService)
+	cartHandler := NewCartHandler(cartService, quoteService)
 
 	cart := api.Group("/cart")
 	cart.Use(middleware.AuthMiddleware())
@@ -21,4 +22,6 @@ func TrashCartRouter(api fiber.Router) {
 	cart.Post("/item", cartHandler.AddOrUpdateItem)
 	cart.Delete("/item/:trash_id", cartHandler.DeleteItem)
 	cart.Delete("/clear", cartHandler.ClearCart)
+	cart.Post("/quote", cartHandler.CreateQuote)
+	cart.Get("/quote/:quote_id", cartHandler.GetQuote)
 }
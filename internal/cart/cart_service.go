@@ -4,28 +4,35 @@ import (
 	"context"
 	"errors"
 	"log"
+	"math"
+	"time"
 
 	// "rijig/dto"
 	// "rijig/internal/repositories"
 	"rijig/internal/trash"
 	"rijig/model"
+
+	"gorm.io/gorm"
 )
 
 type CartService interface {
 	AddOrUpdateItem(ctx context.Context, userID string, req RequestCartItemDTO) error
 	GetCart(ctx context.Context, userID string) (*ResponseCartDTO, error)
+	GetCartForAddress(ctx context.Context, userID, addressID string) (*ResponseCartDTO, error)
 	DeleteItem(ctx context.Context, userID string, trashID string) error
 	ClearCart(ctx context.Context, userID string) error
 	Checkout(ctx context.Context, userID string) error
+	CommitCart(ctx context.Context, userID string) (bool, error)
 }
 
 type cartService struct {
-	repo      CartRepository
-	trashRepo trash.TrashRepositoryInterface
+	repo            CartRepository
+	trashRepo       trash.TrashRepositoryInterface
+	regionalService trash.TrashRegionalPriceServiceInterface
 }
 
-func NewCartService(repo CartRepository, trashRepo trash.TrashRepositoryInterface) CartService {
-	return &cartService{repo, trashRepo}
+func NewCartService(repo CartRepository, trashRepo trash.TrashRepositoryInterface, regionalService trash.TrashRegionalPriceServiceInterface) CartService {
+	return &cartService{repo, trashRepo, regionalService}
 }
 
 func (s *cartService) AddOrUpdateItem(ctx context.Context, userID string, req RequestCartItemDTO) error {
@@ -33,20 +40,17 @@ func (s *cartService) AddOrUpdateItem(ctx context.Context, userID string, req Re
 		return errors.New("amount harus lebih dari 0")
 	}
 
-	_, err := s.trashRepo.GetTrashCategoryByID(ctx, req.TrashID)
+	category, err := s.trashRepo.GetTrashCategoryByID(ctx, req.TrashID)
 	if err != nil {
 		return err
 	}
-
-	existingCart, err := GetCartFromRedis(ctx, userID)
-	if err != nil {
+	if err := trash.ValidateQuantity(category, req.Amount); err != nil {
 		return err
 	}
 
-	if existingCart == nil {
-		existingCart = &RequestCartDTO{
-			CartItems: []RequestCartItemDTO{},
-		}
+	existingCart, err := s.loadCart(ctx, userID)
+	if err != nil {
+		return err
 	}
 
 	updated := false
@@ -65,28 +69,28 @@ func (s *cartService) AddOrUpdateItem(ctx context.Context, userID string, req Re
 		})
 	}
 
+	existingCart.Version++
 	return SetCartToRedis(ctx, userID, *existingCart)
 }
 
 func (s *cartService) GetCart(ctx context.Context, userID string) (*ResponseCartDTO, error) {
+	return s.GetCartForAddress(ctx, userID, "")
+}
 
-	cached, err := GetCartFromRedis(ctx, userID)
+// GetCartForAddress prices the cart with the regional prices of one of the user's
+// addresses; an empty addressID uses national prices.
+func (s *cartService) GetCartForAddress(ctx context.Context, userID, addressID string) (*ResponseCartDTO, error) {
+	prices, err := s.regionalService.ResolveForAddress(ctx, userID, addressID)
 	if err != nil {
 		return nil, err
 	}
 
-	if cached != nil {
-
-		if err := RefreshCartTTL(ctx, userID); err != nil {
-			log.Printf("Warning: Failed to refresh cart TTL for user %s: %v", userID, err)
-		}
-
-		return s.buildResponseFromCache(ctx, userID, cached)
-	}
-
-	cart, err := s.repo.GetCartByUser(ctx, userID)
+	cached, err := s.loadCart(ctx, userID)
 	if err != nil {
+		return nil, err
+	}
 
+	if len(cached.CartItems) == 0 {
 		return &ResponseCartDTO{
 			ID:                  "",
 			UserID:              userID,
@@ -94,33 +98,23 @@ func (s *cartService) GetCart(ctx context.Context, userID string) (*ResponseCart
 			EstimatedTotalPrice: 0,
 			CartItems:           []ResponseCartItemDTO{},
 		}, nil
-
-	}
-
-	response := s.buildResponseFromDB(cart)
-
-	cacheData := RequestCartDTO{CartItems: []RequestCartItemDTO{}}
-	for _, item := range cart.CartItems {
-		cacheData.CartItems = append(cacheData.CartItems, RequestCartItemDTO{
-			TrashID: item.TrashCategoryID,
-			Amount:  item.Amount,
-		})
 	}
 
-	if err := SetCartToRedis(ctx, userID, cacheData); err != nil {
-		log.Printf("Warning: Failed to cache cart for user %s: %v", userID, err)
+	if err := RefreshCartTTL(ctx, userID); err != nil {
+		log.Printf("Warning: Failed to refresh cart TTL for user %s: %v", userID, err)
+	} else {
+		cached.ExpiresAt = time.Now().Add(CartTTL)
 	}
 
-	return response, nil
+	return s.buildResponseFromCache(ctx, userID, cached, prices)
 }
 
 func (s *cartService) DeleteItem(ctx context.Context, userID string, trashID string) error {
-
-	existingCart, err := GetCartFromRedis(ctx, userID)
+	existingCart, err := s.loadCart(ctx, userID)
 	if err != nil {
 		return err
 	}
-	if existingCart == nil {
+	if len(existingCart.CartItems) == 0 {
 		return errors.New("keranjang tidak ditemukan")
 	}
 
@@ -132,6 +126,7 @@ func (s *cartService) DeleteItem(ctx context.Context, userID string, trashID str
 	}
 	existingCart.CartItems = filtered
 
+	existingCart.Version++
 	return SetCartToRedis(ctx, userID, *existingCart)
 }
 
@@ -146,122 +141,156 @@ func (s *cartService) ClearCart(ctx context.Context, userID string) error {
 
 func (s *cartService) Checkout(ctx context.Context, userID string) error {
 
-	cachedCart, err := GetCartFromRedis(ctx, userID)
+	if _, err := s.CommitCart(ctx, userID); err != nil {
+		return err
+	}
+
+	_, err := s.repo.GetCartByUser(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	if cachedCart != nil {
-		if err := s.commitCartFromRedis(ctx, userID, cachedCart); err != nil {
-			return err
+	return s.ClearCart(ctx, userID)
+}
+
+// CommitCart persists the user's Redis cart to the database and then drops it from
+// Redis. It is safe to call repeatedly: a version already stored is not written again,
+// and a cart edited while committing stays in Redis for the next run.
+func (s *cartService) CommitCart(ctx context.Context, userID string) (bool, error) {
+	cached, err := GetCartFromRedis(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if cached == nil {
+		// the key is gone already; only the expiry index entry is left to drop
+		_, err := DeleteCartFromRedisIfUnchanged(ctx, userID, 0)
+		return false, err
+	}
+
+	applied, err := s.repo.SaveCartSnapshot(ctx, userID, cached.Version, s.buildCartItems(ctx, cached))
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := DeleteCartFromRedisIfUnchanged(ctx, userID, cached.Version); err != nil {
+		log.Printf("Warning: Failed to drop committed cart for user %s: %v", userID, err)
+	}
+
+	return applied, nil
+}
+
+// loadCart returns the user's Redis cart. When Redis has none, the cart saved in the
+// database is restored into Redis so the user continues where they left off.
+func (s *cartService) loadCart(ctx context.Context, userID string) (*CachedCart, error) {
+	cached, err := GetCartFromRedis(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		return cached, nil
+	}
+
+	stored, err := s.repo.GetCartByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &CachedCart{CartItems: []RequestCartItemDTO{}}, nil
 		}
+		return nil, err
+	}
+
+	restored := CachedCart{CartItems: []RequestCartItemDTO{}, Version: stored.Version}
+	for _, item := range stored.CartItems {
+		restored.CartItems = append(restored.CartItems, RequestCartItemDTO{
+			TrashID: item.TrashCategoryID,
+			Amount:  item.Amount,
+		})
+	}
+
+	if len(restored.CartItems) == 0 {
+		return &restored, nil
 	}
 
-	_, err = s.repo.GetCartByUser(ctx, userID)
+	set, err := setCartToRedisIfAbsent(ctx, userID, restored)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !set {
+		// another request restored or recreated the cart first
+		if current, err := GetCartFromRedis(ctx, userID); err != nil || current != nil {
+			return current, err
+		}
 	}
 
-	DeleteCartFromRedis(ctx, userID)
-	return s.repo.DeleteCart(ctx, userID)
+	restored.ExpiresAt = time.Now().Add(CartTTL)
+	return &restored, nil
 }
 
-func (s *cartService) buildResponseFromCache(ctx context.Context, userID string, cached *RequestCartDTO) (*ResponseCartDTO, error) {
+func (s *cartService) buildResponseFromCache(ctx context.Context, userID string, cached *CachedCart, prices *trash.RegionalPrices) (*ResponseCartDTO, error) {
 	totalQty := 0.0
 	totalPrice := 0.0
 	items := []ResponseCartItemDTO{}
 
 	for _, item := range cached.CartItems {
-		trash, err := s.trashRepo.GetTrashCategoryByID(ctx, item.TrashID)
+		category, err := s.trashRepo.GetTrashCategoryByID(ctx, item.TrashID)
 		if err != nil {
 			log.Printf("Warning: Trash category %s not found for cached cart item", item.TrashID)
 			continue
 		}
 
-		subtotal := item.Amount * trash.EstimatedPrice
+		price, region := prices.PriceFor(category)
+		subtotal := item.Amount * price
 		totalQty += item.Amount
 		totalPrice += subtotal
 
 		items = append(items, ResponseCartItemDTO{
 			ID:                     "",
 			TrashID:                item.TrashID,
-			TrashName:              trash.Name,
-			TrashIcon:              trash.IconTrash,
-			TrashPrice:             trash.EstimatedPrice,
+			TrashName:              category.Name,
+			TrashIcon:              category.IconTrash,
+			TrashPrice:             price,
+			PriceRegion:            region,
 			Amount:                 item.Amount,
+			Unit:                   trash.UnitOf(category),
 			SubTotalEstimatedPrice: subtotal,
 		})
 	}
 
-	return &ResponseCartDTO{
+	expiresAt := cached.ExpiresAt
+	response := &ResponseCartDTO{
 		ID:                  "-",
 		UserID:              userID,
 		TotalAmount:         totalQty,
 		EstimatedTotalPrice: totalPrice,
 		CartItems:           items,
-	}, nil
-}
-
-func (s *cartService) buildResponseFromDB(cart *model.Cart) *ResponseCartDTO {
-	var items []ResponseCartItemDTO
-	for _, item := range cart.CartItems {
-		items = append(items, ResponseCartItemDTO{
-			ID:                     item.ID,
-			TrashID:                item.TrashCategoryID,
-			TrashName:              item.TrashCategory.Name,
-			TrashIcon:              item.TrashCategory.IconTrash,
-			TrashPrice:             item.TrashCategory.EstimatedPrice,
-			Amount:                 item.Amount,
-			SubTotalEstimatedPrice: item.SubTotalEstimatedPrice,
-		})
+		ExpiresAt:           &expiresAt,
+		ExpiresInSeconds:    int64(math.Max(0, time.Until(expiresAt).Seconds())),
 	}
-
-	return &ResponseCartDTO{
-		ID:                  cart.ID,
-		UserID:              cart.UserID,
-		TotalAmount:         cart.TotalAmount,
-		EstimatedTotalPrice: cart.EstimatedTotalPrice,
-		CartItems:           items,
+	if prices != nil {
+		response.AddressID = prices.AddressID
 	}
+	return response, nil
 }
 
-func (s *cartService) commitCartFromRedis(ctx context.Context, userID string, cachedCart *RequestCartDTO) error {
-	if len(cachedCart.CartItems) == 0 {
-		return nil
-	}
-
-	totalAmount := 0.0
-	totalPrice := 0.0
+func (s *cartService) buildCartItems(ctx context.Context, cached *CachedCart) []model.CartItem {
 	var cartItems []model.CartItem
 
-	for _, item := range cachedCart.CartItems {
+	for _, item := range cached.CartItems {
+		if item.Amount <= 0 {
+			continue
+		}
+
 		trash, err := s.trashRepo.GetTrashCategoryByID(ctx, item.TrashID)
 		if err != nil {
 			log.Printf("Warning: Skipping invalid trash category %s during commit", item.TrashID)
 			continue
 		}
 
-		subtotal := item.Amount * trash.EstimatedPrice
-		totalAmount += item.Amount
-		totalPrice += subtotal
-
 		cartItems = append(cartItems, model.CartItem{
 			TrashCategoryID:        item.TrashID,
 			Amount:                 item.Amount,
-			SubTotalEstimatedPrice: subtotal,
+			SubTotalEstimatedPrice: item.Amount * trash.EstimatedPrice,
 		})
 	}
 
-	if len(cartItems) == 0 {
-		return nil
-	}
-
-	newCart := &model.Cart{
-		UserID:              userID,
-		TotalAmount:         totalAmount,
-		EstimatedTotalPrice: totalPrice,
-		CartItems:           cartItems,
-	}
-
-	return s.repo.CreateCartWithItems(ctx, newCart)
+	return cartItems
 }
@@ -264,3 +264,71 @@ func (r *BulkUpdateAvailableTrashRequest) ValidateBulkUpdateAvailableTrashReques
 
 	return nil, true
 }
+
+type CollectorSlotRequest struct {
+	Weekday   *int   `json:"weekday"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Capacity  int    `json:"capacity,omitempty"`
+	IsActive  *bool  `json:"is_active,omitempty"`
+}
+
+type CollectorSlotResponse struct {
+	ID          string `json:"id"`
+	CollectorID string `json:"collector_id"`
+	Weekday     int    `json:"weekday"`
+	WeekdayName string `json:"weekday_name"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	Capacity    int    `json:"capacity"`
+	IsActive    bool   `json:"is_active"`
+}
+
+var weekdayNames = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+func (r *CollectorSlotRequest) ValidateCollectorSlotRequest() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if r.Weekday == nil {
+		errors["weekday"] = append(errors["weekday"], "Weekday is required")
+	} else if *r.Weekday < 0 || *r.Weekday > 6 {
+		errors["weekday"] = append(errors["weekday"], "Weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	r.StartTime = strings.TrimSpace(r.StartTime)
+	r.EndTime = strings.TrimSpace(r.EndTime)
+
+	startOK := IsValidClock(r.StartTime)
+	endOK := IsValidClock(r.EndTime)
+	if !startOK {
+		errors["start_time"] = append(errors["start_time"], "Start time must use HH:MM format")
+	}
+	if !endOK {
+		errors["end_time"] = append(errors["end_time"], "End time must use HH:MM format")
+	}
+	if startOK && endOK && r.StartTime >= r.EndTime {
+		errors["end_time"] = append(errors["end_time"], "End time must be after start time")
+	}
+
+	if r.Capacity < 0 {
+		errors["capacity"] = append(errors["capacity"], "Capacity must be at least 1")
+	}
+	if r.Capacity == 0 {
+		r.Capacity = 1
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+
+	return nil, true
+}
+
+// IsValidClock accepts zero padded 24h "HH:MM", which keeps slot times comparable as strings.
+func IsValidClock(value string) bool {
+	if len(value) != 5 {
+		return false
+	}
+	_, err := time.Parse("15:04", value)
+	return err == nil
+}
@@ -29,6 +29,7 @@ func (h *CollectorHandler) CreateCollector(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return utils.BadRequest(c, "Invalid request format")
 	}
+	req.UserID = claims.UserID
 
 	errors, isValid := req.ValidateCreateCollectorRequest()
 	if !isValid {
@@ -42,6 +43,12 @@ func (h *CollectorHandler) CreateCollector(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "already exists") {
 			return utils.BadRequest(c, err.Error())
 		}
+		if strings.Contains(err.Error(), "does not belong") {
+			return utils.Forbidden(c, "Address does not belong to this user")
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFound(c, "Address not found")
+		}
 		return utils.InternalServerError(c, "Failed to create collector")
 	}
 
@@ -111,6 +118,9 @@ func (h *CollectorHandler) UpdateCollector(c *fiber.Ctx) error {
 
 	collector, err := h.collectorService.UpdateCollector(c.Context(), claims.UserID, &req)
 	if err != nil {
+		if strings.Contains(err.Error(), "does not belong") {
+			return utils.Forbidden(c, "Address does not belong to this user")
+		}
 		if strings.Contains(err.Error(), "not found") {
 			return utils.NotFound(c, "Collector not found")
 		}
@@ -308,6 +318,80 @@ func (h *CollectorHandler) UpdateAvailableTrash(c *fiber.Ctx) error {
 	return utils.Success(c, "Available trash updated successfully")
 }
 
+func (h *CollectorHandler) UpdateMyJobStatus(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		JobStatus string `json:"job_status" binding:"required"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(c, "Invalid request format")
+	}
+
+	jobStatus := strings.ToLower(strings.TrimSpace(req.JobStatus))
+	if !h.isValidJobStatus(jobStatus, []string{"active", "inactive"}) {
+		return utils.BadRequest(c, "Invalid job status. Valid statuses: active, inactive")
+	}
+
+	collector, err := h.collectorService.GetCollectorByUserID(c.Context(), claims.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFound(c, "Collector not found for this user")
+		}
+		return utils.InternalServerError(c, "Failed to get collector")
+	}
+
+	if err := h.collectorService.UpdateJobStatus(c.Context(), collector.ID, jobStatus); err != nil {
+		return utils.InternalServerError(c, "Failed to update job status")
+	}
+
+	return utils.SuccessWithData(c, "Job status updated successfully", fiber.Map{
+		"collector_id": collector.ID,
+		"job_status":   jobStatus,
+	})
+}
+
+func (h *CollectorHandler) UpdateMyAvailableTrash(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	collector, err := h.collectorService.GetCollectorByUserID(c.Context(), claims.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFound(c, "Collector not found for this user")
+		}
+		return utils.InternalServerError(c, "Failed to get collector")
+	}
+
+	var req BulkUpdateAvailableTrashRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(c, "Invalid request format")
+	}
+	req.CollectorID = collector.ID
+
+	errors, isValid := req.ValidateBulkUpdateAvailableTrashRequest()
+	if !isValid {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errors)
+	}
+
+	if err := h.collectorService.UpdateAvailableTrash(c.Context(), collector.ID, req.AvailableTrashItems); err != nil {
+		return utils.InternalServerError(c, "Failed to update available trash")
+	}
+
+	updated, err := h.collectorService.GetCollectorByID(c.Context(), collector.ID)
+	if err != nil {
+		return utils.InternalServerError(c, "Failed to get collector")
+	}
+
+	return utils.SuccessWithData(c, "Available trash updated successfully", updated)
+}
+
 func (h *CollectorHandler) parsePaginationParams(c *fiber.Ctx) (limit, offset, page int) {
 
 	limitStr := c.Query("limit", "10")
@@ -338,23 +422,3 @@ func (h *CollectorHandler) isValidJobStatus(status string, validStatuses []strin
 	}
 	return false
 }
-
-func (h *CollectorHandler) RegisterRoutes(app *fiber.App) {
-
-	collectors := app.Group("/api/v1/collectors")
-
-	collectors.Post("/", h.CreateCollector)
-	collectors.Get("/:id", h.GetCollectorByID)
-	collectors.Put("/:id", h.UpdateCollector)
-	collectors.Delete("/:id", h.DeleteCollector)
-
-	collectors.Get("/", h.ListCollectors)
-	collectors.Get("/active", h.GetActiveCollectors)
-	collectors.Get("/user/:userID", h.GetCollectorByUserID)
-	collectors.Get("/address/:addressID", h.GetCollectorsByAddress)
-	collectors.Get("/trash-category/:trashCategoryID", h.GetCollectorsByTrashCategory)
-
-	collectors.Patch("/:id/job-status", h.UpdateJobStatus)
-	collectors.Patch("/:id/rating", h.UpdateRating)
-	collectors.Put("/:id/available-trash", h.UpdateAvailableTrash)
-}
@@ -31,6 +31,7 @@ type CollectorRepository interface {
 	DeleteAvailableTrashByCollectorID(ctx context.Context, collectorID string) error
 
 	GetActiveCollectorsWithTrashAndAddress(ctx context.Context) ([]model.Collector, error)
+	GetActiveCollectorsWithTrashAndAddressByIDs(ctx context.Context, ids []string) ([]model.Collector, error)
 	GetCollectorWithAddressAndTrash(ctx context.Context, collectorID string) (*model.Collector, error)
 
 	WithTx(tx *gorm.DB) CollectorRepository
@@ -64,7 +65,7 @@ func (r *collectorRepository) GetActiveCollectorsWithTrashAndAddress(ctx context
 	err := r.db.WithContext(ctx).
 		Preload("User").
 		Preload("Address").
-		Preload("AvaibleTrashbyCollector.TrashCategory").
+		Preload("AvaibleTrashByCollector.TrashCategory").
 		Where("job_status = ?", "active").
 		Find(&collectors).Error
 
@@ -75,11 +76,31 @@ func (r *collectorRepository) GetActiveCollectorsWithTrashAndAddress(ctx context
 	return collectors, nil
 }
 
+func (r *collectorRepository) GetActiveCollectorsWithTrashAndAddressByIDs(ctx context.Context, ids []string) ([]model.Collector, error) {
+	if len(ids) == 0 {
+		return []model.Collector{}, nil
+	}
+
+	var collectors []model.Collector
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Address").
+		Preload("AvaibleTrashByCollector.TrashCategory").
+		Where("job_status = ? AND id IN ?", "active", ids).
+		Find(&collectors).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return collectors, nil
+}
+
 func (r *collectorRepository) GetCollectorWithAddressAndTrash(ctx context.Context, collectorID string) (*model.Collector, error) {
 	var collector model.Collector
 	err := r.db.WithContext(ctx).
 		Preload("Address").
-		Preload("AvaibleTrashbyCollector").
+		Preload("AvaibleTrashByCollector").
 		Where("id = ?", collectorID).
 		First(&collector).Error
 
@@ -1 +1,46 @@
-package collector
\ No newline at end of file
+package collector
+
+import (
+	"rijig/config"
+	"rijig/internal/address"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CollectorRouter(api fiber.Router) {
+	collectorRepo := NewCollectorRepository(config.DB)
+	addressRepo := address.NewAddressRepository(config.DB)
+	collectorService := NewCollectorService(collectorRepo, addressRepo, config.DB)
+	collectorHandler := NewCollectorHandler(collectorService)
+	slotService := NewCollectorSlotService(collectorRepo, NewCollectorSlotRepository(config.DB))
+	slotHandler := NewCollectorSlotHandler(slotService)
+
+	collectorAPI := api.Group("/collector")
+	collectorAPI.Use(middleware.AuthMiddleware())
+
+	// pengepul manages their own collector profile
+	collectorAPI.Post("/create", middleware.RequireRoles(utils.RolePengepul), collectorHandler.CreateCollector)
+	collectorAPI.Get("/profile", middleware.RequireRoles(utils.RolePengepul), collectorHandler.GetCollectorByUserID)
+	collectorAPI.Put("/update", middleware.RequireRoles(utils.RolePengepul), collectorHandler.UpdateCollector)
+	collectorAPI.Delete("/delete", middleware.RequireRoles(utils.RolePengepul), collectorHandler.DeleteCollector)
+	collectorAPI.Patch("/job-status", middleware.RequireRoles(utils.RolePengepul), collectorHandler.UpdateMyJobStatus)
+	collectorAPI.Put("/available-trash", middleware.RequireRoles(utils.RolePengepul), collectorHandler.UpdateMyAvailableTrash)
+
+	// weekly availability slots used to match scheduled pickups
+	collectorAPI.Get("/slots", middleware.RequireRoles(utils.RolePengepul), slotHandler.ListMySlots)
+	collectorAPI.Post("/slots", middleware.RequireRoles(utils.RolePengepul), slotHandler.CreateSlot)
+	collectorAPI.Put("/slots/:slotID", middleware.RequireRoles(utils.RolePengepul), slotHandler.UpdateSlot)
+	collectorAPI.Delete("/slots/:slotID", middleware.RequireRoles(utils.RolePengepul), slotHandler.DeleteSlot)
+
+	// admin listing and filtering
+	collectorAPI.Get("/", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.ListCollectors)
+	collectorAPI.Get("/active", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.GetActiveCollectors)
+	collectorAPI.Get("/address/:addressID", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.GetCollectorsByAddress)
+	collectorAPI.Get("/trash-category/:trashCategoryID", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.GetCollectorsByTrashCategory)
+	collectorAPI.Get("/:id", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.GetCollectorByID)
+	collectorAPI.Get("/:id/slots", middleware.RequireRoles(utils.RoleAdministrator), slotHandler.ListSlotsByCollectorID)
+	collectorAPI.Patch("/:id/job-status", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.UpdateJobStatus)
+	collectorAPI.Put("/:id/available-trash", middleware.RequireRoles(utils.RoleAdministrator), collectorHandler.UpdateAvailableTrash)
+}
@@ -2,8 +2,10 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"rijig/internal/address"
+	"rijig/internal/geoindex"
 	"rijig/internal/trash"
 	"rijig/model"
 	"strings"
@@ -31,12 +33,14 @@ type CollectorService interface {
 
 type collectorService struct {
 	collectorRepo CollectorRepository
+	addressRepo   address.AddressRepository
 	db            *gorm.DB
 }
 
-func NewCollectorService(collectorRepo CollectorRepository, db *gorm.DB) CollectorService {
+func NewCollectorService(collectorRepo CollectorRepository, addressRepo address.AddressRepository, db *gorm.DB) CollectorService {
 	return &collectorService{
 		collectorRepo: collectorRepo,
+		addressRepo:   addressRepo,
 		db:            db,
 	}
 }
@@ -48,7 +52,11 @@ func (s *collectorService) CreateCollector(ctx context.Context, req *CreateColle
 		return nil, fmt.Errorf("failed to check existing collector: %w", err)
 	}
 	if existingCollector != nil {
-		return nil, fmt.Errorf("collector already exists for user_id: %s", req.UserID)
+		return nil, fmt.Errorf("collector already exists for user_id: %s", UserID)
+	}
+
+	if err := s.ensureAddressOwnedByUser(ctx, req.AddressID, UserID); err != nil {
+		return nil, err
 	}
 
 	collector := &model.Collector{
@@ -83,6 +91,8 @@ func (s *collectorService) CreateCollector(ctx context.Context, req *CreateColle
 		return nil, err
 	}
 
+	geoindex.SyncCollector(ctx, s.db, collector.ID)
+
 	createdCollector, err := s.collectorRepo.GetByID(ctx, collector.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch created collector: %w", err)
@@ -116,6 +126,12 @@ func (s *collectorService) UpdateCollector(ctx context.Context, UserID string, r
 		return nil, fmt.Errorf("failed to get collector: %w", err)
 	}
 
+	if req.AddressID != "" && req.AddressID != collector.AddressID {
+		if err := s.ensureAddressOwnedByUser(ctx, req.AddressID, UserID); err != nil {
+			return nil, err
+		}
+	}
+
 	needsUpdate := s.checkCollectorNeedsUpdate(collector, req)
 
 	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -144,6 +160,8 @@ func (s *collectorService) UpdateCollector(ctx context.Context, UserID string, r
 		return nil, err
 	}
 
+	geoindex.SyncCollector(ctx, s.db, collector.ID)
+
 	updatedCollector, err := s.collectorRepo.GetByUserID(ctx, UserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch updated collector: %w", err)
@@ -154,7 +172,7 @@ func (s *collectorService) UpdateCollector(ctx context.Context, UserID string, r
 
 func (s *collectorService) DeleteCollector(ctx context.Context, UserID string) error {
 
-	_, err := s.collectorRepo.GetByUserID(ctx, UserID)
+	collector, err := s.collectorRepo.GetByUserID(ctx, UserID)
 	if err != nil {
 		return fmt.Errorf("collector not found: %w", err)
 	}
@@ -163,6 +181,8 @@ func (s *collectorService) DeleteCollector(ctx context.Context, UserID string) e
 		return fmt.Errorf("failed to delete collector: %w", err)
 	}
 
+	geoindex.RemoveCollector(ctx, collector.ID)
+
 	return nil
 }
 
@@ -219,6 +239,7 @@ func (s *collectorService) UpdateJobStatus(ctx context.Context, id string, jobSt
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
 
+	geoindex.SyncCollector(ctx, s.db, id)
 	return nil
 }
 
@@ -240,6 +261,22 @@ func (s *collectorService) UpdateAvailableTrash(ctx context.Context, collectorID
 	return nil
 }
 
+func (s *collectorService) ensureAddressOwnedByUser(ctx context.Context, addressID, userID string) error {
+	addr, err := s.addressRepo.FindAddressByID(ctx, addressID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("address with id %s not found", addressID)
+		}
+		return fmt.Errorf("failed to get address: %w", err)
+	}
+
+	if addr.UserID != userID {
+		return fmt.Errorf("address %s does not belong to this user", addressID)
+	}
+
+	return nil
+}
+
 func (s *collectorService) buildAvailableTrashList(collectorID string, items []CreateAvailableTrashRequest) []*model.AvaibleTrashByCollector {
 	availableTrashList := make([]*model.AvaibleTrashByCollector, 0, len(items))
 	for _, item := range items {
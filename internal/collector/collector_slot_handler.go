@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"rijig/middleware"
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CollectorSlotHandler struct {
+	slotService CollectorSlotService
+}
+
+func NewCollectorSlotHandler(slotService CollectorSlotService) *CollectorSlotHandler {
+	return &CollectorSlotHandler{
+		slotService: slotService,
+	}
+}
+
+func (h *CollectorSlotHandler) ListMySlots(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	slots, err := h.slotService.ListMySlots(c.Context(), claims.UserID)
+	if err != nil {
+		return handleSlotError(c, err, "Failed to get slots")
+	}
+
+	return utils.SuccessWithData(c, "Slots retrieved successfully", slots)
+}
+
+func (h *CollectorSlotHandler) ListSlotsByCollectorID(c *fiber.Ctx) error {
+	collectorID := c.Params("id")
+	if strings.TrimSpace(collectorID) == "" {
+		return utils.BadRequest(c, "Collector ID is required")
+	}
+
+	slots, err := h.slotService.ListSlotsByCollectorID(c.Context(), collectorID)
+	if err != nil {
+		return handleSlotError(c, err, "Failed to get slots")
+	}
+
+	return utils.SuccessWithData(c, "Slots retrieved successfully", slots)
+}
+
+func (h *CollectorSlotHandler) CreateSlot(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CollectorSlotRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(c, "Invalid request format")
+	}
+
+	if errors, isValid := req.ValidateCollectorSlotRequest(); !isValid {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errors)
+	}
+
+	slot, err := h.slotService.CreateSlot(c.Context(), claims.UserID, &req)
+	if err != nil {
+		return handleSlotError(c, err, "Failed to create slot")
+	}
+
+	return utils.CreateSuccessWithData(c, "Slot created successfully", slot)
+}
+
+func (h *CollectorSlotHandler) UpdateSlot(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	slotID := c.Params("slotID")
+	if strings.TrimSpace(slotID) == "" {
+		return utils.BadRequest(c, "Slot ID is required")
+	}
+
+	var req CollectorSlotRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(c, "Invalid request format")
+	}
+
+	if errors, isValid := req.ValidateCollectorSlotRequest(); !isValid {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errors)
+	}
+
+	slot, err := h.slotService.UpdateSlot(c.Context(), claims.UserID, slotID, &req)
+	if err != nil {
+		return handleSlotError(c, err, "Failed to update slot")
+	}
+
+	return utils.SuccessWithData(c, "Slot updated successfully", slot)
+}
+
+func (h *CollectorSlotHandler) DeleteSlot(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	slotID := c.Params("slotID")
+	if strings.TrimSpace(slotID) == "" {
+		return utils.BadRequest(c, "Slot ID is required")
+	}
+
+	if err := h.slotService.DeleteSlot(c.Context(), claims.UserID, slotID); err != nil {
+		return handleSlotError(c, err, "Failed to delete slot")
+	}
+
+	return utils.Success(c, "Slot deleted successfully")
+}
+
+func handleSlotError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	case strings.Contains(msg, "does not belong"):
+		return utils.Forbidden(c, msg)
+	case strings.Contains(msg, "overlaps") || strings.Contains(msg, "upcoming booking"):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, msg, nil)
+	default:
+		return utils.InternalServerError(c, fallback)
+	}
+}
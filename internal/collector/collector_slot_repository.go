@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CollectorSlotRepository interface {
+	CreateSlot(ctx context.Context, slot *model.CollectorAvailabilitySlot) error
+	GetSlotByID(ctx context.Context, id string) (*model.CollectorAvailabilitySlot, error)
+	ListSlotsByCollector(ctx context.Context, collectorID string) ([]model.CollectorAvailabilitySlot, error)
+	UpdateSlot(ctx context.Context, slot *model.CollectorAvailabilitySlot) error
+	DeleteSlot(ctx context.Context, id string) error
+	HasOverlappingSlot(ctx context.Context, collectorID string, weekday int, start, end, excludeID string) (bool, error)
+	CountUpcomingBookings(ctx context.Context, slotID string, from time.Time) (int64, error)
+
+	FindCoveringSlotForUpdate(ctx context.Context, collectorID string, weekday int, start, end string) (*model.CollectorAvailabilitySlot, error)
+	CountBookings(ctx context.Context, slotID string, date time.Time) (int64, error)
+	CreateBooking(ctx context.Context, booking *model.CollectorSlotBooking) error
+	DeleteBookingByRequest(ctx context.Context, requestID string) error
+	GetFreeCollectorIDs(ctx context.Context, collectorIDs []string, weekday int, start, end string, date time.Time) (map[string]bool, error)
+
+	WithTx(tx *gorm.DB) CollectorSlotRepository
+}
+
+type collectorSlotRepository struct {
+	db *gorm.DB
+}
+
+func NewCollectorSlotRepository(db *gorm.DB) CollectorSlotRepository {
+	return &collectorSlotRepository{
+		db: db,
+	}
+}
+
+func (r *collectorSlotRepository) WithTx(tx *gorm.DB) CollectorSlotRepository {
+	return &collectorSlotRepository{
+		db: tx,
+	}
+}
+
+func (r *collectorSlotRepository) CreateSlot(ctx context.Context, slot *model.CollectorAvailabilitySlot) error {
+	if err := r.db.WithContext(ctx).Create(slot).Error; err != nil {
+		return fmt.Errorf("failed to create slot: %w", err)
+	}
+	return nil
+}
+
+func (r *collectorSlotRepository) GetSlotByID(ctx context.Context, id string) (*model.CollectorAvailabilitySlot, error) {
+	var slot model.CollectorAvailabilitySlot
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&slot).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("slot with id %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to get slot: %w", err)
+	}
+	return &slot, nil
+}
+
+func (r *collectorSlotRepository) ListSlotsByCollector(ctx context.Context, collectorID string) ([]model.CollectorAvailabilitySlot, error) {
+	var slots []model.CollectorAvailabilitySlot
+	err := r.db.WithContext(ctx).
+		Where("collector_id = ?", collectorID).
+		Order("weekday asc, start_time asc").
+		Find(&slots).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list slots: %w", err)
+	}
+	return slots, nil
+}
+
+func (r *collectorSlotRepository) UpdateSlot(ctx context.Context, slot *model.CollectorAvailabilitySlot) error {
+	if err := r.db.WithContext(ctx).Save(slot).Error; err != nil {
+		return fmt.Errorf("failed to update slot: %w", err)
+	}
+	return nil
+}
+
+func (r *collectorSlotRepository) DeleteSlot(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.CollectorAvailabilitySlot{}).Error; err != nil {
+		return fmt.Errorf("failed to delete slot: %w", err)
+	}
+	return nil
+}
+
+// HasOverlappingSlot keeps a collector's slots on the same day disjoint, so one
+// booking can never be counted against two different slots.
+func (r *collectorSlotRepository) HasOverlappingSlot(ctx context.Context, collectorID string, weekday int, start, end, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).
+		Model(&model.CollectorAvailabilitySlot{}).
+		Where("collector_id = ? AND weekday = ? AND start_time < ? AND end_time > ?", collectorID, weekday, end, start)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check overlapping slots: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *collectorSlotRepository) CountUpcomingBookings(ctx context.Context, slotID string, from time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.CollectorSlotBooking{}).
+		Where("slot_id = ? AND window_end >= ?", slotID, from).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bookings: %w", err)
+	}
+	return count, nil
+}
+
+func (r *collectorSlotRepository) FindCoveringSlotForUpdate(ctx context.Context, collectorID string, weekday int, start, end string) (*model.CollectorAvailabilitySlot, error) {
+	var slot model.CollectorAvailabilitySlot
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("collector_id = ? AND weekday = ? AND is_active = ? AND start_time <= ? AND end_time >= ?",
+			collectorID, weekday, true, start, end).
+		First(&slot).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("no slot covers %s-%s", start, end)
+		}
+		return nil, fmt.Errorf("failed to get slot: %w", err)
+	}
+	return &slot, nil
+}
+
+func (r *collectorSlotRepository) CountBookings(ctx context.Context, slotID string, date time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.CollectorSlotBooking{}).
+		Where("slot_id = ? AND slot_date = ?", slotID, date.Format("2006-01-02")).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bookings: %w", err)
+	}
+	return count, nil
+}
+
+func (r *collectorSlotRepository) CreateBooking(ctx context.Context, booking *model.CollectorSlotBooking) error {
+	if err := r.db.WithContext(ctx).Create(booking).Error; err != nil {
+		return fmt.Errorf("failed to create booking: %w", err)
+	}
+	return nil
+}
+
+func (r *collectorSlotRepository) DeleteBookingByRequest(ctx context.Context, requestID string) error {
+	if err := r.db.WithContext(ctx).Where("request_id = ?", requestID).Delete(&model.CollectorSlotBooking{}).Error; err != nil {
+		return fmt.Errorf("failed to release booking: %w", err)
+	}
+	return nil
+}
+
+// GetFreeCollectorIDs returns the collectors among collectorIDs that have an active slot
+// covering the window with capacity left on that date.
+func (r *collectorSlotRepository) GetFreeCollectorIDs(ctx context.Context, collectorIDs []string, weekday int, start, end string, date time.Time) (map[string]bool, error) {
+	free := make(map[string]bool)
+	if len(collectorIDs) == 0 {
+		return free, nil
+	}
+
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&model.CollectorAvailabilitySlot{}).
+		Where("collector_id IN ? AND weekday = ? AND is_active = ? AND start_time <= ? AND end_time >= ?",
+			collectorIDs, weekday, true, start, end).
+		Where("(SELECT COUNT(*) FROM collector_slot_bookings b WHERE b.slot_id = collector_availability_slots.id AND b.slot_date = ?) < capacity",
+			date.Format("2006-01-02")).
+		Distinct().
+		Pluck("collector_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check collector availability: %w", err)
+	}
+
+	for _, id := range ids {
+		free[id] = true
+	}
+	return free, nil
+}
@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"rijig/model"
+	"time"
+)
+
+type CollectorSlotService interface {
+	ListMySlots(ctx context.Context, userID string) ([]CollectorSlotResponse, error)
+	ListSlotsByCollectorID(ctx context.Context, collectorID string) ([]CollectorSlotResponse, error)
+	CreateSlot(ctx context.Context, userID string, req *CollectorSlotRequest) (*CollectorSlotResponse, error)
+	UpdateSlot(ctx context.Context, userID, slotID string, req *CollectorSlotRequest) (*CollectorSlotResponse, error)
+	DeleteSlot(ctx context.Context, userID, slotID string) error
+}
+
+type collectorSlotService struct {
+	collectorRepo CollectorRepository
+	slotRepo      CollectorSlotRepository
+}
+
+func NewCollectorSlotService(collectorRepo CollectorRepository, slotRepo CollectorSlotRepository) CollectorSlotService {
+	return &collectorSlotService{
+		collectorRepo: collectorRepo,
+		slotRepo:      slotRepo,
+	}
+}
+
+func (s *collectorSlotService) ListMySlots(ctx context.Context, userID string) ([]CollectorSlotResponse, error) {
+	collector, err := s.collectorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ListSlotsByCollectorID(ctx, collector.ID)
+}
+
+func (s *collectorSlotService) ListSlotsByCollectorID(ctx context.Context, collectorID string) ([]CollectorSlotResponse, error) {
+	slots, err := s.slotRepo.ListSlotsByCollector(ctx, collectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]CollectorSlotResponse, 0, len(slots))
+	for i := range slots {
+		responses = append(responses, toCollectorSlotResponse(&slots[i]))
+	}
+	return responses, nil
+}
+
+func (s *collectorSlotService) CreateSlot(ctx context.Context, userID string, req *CollectorSlotRequest) (*CollectorSlotResponse, error) {
+	collector, err := s.collectorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureNoOverlap(ctx, collector.ID, *req.Weekday, req.StartTime, req.EndTime, ""); err != nil {
+		return nil, err
+	}
+
+	slot := &model.CollectorAvailabilitySlot{
+		CollectorID: collector.ID,
+		Weekday:     *req.Weekday,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Capacity:    req.Capacity,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		slot.IsActive = *req.IsActive
+	}
+
+	if err := s.slotRepo.CreateSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	response := toCollectorSlotResponse(slot)
+	return &response, nil
+}
+
+func (s *collectorSlotService) UpdateSlot(ctx context.Context, userID, slotID string, req *CollectorSlotRequest) (*CollectorSlotResponse, error) {
+	slot, err := s.getOwnSlot(ctx, userID, slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureNoOverlap(ctx, slot.CollectorID, *req.Weekday, req.StartTime, req.EndTime, slot.ID); err != nil {
+		return nil, err
+	}
+
+	reshaped := slot.Weekday != *req.Weekday || slot.StartTime != req.StartTime || slot.EndTime != req.EndTime
+	if reshaped || req.Capacity < slot.Capacity {
+		if err := s.ensureNoUpcomingBookings(ctx, slot.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	slot.Weekday = *req.Weekday
+	slot.StartTime = req.StartTime
+	slot.EndTime = req.EndTime
+	slot.Capacity = req.Capacity
+	if req.IsActive != nil {
+		slot.IsActive = *req.IsActive
+	}
+	slot.UpdatedAt = time.Now()
+
+	if err := s.slotRepo.UpdateSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	response := toCollectorSlotResponse(slot)
+	return &response, nil
+}
+
+func (s *collectorSlotService) DeleteSlot(ctx context.Context, userID, slotID string) error {
+	slot, err := s.getOwnSlot(ctx, userID, slotID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureNoUpcomingBookings(ctx, slot.ID); err != nil {
+		return err
+	}
+
+	return s.slotRepo.DeleteSlot(ctx, slot.ID)
+}
+
+func (s *collectorSlotService) getOwnSlot(ctx context.Context, userID, slotID string) (*model.CollectorAvailabilitySlot, error) {
+	collector, err := s.collectorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := s.slotRepo.GetSlotByID(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if slot.CollectorID != collector.ID {
+		return nil, fmt.Errorf("slot does not belong to this collector")
+	}
+	return slot, nil
+}
+
+func (s *collectorSlotService) ensureNoOverlap(ctx context.Context, collectorID string, weekday int, start, end, excludeID string) error {
+	overlap, err := s.slotRepo.HasOverlappingSlot(ctx, collectorID, weekday, start, end, excludeID)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return fmt.Errorf("slot overlaps with an existing slot on %s", weekdayNames[weekday])
+	}
+	return nil
+}
+
+// ensureNoUpcomingBookings protects pickups that were already promised against a
+// slot from disappearing when the collector reshapes or removes it.
+func (s *collectorSlotService) ensureNoUpcomingBookings(ctx context.Context, slotID string) error {
+	count, err := s.slotRepo.CountUpcomingBookings(ctx, slotID, time.Now())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("slot still has %d upcoming booking(s)", count)
+	}
+	return nil
+}
+
+func toCollectorSlotResponse(slot *model.CollectorAvailabilitySlot) CollectorSlotResponse {
+	return CollectorSlotResponse{
+		ID:          slot.ID,
+		CollectorID: slot.CollectorID,
+		Weekday:     slot.Weekday,
+		WeekdayName: weekdayNames[slot.Weekday],
+		StartTime:   slot.StartTime,
+		EndTime:     slot.EndTime,
+		Capacity:    slot.Capacity,
+		IsActive:    slot.IsActive,
+	}
+}
@@ -0,0 +1,166 @@
+package facility
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	GradeA      = "A"
+	GradeB      = "B"
+	GradeC      = "C"
+	GradeReject = "reject"
+)
+
+// normalizeGrade accepts letter grades in any case.
+func normalizeGrade(grade string) string {
+	grade = strings.TrimSpace(grade)
+	if strings.EqualFold(grade, GradeReject) {
+		return GradeReject
+	}
+	return strings.ToUpper(grade)
+}
+
+func isValidGrade(grade string) bool {
+	switch grade {
+	case GradeA, GradeB, GradeC, GradeReject:
+		return true
+	}
+	return false
+}
+
+// CreateIntakeDTO records a load when it is weighed in. TareWeight may be sent right
+// away when the weighbridge already knows the empty vehicle; otherwise it is recorded
+// later with RecordTareDTO. Weights are in kg.
+type CreateIntakeDTO struct {
+	CollectorID     string   `json:"collector_id"`
+	TrashCategoryID string   `json:"trash_category_id"`
+	TicketNumber    string   `json:"ticket_number,omitempty"`
+	GrossWeight     float64  `json:"gross_weight"`
+	TareWeight      *float64 `json:"tare_weight,omitempty"`
+	Grade           string   `json:"grade"`
+	PricePerKg      float64  `json:"price_per_kg"`
+	PickupIDs       []string `json:"pickup_ids,omitempty"`
+	LotIDs          []string `json:"lot_ids,omitempty"`
+	Notes           string   `json:"notes,omitempty"`
+	WeighedInAt     string   `json:"weighed_in_at,omitempty"`
+}
+
+func (r *CreateIntakeDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Grade = normalizeGrade(r.Grade)
+	r.TicketNumber = strings.TrimSpace(r.TicketNumber)
+
+	if strings.TrimSpace(r.CollectorID) == "" {
+		errors["collector_id"] = append(errors["collector_id"], "collector_id wajib diisi")
+	}
+	if strings.TrimSpace(r.TrashCategoryID) == "" {
+		errors["trash_category_id"] = append(errors["trash_category_id"], "trash_category_id wajib diisi")
+	}
+	if r.GrossWeight <= 0 {
+		errors["gross_weight"] = append(errors["gross_weight"], "berat kotor harus lebih dari 0")
+	}
+	if r.TareWeight != nil && (*r.TareWeight < 0 || *r.TareWeight >= r.GrossWeight) {
+		errors["tare_weight"] = append(errors["tare_weight"], "berat kosong harus antara 0 dan berat kotor")
+	}
+	if !isValidGrade(r.Grade) {
+		errors["grade"] = append(errors["grade"], "grade harus A, B, C, atau reject")
+	}
+	if r.PricePerKg < 0 {
+		errors["price_per_kg"] = append(errors["price_per_kg"], "harga per kg tidak boleh negatif")
+	}
+	if r.WeighedInAt != "" {
+		if _, err := time.Parse(time.RFC3339, r.WeighedInAt); err != nil {
+			errors["weighed_in_at"] = append(errors["weighed_in_at"], "format waktu harus RFC3339")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, id := range r.PickupIDs {
+		id = strings.TrimSpace(id)
+		key := fmt.Sprintf("pickup_ids[%d]", i)
+		if id == "" {
+			errors[key] = append(errors[key], "ID pickup wajib diisi")
+			continue
+		}
+		if seen[id] {
+			errors[key] = append(errors[key], "ID pickup duplikat")
+		}
+		seen[id] = true
+		r.PickupIDs[i] = id
+	}
+
+	seenLots := make(map[string]bool)
+	for i, id := range r.LotIDs {
+		id = strings.TrimSpace(id)
+		key := fmt.Sprintf("lot_ids[%d]", i)
+		if id == "" {
+			errors[key] = append(errors[key], "ID lot wajib diisi")
+			continue
+		}
+		if seenLots[id] {
+			errors[key] = append(errors[key], "ID lot duplikat")
+		}
+		seenLots[id] = true
+		r.LotIDs[i] = id
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+// RecordTareDTO completes the weighing. PricePerKg and Grade are optional and replace
+// the values given at weigh-in, for when the load is graded after unloading.
+type RecordTareDTO struct {
+	TareWeight float64  `json:"tare_weight"`
+	Grade      string   `json:"grade,omitempty"`
+	PricePerKg *float64 `json:"price_per_kg,omitempty"`
+}
+
+func (r *RecordTareDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Grade = normalizeGrade(r.Grade)
+
+	if r.TareWeight < 0 {
+		errors["tare_weight"] = append(errors["tare_weight"], "berat kosong tidak boleh negatif")
+	}
+	if r.Grade != "" && !isValidGrade(r.Grade) {
+		errors["grade"] = append(errors["grade"], "grade harus A, B, C, atau reject")
+	}
+	if r.PricePerKg != nil && *r.PricePerKg < 0 {
+		errors["price_per_kg"] = append(errors["price_per_kg"], "harga per kg tidak boleh negatif")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type IntakeResponseDTO struct {
+	ID                string   `json:"id"`
+	CompanyProfileID  string   `json:"company_profile_id"`
+	CompanyName       string   `json:"company_name,omitempty"`
+	RecordedBy        string   `json:"recorded_by"`
+	CollectorID       string   `json:"collector_id"`
+	TrashCategoryID   string   `json:"trash_category_id"`
+	TrashCategoryName string   `json:"trash_category_name,omitempty"`
+	TicketNumber      string   `json:"ticket_number,omitempty"`
+	Status            string   `json:"status"`
+	GrossWeight       float64  `json:"gross_weight"`
+	TareWeight        *float64 `json:"tare_weight,omitempty"`
+	NetWeight         float64  `json:"net_weight"`
+	Grade             string   `json:"grade"`
+	PricePerKg        float64  `json:"price_per_kg"`
+	TotalPrice        float64  `json:"total_price"`
+	Notes             string   `json:"notes,omitempty"`
+	PickupIDs         []string `json:"pickup_ids"`
+	WeighedInAt       string   `json:"weighed_in_at"`
+	WeighedOutAt      string   `json:"weighed_out_at,omitempty"`
+	CreatedAt         string   `json:"created_at"`
+}
@@ -0,0 +1,193 @@
+package facility
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"rijig/internal/trace"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FacilityHandler struct {
+	service FacilityService
+}
+
+func NewFacilityHandler(service FacilityService) *FacilityHandler {
+	return &FacilityHandler{service: service}
+}
+
+func (h *FacilityHandler) CreateIntake(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateIntakeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	intake, err := h.service.CreateIntake(context.Background(), IntakeActor{UserID: claims.UserID, Role: claims.Role}, req)
+	if err != nil {
+		return handleFacilityError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Penerimaan berhasil dicatat", intake)
+}
+
+func (h *FacilityHandler) RecordTare(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	intakeID := c.Params("id")
+	if intakeID == "" {
+		return utils.BadRequest(c, "ID penerimaan wajib diisi")
+	}
+
+	var req RecordTareDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	intake, err := h.service.RecordTare(context.Background(), IntakeActor{UserID: claims.UserID, Role: claims.Role}, intakeID, req)
+	if err != nil {
+		return handleFacilityError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Penimbangan berhasil diselesaikan", intake)
+}
+
+func (h *FacilityHandler) GetIntake(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	intake, err := h.service.GetIntake(context.Background(), IntakeActor{UserID: claims.UserID, Role: claims.Role}, c.Params("id"))
+	if err != nil {
+		return handleFacilityError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Data penerimaan berhasil diambil", intake)
+}
+
+func (h *FacilityHandler) ListFacilityIntakes(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	filter, errs := parseIntakeFilter(c)
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+	filter.CollectorID = c.Query("collector_id")
+
+	limit, offset, page := utils.ParsePagination(c, 10)
+	intakes, total, err := h.service.ListFacilityIntakes(context.Background(), claims.UserID, filter, limit, offset)
+	if err != nil {
+		return handleFacilityError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar penerimaan berhasil diambil", intakes, page, limit, int(total))
+}
+
+func (h *FacilityHandler) ListMyDeliveries(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	filter, errs := parseIntakeFilter(c)
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	limit, offset, page := utils.ParsePagination(c, 10)
+	intakes, total, err := h.service.ListCollectorDeliveries(context.Background(), claims.UserID, filter, limit, offset)
+	if err != nil {
+		return handleFacilityError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar pengantaran berhasil diambil", intakes, page, limit, int(total))
+}
+
+// parseIntakeFilter reads trash_category_id, status and the from/to dates
+// (YYYY-MM-DD, inclusive) of the weigh-in.
+func parseIntakeFilter(c *fiber.Ctx) (IntakeFilter, map[string][]string) {
+	errs := make(map[string][]string)
+	filter := IntakeFilter{
+		TrashCategoryID: c.Query("trash_category_id"),
+		Status:          c.Query("status"),
+	}
+
+	if filter.Status != "" && filter.Status != IntakeWeighing && filter.Status != IntakeCompleted {
+		errs["status"] = append(errs["status"], "status harus weighing atau completed")
+	}
+
+	loc := utils.IndonesianLocation()
+	if from := c.Query("from"); from != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			errs["from"] = append(errs["from"], "format tanggal harus YYYY-MM-DD")
+		} else {
+			filter.From = &parsed
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			errs["to"] = append(errs["to"], "format tanggal harus YYYY-MM-DD")
+		} else {
+			end := parsed.AddDate(0, 0, 1)
+			filter.To = &end
+		}
+	}
+
+	if len(errs) > 0 {
+		return filter, errs
+	}
+	return filter, nil
+}
+
+func handleFacilityError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrIntakeNotFound), errors.Is(err, ErrCollectorNotFound), errors.Is(err, ErrTrashCategoryNotFound),
+		errors.Is(err, ErrPickupNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrIntakeForbidden), errors.Is(err, ErrCompanyProfileRequired):
+		return utils.Forbidden(c, err.Error())
+	case errors.Is(err, ErrPickupMismatch), errors.Is(err, ErrInvalidTare), errors.Is(err, ErrCategoryNotWeighable):
+		return utils.BadRequest(c, err.Error())
+	case errors.Is(err, ErrTicketNumberExists), errors.Is(err, ErrPickupAlreadyDelivered), errors.Is(err, ErrIntakeCompleted):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	case errors.Is(err, trace.ErrLotNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, trace.ErrLotNotOwned), errors.Is(err, trace.ErrLotMismatch), errors.Is(err, trace.ErrLotStage),
+		errors.Is(err, trace.ErrLotNotKilogram):
+		return utils.BadRequest(c, err.Error())
+	case errors.Is(err, trace.ErrLotConsumed):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	case strings.HasPrefix(err.Error(), "forbidden"):
+		return utils.Forbidden(c, err.Error())
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
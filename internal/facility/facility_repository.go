@@ -0,0 +1,194 @@
+package facility
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IntakeFilter narrows ListIntakes to a facility or a collector and optional criteria.
+type IntakeFilter struct {
+	CompanyProfileID string
+	CollectorID      string
+	TrashCategoryID  string
+	Status           string
+	From             *time.Time
+	To               *time.Time
+}
+
+type FacilityRepository interface {
+	GetCompanyByUserID(ctx context.Context, userID string) (*model.CompanyProfile, error)
+	GetCollectorByID(ctx context.Context, id string) (*model.Collector, error)
+	GetCollectorByUserID(ctx context.Context, userID string) (*model.Collector, error)
+	GetTrashCategoryByID(ctx context.Context, id string) (*model.TrashCategory, error)
+	GetPickupsForIntake(ctx context.Context, ids []string) ([]model.RequestPickup, error)
+	GetDeliveredPickupIDs(ctx context.Context, pickupIDs []string, trashCategoryID string) ([]string, error)
+	TicketNumberExists(ctx context.Context, companyProfileID, ticketNumber string) (bool, error)
+
+	CreateIntake(ctx context.Context, intake *model.FacilityIntake) error
+	GetIntakeByID(ctx context.Context, id string) (*model.FacilityIntake, error)
+	GetIntakeForUpdate(ctx context.Context, id string) (*model.FacilityIntake, error)
+	UpdateIntakeFields(ctx context.Context, id string, fields map[string]interface{}) error
+	ListIntakes(ctx context.Context, filter IntakeFilter, limit, offset int) ([]model.FacilityIntake, int64, error)
+
+	WithTx(tx *gorm.DB) FacilityRepository
+}
+
+type facilityRepository struct {
+	db *gorm.DB
+}
+
+func NewFacilityRepository() FacilityRepository {
+	return &facilityRepository{db: config.DB}
+}
+
+func (r *facilityRepository) WithTx(tx *gorm.DB) FacilityRepository {
+	return &facilityRepository{db: tx}
+}
+
+func (r *facilityRepository) GetCompanyByUserID(ctx context.Context, userID string) (*model.CompanyProfile, error) {
+	var company model.CompanyProfile
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&company).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
+
+func (r *facilityRepository) GetCollectorByID(ctx context.Context, id string) (*model.Collector, error) {
+	var collector model.Collector
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&collector).Error; err != nil {
+		return nil, err
+	}
+	return &collector, nil
+}
+
+func (r *facilityRepository) GetCollectorByUserID(ctx context.Context, userID string) (*model.Collector, error) {
+	var collector model.Collector
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&collector).Error; err != nil {
+		return nil, err
+	}
+	return &collector, nil
+}
+
+func (r *facilityRepository) GetTrashCategoryByID(ctx context.Context, id string) (*model.TrashCategory, error) {
+	var category model.TrashCategory
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetPickupsForIntake locks the pickups so two intakes cannot claim the same load at once.
+func (r *facilityRepository) GetPickupsForIntake(ctx context.Context, ids []string) ([]model.RequestPickup, error) {
+	var pickups []model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("RequestItems").
+		Where("id IN ?", ids).
+		Find(&pickups).Error
+	return pickups, err
+}
+
+// GetDeliveredPickupIDs returns which of the pickups already have their load of the
+// category recorded at some facility.
+func (r *facilityRepository) GetDeliveredPickupIDs(ctx context.Context, pickupIDs []string, trashCategoryID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Table("facility_intake_pickups fip").
+		Joins("JOIN facility_intakes fi ON fi.id = fip.intake_id").
+		Where("fip.request_pickup_id IN ? AND fi.trash_category_id = ?", pickupIDs, trashCategoryID).
+		Distinct().
+		Pluck("fip.request_pickup_id", &ids).Error
+	return ids, err
+}
+
+func (r *facilityRepository) TicketNumberExists(ctx context.Context, companyProfileID, ticketNumber string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.FacilityIntake{}).
+		Where("company_profile_id = ? AND ticket_number = ?", companyProfileID, ticketNumber).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *facilityRepository) CreateIntake(ctx context.Context, intake *model.FacilityIntake) error {
+	return r.db.WithContext(ctx).Create(intake).Error
+}
+
+func (r *facilityRepository) GetIntakeByID(ctx context.Context, id string) (*model.FacilityIntake, error) {
+	var intake model.FacilityIntake
+	if err := r.db.WithContext(ctx).
+		Preload("TrashCategory").
+		Preload("CompanyProfile").
+		Preload("Pickups").
+		Where("id = ?", id).
+		First(&intake).Error; err != nil {
+		return nil, err
+	}
+	return &intake, nil
+}
+
+func (r *facilityRepository) GetIntakeForUpdate(ctx context.Context, id string) (*model.FacilityIntake, error) {
+	var intake model.FacilityIntake
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&intake).Error; err != nil {
+		return nil, err
+	}
+	return &intake, nil
+}
+
+func (r *facilityRepository) UpdateIntakeFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.FacilityIntake{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *facilityRepository) ListIntakes(ctx context.Context, filter IntakeFilter, limit, offset int) ([]model.FacilityIntake, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.FacilityIntake{})
+	if filter.CompanyProfileID != "" {
+		query = query.Where("company_profile_id = ?", filter.CompanyProfileID)
+	}
+	if filter.CollectorID != "" {
+		query = query.Where("collector_id = ?", filter.CollectorID)
+	}
+	if filter.TrashCategoryID != "" {
+		query = query.Where("trash_category_id = ?", filter.TrashCategoryID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("weighed_in_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("weighed_in_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var intakes []model.FacilityIntake
+	if err := query.
+		Preload("TrashCategory").
+		Preload("CompanyProfile").
+		Preload("Pickups").
+		Order("weighed_in_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&intakes).Error; err != nil {
+		return nil, 0, err
+	}
+	return intakes, total, nil
+}
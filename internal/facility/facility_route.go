@@ -0,0 +1,26 @@
+package facility
+
+import (
+	"rijig/config"
+	"rijig/internal/trace"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func FacilityRouter(api fiber.Router) {
+	service := NewFacilityService(config.DB, NewFacilityRepository(), trace.NewTraceService(config.DB, trace.NewTraceRepository()))
+	handler := NewFacilityHandler(service)
+
+	facility := api.Group("/facility")
+	facility.Use(middleware.AuthMiddleware())
+
+	pengelola := middleware.RequireRoles(utils.RolePengelola)
+	facility.Post("/intakes", pengelola, handler.CreateIntake)
+	facility.Get("/intakes", pengelola, handler.ListFacilityIntakes)
+	facility.Patch("/intakes/:id/tare", pengelola, handler.RecordTare)
+	facility.Get("/intakes/:id", middleware.RequireRoles(utils.RolePengelola, utils.RolePengepul, utils.RoleAdministrator), handler.GetIntake)
+
+	facility.Get("/deliveries/me", middleware.RequireRoles(utils.RolePengepul), handler.ListMyDeliveries)
+}
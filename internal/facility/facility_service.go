@@ -0,0 +1,383 @@
+package facility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"rijig/internal/requestpickup"
+	"rijig/internal/trace"
+	"rijig/internal/trash"
+	"rijig/model"
+	"rijig/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	IntakeWeighing  = "weighing"
+	IntakeCompleted = "completed"
+)
+
+var (
+	ErrCompanyProfileRequired = errors.New("profil perusahaan belum dibuat")
+	ErrIntakeNotFound         = errors.New("data penerimaan tidak ditemukan")
+	ErrIntakeForbidden        = errors.New("forbidden: anda tidak memiliki akses ke data penerimaan ini")
+	ErrCollectorNotFound      = errors.New("pengepul tidak ditemukan")
+	ErrTrashCategoryNotFound  = errors.New("kategori sampah tidak ditemukan")
+	ErrTicketNumberExists     = errors.New("nomor tiket timbangan sudah digunakan")
+	ErrPickupNotFound         = errors.New("pickup tidak ditemukan")
+	ErrPickupMismatch         = errors.New("pickup tidak sesuai dengan muatan ini")
+	ErrPickupAlreadyDelivered = errors.New("muatan pickup untuk kategori ini sudah tercatat di penerimaan lain")
+	ErrIntakeCompleted        = errors.New("penimbangan sudah selesai")
+	ErrInvalidTare            = errors.New("berat kosong harus lebih kecil dari berat kotor")
+	ErrCategoryNotWeighable   = errors.New("kategori sampah ini tidak diukur dalam kg sehingga tidak dapat ditimbang")
+)
+
+// IntakeActor is the user acting on intakes: the pengelola, a collector or an admin.
+type IntakeActor struct {
+	UserID string
+	Role   string
+}
+
+type FacilityService interface {
+	CreateIntake(ctx context.Context, actor IntakeActor, req CreateIntakeDTO) (*IntakeResponseDTO, error)
+	RecordTare(ctx context.Context, actor IntakeActor, intakeID string, req RecordTareDTO) (*IntakeResponseDTO, error)
+	GetIntake(ctx context.Context, actor IntakeActor, intakeID string) (*IntakeResponseDTO, error)
+	ListFacilityIntakes(ctx context.Context, userID string, filter IntakeFilter, limit, offset int) ([]IntakeResponseDTO, int64, error)
+	ListCollectorDeliveries(ctx context.Context, userID string, filter IntakeFilter, limit, offset int) ([]IntakeResponseDTO, int64, error)
+}
+
+type facilityService struct {
+	db           *gorm.DB
+	repo         FacilityRepository
+	traceService trace.TraceService
+}
+
+func NewFacilityService(db *gorm.DB, repo FacilityRepository, traceService trace.TraceService) FacilityService {
+	return &facilityService{db: db, repo: repo, traceService: traceService}
+}
+
+func roundWeight(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// CreateIntake records a weighed-in load. Linked pickups must be completed pickups of
+// the same collector that contained the category, and each pickup's load of a
+// category can only arrive at a facility once. The intake also gets its trace lot,
+// made from the given lots and the open lots of the linked pickups.
+func (s *facilityService) CreateIntake(ctx context.Context, actor IntakeActor, req CreateIntakeDTO) (*IntakeResponseDTO, error) {
+	company, err := s.requireCompany(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	weighedInAt := time.Now()
+	if req.WeighedInAt != "" {
+		weighedInAt, _ = time.Parse(time.RFC3339, req.WeighedInAt)
+	}
+
+	intake := &model.FacilityIntake{
+		CompanyProfileID: company.ID,
+		RecordedBy:       actor.UserID,
+		CollectorID:      req.CollectorID,
+		TrashCategoryID:  req.TrashCategoryID,
+		Status:           IntakeWeighing,
+		GrossWeight:      roundWeight(req.GrossWeight),
+		Grade:            req.Grade,
+		PricePerKg:       req.PricePerKg,
+		Notes:            strings.TrimSpace(req.Notes),
+		WeighedInAt:      weighedInAt,
+	}
+	if req.TicketNumber != "" {
+		intake.TicketNumber = &req.TicketNumber
+	}
+	if req.TareWeight != nil {
+		completeWeighing(intake, *req.TareWeight, weighedInAt)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		if _, err := repo.GetCollectorByID(ctx, req.CollectorID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCollectorNotFound
+			}
+			return err
+		}
+		category, err := repo.GetTrashCategoryByID(ctx, req.TrashCategoryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTrashCategoryNotFound
+			}
+			return err
+		}
+		// the weighbridge reading becomes the lot quantity, which only works in kg
+		if trash.UnitOf(category) != trash.UnitKilogram {
+			return ErrCategoryNotWeighable
+		}
+		if intake.TicketNumber != nil {
+			exists, err := repo.TicketNumberExists(ctx, company.ID, *intake.TicketNumber)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrTicketNumberExists
+			}
+		}
+
+		if len(req.PickupIDs) > 0 {
+			if err := s.checkPickups(ctx, repo, req); err != nil {
+				return err
+			}
+			for _, pickupID := range req.PickupIDs {
+				intake.Pickups = append(intake.Pickups, model.FacilityIntakePickup{RequestPickupID: pickupID})
+			}
+		}
+
+		if err := repo.CreateIntake(ctx, intake); err != nil {
+			return err
+		}
+		return s.traceService.RecordIntakeLot(ctx, tx, intake, req.LotIDs, req.PickupIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getIntake(ctx, intake.ID)
+}
+
+func (s *facilityService) checkPickups(ctx context.Context, repo FacilityRepository, req CreateIntakeDTO) error {
+	pickups, err := repo.GetPickupsForIntake(ctx, req.PickupIDs)
+	if err != nil {
+		return err
+	}
+	if len(pickups) != len(req.PickupIDs) {
+		return ErrPickupNotFound
+	}
+
+	for _, pickup := range pickups {
+		if pickup.StatusPickup != requestpickup.StatusCompleted {
+			return fmt.Errorf("%w: pickup %s belum selesai", ErrPickupMismatch, pickup.ID)
+		}
+		if pickup.CollectorID == nil || *pickup.CollectorID != req.CollectorID {
+			return fmt.Errorf("%w: pickup %s diambil pengepul lain", ErrPickupMismatch, pickup.ID)
+		}
+
+		hasCategory := false
+		for _, item := range pickup.RequestItems {
+			if item.TrashCategoryId == req.TrashCategoryID {
+				hasCategory = true
+				break
+			}
+		}
+		if !hasCategory {
+			return fmt.Errorf("%w: pickup %s tidak memuat kategori ini", ErrPickupMismatch, pickup.ID)
+		}
+	}
+
+	delivered, err := repo.GetDeliveredPickupIDs(ctx, req.PickupIDs, req.TrashCategoryID)
+	if err != nil {
+		return err
+	}
+	if len(delivered) > 0 {
+		return fmt.Errorf("%w: %s", ErrPickupAlreadyDelivered, strings.Join(delivered, ", "))
+	}
+	return nil
+}
+
+// RecordTare completes the weighing of a load that was weighed in without its tare.
+func (s *facilityService) RecordTare(ctx context.Context, actor IntakeActor, intakeID string, req RecordTareDTO) (*IntakeResponseDTO, error) {
+	company, err := s.requireCompany(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		intake, err := repo.GetIntakeForUpdate(ctx, intakeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrIntakeNotFound
+			}
+			return err
+		}
+		if intake.CompanyProfileID != company.ID {
+			return ErrIntakeForbidden
+		}
+		if intake.Status != IntakeWeighing {
+			return ErrIntakeCompleted
+		}
+		if req.TareWeight >= intake.GrossWeight {
+			return ErrInvalidTare
+		}
+
+		if req.Grade != "" {
+			intake.Grade = req.Grade
+		}
+		if req.PricePerKg != nil {
+			intake.PricePerKg = *req.PricePerKg
+		}
+		completeWeighing(intake, req.TareWeight, time.Now())
+
+		if err := repo.UpdateIntakeFields(ctx, intake.ID, map[string]interface{}{
+			"status":         intake.Status,
+			"tare_weight":    *intake.TareWeight,
+			"net_weight":     intake.NetWeight,
+			"grade":          intake.Grade,
+			"price_per_kg":   intake.PricePerKg,
+			"total_price":    intake.TotalPrice,
+			"weighed_out_at": *intake.WeighedOutAt,
+			"updated_at":     time.Now(),
+		}); err != nil {
+			return err
+		}
+		return s.traceService.RecordIntakeWeighing(ctx, tx, intake, actor.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getIntake(ctx, intakeID)
+}
+
+// completeWeighing derives the net weight and the price paid from the tare weight.
+func completeWeighing(intake *model.FacilityIntake, tareWeight float64, weighedOutAt time.Time) {
+	tare := roundWeight(tareWeight)
+	intake.TareWeight = &tare
+	intake.NetWeight = roundWeight(intake.GrossWeight - tare)
+	intake.TotalPrice = roundWeight(intake.NetWeight * intake.PricePerKg)
+	intake.WeighedOutAt = &weighedOutAt
+	intake.Status = IntakeCompleted
+}
+
+// GetIntake is open to the receiving facility, the delivering collector and admins.
+func (s *facilityService) GetIntake(ctx context.Context, actor IntakeActor, intakeID string) (*IntakeResponseDTO, error) {
+	intake, err := s.repo.GetIntakeByID(ctx, intakeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIntakeNotFound
+		}
+		return nil, err
+	}
+
+	switch actor.Role {
+	case utils.RoleAdministrator:
+	case utils.RolePengelola:
+		company, err := s.repo.GetCompanyByUserID(ctx, actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if company == nil || company.ID != intake.CompanyProfileID {
+			return nil, ErrIntakeForbidden
+		}
+	case utils.RolePengepul:
+		collector, err := s.repo.GetCollectorByUserID(ctx, actor.UserID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if collector == nil || collector.ID != intake.CollectorID {
+			return nil, ErrIntakeForbidden
+		}
+	default:
+		return nil, ErrIntakeForbidden
+	}
+
+	return toIntakeResponse(intake), nil
+}
+
+func (s *facilityService) ListFacilityIntakes(ctx context.Context, userID string, filter IntakeFilter, limit, offset int) ([]IntakeResponseDTO, int64, error) {
+	company, err := s.requireCompany(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter.CompanyProfileID = company.ID
+	return s.listIntakes(ctx, filter, limit, offset)
+}
+
+func (s *facilityService) ListCollectorDeliveries(ctx context.Context, userID string, filter IntakeFilter, limit, offset int) ([]IntakeResponseDTO, int64, error) {
+	collector, err := s.repo.GetCollectorByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrCollectorNotFound
+		}
+		return nil, 0, err
+	}
+
+	filter.CollectorID = collector.ID
+	return s.listIntakes(ctx, filter, limit, offset)
+}
+
+func (s *facilityService) listIntakes(ctx context.Context, filter IntakeFilter, limit, offset int) ([]IntakeResponseDTO, int64, error) {
+	intakes, total, err := s.repo.ListIntakes(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]IntakeResponseDTO, 0, len(intakes))
+	for i := range intakes {
+		responses = append(responses, *toIntakeResponse(&intakes[i]))
+	}
+	return responses, total, nil
+}
+
+func (s *facilityService) requireCompany(ctx context.Context, userID string) (*model.CompanyProfile, error) {
+	company, err := s.repo.GetCompanyByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if company == nil {
+		return nil, ErrCompanyProfileRequired
+	}
+	return company, nil
+}
+
+func (s *facilityService) getIntake(ctx context.Context, intakeID string) (*IntakeResponseDTO, error) {
+	intake, err := s.repo.GetIntakeByID(ctx, intakeID)
+	if err != nil {
+		return nil, err
+	}
+	return toIntakeResponse(intake), nil
+}
+
+func toIntakeResponse(intake *model.FacilityIntake) *IntakeResponseDTO {
+	response := &IntakeResponseDTO{
+		ID:               intake.ID,
+		CompanyProfileID: intake.CompanyProfileID,
+		RecordedBy:       intake.RecordedBy,
+		CollectorID:      intake.CollectorID,
+		TrashCategoryID:  intake.TrashCategoryID,
+		Status:           intake.Status,
+		GrossWeight:      intake.GrossWeight,
+		TareWeight:       intake.TareWeight,
+		NetWeight:        intake.NetWeight,
+		Grade:            intake.Grade,
+		PricePerKg:       intake.PricePerKg,
+		TotalPrice:       intake.TotalPrice,
+		Notes:            intake.Notes,
+		PickupIDs:        make([]string, 0, len(intake.Pickups)),
+		WeighedInAt:      intake.WeighedInAt.Format(time.RFC3339),
+		CreatedAt:        intake.CreatedAt.Format(time.RFC3339),
+	}
+	if intake.CompanyProfile != nil {
+		response.CompanyName = intake.CompanyProfile.CompanyName
+	}
+	if intake.TrashCategory != nil {
+		response.TrashCategoryName = intake.TrashCategory.Name
+	}
+	if intake.TicketNumber != nil {
+		response.TicketNumber = *intake.TicketNumber
+	}
+	if intake.WeighedOutAt != nil {
+		response.WeighedOutAt = intake.WeighedOutAt.Format(time.RFC3339)
+	}
+	for _, pickup := range intake.Pickups {
+		response.PickupIDs = append(response.PickupIDs, pickup.RequestPickupID)
+	}
+	return response
+}
@@ -0,0 +1,215 @@
+package geoindex
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"rijig/config"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// Two Redis GEO sets back the matching radius queries: active collectors keyed by
+// collector ID, and open automatic pickups keyed by pickup ID. Postgres stays the
+// source of truth; every Sync* call re-reads the row and adds or removes the member
+// so callers never have to know why something left the index.
+const (
+	CollectorKey = "geo:collectors:active"
+	PickupKey    = "geo:pickups:open"
+)
+
+type Hit struct {
+	ID         string
+	DistanceKm float64
+}
+
+type point struct {
+	ID        string
+	Latitude  float64
+	Longitude float64
+}
+
+func activeCollectorPoints(ctx context.Context, db *gorm.DB, collectorIDs ...string) ([]point, error) {
+	var points []point
+	query := db.WithContext(ctx).
+		Table("collectors").
+		Select("collectors.id AS id, addresses.latitude AS latitude, addresses.longitude AS longitude").
+		Joins("JOIN addresses ON addresses.id = collectors.address_id").
+		Where("collectors.job_status = ?", "active")
+	if len(collectorIDs) > 0 {
+		query = query.Where("collectors.id IN ?", collectorIDs)
+	}
+	if err := query.Scan(&points).Error; err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func openPickupPoints(ctx context.Context, db *gorm.DB, pickupIDs ...string) ([]point, error) {
+	var points []point
+	query := db.WithContext(ctx).
+		Table("request_pickups").
+		Select("request_pickups.id AS id, addresses.latitude AS latitude, addresses.longitude AS longitude").
+		Joins("JOIN addresses ON addresses.id = request_pickups.address_id").
+		Where("request_pickups.request_method = ? AND request_pickups.status_pickup = ? AND request_pickups.collector_id IS NULL",
+			"otomatis", "waiting_collector")
+	if len(pickupIDs) > 0 {
+		query = query.Where("request_pickups.id IN ?", pickupIDs)
+	}
+	if err := query.Scan(&points).Error; err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// validPoint filters out coordinates Redis GEO refuses, such as unset addresses
+// stored with latitudes beyond the web mercator range.
+func validPoint(p point) bool {
+	return p.Latitude >= -85.05112878 && p.Latitude <= 85.05112878 &&
+		p.Longitude >= -180 && p.Longitude <= 180
+}
+
+func syncMember(ctx context.Context, key, id string, points []point) error {
+	for _, p := range points {
+		if p.ID != id || !validPoint(p) {
+			continue
+		}
+		return config.RedisClient.GeoAdd(ctx, key, &redis.GeoLocation{
+			Name:      p.ID,
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+		}).Err()
+	}
+	return config.RedisClient.ZRem(ctx, key, id).Err()
+}
+
+// SyncCollector indexes the collector when it is active, otherwise drops it.
+func SyncCollector(ctx context.Context, db *gorm.DB, collectorID string) {
+	points, err := activeCollectorPoints(ctx, db, collectorID)
+	if err == nil {
+		err = syncMember(ctx, CollectorKey, collectorID, points)
+	}
+	if err != nil {
+		log.Printf("[GEO-INDEX] failed to sync collector %s: %v", collectorID, err)
+	}
+}
+
+func RemoveCollector(ctx context.Context, collectorID string) {
+	if err := config.RedisClient.ZRem(ctx, CollectorKey, collectorID).Err(); err != nil {
+		log.Printf("[GEO-INDEX] failed to remove collector %s: %v", collectorID, err)
+	}
+}
+
+// SyncPickup indexes the pickup while it is an unassigned automatic request.
+func SyncPickup(ctx context.Context, db *gorm.DB, pickupID string) {
+	points, err := openPickupPoints(ctx, db, pickupID)
+	if err == nil {
+		err = syncMember(ctx, PickupKey, pickupID, points)
+	}
+	if err != nil {
+		log.Printf("[GEO-INDEX] failed to sync pickup %s: %v", pickupID, err)
+	}
+}
+
+// SyncAddress refreshes every collector and open pickup that points at the address,
+// used after the coordinates of an address change.
+func SyncAddress(ctx context.Context, db *gorm.DB, addressID string) {
+	var collectorIDs, pickupIDs []string
+
+	if err := db.WithContext(ctx).Table("collectors").Where("address_id = ?", addressID).Pluck("id", &collectorIDs).Error; err != nil {
+		log.Printf("[GEO-INDEX] failed to load collectors for address %s: %v", addressID, err)
+	}
+	if err := db.WithContext(ctx).Table("request_pickups").Where("address_id = ?", addressID).Pluck("id", &pickupIDs).Error; err != nil {
+		log.Printf("[GEO-INDEX] failed to load pickups for address %s: %v", addressID, err)
+	}
+
+	for _, id := range collectorIDs {
+		SyncCollector(ctx, db, id)
+	}
+	for _, id := range pickupIDs {
+		SyncPickup(ctx, db, id)
+	}
+}
+
+func NearbyCollectors(ctx context.Context, lat, lon, radiusKm float64) ([]Hit, error) {
+	return search(ctx, CollectorKey, lat, lon, radiusKm)
+}
+
+func NearbyPickups(ctx context.Context, lat, lon, radiusKm float64) ([]Hit, error) {
+	return search(ctx, PickupKey, lat, lon, radiusKm)
+}
+
+func search(ctx context.Context, key string, lat, lon, radiusKm float64) ([]Hit, error) {
+	locations, err := config.RedisClient.GeoSearchLocation(ctx, key, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  lon,
+			Latitude:   lat,
+			Radius:     radiusKm,
+			RadiusUnit: "km",
+			Sort:       "ASC",
+		},
+		WithDist: true,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("geo search on %s failed: %w", key, err)
+	}
+
+	hits := make([]Hit, 0, len(locations))
+	for _, loc := range locations {
+		hits = append(hits, Hit{ID: loc.Name, DistanceKm: loc.Dist})
+	}
+	return hits, nil
+}
+
+// Rebuild repopulates both sets from Postgres. Each set is written to a temporary
+// key and renamed over the live one, so radius queries never see a half-built index.
+func Rebuild(ctx context.Context, db *gorm.DB) error {
+	collectors, err := activeCollectorPoints(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to load collectors: %w", err)
+	}
+	if err := replaceSet(ctx, CollectorKey, collectors); err != nil {
+		return err
+	}
+
+	pickups, err := openPickupPoints(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to load pickups: %w", err)
+	}
+	if err := replaceSet(ctx, PickupKey, pickups); err != nil {
+		return err
+	}
+
+	log.Printf("[GEO-INDEX] rebuilt index with %d collectors and %d pickups", len(collectors), len(pickups))
+	return nil
+}
+
+func replaceSet(ctx context.Context, key string, points []point) error {
+	locations := make([]*redis.GeoLocation, 0, len(points))
+	for _, p := range points {
+		if !validPoint(p) {
+			continue
+		}
+		locations = append(locations, &redis.GeoLocation{
+			Name:      p.ID,
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+		})
+	}
+
+	if len(locations) == 0 {
+		return config.RedisClient.Del(ctx, key).Err()
+	}
+
+	tmpKey := key + ":rebuild"
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Del(ctx, tmpKey)
+	pipe.GeoAdd(ctx, tmpKey, locations...)
+	pipe.Rename(ctx, tmpKey, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild %s: %w", key, err)
+	}
+	return nil
+}
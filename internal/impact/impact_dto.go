@@ -0,0 +1,113 @@
+package impact
+
+import (
+	"strconv"
+	"time"
+
+	"rijig/utils"
+)
+
+type ImpactTotalsDTO struct {
+	WeightKg    float64 `json:"weight_kg"`
+	CO2eAvoided float64 `json:"co2e_avoided"`
+	PickupCount int64   `json:"pickup_count"`
+}
+
+type ImpactCategoryDTO struct {
+	TrashCategoryID string  `json:"trash_category_id"`
+	Name            string  `json:"name"`
+	WeightKg        float64 `json:"weight_kg"`
+	CO2eAvoided     float64 `json:"co2e_avoided"`
+}
+
+// ImpactSummaryDTO is a scope's impact over a date range, computed from the records.
+type ImpactSummaryDTO struct {
+	Scope      string              `json:"scope"`
+	ScopeID    string              `json:"scope_id,omitempty"`
+	From       string              `json:"from"`
+	To         string              `json:"to"`
+	Totals     ImpactTotalsDTO     `json:"totals"`
+	Categories []ImpactCategoryDTO `json:"categories"`
+}
+
+type ImpactMonthDTO struct {
+	Month string `json:"month"`
+	ImpactTotalsDTO
+}
+
+// ImpactYearDTO is a scope's impact in one calendar year, read from the rollups. For
+// the current year Totals is the year to date.
+type ImpactYearDTO struct {
+	Scope   string           `json:"scope"`
+	ScopeID string           `json:"scope_id,omitempty"`
+	Year    int              `json:"year"`
+	Totals  ImpactTotalsDTO  `json:"totals"`
+	Months  []ImpactMonthDTO `json:"months"`
+}
+
+// MyImpactDTO is what a member sees of their own contribution.
+type MyImpactDTO struct {
+	Summary    ImpactSummaryDTO `json:"summary"`
+	YearToDate ImpactTotalsDTO  `json:"year_to_date"`
+}
+
+type BackfillResultDTO struct {
+	Pickups int  `json:"pickups"`
+	Records int  `json:"records"`
+	HasMore bool `json:"has_more"`
+}
+
+// RangeQuery holds the optional from/to dates (YYYY-MM-DD, Asia/Jakarta) of a summary
+// request. Missing dates default to the current year to date.
+type RangeQuery struct {
+	From string
+	To   string
+}
+
+func (q RangeQuery) Range(now time.Time) (time.Time, time.Time, map[string][]string) {
+	errors := make(map[string][]string)
+	loc := utils.IndonesianLocation()
+
+	today := now.In(loc)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	if q.To != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", q.To, loc)
+		if err != nil {
+			errors["to"] = append(errors["to"], "format tanggal harus YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := time.Date(to.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	if q.From != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", q.From, loc)
+		if err != nil {
+			errors["from"] = append(errors["from"], "format tanggal harus YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if len(errors) == 0 && from.After(to) {
+		errors["from"] = append(errors["from"], "tanggal awal tidak boleh setelah tanggal akhir")
+	}
+	if len(errors) > 0 {
+		return time.Time{}, time.Time{}, errors
+	}
+
+	// to is inclusive, so the range ends at the start of the following day.
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// ParseYear reads a year query value, defaulting to the current year in Asia/Jakarta.
+func ParseYear(value string, now time.Time) (int, map[string][]string) {
+	current := now.In(utils.IndonesianLocation()).Year()
+	if value == "" {
+		return current, nil
+	}
+
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 2000 || year > current {
+		return 0, map[string][]string{"year": {"tahun tidak valid"}}
+	}
+	return year, nil
+}
@@ -0,0 +1,179 @@
+package impact
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// scopeParams maps the :scope path segment to the scope it reports on.
+var scopeParams = map[string]string{
+	"users":      ScopeUser,
+	"collectors": ScopeCollector,
+	"regencies":  ScopeRegency,
+}
+
+type ImpactHandler struct {
+	service ImpactService
+}
+
+func NewImpactHandler(service ImpactService) *ImpactHandler {
+	return &ImpactHandler{service: service}
+}
+
+func (h *ImpactHandler) GetMyImpact(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	query := RangeQuery{From: c.Query("from"), To: c.Query("to")}
+	from, to, errs := query.Range(time.Now())
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	impact, err := h.service.GetMyImpact(context.Background(), claims.UserID, from, to)
+	if err != nil {
+		return handleImpactError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Dampak lingkungan berhasil diambil", impact)
+}
+
+func (h *ImpactHandler) GetMyMonthly(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respondYear(c, claims, ScopeUser, claims.UserID)
+}
+
+func (h *ImpactHandler) GetScopeSummary(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	scope, scopeID, ok := scopeFromParams(c)
+	if !ok {
+		return utils.NotFound(c, ErrInvalidScope.Error())
+	}
+
+	query := RangeQuery{From: c.Query("from"), To: c.Query("to")}
+	from, to, errs := query.Range(time.Now())
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	actor := ImpactActor{UserID: claims.UserID, Role: claims.Role}
+	summary, err := h.service.GetSummary(context.Background(), actor, scope, scopeID, from, to)
+	if err != nil {
+		return handleImpactError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Dampak lingkungan berhasil diambil", summary)
+}
+
+func (h *ImpactHandler) GetScopeMonthly(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	scope, scopeID, ok := scopeFromParams(c)
+	if !ok {
+		return utils.NotFound(c, ErrInvalidScope.Error())
+	}
+
+	return h.respondYear(c, claims, scope, scopeID)
+}
+
+func (h *ImpactHandler) GetPlatformSummary(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	query := RangeQuery{From: c.Query("from"), To: c.Query("to")}
+	from, to, errs := query.Range(time.Now())
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	actor := ImpactActor{UserID: claims.UserID, Role: claims.Role}
+	summary, err := h.service.GetSummary(context.Background(), actor, ScopePlatform, "", from, to)
+	if err != nil {
+		return handleImpactError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Dampak lingkungan berhasil diambil", summary)
+}
+
+func (h *ImpactHandler) GetPlatformMonthly(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respondYear(c, claims, ScopePlatform, "")
+}
+
+func (h *ImpactHandler) respondYear(c *fiber.Ctx, claims *utils.JWTClaims, scope, scopeID string) error {
+	year, errs := ParseYear(c.Query("year"), time.Now())
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	actor := ImpactActor{UserID: claims.UserID, Role: claims.Role}
+	result, err := h.service.GetYear(context.Background(), actor, scope, scopeID, year)
+	if err != nil {
+		return handleImpactError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Rekap bulanan dampak lingkungan berhasil diambil", result)
+}
+
+func (h *ImpactHandler) Backfill(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultBackfillLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultBackfillLimit
+	}
+
+	result, err := h.service.Backfill(context.Background(), limit)
+	if err != nil {
+		return handleImpactError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pencatatan dampak lingkungan berhasil dilengkapi", result)
+}
+
+// scopeFromParams reads the scope and its id from the path. Regency names may contain
+// spaces, so the id is unescaped.
+func scopeFromParams(c *fiber.Ctx) (string, string, bool) {
+	scope, ok := scopeParams[c.Params("scope")]
+	scopeID, err := url.PathUnescape(c.Params("scope_id"))
+	if !ok || err != nil || scopeID == "" {
+		return "", "", false
+	}
+	return scope, scopeID, true
+}
+
+func handleImpactError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrCollectorNotFound), errors.Is(err, ErrInvalidScope):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrImpactForbidden):
+		return utils.Forbidden(c, err.Error())
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
@@ -0,0 +1,209 @@
+package impact
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"rijig/config"
+	"rijig/internal/trash"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ScopeUser      = "user"
+	ScopeCollector = "collector"
+	ScopeRegency   = "regency"
+	ScopePlatform  = "platform"
+
+	PeriodMonth = "month"
+	PeriodYear  = "year"
+
+	platformScopeID = "all"
+)
+
+// ImpactTotalsRow is the sum of impact records matching a filter.
+type ImpactTotalsRow struct {
+	WeightKg    float64
+	CO2eAvoided float64
+	PickupCount int64
+}
+
+// ImpactCategoryRow is the sum of impact records of one category.
+type ImpactCategoryRow struct {
+	TrashCategoryID string
+	Name            string
+	WeightKg        float64
+	CO2eAvoided     float64
+}
+
+type ImpactRepository interface {
+	HasPickupRecords(ctx context.Context, pickupID string) (bool, error)
+	CreateRecords(ctx context.Context, records []model.ImpactRecord) error
+	IncrementRollup(ctx context.Context, rollup *model.ImpactRollup) error
+
+	GetEmissionFactors(ctx context.Context, categoryIDs []string) (map[string]float64, error)
+	GetAddressRegency(ctx context.Context, addressID string) (string, error)
+	GetPickupItems(ctx context.Context, pickupID string) ([]model.RequestPickupItem, error)
+	GetUnrecordedPickups(ctx context.Context, status string, limit int) ([]model.RequestPickup, error)
+
+	SumRecords(ctx context.Context, scopeType, scopeID string, from, to time.Time) (*ImpactTotalsRow, error)
+	SumRecordsByCategory(ctx context.Context, scopeType, scopeID string, from, to time.Time) ([]ImpactCategoryRow, error)
+	GetRollups(ctx context.Context, scopeType, scopeID, periodType string, from, to time.Time) ([]model.ImpactRollup, error)
+
+	GetCollectorByID(ctx context.Context, id string) (*model.Collector, error)
+
+	WithTx(tx *gorm.DB) ImpactRepository
+}
+
+type impactRepository struct {
+	db *gorm.DB
+}
+
+func NewImpactRepository() ImpactRepository {
+	return &impactRepository{db: config.DB}
+}
+
+func (r *impactRepository) WithTx(tx *gorm.DB) ImpactRepository {
+	return &impactRepository{db: tx}
+}
+
+func (r *impactRepository) HasPickupRecords(ctx context.Context, pickupID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.ImpactRecord{}).
+		Where("request_pickup_id = ?", pickupID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *impactRepository) CreateRecords(ctx context.Context, records []model.ImpactRecord) error {
+	return r.db.WithContext(ctx).Create(&records).Error
+}
+
+// IncrementRollup adds the rollup's totals to the stored row of its scope and period,
+// creating the row on first use.
+func (r *impactRepository) IncrementRollup(ctx context.Context, rollup *model.ImpactRollup) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "scope_type"}, {Name: "scope_id"}, {Name: "period_type"}, {Name: "period_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"weight_kg":    gorm.Expr("impact_rollups.weight_kg + EXCLUDED.weight_kg"),
+				"co2e_avoided": gorm.Expr("impact_rollups.co2e_avoided + EXCLUDED.co2e_avoided"),
+				"pickup_count": gorm.Expr("impact_rollups.pickup_count + EXCLUDED.pickup_count"),
+				"updated_at":   gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		}).
+		Create(rollup).Error
+}
+
+func (r *impactRepository) GetEmissionFactors(ctx context.Context, categoryIDs []string) (map[string]float64, error) {
+	var categories []model.TrashCategory
+	if err := r.db.WithContext(ctx).
+		Select("id", "emission_factor").
+		Where("id IN ?", categoryIDs).
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	factors := make(map[string]float64, len(categories))
+	for _, category := range categories {
+		factors[category.ID] = category.EmissionFactor
+	}
+	return factors, nil
+}
+
+func (r *impactRepository) GetAddressRegency(ctx context.Context, addressID string) (string, error) {
+	var address model.Address
+	err := r.db.WithContext(ctx).Select("id", "regency").Where("id = ?", addressID).First(&address).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return address.Regency, nil
+}
+
+func (r *impactRepository) GetPickupItems(ctx context.Context, pickupID string) ([]model.RequestPickupItem, error) {
+	var items []model.RequestPickupItem
+	err := r.db.WithContext(ctx).Where("request_pickup_id = ?", pickupID).Find(&items).Error
+	return items, err
+}
+
+// GetUnrecordedPickups returns pickups in the given status that have items weighed
+// in kg but no impact records yet, oldest completion first.
+func (r *impactRepository) GetUnrecordedPickups(ctx context.Context, status string, limit int) ([]model.RequestPickup, error) {
+	var pickups []model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Where("status_pickup = ? AND completed_at IS NOT NULL", status).
+		Where("EXISTS (SELECT 1 FROM request_pickup_items rpi WHERE rpi.request_pickup_id = request_pickups.id "+
+			"AND rpi.actual_amount > 0 AND rpi.unit IN ?)", []string{"", trash.UnitKilogram}).
+		Where("NOT EXISTS (SELECT 1 FROM impact_records ir WHERE ir.request_pickup_id = request_pickups.id)").
+		Order("completed_at ASC").
+		Limit(limit).
+		Find(&pickups).Error
+	return pickups, err
+}
+
+func (r *impactRepository) scopedRecords(ctx context.Context, scopeType, scopeID string, from, to time.Time) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.ImpactRecord{}).
+		Where("impact_records.settled_at >= ? AND impact_records.settled_at < ?", from, to)
+
+	switch scopeType {
+	case ScopeUser:
+		query = query.Where("impact_records.user_id = ?", scopeID)
+	case ScopeCollector:
+		query = query.Where("impact_records.collector_id = ?", scopeID)
+	case ScopeRegency:
+		query = query.Where("LOWER(impact_records.regency) = ?", scopeID)
+	}
+	return query
+}
+
+func (r *impactRepository) SumRecords(ctx context.Context, scopeType, scopeID string, from, to time.Time) (*ImpactTotalsRow, error) {
+	var totals ImpactTotalsRow
+	err := r.scopedRecords(ctx, scopeType, scopeID, from, to).
+		Select("COALESCE(SUM(weight_kg), 0) AS weight_kg, " +
+			"COALESCE(SUM(co2e_avoided), 0) AS co2e_avoided, " +
+			"COUNT(DISTINCT request_pickup_id) AS pickup_count").
+		Scan(&totals).Error
+	return &totals, err
+}
+
+func (r *impactRepository) SumRecordsByCategory(ctx context.Context, scopeType, scopeID string, from, to time.Time) ([]ImpactCategoryRow, error) {
+	var rows []ImpactCategoryRow
+	err := r.scopedRecords(ctx, scopeType, scopeID, from, to).
+		Select("impact_records.trash_category_id, trash_categories.name, " +
+			"SUM(impact_records.weight_kg) AS weight_kg, SUM(impact_records.co2e_avoided) AS co2e_avoided").
+		Joins("JOIN trash_categories ON trash_categories.id = impact_records.trash_category_id").
+		Group("impact_records.trash_category_id, trash_categories.name").
+		Order("co2e_avoided DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetRollups returns the stored rollups of a scope whose period starts in [from, to).
+func (r *impactRepository) GetRollups(ctx context.Context, scopeType, scopeID, periodType string, from, to time.Time) ([]model.ImpactRollup, error) {
+	var rollups []model.ImpactRollup
+	err := r.db.WithContext(ctx).
+		Where("scope_type = ? AND scope_id = ? AND period_type = ?", scopeType, scopeID, periodType).
+		Where("period_start >= ? AND period_start < ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("period_start ASC").
+		Find(&rollups).Error
+	return rollups, err
+}
+
+func (r *impactRepository) GetCollectorByID(ctx context.Context, id string) (*model.Collector, error) {
+	var collector model.Collector
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&collector).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &collector, nil
+}
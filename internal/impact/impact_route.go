@@ -0,0 +1,28 @@
+package impact
+
+import (
+	"rijig/config"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ImpactRouter(api fiber.Router) {
+	service := NewImpactService(config.DB, NewImpactRepository())
+	handler := NewImpactHandler(service)
+
+	impact := api.Group("/impact")
+	impact.Use(middleware.AuthMiddleware())
+
+	impact.Get("/me", handler.GetMyImpact)
+	impact.Get("/me/monthly", handler.GetMyMonthly)
+
+	admin := middleware.RequireRoles(utils.RoleAdministrator)
+	impact.Get("/platform/summary", admin, handler.GetPlatformSummary)
+	impact.Get("/platform/monthly", admin, handler.GetPlatformMonthly)
+	impact.Post("/backfill", admin, handler.Backfill)
+
+	impact.Get("/:scope/:scope_id/summary", handler.GetScopeSummary)
+	impact.Get("/:scope/:scope_id/monthly", handler.GetScopeMonthly)
+}
@@ -0,0 +1,393 @@
+package impact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"rijig/internal/trash"
+	"rijig/model"
+	"rijig/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// pickupStatusCompleted mirrors requestpickup.StatusCompleted, which can't be
+	// imported here because requestpickup records impact through this package.
+	pickupStatusCompleted = "completed"
+
+	defaultBackfillLimit = 100
+	maxBackfillLimit     = 500
+)
+
+var (
+	ErrImpactForbidden   = errors.New("tidak memiliki akses ke dampak lingkungan ini")
+	ErrCollectorNotFound = errors.New("pengepul tidak ditemukan")
+	ErrInvalidScope      = errors.New("cakupan dampak tidak valid")
+)
+
+// ImpactActor is the caller asking for impact figures.
+type ImpactActor struct {
+	UserID string
+	Role   string
+}
+
+type ImpactService interface {
+	RecordPickupImpact(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, items []model.RequestPickupItem) error
+	GetMyImpact(ctx context.Context, userID string, from, to time.Time) (*MyImpactDTO, error)
+	GetSummary(ctx context.Context, actor ImpactActor, scope, scopeID string, from, to time.Time) (*ImpactSummaryDTO, error)
+	GetYear(ctx context.Context, actor ImpactActor, scope, scopeID string, year int) (*ImpactYearDTO, error)
+	Backfill(ctx context.Context, limit int) (*BackfillResultDTO, error)
+}
+
+type impactService struct {
+	db   *gorm.DB
+	repo ImpactRepository
+}
+
+func NewImpactService(db *gorm.DB, repo ImpactRepository) ImpactService {
+	return &impactService{db: db, repo: repo}
+}
+
+// isWeighedInKg reports whether a settled item has a weight an emission factor applies to.
+func isWeighedInKg(item model.RequestPickupItem) bool {
+	if item.ActualAmount == nil || *item.ActualAmount <= 0 {
+		return false
+	}
+	return item.Unit == "" || item.Unit == trash.UnitKilogram
+}
+
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// normalizeRegency is how a regency name is keyed in rollups and matched in queries,
+// since addresses store the name as typed.
+func normalizeRegency(regency string) string {
+	return strings.ToLower(strings.TrimSpace(regency))
+}
+
+// RecordPickupImpact books the impact of a completed pickup's settled items and adds
+// it to the month and year rollups of the household, the collector, the regency and
+// the platform. It runs inside the completion transaction; a pickup that already has
+// records is skipped, so the rollups are never incremented twice. Items not weighed
+// in kg carry no emission factor and are left out.
+func (s *impactService) RecordPickupImpact(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, items []model.RequestPickupItem) error {
+	repo := s.repo.WithTx(tx)
+
+	recorded, err := repo.HasPickupRecords(ctx, pickup.ID)
+	if err != nil {
+		return err
+	}
+	if recorded {
+		return nil
+	}
+
+	var weighed []model.RequestPickupItem
+	categoryIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if !isWeighedInKg(item) {
+			continue
+		}
+		weighed = append(weighed, item)
+		categoryIDs = append(categoryIDs, item.TrashCategoryId)
+	}
+	if len(weighed) == 0 {
+		return nil
+	}
+
+	factors, err := repo.GetEmissionFactors(ctx, categoryIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get emission factors: %w", err)
+	}
+	regency, err := repo.GetAddressRegency(ctx, pickup.AddressId)
+	if err != nil {
+		return fmt.Errorf("failed to get pickup regency: %w", err)
+	}
+	regency = strings.TrimSpace(regency)
+
+	settledAt := time.Now()
+	if pickup.CompletedAt != nil {
+		settledAt = *pickup.CompletedAt
+	}
+
+	records := make([]model.ImpactRecord, 0, len(weighed))
+	var totals ImpactTotalsDTO
+	for _, item := range weighed {
+		weight := roundTo(*item.ActualAmount, 2)
+		factor := factors[item.TrashCategoryId]
+		avoided := roundTo(weight*factor, 4)
+
+		records = append(records, model.ImpactRecord{
+			RequestPickupID:     pickup.ID,
+			RequestPickupItemID: item.ID,
+			UserID:              pickup.UserId,
+			CollectorID:         pickup.CollectorID,
+			Regency:             regency,
+			TrashCategoryID:     item.TrashCategoryId,
+			WeightKg:            weight,
+			EmissionFactor:      factor,
+			CO2eAvoided:         avoided,
+			SettledAt:           settledAt,
+		})
+		totals.WeightKg += weight
+		totals.CO2eAvoided += avoided
+	}
+	totals.PickupCount = 1
+
+	if err := repo.CreateRecords(ctx, records); err != nil {
+		return fmt.Errorf("failed to record impact: %w", err)
+	}
+
+	// rollups are always incremented in the same order so concurrent completions
+	// lock the shared rows in the same order
+	scopes := [][2]string{{ScopeUser, pickup.UserId}}
+	if pickup.CollectorID != nil {
+		scopes = append(scopes, [2]string{ScopeCollector, *pickup.CollectorID})
+	}
+	if key := normalizeRegency(regency); key != "" {
+		scopes = append(scopes, [2]string{ScopeRegency, key})
+	}
+	scopes = append(scopes, [2]string{ScopePlatform, platformScopeID})
+
+	for _, scope := range scopes {
+		for _, period := range periodsOf(settledAt) {
+			rollup := &model.ImpactRollup{
+				ScopeType:   scope[0],
+				ScopeID:     scope[1],
+				PeriodType:  period.periodType,
+				PeriodStart: period.start,
+				WeightKg:    totals.WeightKg,
+				CO2eAvoided: totals.CO2eAvoided,
+				PickupCount: totals.PickupCount,
+			}
+			if err := repo.IncrementRollup(ctx, rollup); err != nil {
+				return fmt.Errorf("failed to update impact rollup: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+type rollupPeriod struct {
+	periodType string
+	start      time.Time
+}
+
+// periodsOf returns the month and year a moment falls in, in Asia/Jakarta time.
+func periodsOf(at time.Time) []rollupPeriod {
+	local := at.In(utils.IndonesianLocation())
+	return []rollupPeriod{
+		{periodType: PeriodMonth, start: time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.UTC)},
+		{periodType: PeriodYear, start: time.Date(local.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func (s *impactService) GetMyImpact(ctx context.Context, userID string, from, to time.Time) (*MyImpactDTO, error) {
+	summary, err := s.summary(ctx, ScopeUser, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	year := time.Now().In(utils.IndonesianLocation()).Year()
+	yearToDate, err := s.yearTotals(ctx, ScopeUser, userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MyImpactDTO{Summary: *summary, YearToDate: *yearToDate}, nil
+}
+
+// authorizeScope checks the actor may see the scope and returns the scope id as it
+// is stored. Members see their own figures, collectors their own, regencies are
+// public to every signed-in user and administrators see everything.
+func (s *impactService) authorizeScope(ctx context.Context, actor ImpactActor, scope, scopeID string) (string, error) {
+	isAdmin := actor.Role == utils.RoleAdministrator
+
+	switch scope {
+	case ScopeUser:
+		if !isAdmin && actor.UserID != scopeID {
+			return "", ErrImpactForbidden
+		}
+		return scopeID, nil
+	case ScopeCollector:
+		collector, err := s.repo.GetCollectorByID(ctx, scopeID)
+		if err != nil {
+			return "", err
+		}
+		if collector == nil {
+			return "", ErrCollectorNotFound
+		}
+		if !isAdmin && collector.UserID != actor.UserID {
+			return "", ErrImpactForbidden
+		}
+		return collector.ID, nil
+	case ScopeRegency:
+		return normalizeRegency(scopeID), nil
+	case ScopePlatform:
+		if !isAdmin {
+			return "", ErrImpactForbidden
+		}
+		return platformScopeID, nil
+	}
+	return "", ErrInvalidScope
+}
+
+func (s *impactService) GetSummary(ctx context.Context, actor ImpactActor, scope, scopeID string, from, to time.Time) (*ImpactSummaryDTO, error) {
+	key, err := s.authorizeScope(ctx, actor, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summary(ctx, scope, key, from, to)
+}
+
+func (s *impactService) summary(ctx context.Context, scope, scopeID string, from, to time.Time) (*ImpactSummaryDTO, error) {
+	totals, err := s.repo.SumRecords(ctx, scope, scopeID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum impact: %w", err)
+	}
+	rows, err := s.repo.SumRecordsByCategory(ctx, scope, scopeID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum impact by category: %w", err)
+	}
+
+	categories := make([]ImpactCategoryDTO, 0, len(rows))
+	for _, row := range rows {
+		categories = append(categories, ImpactCategoryDTO{
+			TrashCategoryID: row.TrashCategoryID,
+			Name:            row.Name,
+			WeightKg:        roundTo(row.WeightKg, 2),
+			CO2eAvoided:     roundTo(row.CO2eAvoided, 4),
+		})
+	}
+
+	loc := utils.IndonesianLocation()
+	summary := &ImpactSummaryDTO{
+		Scope:   scope,
+		ScopeID: scopeID,
+		From:    from.In(loc).Format("2006-01-02"),
+		To:      to.AddDate(0, 0, -1).In(loc).Format("2006-01-02"),
+		Totals: ImpactTotalsDTO{
+			WeightKg:    roundTo(totals.WeightKg, 2),
+			CO2eAvoided: roundTo(totals.CO2eAvoided, 4),
+			PickupCount: totals.PickupCount,
+		},
+		Categories: categories,
+	}
+	if scope == ScopePlatform {
+		summary.ScopeID = ""
+	}
+	return summary, nil
+}
+
+func yearBounds(year int) (time.Time, time.Time) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(1, 0, 0)
+}
+
+func (s *impactService) yearTotals(ctx context.Context, scope, scopeID string, year int) (*ImpactTotalsDTO, error) {
+	start, end := yearBounds(year)
+	rollups, err := s.repo.GetRollups(ctx, scope, scopeID, PeriodYear, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impact rollups: %w", err)
+	}
+
+	totals := &ImpactTotalsDTO{}
+	if len(rollups) > 0 {
+		*totals = toTotalsDTO(rollups[0])
+	}
+	return totals, nil
+}
+
+// GetYear reads the year and its twelve months from the rollups, filling months
+// without pickups with zeros.
+func (s *impactService) GetYear(ctx context.Context, actor ImpactActor, scope, scopeID string, year int) (*ImpactYearDTO, error) {
+	key, err := s.authorizeScope(ctx, actor, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.yearTotals(ctx, scope, key, year)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := yearBounds(year)
+	rollups, err := s.repo.GetRollups(ctx, scope, key, PeriodMonth, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impact rollups: %w", err)
+	}
+	byMonth := make(map[time.Month]model.ImpactRollup, len(rollups))
+	for _, rollup := range rollups {
+		byMonth[rollup.PeriodStart.Month()] = rollup
+	}
+
+	months := make([]ImpactMonthDTO, 0, 12)
+	for month := time.January; month <= time.December; month++ {
+		dto := ImpactMonthDTO{Month: fmt.Sprintf("%04d-%02d", year, int(month))}
+		if rollup, ok := byMonth[month]; ok {
+			dto.ImpactTotalsDTO = toTotalsDTO(rollup)
+		}
+		months = append(months, dto)
+	}
+
+	result := &ImpactYearDTO{Scope: scope, ScopeID: key, Year: year, Totals: *totals, Months: months}
+	if scope == ScopePlatform {
+		result.ScopeID = ""
+	}
+	return result, nil
+}
+
+// Backfill records the impact of completed pickups that have none, such as pickups
+// completed before impact accounting existed. Each pickup is booked in its own
+// transaction, so a failure keeps the pickups already done.
+func (s *impactService) Backfill(ctx context.Context, limit int) (*BackfillResultDTO, error) {
+	if limit <= 0 {
+		limit = defaultBackfillLimit
+	}
+	if limit > maxBackfillLimit {
+		limit = maxBackfillLimit
+	}
+
+	pickups, err := s.repo.GetUnrecordedPickups(ctx, pickupStatusCompleted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pickups to backfill: %w", err)
+	}
+
+	result := &BackfillResultDTO{HasMore: len(pickups) == limit}
+	for i := range pickups {
+		pickup := &pickups[i]
+		items, err := s.repo.GetPickupItems(ctx, pickup.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pickup items: %w", err)
+		}
+
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.RecordPickupImpact(ctx, tx, pickup, items)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to backfill pickup %s: %w", pickup.ID, err)
+		}
+
+		result.Pickups++
+		for _, item := range items {
+			if isWeighedInKg(item) {
+				result.Records++
+			}
+		}
+	}
+	return result, nil
+}
+
+func toTotalsDTO(rollup model.ImpactRollup) ImpactTotalsDTO {
+	return ImpactTotalsDTO{
+		WeightKg:    roundTo(rollup.WeightKg, 2),
+		CO2eAvoided: roundTo(rollup.CO2eAvoided, 4),
+		PickupCount: rollup.PickupCount,
+	}
+}
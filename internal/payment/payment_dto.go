@@ -0,0 +1,71 @@
+package payment
+
+import "strings"
+
+type CreatePaymentDTO struct {
+	Purpose     string `json:"purpose"`
+	ReferenceID string `json:"reference_id"`
+	Channel     string `json:"channel"`
+}
+
+func (r *CreatePaymentDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Purpose = strings.TrimSpace(r.Purpose)
+	r.ReferenceID = strings.TrimSpace(r.ReferenceID)
+	r.Channel = strings.ToLower(strings.TrimSpace(r.Channel))
+
+	if r.Purpose != PurposePickupSettlement && r.Purpose != PurposeStoreOrder {
+		errors["purpose"] = append(errors["purpose"], "tujuan pembayaran harus pickup_settlement atau store_order")
+	}
+	if r.ReferenceID == "" {
+		errors["reference_id"] = append(errors["reference_id"], "reference_id wajib diisi")
+	}
+	if r.Channel == "" {
+		errors["channel"] = append(errors["channel"], "channel pembayaran wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type SimulatePaymentDTO struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (r *SimulatePaymentDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Status = strings.ToLower(strings.TrimSpace(r.Status))
+	if r.Status != StatusPaid && r.Status != StatusFailed && r.Status != StatusExpired {
+		errors["status"] = append(errors["status"], "status harus paid, failed, atau expired")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type PaymentResponseDTO struct {
+	ID               string  `json:"id"`
+	Provider         string  `json:"provider"`
+	ProviderChargeID *string `json:"provider_charge_id,omitempty"`
+	Channel          string  `json:"channel"`
+	Purpose          string  `json:"purpose"`
+	ReferenceID      string  `json:"reference_id"`
+	PayerID          string  `json:"payer_id"`
+	Amount           float64 `json:"amount"`
+	Status           string  `json:"status"`
+	PaymentURL       string  `json:"payment_url,omitempty"`
+	QRString         string  `json:"qr_string,omitempty"`
+	VANumber         string  `json:"va_number,omitempty"`
+	FailureReason    string  `json:"failure_reason,omitempty"`
+	SettlementError  string  `json:"settlement_error,omitempty"`
+	ExpiresAt        string  `json:"expires_at,omitempty"`
+	PaidAt           string  `json:"paid_at,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+}
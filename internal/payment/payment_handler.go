@@ -0,0 +1,157 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"rijig/internal/store"
+	"rijig/internal/wallet"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PaymentHandler struct {
+	service PaymentService
+}
+
+func NewPaymentHandler(service PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreatePaymentDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	payment, err := h.service.CreatePayment(context.Background(), PaymentActor{UserID: claims.UserID, Role: claims.Role}, req)
+	if err != nil {
+		return handlePaymentError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Tagihan pembayaran berhasil dibuat", payment)
+}
+
+func (h *PaymentHandler) GetPayment(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	payment, err := h.service.GetPayment(context.Background(), PaymentActor{UserID: claims.UserID, Role: claims.Role}, c.Params("id"))
+	if err != nil {
+		return handlePaymentError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pembayaran berhasil diambil", payment)
+}
+
+func (h *PaymentHandler) GetMyPayments(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respondPayments(c, claims.UserID)
+}
+
+func (h *PaymentHandler) GetPayments(c *fiber.Ctx) error {
+	return h.respondPayments(c, "")
+}
+
+func (h *PaymentHandler) respondPayments(c *fiber.Ctx, payerID string) error {
+	limit, offset, page := utils.ParsePagination(c, 10)
+
+	payments, total, err := h.service.ListPayments(context.Background(), payerID, c.Query("status"), limit, offset)
+	if err != nil {
+		return handlePaymentError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar pembayaran berhasil diambil", payments, page, limit, int(total))
+}
+
+func (h *PaymentHandler) RefreshPayment(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	payment, err := h.service.RefreshPayment(context.Background(), PaymentActor{UserID: claims.UserID, Role: claims.Role}, c.Params("id"))
+	if err != nil {
+		return handlePaymentError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Status pembayaran berhasil diperbarui", payment)
+}
+
+func (h *PaymentHandler) SimulatePayment(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req SimulatePaymentDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	payment, err := h.service.SimulatePayment(context.Background(), PaymentActor{UserID: claims.UserID, Role: claims.Role}, c.Params("id"), req)
+	if err != nil {
+		return handlePaymentError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Simulasi pembayaran berhasil", payment)
+}
+
+// HandleWebhook is called by the provider, not by a user; the provider signature is
+// the only authentication.
+func (h *PaymentHandler) HandleWebhook(c *fiber.Ctx) error {
+	err := h.service.HandleWebhook(context.Background(), c.Params("provider"), func(key string) string {
+		return c.Get(key)
+	}, c.Body())
+	if err != nil {
+		return handlePaymentError(c, err)
+	}
+
+	return utils.Success(c, "Webhook diterima")
+}
+
+func handlePaymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrPaymentNotFound), errors.Is(err, ErrProviderNotFound), errors.Is(err, ErrChargeNotFound),
+		errors.Is(err, wallet.ErrSettlementNotFound), errors.Is(err, store.ErrOrderNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrInvalidSignature):
+		return utils.ResponseErrorData(c, fiber.StatusUnauthorized, err.Error(), nil)
+	case errors.Is(err, wallet.ErrNotPickupCollector), errors.Is(err, store.ErrOrderForbidden):
+		return utils.Forbidden(c, err.Error())
+	case errors.Is(err, ErrUnknownPurpose), errors.Is(err, ErrChannelNotSupported), errors.Is(err, ErrAmountMismatch),
+		errors.Is(err, ErrNotSimulated):
+		return utils.BadRequest(c, err.Error())
+	case errors.Is(err, ErrPaymentNotPending), errors.Is(err, ErrPaymentInProgress), errors.Is(err, ErrChargeNotPending),
+		errors.Is(err, wallet.ErrNothingOutstanding), errors.Is(err, store.ErrOrderNotPayable):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	case strings.HasPrefix(err.Error(), "forbidden"):
+		return utils.Forbidden(c, err.Error())
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
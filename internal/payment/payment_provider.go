@@ -0,0 +1,112 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusPaid      = "paid"
+	StatusFailed    = "failed"
+	StatusExpired   = "expired"
+	StatusCancelled = "cancelled"
+
+	// Channels a provider may offer. Indonesian gateways mostly expose QRIS and bank
+	// virtual accounts; a provider lists the ones it supports in Channels.
+	ChannelQRIS           = "qris"
+	ChannelVirtualAccount = "virtual_account"
+)
+
+var (
+	ErrProviderNotFound = errors.New("penyedia pembayaran tidak dikenal")
+	ErrInvalidSignature = errors.New("tanda tangan webhook tidak valid")
+	ErrChargeNotFound   = errors.New("tagihan tidak ditemukan di penyedia pembayaran")
+	ErrChargeNotPending = errors.New("tagihan di penyedia pembayaran sudah tidak menunggu pembayaran")
+
+	// configuration errors stop the server at startup
+	ErrNoProviderConfigured = errors.New("PAYMENT_PROVIDER is not set; set it or enable the simulator with PAYMENT_SIMULATOR_ENABLED=true")
+	ErrSimulatorDisabled    = errors.New("PAYMENT_PROVIDER is simulator but PAYMENT_SIMULATOR_ENABLED is not true")
+)
+
+// ChargeRequest asks a provider to open a charge for a payment.
+type ChargeRequest struct {
+	PaymentID   string
+	Channel     string
+	Amount      float64
+	Description string
+	ExpiresAt   time.Time
+}
+
+// Charge is the provider's view of a charge. Depending on the channel the payer uses
+// PaymentURL, QRString or VANumber to pay.
+type Charge struct {
+	ChargeID   string
+	Status     string
+	Amount     float64
+	PaymentURL string
+	QRString   string
+	VANumber   string
+	ExpiresAt  *time.Time
+	PaidAt     *time.Time
+	Reason     string
+}
+
+// WebhookEvent is a verified notification about a charge.
+type WebhookEvent struct {
+	EventID    string
+	ChargeID   string
+	Status     string
+	Amount     float64
+	Reason     string
+	OccurredAt time.Time
+	Payload    string
+}
+
+// Provider is implemented by every payment gateway. ParseWebhook must verify the
+// signature of the request before trusting anything in it. CancelCharge must fail
+// when the charge can no longer be cancelled, for instance because it was paid.
+type Provider interface {
+	Name() string
+	Channels() []string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	CheckStatus(ctx context.Context, chargeID string) (*Charge, error)
+	CancelCharge(ctx context.Context, chargeID string) error
+	ParseWebhook(header func(key string) string, body []byte) (*WebhookEvent, error)
+}
+
+// ProviderRegistry holds the configured providers and the one new payments use.
+type ProviderRegistry struct {
+	providers map[string]Provider
+	active    string
+}
+
+func NewProviderRegistry(active string, providers ...Provider) *ProviderRegistry {
+	registry := &ProviderRegistry{providers: make(map[string]Provider), active: active}
+	for _, provider := range providers {
+		registry.providers[provider.Name()] = provider
+	}
+	return registry
+}
+
+func (r *ProviderRegistry) Get(name string) (Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	return provider, nil
+}
+
+func (r *ProviderRegistry) Active() (Provider, error) {
+	return r.Get(r.active)
+}
+
+func supportsChannel(provider Provider, channel string) bool {
+	for _, supported := range provider.Channels() {
+		if supported == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package payment
+
+import (
+	"context"
+
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *model.Payment) error
+	GetPaymentByID(ctx context.Context, id string) (*model.Payment, error)
+	GetPaymentForUpdate(ctx context.Context, id string) (*model.Payment, error)
+	GetPaymentByCharge(ctx context.Context, provider, chargeID string) (*model.Payment, error)
+	LockReference(ctx context.Context, purpose, referenceID string) error
+	GetOpenPayments(ctx context.Context, purpose, referenceID string) ([]model.Payment, error)
+	UpdatePaymentFields(ctx context.Context, id string, fields map[string]interface{}) error
+	UpdatePendingPayment(ctx context.Context, id string, fields map[string]interface{}) (bool, error)
+	ListPayments(ctx context.Context, payerID, status string, limit, offset int) ([]model.Payment, int64, error)
+	CreateEvent(ctx context.Context, event *model.PaymentEvent) (bool, error)
+
+	WithTx(tx *gorm.DB) PaymentRepository
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository() PaymentRepository {
+	return &paymentRepository{db: config.DB}
+}
+
+func (r *paymentRepository) WithTx(tx *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: tx}
+}
+
+func (r *paymentRepository) CreatePayment(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) GetPaymentByID(ctx context.Context, id string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetPaymentForUpdate(ctx context.Context, id string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetPaymentByCharge(ctx context.Context, provider, chargeID string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := r.db.WithContext(ctx).
+		Where("provider = ? AND provider_charge_id = ?", provider, chargeID).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// LockReference takes a transaction-scoped advisory lock on a purpose and reference,
+// so only one payment for it is opened at a time.
+func (r *paymentRepository) LockReference(ctx context.Context, purpose, referenceID string) error {
+	return r.db.WithContext(ctx).
+		Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "payment:"+purpose+":"+referenceID).Error
+}
+
+// GetOpenPayments returns the payments of a reference that may still be paid: pending
+// ones, and expired ones because gateways can confirm late transfers. Newest first.
+func (r *paymentRepository) GetOpenPayments(ctx context.Context, purpose, referenceID string) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.WithContext(ctx).
+		Where("purpose = ? AND reference_id = ? AND status IN ?", purpose, referenceID, []string{StatusPending, StatusExpired}).
+		Order("created_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) UpdatePaymentFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Payment{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// UpdatePendingPayment updates a payment only while it is still pending and reports
+// whether it was.
+func (r *paymentRepository) UpdatePendingPayment(ctx context.Context, id string, fields map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Payment{}).
+		Where("id = ? AND status = ?", id, StatusPending).
+		Updates(fields)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *paymentRepository) ListPayments(ctx context.Context, payerID, status string, limit, offset int) ([]model.Payment, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.Payment{})
+	if payerID != "" {
+		query = query.Where("payer_id = ?", payerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var payments []model.Payment
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&payments).Error; err != nil {
+		return nil, 0, err
+	}
+	return payments, total, nil
+}
+
+// CreateEvent stores a webhook event and reports false when the provider already
+// delivered an event with the same id.
+func (r *paymentRepository) CreateEvent(ctx context.Context, event *model.PaymentEvent) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provider"}, {Name: "event_id"}},
+			DoNothing: true,
+		}).
+		Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
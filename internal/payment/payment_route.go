@@ -0,0 +1,110 @@
+package payment
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"rijig/config"
+	"rijig/internal/points"
+	"rijig/internal/store"
+	"rijig/internal/wallet"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	registryOnce     sync.Once
+	registry         *ProviderRegistry
+	simulatorEnabled bool
+)
+
+// providerRegistry builds the providers once; both routers share it. A real gateway
+// is chosen with PAYMENT_PROVIDER and registered here as it is added. The simulator
+// is only registered when PAYMENT_SIMULATOR_ENABLED is true, and the server refuses
+// to start when neither gives it a usable provider, so payments are never settled by
+// a simulator nobody asked for.
+func providerRegistry() *ProviderRegistry {
+	registryOnce.Do(func() {
+		var err error
+		registry, simulatorEnabled, err = buildProviderRegistry(os.Getenv, NewDBSimulatorChargeStore(config.DB))
+		if err != nil {
+			log.Fatalf("[PAYMENT] %v", err)
+		}
+	})
+	return registry
+}
+
+func buildProviderRegistry(getenv func(key string) string, simulatorStore SimulatorChargeStore) (*ProviderRegistry, bool, error) {
+	simulator := getenv("PAYMENT_SIMULATOR_ENABLED") == "true"
+
+	active := getenv("PAYMENT_PROVIDER")
+	if active == "" {
+		if !simulator {
+			return nil, false, ErrNoProviderConfigured
+		}
+		active = SimulatorName
+	}
+	if active == SimulatorName && !simulator {
+		return nil, false, ErrSimulatorDisabled
+	}
+
+	var providers []Provider
+	if simulator {
+		providers = append(providers, NewSimulatorProvider(simulatorSecret(), simulatorStore))
+	}
+	registry := NewProviderRegistry(active, providers...)
+	if _, err := registry.Active(); err != nil {
+		return nil, false, fmt.Errorf("PAYMENT_PROVIDER %q: %w", active, err)
+	}
+	return registry, simulator, nil
+}
+
+func newPaymentService() PaymentService {
+	walletService := wallet.NewWalletService(config.DB, wallet.NewWalletRepository())
+	pointsService := points.NewPointsService(config.DB, points.NewPointsRepository())
+	orderService := store.NewStoreOrderService(config.DB, store.NewStoreRepository(), pointsService, nil)
+
+	return NewPaymentService(config.DB, NewPaymentRepository(), providerRegistry(), map[string]PaymentTarget{
+		PurposePickupSettlement: NewPickupSettlementTarget(walletService),
+		PurposeStoreOrder:       NewStoreOrderTarget(orderService),
+	})
+}
+
+func newPaymentHandler() *PaymentHandler {
+	return NewPaymentHandler(newPaymentService())
+}
+
+// StoreOrderPayments is what the store router uses to withdraw the charges of the
+// orders it cancels.
+func StoreOrderPayments() store.OrderPaymentCanceller {
+	return NewStoreOrderPayments(newPaymentService())
+}
+
+// PaymentWebhookRouter must be mounted outside the API key middleware: providers
+// authenticate with their webhook signature instead.
+func PaymentWebhookRouter(router fiber.Router) {
+	handler := newPaymentHandler()
+
+	router.Post("/payment-webhooks/:provider", handler.HandleWebhook)
+}
+
+func PaymentRouter(api fiber.Router) {
+	handler := newPaymentHandler()
+
+	payments := api.Group("/payments")
+	payments.Use(middleware.AuthMiddleware())
+
+	payments.Post("/", middleware.RequireRoles(utils.RoleMasyarakat, utils.RolePengepul), handler.CreatePayment)
+	payments.Get("/me", handler.GetMyPayments)
+	payments.Get("/", middleware.RequireRoles(utils.RoleAdministrator), handler.GetPayments)
+	payments.Get("/:id", handler.GetPayment)
+	payments.Post("/:id/refresh", handler.RefreshPayment)
+
+	if simulatorEnabled {
+		payments.Post("/:id/simulate", middleware.RequireRoles(utils.RoleAdministrator), handler.SimulatePayment)
+	}
+}
@@ -0,0 +1,528 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"rijig/model"
+	"rijig/utils"
+
+	"gorm.io/gorm"
+)
+
+const defaultPaymentExpiry = 24 * time.Hour
+
+var (
+	ErrPaymentNotFound     = errors.New("pembayaran tidak ditemukan")
+	ErrPaymentForbidden    = errors.New("forbidden: anda tidak memiliki akses ke pembayaran ini")
+	ErrUnknownPurpose      = errors.New("tujuan pembayaran tidak dikenal")
+	ErrChannelNotSupported = errors.New("channel pembayaran tidak didukung oleh penyedia")
+	ErrAmountMismatch      = errors.New("jumlah pembayaran tidak sesuai dengan tagihan")
+	ErrNotSimulated        = errors.New("pembayaran ini tidak menggunakan simulator")
+	ErrPaymentNotPending   = errors.New("pembayaran sudah tidak menunggu pembayaran")
+	ErrPaymentInProgress   = errors.New("pembayaran sebelumnya untuk tagihan ini masih diproses, coba lagi nanti")
+)
+
+// paymentTransitions lists, per current status, the statuses a payment may move to.
+// An expired charge may still turn paid because gateways can confirm late transfers,
+// until it is withdrawn at the provider.
+var paymentTransitions = map[string][]string{
+	StatusPending: {StatusPaid, StatusFailed, StatusExpired, StatusCancelled},
+	StatusExpired: {StatusPaid, StatusCancelled},
+}
+
+// PaymentActor is the user asking for a payment action.
+type PaymentActor struct {
+	UserID string
+	Role   string
+}
+
+type PaymentService interface {
+	CreatePayment(ctx context.Context, actor PaymentActor, req CreatePaymentDTO) (*PaymentResponseDTO, error)
+	GetPayment(ctx context.Context, actor PaymentActor, paymentID string) (*PaymentResponseDTO, error)
+	ListPayments(ctx context.Context, payerID, status string, limit, offset int) ([]PaymentResponseDTO, int64, error)
+	RefreshPayment(ctx context.Context, actor PaymentActor, paymentID string) (*PaymentResponseDTO, error)
+	HandleWebhook(ctx context.Context, providerName string, header func(key string) string, body []byte) error
+	SimulatePayment(ctx context.Context, actor PaymentActor, paymentID string, req SimulatePaymentDTO) (*PaymentResponseDTO, error)
+	CancelReferencePayments(ctx context.Context, purpose, referenceID, reason string) error
+}
+
+type paymentService struct {
+	db        *gorm.DB
+	repo      PaymentRepository
+	providers *ProviderRegistry
+	targets   map[string]PaymentTarget
+}
+
+func NewPaymentService(db *gorm.DB, repo PaymentRepository, providers *ProviderRegistry, targets map[string]PaymentTarget) PaymentService {
+	return &paymentService{db: db, repo: repo, providers: providers, targets: targets}
+}
+
+// paymentExpiry reads PAYMENT_EXPIRY, how long a charge stays payable.
+func paymentExpiry() time.Duration {
+	raw := os.Getenv("PAYMENT_EXPIRY")
+	if raw == "" {
+		return defaultPaymentExpiry
+	}
+
+	expiry, err := time.ParseDuration(raw)
+	if err != nil || expiry <= 0 {
+		log.Printf("[PAYMENT] invalid PAYMENT_EXPIRY %q, using %s", raw, defaultPaymentExpiry)
+		return defaultPaymentExpiry
+	}
+	return expiry
+}
+
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// CreatePayment opens a charge with the active provider for whatever the reference
+// still needs. Payments of one reference are opened one at a time: an open charge of
+// the payer for the same amount and channel is reused, any other open charge is
+// cancelled at its provider first, and when that fails (it may have just been paid)
+// the new payment is refused, so a reference can never be paid twice. The provider
+// is never called inside a transaction, so a rollback cannot leave the gateway with
+// a charge the database does not know about.
+func (s *paymentService) CreatePayment(ctx context.Context, actor PaymentActor, req CreatePaymentDTO) (*PaymentResponseDTO, error) {
+	target, ok := s.targets[req.Purpose]
+	if !ok {
+		return nil, ErrUnknownPurpose
+	}
+	provider, err := s.providers.Active()
+	if err != nil {
+		return nil, err
+	}
+	if !supportsChannel(provider, req.Channel) {
+		return nil, ErrChannelNotSupported
+	}
+
+	reused, replaced, err := s.openPayments(ctx, target, actor, provider.Name(), req)
+	if err != nil {
+		return nil, err
+	}
+	if reused != "" {
+		return s.getPayment(ctx, reused)
+	}
+	for i := range replaced {
+		if err := s.cancelOpenPayment(ctx, &replaced[i], "digantikan oleh pembayaran baru"); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentInProgress, err)
+		}
+	}
+
+	// the reference is checked again: it may have been paid or given another payment
+	// while the replaced charges were being cancelled
+	var payment *model.Payment
+	var payable *PayableTarget
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.LockReference(ctx, req.Purpose, req.ReferenceID); err != nil {
+			return err
+		}
+
+		payable, err = target.Resolve(ctx, actor.UserID, req.ReferenceID)
+		if err != nil {
+			return err
+		}
+		open, err := repo.GetOpenPayments(ctx, req.Purpose, req.ReferenceID)
+		if err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			return ErrPaymentInProgress
+		}
+
+		expiresAt := time.Now().Add(paymentExpiry())
+		payment = &model.Payment{
+			Provider:    provider.Name(),
+			Channel:     req.Channel,
+			Purpose:     req.Purpose,
+			ReferenceID: req.ReferenceID,
+			PayerID:     actor.UserID,
+			Amount:      math.Round(payable.Amount*100) / 100,
+			Status:      StatusPending,
+			ExpiresAt:   &expiresAt,
+		}
+		return repo.CreatePayment(ctx, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	charge, err := provider.CreateCharge(ctx, ChargeRequest{
+		PaymentID:   payment.ID,
+		Channel:     req.Channel,
+		Amount:      payment.Amount,
+		Description: payable.Description,
+		ExpiresAt:   *payment.ExpiresAt,
+	})
+	if err != nil {
+		// the failed attempt is kept for review
+		if _, updateErr := s.repo.UpdatePendingPayment(ctx, payment.ID, map[string]interface{}{
+			"status":         StatusFailed,
+			"failure_reason": err.Error(),
+		}); updateErr != nil {
+			log.Printf("[PAYMENT] failed to record charge failure of payment %s: %v", payment.ID, updateErr)
+		}
+		return nil, fmt.Errorf("failed to create charge: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"provider_charge_id": charge.ChargeID,
+		"payment_url":        charge.PaymentURL,
+		"qr_string":          charge.QRString,
+		"va_number":          charge.VANumber,
+	}
+	if charge.ExpiresAt != nil {
+		fields["expires_at"] = *charge.ExpiresAt
+	}
+	recorded, err := s.repo.UpdatePendingPayment(ctx, payment.ID, fields)
+	if err == nil && !recorded {
+		err = ErrPaymentNotPending
+	}
+	if err != nil {
+		// nothing points at the charge, so nobody could settle it if it were paid
+		if cancelErr := provider.CancelCharge(ctx, charge.ChargeID); cancelErr != nil {
+			log.Printf("[PAYMENT] charge %s of payment %s is open but not recorded: %v", charge.ChargeID, payment.ID, cancelErr)
+		}
+		return nil, fmt.Errorf("failed to record charge: %w", err)
+	}
+
+	return s.getPayment(ctx, payment.ID)
+}
+
+// openPayments looks at the open payments of a reference under its lock. It returns
+// the id of one the payer can be handed back, or else the ones to replace.
+func (s *paymentService) openPayments(ctx context.Context, target PaymentTarget, actor PaymentActor, provider string, req CreatePaymentDTO) (string, []model.Payment, error) {
+	var reused string
+	var open []model.Payment
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.LockReference(ctx, req.Purpose, req.ReferenceID); err != nil {
+			return err
+		}
+
+		payable, err := target.Resolve(ctx, actor.UserID, req.ReferenceID)
+		if err != nil {
+			return err
+		}
+		amount := math.Round(payable.Amount*100) / 100
+
+		open, err = repo.GetOpenPayments(ctx, req.Purpose, req.ReferenceID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range open {
+			if reusablePayment(&open[i], actor.UserID, provider, req.Channel, amount, now) {
+				reused = open[i].ID
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return reused, open, nil
+}
+
+// reusablePayment reports whether an open payment is exactly what the payer is asking
+// for again, so its charge can be handed back instead of opening another one.
+func reusablePayment(payment *model.Payment, payerID, provider, channel string, amount float64, now time.Time) bool {
+	return payment.Status == StatusPending &&
+		payment.PayerID == payerID &&
+		payment.Provider == provider &&
+		payment.Channel == channel &&
+		payment.ProviderChargeID != nil &&
+		(payment.ExpiresAt == nil || payment.ExpiresAt.After(now)) &&
+		sameAmount(payment.Amount, amount)
+}
+
+// cancelOpenPayment withdraws a payment's charge at its provider and only then marks
+// the payment cancelled. A charge the provider refuses to cancel may already be paid,
+// so the payment is left for its webhook and the error returned.
+func (s *paymentService) cancelOpenPayment(ctx context.Context, payment *model.Payment, reason string) error {
+	if payment.ProviderChargeID != nil {
+		provider, err := s.providers.Get(payment.Provider)
+		if err != nil {
+			return err
+		}
+		if err := provider.CancelCharge(ctx, *payment.ProviderChargeID); err != nil {
+			return err
+		}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.applyStatus(ctx, tx, payment.ID, StatusCancelled, 0, reason, nil)
+	})
+}
+
+// CancelReferencePayments withdraws every open charge of a reference that was called
+// off. Charges that can no longer be cancelled are left for their webhook, and their
+// errors are returned together.
+func (s *paymentService) CancelReferencePayments(ctx context.Context, purpose, referenceID, reason string) error {
+	open, err := s.repo.GetOpenPayments(ctx, purpose, referenceID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range open {
+		if err := s.cancelOpenPayment(ctx, &open[i], reason); err != nil {
+			errs = append(errs, fmt.Errorf("payment %s: %w", open[i].ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *paymentService) GetPayment(ctx context.Context, actor PaymentActor, paymentID string) (*PaymentResponseDTO, error) {
+	payment, err := s.authorizedPayment(ctx, actor, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentResponse(payment), nil
+}
+
+func (s *paymentService) ListPayments(ctx context.Context, payerID, status string, limit, offset int) ([]PaymentResponseDTO, int64, error) {
+	payments, total, err := s.repo.ListPayments(ctx, payerID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]PaymentResponseDTO, 0, len(payments))
+	for i := range payments {
+		response = append(response, *toPaymentResponse(&payments[i]))
+	}
+	return response, total, nil
+}
+
+// RefreshPayment asks the provider for the charge status, for when a webhook was lost.
+func (s *paymentService) RefreshPayment(ctx context.Context, actor PaymentActor, paymentID string) (*PaymentResponseDTO, error) {
+	payment, err := s.authorizedPayment(ctx, actor, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.ProviderChargeID == nil {
+		return toPaymentResponse(payment), nil
+	}
+
+	provider, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	charge, err := provider.CheckStatus(ctx, *payment.ProviderChargeID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.applyStatus(ctx, tx, payment.ID, charge.Status, charge.Amount, charge.Reason, charge.PaidAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getPayment(ctx, payment.ID)
+}
+
+// HandleWebhook verifies and applies a provider notification. Redelivered events are
+// recognised by their id and ignored.
+func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, header func(key string) string, body []byte) error {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return err
+	}
+
+	event, err := provider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.repo.GetPaymentByCharge(ctx, provider.Name(), event.ChargeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created, err := s.repo.WithTx(tx).CreateEvent(ctx, &model.PaymentEvent{
+			PaymentID: payment.ID,
+			Provider:  provider.Name(),
+			EventID:   event.EventID,
+			Status:    event.Status,
+			Payload:   event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+		if !created {
+			return nil
+		}
+
+		var paidAt *time.Time
+		if event.Status == StatusPaid {
+			paidAt = &event.OccurredAt
+		}
+		return s.applyStatus(ctx, tx, payment.ID, event.Status, event.Amount, event.Reason, paidAt)
+	})
+}
+
+// SimulatePayment completes a simulator charge and feeds the signed webhook through
+// HandleWebhook, exactly as a real gateway notification would arrive.
+func (s *paymentService) SimulatePayment(ctx context.Context, actor PaymentActor, paymentID string, req SimulatePaymentDTO) (*PaymentResponseDTO, error) {
+	payment, err := s.authorizedPayment(ctx, actor, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Provider != SimulatorName || payment.ProviderChargeID == nil {
+		return nil, ErrNotSimulated
+	}
+	if payment.Status != StatusPending {
+		return nil, ErrPaymentNotPending
+	}
+
+	provider, err := s.providers.Get(SimulatorName)
+	if err != nil {
+		return nil, err
+	}
+	simulator, ok := provider.(*SimulatorProvider)
+	if !ok {
+		return nil, ErrNotSimulated
+	}
+
+	body, signature, err := simulator.Complete(ctx, *payment.ProviderChargeID, req.Status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return nil, err
+	}
+	header := func(key string) string {
+		if key == simulator.SignatureHeader() {
+			return signature
+		}
+		return ""
+	}
+	if err := s.HandleWebhook(ctx, SimulatorName, header, body); err != nil {
+		return nil, err
+	}
+
+	return s.getPayment(ctx, payment.ID)
+}
+
+// applyStatus moves a locked payment to status. A paid payment is booked by its target
+// inside a savepoint: if booking fails (say the order was cancelled meanwhile) the
+// money has still arrived, so the payment stays paid and the error is kept for review.
+func (s *paymentService) applyStatus(ctx context.Context, tx *gorm.DB, paymentID, status string, amount float64, reason string, paidAt *time.Time) error {
+	repo := s.repo.WithTx(tx)
+
+	payment, err := repo.GetPaymentForUpdate(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status == status {
+		return nil
+	}
+	if !canTransition(payment.Status, status) {
+		log.Printf("[PAYMENT] ignoring %s -> %s for payment %s", payment.Status, status, payment.ID)
+		return nil
+	}
+	if status == StatusPaid && !sameAmount(amount, payment.Amount) {
+		return fmt.Errorf("%w: %.2f, tagihan %.2f", ErrAmountMismatch, amount, payment.Amount)
+	}
+
+	now := time.Now()
+	fields := map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+	}
+	switch status {
+	case StatusPaid:
+		if paidAt == nil || paidAt.IsZero() {
+			paidAt = &now
+		}
+		payment.PaidAt = paidAt
+		fields["paid_at"] = *paidAt
+
+		target, ok := s.targets[payment.Purpose]
+		if !ok {
+			fields["settlement_error"] = ErrUnknownPurpose.Error()
+			break
+		}
+		if err := tx.Transaction(func(sp *gorm.DB) error {
+			return target.OnPaid(ctx, sp, payment)
+		}); err != nil {
+			log.Printf("[PAYMENT] payment %s paid but could not be settled: %v", payment.ID, err)
+			fields["settlement_error"] = err.Error()
+		}
+	case StatusFailed, StatusCancelled:
+		fields["failure_reason"] = reason
+	}
+
+	return repo.UpdatePaymentFields(ctx, payment.ID, fields)
+}
+
+func canTransition(from, to string) bool {
+	for _, allowed := range paymentTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *paymentService) authorizedPayment(ctx context.Context, actor PaymentActor, paymentID string) (*model.Payment, error) {
+	payment, err := s.repo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if actor.Role != utils.RoleAdministrator && payment.PayerID != actor.UserID {
+		return nil, ErrPaymentForbidden
+	}
+	return payment, nil
+}
+
+func (s *paymentService) getPayment(ctx context.Context, paymentID string) (*PaymentResponseDTO, error) {
+	payment, err := s.repo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentResponse(payment), nil
+}
+
+func toPaymentResponse(payment *model.Payment) *PaymentResponseDTO {
+	response := &PaymentResponseDTO{
+		ID:               payment.ID,
+		Provider:         payment.Provider,
+		ProviderChargeID: payment.ProviderChargeID,
+		Channel:          payment.Channel,
+		Purpose:          payment.Purpose,
+		ReferenceID:      payment.ReferenceID,
+		PayerID:          payment.PayerID,
+		Amount:           payment.Amount,
+		Status:           payment.Status,
+		PaymentURL:       payment.PaymentURL,
+		QRString:         payment.QRString,
+		VANumber:         payment.VANumber,
+		FailureReason:    payment.FailureReason,
+		SettlementError:  payment.SettlementError,
+		CreatedAt:        payment.CreatedAt.Format(time.RFC3339),
+	}
+	if payment.ExpiresAt != nil {
+		response.ExpiresAt = payment.ExpiresAt.Format(time.RFC3339)
+	}
+	if payment.PaidAt != nil {
+		response.PaidAt = payment.PaidAt.Format(time.RFC3339)
+	}
+	return response
+}
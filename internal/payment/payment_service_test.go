@@ -0,0 +1,230 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"rijig/model"
+	"rijig/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPurpose = "test_purpose"
+
+// fakeTarget prices every reference at amount and counts the payments booked.
+type fakeTarget struct {
+	mu      sync.Mutex
+	amount  float64
+	failErr error
+	paid    map[string]int
+}
+
+func (t *fakeTarget) Resolve(ctx context.Context, payerID, referenceID string) (*PayableTarget, error) {
+	return &PayableTarget{Amount: t.amount, Description: "test " + referenceID}, nil
+}
+
+func (t *fakeTarget) OnPaid(ctx context.Context, tx *gorm.DB, payment *model.Payment) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failErr != nil {
+		return t.failErr
+	}
+	t.paid[payment.ReferenceID]++
+	return nil
+}
+
+// newTestPaymentService runs the payment flow against the simulator and the database
+// in TEST_DATABASE_URL; the tests are skipped without it.
+func newTestPaymentService(t *testing.T) (PaymentService, *SimulatorProvider, *fakeTarget, *gorm.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatalf("extension: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Payment{}, &model.PaymentEvent{}, &model.PaymentSimulatorCharge{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	simulator := NewSimulatorProvider("test-secret", NewDBSimulatorChargeStore(db))
+	target := &fakeTarget{amount: 25000, paid: make(map[string]int)}
+	service := NewPaymentService(db, &paymentRepository{db: db}, NewProviderRegistry(SimulatorName, simulator),
+		map[string]PaymentTarget{testPurpose: target})
+	return service, simulator, target, db
+}
+
+func createTestPayment(t *testing.T, service PaymentService, payer PaymentActor, referenceID, channel string) *PaymentResponseDTO {
+	t.Helper()
+
+	payment, err := service.CreatePayment(context.Background(), payer, CreatePaymentDTO{
+		Purpose:     testPurpose,
+		ReferenceID: referenceID,
+		Channel:     channel,
+	})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	return payment
+}
+
+func TestPaymentPaidThroughSimulator(t *testing.T) {
+	service, _, target, _ := newTestPaymentService(t)
+	ctx := context.Background()
+	payer := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+	admin := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleAdministrator}
+	referenceID := uuid.NewString()
+
+	payment := createTestPayment(t, service, payer, referenceID, ChannelQRIS)
+	if payment.Status != StatusPending || payment.ProviderChargeID == nil || payment.Amount != 25000 {
+		t.Fatalf("unexpected payment %+v", payment)
+	}
+
+	paid, err := service.SimulatePayment(ctx, admin, payment.ID, SimulatePaymentDTO{Status: StatusPaid})
+	if err != nil {
+		t.Fatalf("SimulatePayment: %v", err)
+	}
+	if paid.Status != StatusPaid || paid.PaidAt == "" || paid.SettlementError != "" {
+		t.Fatalf("payment not settled: %+v", paid)
+	}
+	if target.paid[referenceID] != 1 {
+		t.Fatalf("OnPaid called %d times, want 1", target.paid[referenceID])
+	}
+
+	if _, err := service.SimulatePayment(ctx, admin, payment.ID, SimulatePaymentDTO{Status: StatusPaid}); !errors.Is(err, ErrPaymentNotPending) {
+		t.Fatalf("second simulate: err = %v, want ErrPaymentNotPending", err)
+	}
+}
+
+func TestPaymentWebhookRedeliveryIsIgnored(t *testing.T) {
+	service, simulator, target, _ := newTestPaymentService(t)
+	ctx := context.Background()
+	payer := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+	referenceID := uuid.NewString()
+
+	payment := createTestPayment(t, service, payer, referenceID, ChannelQRIS)
+	body, signature, err := simulator.Complete(ctx, *payment.ProviderChargeID, StatusPaid, "")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	header := simulatorHeader(simulator, signature)
+	for i := 0; i < 3; i++ {
+		if err := service.HandleWebhook(ctx, SimulatorName, header, body); err != nil {
+			t.Fatalf("delivery %d: %v", i, err)
+		}
+	}
+	if target.paid[referenceID] != 1 {
+		t.Fatalf("OnPaid called %d times, want 1", target.paid[referenceID])
+	}
+
+	forged := simulatorHeader(simulator, "forged")
+	if err := service.HandleWebhook(ctx, SimulatorName, forged, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("forged webhook: err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestPaymentReusesOpenChargeAndCancelsReplacedOne(t *testing.T) {
+	service, simulator, _, _ := newTestPaymentService(t)
+	ctx := context.Background()
+	payer := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+	referenceID := uuid.NewString()
+
+	first := createTestPayment(t, service, payer, referenceID, ChannelQRIS)
+	again := createTestPayment(t, service, payer, referenceID, ChannelQRIS)
+	if again.ID != first.ID {
+		t.Fatalf("identical request opened payment %s, want reuse of %s", again.ID, first.ID)
+	}
+
+	second := createTestPayment(t, service, payer, referenceID, ChannelVirtualAccount)
+	if second.ID == first.ID {
+		t.Fatal("different channel reused the open payment")
+	}
+
+	replaced, err := service.GetPayment(ctx, payer, first.ID)
+	if err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+	if replaced.Status != StatusCancelled {
+		t.Fatalf("replaced payment status = %s, want cancelled", replaced.Status)
+	}
+	charge, err := simulator.CheckStatus(ctx, *first.ProviderChargeID)
+	if err != nil {
+		t.Fatalf("CheckStatus: %v", err)
+	}
+	if charge.Status != StatusCancelled {
+		t.Fatalf("replaced charge status = %s, want cancelled", charge.Status)
+	}
+}
+
+func TestPaymentRefusedWhileEarlierChargeIsPaid(t *testing.T) {
+	service, simulator, target, _ := newTestPaymentService(t)
+	ctx := context.Background()
+	payer := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+	referenceID := uuid.NewString()
+
+	first := createTestPayment(t, service, payer, referenceID, ChannelQRIS)
+
+	// the payer has paid but the webhook has not arrived yet
+	body, signature, err := simulator.Complete(ctx, *first.ProviderChargeID, StatusPaid, "")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	_, err = service.CreatePayment(ctx, payer, CreatePaymentDTO{Purpose: testPurpose, ReferenceID: referenceID, Channel: ChannelVirtualAccount})
+	if !errors.Is(err, ErrPaymentInProgress) {
+		t.Fatalf("CreatePayment: err = %v, want ErrPaymentInProgress", err)
+	}
+
+	if err := service.HandleWebhook(ctx, SimulatorName, simulatorHeader(simulator, signature), body); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if target.paid[referenceID] != 1 {
+		t.Fatalf("OnPaid called %d times, want 1", target.paid[referenceID])
+	}
+}
+
+func TestPaymentKeptPaidWhenBookingFails(t *testing.T) {
+	service, _, target, _ := newTestPaymentService(t)
+	ctx := context.Background()
+	payer := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+	admin := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleAdministrator}
+	target.failErr = errors.New("order cancelled")
+
+	payment := createTestPayment(t, service, payer, uuid.NewString(), ChannelQRIS)
+	paid, err := service.SimulatePayment(ctx, admin, payment.ID, SimulatePaymentDTO{Status: StatusPaid})
+	if err != nil {
+		t.Fatalf("SimulatePayment: %v", err)
+	}
+	if paid.Status != StatusPaid || paid.SettlementError != "order cancelled" {
+		t.Fatalf("unexpected payment %+v", paid)
+	}
+}
+
+func TestPaymentForbiddenForOtherPayer(t *testing.T) {
+	service, _, _, _ := newTestPaymentService(t)
+	payer := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+	other := PaymentActor{UserID: uuid.NewString(), Role: utils.RoleMasyarakat}
+
+	payment := createTestPayment(t, service, payer, uuid.NewString(), ChannelQRIS)
+	if _, err := service.GetPayment(context.Background(), other, payment.ID); !errors.Is(err, ErrPaymentForbidden) {
+		t.Fatalf("GetPayment: err = %v, want ErrPaymentForbidden", err)
+	}
+}
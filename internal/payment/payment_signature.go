@@ -0,0 +1,26 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignPayload returns the hex encoded HMAC-SHA256 of body under secret.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature compares in constant time so the check leaks nothing about the secret.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || secret == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
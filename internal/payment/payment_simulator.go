@@ -0,0 +1,289 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"rijig/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SimulatorName = "simulator"
+
+	simulatorSignatureHeader = "X-Simulator-Signature"
+)
+
+type simulatorWebhook struct {
+	EventID    string    `json:"event_id"`
+	ChargeID   string    `json:"charge_id"`
+	Status     string    `json:"status"`
+	Amount     float64   `json:"amount"`
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// SimulatorProvider is an offline gateway for development and tests. Charges only
+// change when Complete is called, which also returns the signed webhook a real
+// gateway would send, so the whole payment flow runs without a gateway. Charges live
+// in a SimulatorChargeStore: the database in the server, memory in tests.
+type SimulatorProvider struct {
+	secret string
+	store  SimulatorChargeStore
+}
+
+func NewSimulatorProvider(secret string, store SimulatorChargeStore) *SimulatorProvider {
+	return &SimulatorProvider{secret: secret, store: store}
+}
+
+// simulatorSecret reads PAYMENT_SIMULATOR_SECRET; without it a random secret is used,
+// which is enough because the simulator only verifies webhooks it signed itself.
+func simulatorSecret() string {
+	if secret := os.Getenv("PAYMENT_SIMULATOR_SECRET"); secret != "" {
+		return secret
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("failed to generate payment simulator secret: %v", err)
+	}
+	log.Printf("[PAYMENT] PAYMENT_SIMULATOR_SECRET not set, using a random secret")
+	return hex.EncodeToString(buf)
+}
+
+func (p *SimulatorProvider) Name() string {
+	return SimulatorName
+}
+
+func (p *SimulatorProvider) Channels() []string {
+	return []string{ChannelQRIS, ChannelVirtualAccount}
+}
+
+func (p *SimulatorProvider) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	chargeID := "sim_" + uuid.NewString()
+	expiresAt := req.ExpiresAt
+
+	charge := &Charge{
+		ChargeID:   chargeID,
+		Status:     StatusPending,
+		Amount:     req.Amount,
+		PaymentURL: fmt.Sprintf("https://simulator.local/pay/%s", chargeID),
+		ExpiresAt:  &expiresAt,
+	}
+	switch req.Channel {
+	case ChannelQRIS:
+		charge.QRString = fmt.Sprintf("SIMQRIS|%s|%.2f", chargeID, req.Amount)
+	case ChannelVirtualAccount:
+		number, err := rand.Int(rand.Reader, big.NewInt(1e10))
+		if err != nil {
+			return nil, err
+		}
+		charge.VANumber = fmt.Sprintf("8808%010d", number.Int64())
+	}
+
+	if err := p.store.Create(ctx, charge); err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+func (p *SimulatorProvider) CheckStatus(ctx context.Context, chargeID string) (*Charge, error) {
+	charge, err := p.store.Get(ctx, chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status == StatusPending && charge.ExpiresAt != nil && time.Now().After(*charge.ExpiresAt) {
+		charge.Status = StatusExpired
+	}
+	return charge, nil
+}
+
+func (p *SimulatorProvider) CancelCharge(ctx context.Context, chargeID string) error {
+	_, err := p.store.Transition(ctx, chargeID, StatusPending, StatusCancelled, "dibatalkan", time.Now())
+	return err
+}
+
+// Complete moves a pending charge to status and returns the webhook body and the
+// signature header value announcing it.
+func (p *SimulatorProvider) Complete(ctx context.Context, chargeID, status, reason string) ([]byte, string, error) {
+	now := time.Now()
+	charge, err := p.store.Transition(ctx, chargeID, StatusPending, status, reason, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := json.Marshal(simulatorWebhook{
+		EventID:    "evt_" + uuid.NewString(),
+		ChargeID:   chargeID,
+		Status:     status,
+		Amount:     charge.Amount,
+		Reason:     reason,
+		OccurredAt: now,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, SignPayload(p.secret, body), nil
+}
+
+// SignatureHeader is the header ParseWebhook reads the signature from.
+func (p *SimulatorProvider) SignatureHeader() string {
+	return simulatorSignatureHeader
+}
+
+func (p *SimulatorProvider) ParseWebhook(header func(key string) string, body []byte) (*WebhookEvent, error) {
+	if !VerifySignature(p.secret, body, header(simulatorSignatureHeader)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var webhook simulatorWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("invalid simulator webhook: %w", err)
+	}
+
+	return &WebhookEvent{
+		EventID:    webhook.EventID,
+		ChargeID:   webhook.ChargeID,
+		Status:     webhook.Status,
+		Amount:     webhook.Amount,
+		Reason:     webhook.Reason,
+		OccurredAt: webhook.OccurredAt,
+		Payload:    string(body),
+	}, nil
+}
+
+// SimulatorChargeStore keeps the simulator's charges. Transition moves a charge from
+// one status to another atomically and fails with ErrChargeNotPending when the charge
+// is no longer in the expected status.
+type SimulatorChargeStore interface {
+	Create(ctx context.Context, charge *Charge) error
+	Get(ctx context.Context, chargeID string) (*Charge, error)
+	Transition(ctx context.Context, chargeID, from, to, reason string, at time.Time) (*Charge, error)
+}
+
+type dbSimulatorChargeStore struct {
+	db *gorm.DB
+}
+
+func NewDBSimulatorChargeStore(db *gorm.DB) SimulatorChargeStore {
+	return &dbSimulatorChargeStore{db: db}
+}
+
+func (s *dbSimulatorChargeStore) Create(ctx context.Context, charge *Charge) error {
+	return s.db.WithContext(ctx).Create(&model.PaymentSimulatorCharge{
+		ChargeID:   charge.ChargeID,
+		Status:     charge.Status,
+		Amount:     charge.Amount,
+		PaymentURL: charge.PaymentURL,
+		QRString:   charge.QRString,
+		VANumber:   charge.VANumber,
+		ExpiresAt:  charge.ExpiresAt,
+	}).Error
+}
+
+func (s *dbSimulatorChargeStore) Get(ctx context.Context, chargeID string) (*Charge, error) {
+	var stored model.PaymentSimulatorCharge
+	err := s.db.WithContext(ctx).Where("charge_id = ?", chargeID).First(&stored).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChargeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return simulatorChargeFromModel(&stored), nil
+}
+
+func (s *dbSimulatorChargeStore) Transition(ctx context.Context, chargeID, from, to, reason string, at time.Time) (*Charge, error) {
+	fields := map[string]interface{}{"status": to, "reason": reason, "updated_at": at}
+	if to == StatusPaid {
+		fields["paid_at"] = at
+	}
+
+	result := s.db.WithContext(ctx).Model(&model.PaymentSimulatorCharge{}).
+		Where("charge_id = ? AND status = ?", chargeID, from).
+		Updates(fields)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := s.Get(ctx, chargeID); err != nil {
+			return nil, err
+		}
+		return nil, ErrChargeNotPending
+	}
+	return s.Get(ctx, chargeID)
+}
+
+func simulatorChargeFromModel(stored *model.PaymentSimulatorCharge) *Charge {
+	return &Charge{
+		ChargeID:   stored.ChargeID,
+		Status:     stored.Status,
+		Amount:     stored.Amount,
+		PaymentURL: stored.PaymentURL,
+		QRString:   stored.QRString,
+		VANumber:   stored.VANumber,
+		ExpiresAt:  stored.ExpiresAt,
+		PaidAt:     stored.PaidAt,
+		Reason:     stored.Reason,
+	}
+}
+
+// memorySimulatorChargeStore keeps charges in memory, for tests.
+type memorySimulatorChargeStore struct {
+	mu      sync.Mutex
+	charges map[string]Charge
+}
+
+func NewMemorySimulatorChargeStore() SimulatorChargeStore {
+	return &memorySimulatorChargeStore{charges: make(map[string]Charge)}
+}
+
+func (s *memorySimulatorChargeStore) Create(ctx context.Context, charge *Charge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.charges[charge.ChargeID] = *charge
+	return nil
+}
+
+func (s *memorySimulatorChargeStore) Get(ctx context.Context, chargeID string) (*Charge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charge, ok := s.charges[chargeID]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	return &charge, nil
+}
+
+func (s *memorySimulatorChargeStore) Transition(ctx context.Context, chargeID, from, to, reason string, at time.Time) (*Charge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charge, ok := s.charges[chargeID]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if charge.Status != from {
+		return nil, ErrChargeNotPending
+	}
+
+	charge.Status = to
+	charge.Reason = reason
+	if to == StatusPaid {
+		charge.PaidAt = &at
+	}
+	s.charges[chargeID] = charge
+	return &charge, nil
+}
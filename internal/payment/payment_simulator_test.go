@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func simulatorHeader(simulator *SimulatorProvider, signature string) func(string) string {
+	return func(key string) string {
+		if key == simulator.SignatureHeader() {
+			return signature
+		}
+		return ""
+	}
+}
+
+func newTestCharge(t *testing.T, simulator *SimulatorProvider, channel string) *Charge {
+	t.Helper()
+
+	charge, err := simulator.CreateCharge(context.Background(), ChargeRequest{
+		PaymentID: "payment-1",
+		Channel:   channel,
+		Amount:    15000,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateCharge: %v", err)
+	}
+	return charge
+}
+
+func TestBuildProviderRegistry(t *testing.T) {
+	store := NewMemorySimulatorChargeStore()
+	tests := []struct {
+		name          string
+		env           map[string]string
+		wantErr       error
+		wantSimulator bool
+	}{
+		{name: "nothing configured", env: map[string]string{}, wantErr: ErrNoProviderConfigured},
+		{name: "simulator without flag", env: map[string]string{"PAYMENT_PROVIDER": SimulatorName}, wantErr: ErrSimulatorDisabled},
+		{name: "unknown provider", env: map[string]string{"PAYMENT_PROVIDER": "nonexistent"}, wantErr: ErrProviderNotFound},
+		{name: "simulator enabled", env: map[string]string{"PAYMENT_SIMULATOR_ENABLED": "true"}, wantSimulator: true},
+		{
+			name:          "simulator enabled explicitly",
+			env:           map[string]string{"PAYMENT_PROVIDER": SimulatorName, "PAYMENT_SIMULATOR_ENABLED": "true"},
+			wantSimulator: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+
+			registry, simulator, err := buildProviderRegistry(getenv, store)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if simulator != tt.wantSimulator {
+				t.Fatalf("simulator = %v, want %v", simulator, tt.wantSimulator)
+			}
+			active, err := registry.Active()
+			if err != nil || active.Name() != SimulatorName {
+				t.Fatalf("active provider = %v, %v", active, err)
+			}
+		})
+	}
+}
+
+func TestSimulatorCompleteSignsVerifiableWebhook(t *testing.T) {
+	simulator := NewSimulatorProvider("secret", NewMemorySimulatorChargeStore())
+	charge := newTestCharge(t, simulator, ChannelQRIS)
+	if charge.QRString == "" {
+		t.Fatal("QRIS charge has no QR string")
+	}
+
+	body, signature, err := simulator.Complete(context.Background(), charge.ChargeID, StatusPaid, "")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	event, err := simulator.ParseWebhook(simulatorHeader(simulator, signature), body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if event.ChargeID != charge.ChargeID || event.Status != StatusPaid || event.Amount != 15000 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	status, err := simulator.CheckStatus(context.Background(), charge.ChargeID)
+	if err != nil {
+		t.Fatalf("CheckStatus: %v", err)
+	}
+	if status.Status != StatusPaid || status.PaidAt == nil {
+		t.Fatalf("charge not paid: %+v", status)
+	}
+}
+
+func TestSimulatorRejectsTamperedWebhook(t *testing.T) {
+	simulator := NewSimulatorProvider("secret", NewMemorySimulatorChargeStore())
+	charge := newTestCharge(t, simulator, ChannelVirtualAccount)
+
+	body, signature, err := simulator.Complete(context.Background(), charge.ChargeID, StatusPaid, "")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] ^= 1
+	if _, err := simulator.ParseWebhook(simulatorHeader(simulator, signature), tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered body: err = %v, want ErrInvalidSignature", err)
+	}
+
+	other := NewSimulatorProvider("other-secret", NewMemorySimulatorChargeStore())
+	if _, err := other.ParseWebhook(simulatorHeader(other, signature), body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("wrong secret: err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSimulatorChargeSettlesOnce(t *testing.T) {
+	simulator := NewSimulatorProvider("secret", NewMemorySimulatorChargeStore())
+	charge := newTestCharge(t, simulator, ChannelQRIS)
+
+	if _, _, err := simulator.Complete(context.Background(), charge.ChargeID, StatusPaid, ""); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, _, err := simulator.Complete(context.Background(), charge.ChargeID, StatusFailed, "late"); !errors.Is(err, ErrChargeNotPending) {
+		t.Fatalf("second Complete: err = %v, want ErrChargeNotPending", err)
+	}
+	if err := simulator.CancelCharge(context.Background(), charge.ChargeID); !errors.Is(err, ErrChargeNotPending) {
+		t.Fatalf("cancel after paid: err = %v, want ErrChargeNotPending", err)
+	}
+}
+
+func TestSimulatorCancelledChargeCannotBePaid(t *testing.T) {
+	simulator := NewSimulatorProvider("secret", NewMemorySimulatorChargeStore())
+	charge := newTestCharge(t, simulator, ChannelQRIS)
+
+	if err := simulator.CancelCharge(context.Background(), charge.ChargeID); err != nil {
+		t.Fatalf("CancelCharge: %v", err)
+	}
+	if _, _, err := simulator.Complete(context.Background(), charge.ChargeID, StatusPaid, ""); !errors.Is(err, ErrChargeNotPending) {
+		t.Fatalf("Complete after cancel: err = %v, want ErrChargeNotPending", err)
+	}
+	if _, err := simulator.CheckStatus(context.Background(), "sim_unknown"); !errors.Is(err, ErrChargeNotFound) {
+		t.Fatalf("unknown charge: err = %v, want ErrChargeNotFound", err)
+	}
+}
@@ -0,0 +1,113 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rijig/internal/store"
+	"rijig/internal/wallet"
+	"rijig/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PurposePickupSettlement = "pickup_settlement"
+	PurposeStoreOrder       = "store_order"
+)
+
+// PayableTarget is what a payment is going to settle.
+type PayableTarget struct {
+	Amount      float64
+	Description string
+}
+
+// PaymentTarget connects a payment purpose to the part of the platform it settles.
+// Resolve checks that the payer may pay the reference and prices it; OnPaid books a
+// confirmed payment inside the transaction that marks it paid.
+type PaymentTarget interface {
+	Resolve(ctx context.Context, payerID, referenceID string) (*PayableTarget, error)
+	OnPaid(ctx context.Context, tx *gorm.DB, payment *model.Payment) error
+}
+
+func paymentReference(payment *model.Payment) string {
+	if payment.ProviderChargeID == nil {
+		return payment.Provider + ":" + payment.ID
+	}
+	return payment.Provider + ":" + *payment.ProviderChargeID
+}
+
+// pickupSettlementTarget lets a collector pay what it owes for a completed pickup.
+type pickupSettlementTarget struct {
+	walletService wallet.WalletService
+}
+
+func NewPickupSettlementTarget(walletService wallet.WalletService) PaymentTarget {
+	return &pickupSettlementTarget{walletService: walletService}
+}
+
+func (t *pickupSettlementTarget) Resolve(ctx context.Context, payerID, pickupID string) (*PayableTarget, error) {
+	outstanding, err := t.walletService.GetOutstanding(ctx, payerID, pickupID)
+	if err != nil {
+		return nil, err
+	}
+	if outstanding <= 0 {
+		return nil, wallet.ErrNothingOutstanding
+	}
+
+	return &PayableTarget{
+		Amount:      outstanding,
+		Description: fmt.Sprintf("Pembayaran pickup %s", pickupID),
+	}, nil
+}
+
+// OnPaid books the payment into the platform's clearing wallet: the gateway pays the
+// platform, which disburses to the household separately.
+func (t *pickupSettlementTarget) OnPaid(ctx context.Context, tx *gorm.DB, payment *model.Payment) error {
+	return t.walletService.RecordGatewayPayment(ctx, tx, payment.PayerID, payment.ReferenceID, payment.Amount, paymentReference(payment))
+}
+
+// storeOrderTarget lets a buyer pay an online store order.
+type storeOrderTarget struct {
+	orderService store.StoreOrderService
+}
+
+func NewStoreOrderTarget(orderService store.StoreOrderService) PaymentTarget {
+	return &storeOrderTarget{orderService: orderService}
+}
+
+func (t *storeOrderTarget) Resolve(ctx context.Context, payerID, orderID string) (*PayableTarget, error) {
+	order, err := t.orderService.GetPayableOrder(ctx, payerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PayableTarget{
+		Amount:      order.TotalPrice,
+		Description: fmt.Sprintf("Pembayaran pesanan %s", order.ID),
+	}, nil
+}
+
+// OnPaid marks the order paid. A payment arriving after the order was cancelled is
+// recorded on it as a pending refund instead of failing the settlement.
+func (t *storeOrderTarget) OnPaid(ctx context.Context, tx *gorm.DB, payment *model.Payment) error {
+	paidAt := time.Now()
+	if payment.PaidAt != nil {
+		paidAt = *payment.PaidAt
+	}
+	return t.orderService.MarkOrderPaid(ctx, tx, payment.ReferenceID, paymentReference(payment), paidAt)
+}
+
+// storeOrderPayments withdraws the open charges of store orders that are cancelled.
+type storeOrderPayments struct {
+	service PaymentService
+}
+
+func NewStoreOrderPayments(service PaymentService) store.OrderPaymentCanceller {
+	return &storeOrderPayments{service: service}
+}
+
+func (p *storeOrderPayments) CancelOrderPayments(ctx context.Context, orderID string) error {
+	return p.service.CancelReferencePayments(ctx, PurposeStoreOrder, orderID, "pesanan dibatalkan")
+}
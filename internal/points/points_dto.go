@@ -0,0 +1,85 @@
+package points
+
+import "strings"
+
+type PointBalanceDTO struct {
+	UserID       string  `json:"user_id"`
+	Balance      int64   `json:"balance"`
+	CoinName     string  `json:"coin_name,omitempty"`
+	ValuePerUnit float64 `json:"value_per_unit,omitempty"`
+}
+
+type PointHistoryDTO struct {
+	EntryID       string  `json:"entry_id"`
+	TransactionID string  `json:"transaction_id"`
+	Kind          string  `json:"kind"`
+	ReferenceType string  `json:"reference_type,omitempty"`
+	ReferenceID   string  `json:"reference_id,omitempty"`
+	ReversalOfID  *string `json:"reversal_of_id,omitempty"`
+	Description   string  `json:"description"`
+	Amount        int64   `json:"amount"`
+	BalanceAfter  int64   `json:"balance_after"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+type PointEntryDTO struct {
+	AccountID string `json:"account_id"`
+	Amount    int64  `json:"amount"`
+}
+
+type PointTransactionDTO struct {
+	ID            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	ReferenceType string          `json:"reference_type,omitempty"`
+	ReferenceID   string          `json:"reference_id,omitempty"`
+	ReversalOfID  *string         `json:"reversal_of_id,omitempty"`
+	Description   string          `json:"description"`
+	Entries       []PointEntryDTO `json:"entries"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+type ReverseTransactionDTO struct {
+	Reason string `json:"reason"`
+}
+
+func (r *ReverseTransactionDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.Reason) == "" {
+		errors["reason"] = append(errors["reason"], "alasan koreksi wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type CoinDTO struct {
+	ID           string  `json:"id"`
+	CoinName     string  `json:"coin_name"`
+	ValuePerUnit float64 `json:"value_per_unit"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+// UpdateCoinDTO.ValuePerUnit is the number of points credited per kg of settled weight.
+type UpdateCoinDTO struct {
+	CoinName     string  `json:"coin_name"`
+	ValuePerUnit float64 `json:"value_per_unit"`
+}
+
+func (r *UpdateCoinDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.CoinName) == "" {
+		errors["coin_name"] = append(errors["coin_name"], "nama koin wajib diisi")
+	}
+	if r.ValuePerUnit <= 0 {
+		errors["value_per_unit"] = append(errors["value_per_unit"], "nilai per unit harus lebih dari 0")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
@@ -0,0 +1,133 @@
+package points
+
+import (
+	"context"
+	"errors"
+
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PointsHandler struct {
+	service PointsService
+}
+
+func NewPointsHandler(service PointsService) *PointsHandler {
+	return &PointsHandler{service: service}
+}
+
+func (h *PointsHandler) GetMyBalance(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	balance, err := h.service.GetBalance(context.Background(), claims.UserID)
+	if err != nil {
+		return handlePointsError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Saldo poin berhasil diambil", balance)
+}
+
+func (h *PointsHandler) GetMyHistory(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respondHistory(c, claims.UserID)
+}
+
+func (h *PointsHandler) GetUserHistory(c *fiber.Ctx) error {
+	userID := c.Params("user_id")
+	if userID == "" {
+		return utils.BadRequest(c, "user_id wajib diisi")
+	}
+
+	return h.respondHistory(c, userID)
+}
+
+func (h *PointsHandler) respondHistory(c *fiber.Ctx, userID string) error {
+	limit, offset, page := utils.ParsePagination(c, 20)
+
+	history, total, err := h.service.GetHistory(context.Background(), userID, limit, offset)
+	if err != nil {
+		return handlePointsError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Riwayat poin berhasil diambil", history, page, limit, int(total))
+}
+
+func (h *PointsHandler) ReverseTransaction(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	transactionID := c.Params("id")
+	if transactionID == "" {
+		return utils.BadRequest(c, "ID transaksi wajib diisi")
+	}
+
+	var req ReverseTransactionDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	reversal, err := h.service.ReverseTransaction(context.Background(), claims.UserID, transactionID, req.Reason)
+	if err != nil {
+		return handlePointsError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Transaksi poin berhasil dikoreksi", reversal)
+}
+
+func (h *PointsHandler) GetCoin(c *fiber.Ctx) error {
+	coin, err := h.service.GetCoin(context.Background())
+	if err != nil {
+		return handlePointsError(c, err)
+	}
+	if coin == nil {
+		return utils.NotFound(c, "Koin belum dikonfigurasi")
+	}
+
+	return utils.SuccessWithData(c, "Koin berhasil diambil", coin)
+}
+
+func (h *PointsHandler) UpdateCoin(c *fiber.Ctx) error {
+	var req UpdateCoinDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	coin, err := h.service.UpdateCoin(context.Background(), req)
+	if err != nil {
+		return handlePointsError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Koin berhasil diperbarui", coin)
+}
+
+func handlePointsError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrAlreadyReversed), errors.Is(err, ErrReversalNotReversible), errors.Is(err, ErrRedemptionReversal):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
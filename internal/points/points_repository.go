@@ -0,0 +1,203 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"rijig/config"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	AccountTypeUser   = "user"
+	AccountTypeSystem = "system"
+)
+
+// PointEntryRow is an entry of an account joined with the transaction it belongs to.
+type PointEntryRow struct {
+	EntryID        string
+	TransactionID  string
+	Kind           string
+	ReferenceType  string
+	ReferenceID    string
+	ReversalOfID   *string
+	Description    string
+	Amount         int64
+	CreatedAt      time.Time
+	RunningBalance int64
+}
+
+type PointsRepository interface {
+	GetOrCreateAccount(ctx context.Context, code, accountType string, userID *string) (*model.PointAccount, error)
+	GetUserAccount(ctx context.Context, userID string) (*model.PointAccount, error)
+	LockAccounts(ctx context.Context, accountIDs []string) error
+	GetBalance(ctx context.Context, accountID string) (int64, error)
+	GetEntries(ctx context.Context, accountID string, limit, offset int) ([]PointEntryRow, int64, error)
+
+	CreateTransaction(ctx context.Context, txn *model.PointTransaction) error
+	GetTransactionByID(ctx context.Context, id string) (*model.PointTransaction, error)
+	GetTransactionForUpdate(ctx context.Context, id string) (*model.PointTransaction, error)
+	GetTransactionByIdempotencyKey(ctx context.Context, key string) (*model.PointTransaction, error)
+	IsReversed(ctx context.Context, transactionID string) (bool, error)
+
+	GetActiveCoin(ctx context.Context) (*model.InitialCoint, error)
+	SaveCoin(ctx context.Context, coin *model.InitialCoint) error
+
+	WithTx(tx *gorm.DB) PointsRepository
+}
+
+type pointsRepository struct {
+	db *gorm.DB
+}
+
+func NewPointsRepository() PointsRepository {
+	return &pointsRepository{db: config.DB}
+}
+
+func (r *pointsRepository) WithTx(tx *gorm.DB) PointsRepository {
+	return &pointsRepository{db: tx}
+}
+
+// GetOrCreateAccount is safe to call concurrently: the unique code decides which insert
+// wins and everyone reads the same row back.
+func (r *pointsRepository) GetOrCreateAccount(ctx context.Context, code, accountType string, userID *string) (*model.PointAccount, error) {
+	account := model.PointAccount{Code: code, Type: accountType, UserID: userID}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+		Create(&account).Error; err != nil {
+		return nil, err
+	}
+
+	var stored model.PointAccount
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *pointsRepository) GetUserAccount(ctx context.Context, userID string) (*model.PointAccount, error) {
+	var account model.PointAccount
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// LockAccounts takes row locks in a fixed order so two postings touching the same
+// accounts cannot deadlock.
+func (r *pointsRepository) LockAccounts(ctx context.Context, accountIDs []string) error {
+	var locked []model.PointAccount
+	return r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", accountIDs).
+		Order("id").
+		Find(&locked).Error
+}
+
+func (r *pointsRepository) GetBalance(ctx context.Context, accountID string) (int64, error) {
+	var balance int64
+	err := r.db.WithContext(ctx).
+		Model(&model.PointEntry{}).
+		Where("account_id = ?", accountID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+// GetEntries returns the newest entries first, each with the balance right after it.
+func (r *pointsRepository) GetEntries(ctx context.Context, accountID string, limit, offset int) ([]PointEntryRow, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&model.PointEntry{}).Where("account_id = ?", accountID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []PointEntryRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT e.id AS entry_id, e.transaction_id, t.kind, t.reference_type, t.reference_id,
+				t.reversal_of_id, t.description, e.amount, e.created_at,
+				SUM(e.amount) OVER (ORDER BY e.created_at, e.id) AS running_balance
+			FROM point_entries e
+			JOIN point_transactions t ON t.id = e.transaction_id
+			WHERE e.account_id = ?
+		) history
+		ORDER BY created_at DESC, entry_id DESC
+		LIMIT ? OFFSET ?`, accountID, limit, offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *pointsRepository) CreateTransaction(ctx context.Context, txn *model.PointTransaction) error {
+	return r.db.WithContext(ctx).Create(txn).Error
+}
+
+func (r *pointsRepository) GetTransactionByID(ctx context.Context, id string) (*model.PointTransaction, error) {
+	var txn model.PointTransaction
+	if err := r.db.WithContext(ctx).Preload("Entries").Where("id = ?", id).First(&txn).Error; err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *pointsRepository) GetTransactionForUpdate(ctx context.Context, id string) (*model.PointTransaction, error) {
+	var txn model.PointTransaction
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&txn).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("transaction_id = ?", id).Find(&txn.Entries).Error; err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *pointsRepository) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*model.PointTransaction, error) {
+	var txn model.PointTransaction
+	err := r.db.WithContext(ctx).Where("idempotency_key = ?", key).First(&txn).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *pointsRepository) IsReversed(ctx context.Context, transactionID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.PointTransaction{}).
+		Where("reversal_of_id = ?", transactionID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetActiveCoin returns the most recently updated coin definition, or nil when none
+// has been configured.
+func (r *pointsRepository) GetActiveCoin(ctx context.Context) (*model.InitialCoint, error) {
+	var coin model.InitialCoint
+	err := r.db.WithContext(ctx).Order("updated_at DESC").First(&coin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coin, nil
+}
+
+func (r *pointsRepository) SaveCoin(ctx context.Context, coin *model.InitialCoint) error {
+	return r.db.WithContext(ctx).Save(coin).Error
+}
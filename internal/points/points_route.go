@@ -0,0 +1,25 @@
+package points
+
+import (
+	"rijig/config"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PointsRouter(api fiber.Router) {
+	service := NewPointsService(config.DB, NewPointsRepository())
+	handler := NewPointsHandler(service)
+
+	points := api.Group("/points")
+	points.Use(middleware.AuthMiddleware())
+
+	points.Get("/balance", handler.GetMyBalance)
+	points.Get("/transactions", handler.GetMyHistory)
+	points.Get("/coin", handler.GetCoin)
+
+	points.Put("/coin", middleware.RequireRoles(utils.RoleAdministrator), handler.UpdateCoin)
+	points.Get("/users/:user_id/transactions", middleware.RequireRoles(utils.RoleAdministrator), handler.GetUserHistory)
+	points.Post("/transactions/:id/reverse", middleware.RequireRoles(utils.RoleAdministrator), handler.ReverseTransaction)
+}
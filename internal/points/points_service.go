@@ -0,0 +1,431 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"rijig/internal/trash"
+	"rijig/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	KindPickupReward      = "pickup_reward"
+	KindReversal          = "reversal"
+	KindRedemptionHold    = "redemption_hold"
+	KindRedemptionCapture = "redemption_capture"
+	KindRedemptionRefund  = "redemption_refund"
+
+	ReferencePickup      = "pickup"
+	ReferenceTransaction = "point_transaction"
+	ReferenceStoreOrder  = "store_order"
+
+	// issuanceAccountCode is where rewarded points come from; its balance is minus the
+	// number of points ever issued.
+	issuanceAccountCode = "system:issuance"
+	// holdAccountCode keeps the points of store orders that are not handed over yet.
+	holdAccountCode = "system:redemption_hold"
+	// redeemedAccountCode receives points once the redeemed product is handed over.
+	redeemedAccountCode = "system:redemption"
+)
+
+var (
+	ErrTransactionNotFound   = errors.New("transaksi poin tidak ditemukan")
+	ErrAlreadyReversed       = errors.New("transaksi poin sudah pernah dikoreksi")
+	ErrReversalNotReversible = errors.New("transaksi koreksi tidak dapat dikoreksi lagi")
+	ErrUnbalancedPosting     = errors.New("entri transaksi poin tidak seimbang")
+	ErrInsufficientPoints    = errors.New("saldo poin tidak mencukupi")
+	ErrRedemptionReversal    = errors.New("transaksi penukaran dikoreksi dengan membatalkan pesanannya")
+)
+
+type PointsService interface {
+	CreditPickup(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, items []model.RequestPickupItem) (*model.PointTransaction, error)
+	HoldPoints(ctx context.Context, tx *gorm.DB, userID, orderID string, amount int64) (*model.PointTransaction, error)
+	CapturePoints(ctx context.Context, tx *gorm.DB, orderID string, amount int64) (*model.PointTransaction, error)
+	ReleasePoints(ctx context.Context, tx *gorm.DB, userID, orderID string, amount int64) (*model.PointTransaction, error)
+	GetBalance(ctx context.Context, userID string) (*PointBalanceDTO, error)
+	GetHistory(ctx context.Context, userID string, limit, offset int) ([]PointHistoryDTO, int64, error)
+	ReverseTransaction(ctx context.Context, adminID, transactionID, reason string) (*PointTransactionDTO, error)
+	GetCoin(ctx context.Context) (*CoinDTO, error)
+	UpdateCoin(ctx context.Context, req UpdateCoinDTO) (*CoinDTO, error)
+}
+
+type pointsService struct {
+	db   *gorm.DB
+	repo PointsRepository
+}
+
+func NewPointsService(db *gorm.DB, repo PointsRepository) PointsService {
+	return &pointsService{db: db, repo: repo}
+}
+
+// ledgerLeg is one side of a posting before it becomes a PointEntry.
+type ledgerLeg struct {
+	AccountID string
+	Amount    int64
+}
+
+func userAccountCode(userID string) string {
+	return "user:" + userID
+}
+
+func (s *pointsService) userAccount(ctx context.Context, repo PointsRepository, userID string) (*model.PointAccount, error) {
+	return repo.GetOrCreateAccount(ctx, userAccountCode(userID), AccountTypeUser, &userID)
+}
+
+func (s *pointsService) systemAccount(ctx context.Context, repo PointsRepository, code string) (*model.PointAccount, error) {
+	return repo.GetOrCreateAccount(ctx, code, AccountTypeSystem, nil)
+}
+
+// post writes a balanced transaction. Every account touched is locked first, so
+// balance checks made by the caller inside the same database transaction hold.
+func (s *pointsService) post(ctx context.Context, repo PointsRepository, txn *model.PointTransaction, legs []ledgerLeg) error {
+	if len(legs) < 2 {
+		return ErrUnbalancedPosting
+	}
+
+	var sum int64
+	accountIDs := make([]string, 0, len(legs))
+	for _, leg := range legs {
+		sum += leg.Amount
+		accountIDs = append(accountIDs, leg.AccountID)
+	}
+	if sum != 0 {
+		return ErrUnbalancedPosting
+	}
+
+	if err := repo.LockAccounts(ctx, accountIDs); err != nil {
+		return fmt.Errorf("failed to lock point accounts: %w", err)
+	}
+
+	txn.Entries = make([]model.PointEntry, 0, len(legs))
+	for _, leg := range legs {
+		txn.Entries = append(txn.Entries, model.PointEntry{AccountID: leg.AccountID, Amount: leg.Amount})
+	}
+	return repo.CreateTransaction(ctx, txn)
+}
+
+// CreditPickup rewards the requester of a completed pickup with points for the settled
+// weight. Only items measured in kg count towards the weight. It runs inside the
+// completion transaction and is idempotent per pickup.
+func (s *pointsService) CreditPickup(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, items []model.RequestPickupItem) (*model.PointTransaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	key := fmt.Sprintf("%s:%s", KindPickupReward, pickup.ID)
+	existing, err := repo.GetTransactionByIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	coin, err := repo.GetActiveCoin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if coin == nil {
+		log.Printf("[POINTS] no coin configured, pickup %s earns no points", pickup.ID)
+		return nil, nil
+	}
+
+	var weight float64
+	for _, item := range items {
+		if item.ActualAmount != nil && (item.Unit == "" || item.Unit == trash.UnitKilogram) {
+			weight += *item.ActualAmount
+		}
+	}
+
+	points := int64(math.Floor(weight * coin.ValuePerUnit))
+	if points <= 0 {
+		return nil, nil
+	}
+
+	userAccount, err := s.userAccount(ctx, repo, pickup.UserId)
+	if err != nil {
+		return nil, err
+	}
+	issuance, err := s.systemAccount(ctx, repo, issuanceAccountCode)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := &model.PointTransaction{
+		Kind:           KindPickupReward,
+		ReferenceType:  ReferencePickup,
+		ReferenceID:    pickup.ID,
+		IdempotencyKey: &key,
+		Description:    fmt.Sprintf("%d %s dari pickup seberat %.2f kg", points, coin.CoinName, weight),
+	}
+	err = s.post(ctx, repo, txn, []ledgerLeg{
+		{AccountID: userAccount.ID, Amount: points},
+		{AccountID: issuance.ID, Amount: -points},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to credit pickup points: %w", err)
+	}
+	return txn, nil
+}
+
+// HoldPoints moves the points of a store order out of the user's balance into the hold
+// account, failing when the user cannot cover them.
+func (s *pointsService) HoldPoints(ctx context.Context, tx *gorm.DB, userID, orderID string, amount int64) (*model.PointTransaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	userAccount, err := s.userAccount(ctx, repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	hold, err := s.systemAccount(ctx, repo, holdAccountCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.LockAccounts(ctx, []string{userAccount.ID, hold.ID}); err != nil {
+		return nil, fmt.Errorf("failed to lock point accounts: %w", err)
+	}
+	balance, err := repo.GetBalance(ctx, userAccount.ID)
+	if err != nil {
+		return nil, err
+	}
+	if balance < amount {
+		return nil, ErrInsufficientPoints
+	}
+
+	return s.transfer(ctx, repo, KindRedemptionHold, orderID, userAccount.ID, hold.ID, amount,
+		fmt.Sprintf("Poin ditahan untuk pesanan %s", orderID))
+}
+
+// CapturePoints settles the hold of an order that has been handed over.
+func (s *pointsService) CapturePoints(ctx context.Context, tx *gorm.DB, orderID string, amount int64) (*model.PointTransaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	hold, err := s.systemAccount(ctx, repo, holdAccountCode)
+	if err != nil {
+		return nil, err
+	}
+	redeemed, err := s.systemAccount(ctx, repo, redeemedAccountCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transfer(ctx, repo, KindRedemptionCapture, orderID, hold.ID, redeemed.ID, amount,
+		fmt.Sprintf("Penukaran poin pesanan %s selesai", orderID))
+}
+
+// ReleasePoints refunds the hold of a cancelled order to the user.
+func (s *pointsService) ReleasePoints(ctx context.Context, tx *gorm.DB, userID, orderID string, amount int64) (*model.PointTransaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	hold, err := s.systemAccount(ctx, repo, holdAccountCode)
+	if err != nil {
+		return nil, err
+	}
+	userAccount, err := s.userAccount(ctx, repo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transfer(ctx, repo, KindRedemptionRefund, orderID, hold.ID, userAccount.ID, amount,
+		fmt.Sprintf("Pengembalian poin pesanan %s yang dibatalkan", orderID))
+}
+
+// transfer posts amount from one account to another for a store order. Each kind is
+// posted at most once per order; a repeated call returns the existing transaction.
+func (s *pointsService) transfer(ctx context.Context, repo PointsRepository, kind, orderID, fromID, toID string, amount int64, description string) (*model.PointTransaction, error) {
+	key := fmt.Sprintf("%s:%s", kind, orderID)
+	existing, err := repo.GetTransactionByIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	txn := &model.PointTransaction{
+		Kind:           kind,
+		ReferenceType:  ReferenceStoreOrder,
+		ReferenceID:    orderID,
+		IdempotencyKey: &key,
+		Description:    description,
+	}
+	err = s.post(ctx, repo, txn, []ledgerLeg{
+		{AccountID: fromID, Amount: -amount},
+		{AccountID: toID, Amount: amount},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (s *pointsService) GetBalance(ctx context.Context, userID string) (*PointBalanceDTO, error) {
+	response := &PointBalanceDTO{UserID: userID}
+
+	account, err := s.repo.GetUserAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		if response.Balance, err = s.repo.GetBalance(ctx, account.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	coin, err := s.repo.GetActiveCoin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if coin != nil {
+		response.CoinName = coin.CoinName
+		response.ValuePerUnit = coin.ValuePerUnit
+	}
+	return response, nil
+}
+
+func (s *pointsService) GetHistory(ctx context.Context, userID string, limit, offset int) ([]PointHistoryDTO, int64, error) {
+	account, err := s.repo.GetUserAccount(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if account == nil {
+		return []PointHistoryDTO{}, 0, nil
+	}
+
+	rows, total, err := s.repo.GetEntries(ctx, account.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	history := make([]PointHistoryDTO, 0, len(rows))
+	for _, row := range rows {
+		history = append(history, PointHistoryDTO{
+			EntryID:       row.EntryID,
+			TransactionID: row.TransactionID,
+			Kind:          row.Kind,
+			ReferenceType: row.ReferenceType,
+			ReferenceID:   row.ReferenceID,
+			ReversalOfID:  row.ReversalOfID,
+			Description:   row.Description,
+			Amount:        row.Amount,
+			BalanceAfter:  row.RunningBalance,
+			CreatedAt:     row.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return history, total, nil
+}
+
+// ReverseTransaction corrects a posting by adding its mirror image; the original entries
+// stay untouched so the history shows both the mistake and the correction.
+func (s *pointsService) ReverseTransaction(ctx context.Context, adminID, transactionID, reason string) (*PointTransactionDTO, error) {
+	var reversal *model.PointTransaction
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		original, err := repo.GetTransactionForUpdate(ctx, transactionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTransactionNotFound
+			}
+			return err
+		}
+		if original.ReversalOfID != nil {
+			return ErrReversalNotReversible
+		}
+		switch original.Kind {
+		case KindRedemptionHold, KindRedemptionCapture, KindRedemptionRefund:
+			return ErrRedemptionReversal
+		}
+
+		reversed, err := repo.IsReversed(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+		if reversed {
+			return ErrAlreadyReversed
+		}
+
+		legs := make([]ledgerLeg, 0, len(original.Entries))
+		for _, entry := range original.Entries {
+			legs = append(legs, ledgerLeg{AccountID: entry.AccountID, Amount: -entry.Amount})
+		}
+
+		reversal = &model.PointTransaction{
+			Kind:          KindReversal,
+			ReferenceType: ReferenceTransaction,
+			ReferenceID:   original.ID,
+			ReversalOfID:  &original.ID,
+			Description:   fmt.Sprintf("Koreksi: %s", strings.TrimSpace(reason)),
+			CreatedBy:     &adminID,
+		}
+		return s.post(ctx, repo, reversal, legs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toPointTransactionDTO(reversal), nil
+}
+
+func (s *pointsService) GetCoin(ctx context.Context) (*CoinDTO, error) {
+	coin, err := s.repo.GetActiveCoin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if coin == nil {
+		return nil, nil
+	}
+	return toCoinDTO(coin), nil
+}
+
+// UpdateCoin changes the coin definition. Points already credited keep the value they
+// were posted with; only later pickups use the new rate.
+func (s *pointsService) UpdateCoin(ctx context.Context, req UpdateCoinDTO) (*CoinDTO, error) {
+	coin, err := s.repo.GetActiveCoin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if coin == nil {
+		coin = &model.InitialCoint{CreatedAt: time.Now()}
+	}
+
+	coin.CoinName = strings.TrimSpace(req.CoinName)
+	coin.ValuePerUnit = req.ValuePerUnit
+	coin.UpdatedAt = time.Now()
+	if err := s.repo.SaveCoin(ctx, coin); err != nil {
+		return nil, err
+	}
+	return toCoinDTO(coin), nil
+}
+
+func toPointTransactionDTO(txn *model.PointTransaction) *PointTransactionDTO {
+	response := &PointTransactionDTO{
+		ID:            txn.ID,
+		Kind:          txn.Kind,
+		ReferenceType: txn.ReferenceType,
+		ReferenceID:   txn.ReferenceID,
+		ReversalOfID:  txn.ReversalOfID,
+		Description:   txn.Description,
+		CreatedAt:     txn.CreatedAt.Format(time.RFC3339),
+	}
+	for _, entry := range txn.Entries {
+		response.Entries = append(response.Entries, PointEntryDTO{AccountID: entry.AccountID, Amount: entry.Amount})
+	}
+	return response
+}
+
+func toCoinDTO(coin *model.InitialCoint) *CoinDTO {
+	return &CoinDTO{
+		ID:           coin.ID,
+		CoinName:     coin.CoinName,
+		ValuePerUnit: coin.ValuePerUnit,
+		UpdatedAt:    coin.UpdatedAt.Format(time.RFC3339),
+	}
+}
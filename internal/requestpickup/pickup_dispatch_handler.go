@@ -0,0 +1,97 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/middleware"
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PickupDispatchHandler struct {
+	service PickupDispatchService
+}
+
+func NewPickupDispatchHandler(service PickupDispatchService) *PickupDispatchHandler {
+	return &PickupDispatchHandler{
+		service: service,
+	}
+}
+
+func (h *PickupDispatchHandler) GetMyOffers(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	offers, err := h.service.GetMyOffers(context.Background(), claims.UserID)
+	if err != nil {
+		return handleDispatchError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Data offer pickup berhasil diambil", offers)
+}
+
+func (h *PickupDispatchHandler) AcceptOffer(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	offerID := c.Params("offerID")
+	if offerID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"offer_id": {"offer ID harus disertakan"},
+		})
+	}
+
+	if err := h.service.AcceptOffer(context.Background(), offerID, pickupActorFromClaims(claims)); err != nil {
+		return handleDispatchError(c, err)
+	}
+
+	return utils.Success(c, "Offer pickup berhasil diterima")
+}
+
+func (h *PickupDispatchHandler) DeclineOffer(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	offerID := c.Params("offerID")
+	if offerID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"offer_id": {"offer ID harus disertakan"},
+		})
+	}
+
+	if err := h.service.DeclineOffer(context.Background(), offerID, pickupActorFromClaims(claims)); err != nil {
+		return handleDispatchError(c, err)
+	}
+
+	return utils.Success(c, "Offer pickup berhasil ditolak")
+}
+
+func (h *PickupDispatchHandler) GetEscalatedPickups(c *fiber.Ctx) error {
+	pickups, err := h.service.GetEscalatedPickups(context.Background())
+	if err != nil {
+		return utils.InternalServerError(c, err.Error())
+	}
+
+	return utils.SuccessWithData(c, "Data pickup yang membutuhkan penugasan manual berhasil diambil", pickups)
+}
+
+func handleDispatchError(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "tidak berlaku lagi"):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, msg, nil)
+	case strings.HasPrefix(msg, "forbidden"):
+		return utils.Forbidden(c, msg)
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	default:
+		return handlePickupError(c, err)
+	}
+}
@@ -0,0 +1,181 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/config"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	OfferStatusOffered  = "offered"
+	OfferStatusAccepted = "accepted"
+	OfferStatusDeclined = "declined"
+	OfferStatusExpired  = "expired"
+)
+
+type PickupDispatchRepository interface {
+	CreateOffer(ctx context.Context, offer *model.PickupDispatchOffer) error
+	GetOfferByID(ctx context.Context, id string) (*model.PickupDispatchOffer, error)
+	GetOfferForUpdate(ctx context.Context, id string) (*model.PickupDispatchOffer, error)
+	UpdateOfferStatus(ctx context.Context, id, status string, respondedAt *time.Time) error
+	ExpireOverdueOffers(ctx context.Context, now time.Time) ([]model.PickupDispatchOffer, error)
+	HasOpenOffer(ctx context.Context, requestID string) (bool, error)
+	GetOfferedCollectorIDs(ctx context.Context, requestID string) (map[string]bool, error)
+	GetOpenOffersByCollector(ctx context.Context, collectorID string) ([]model.PickupDispatchOffer, error)
+	GetDispatchablePickups(ctx context.Context) ([]model.RequestPickup, error)
+	MarkEscalated(ctx context.Context, requestID string, at time.Time) error
+	GetEscalatedPickups(ctx context.Context) ([]model.RequestPickup, error)
+	AssignCollectorIfFree(ctx context.Context, requestID, collectorID string) (bool, error)
+
+	WithTx(tx *gorm.DB) PickupDispatchRepository
+}
+
+type pickupDispatchRepository struct {
+	db *gorm.DB
+}
+
+func NewPickupDispatchRepository() PickupDispatchRepository {
+	return &pickupDispatchRepository{db: config.DB}
+}
+
+func (r *pickupDispatchRepository) WithTx(tx *gorm.DB) PickupDispatchRepository {
+	return &pickupDispatchRepository{db: tx}
+}
+
+func (r *pickupDispatchRepository) CreateOffer(ctx context.Context, offer *model.PickupDispatchOffer) error {
+	return r.db.WithContext(ctx).Create(offer).Error
+}
+
+func (r *pickupDispatchRepository) GetOfferByID(ctx context.Context, id string) (*model.PickupDispatchOffer, error) {
+	var offer model.PickupDispatchOffer
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&offer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *pickupDispatchRepository) GetOfferForUpdate(ctx context.Context, id string) (*model.PickupDispatchOffer, error) {
+	var offer model.PickupDispatchOffer
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&offer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *pickupDispatchRepository) UpdateOfferStatus(ctx context.Context, id, status string, respondedAt *time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.PickupDispatchOffer{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": respondedAt,
+		}).Error
+}
+
+func (r *pickupDispatchRepository) ExpireOverdueOffers(ctx context.Context, now time.Time) ([]model.PickupDispatchOffer, error) {
+	var expired []model.PickupDispatchOffer
+	err := r.db.WithContext(ctx).
+		Model(&expired).
+		Clauses(clause.Returning{}).
+		Where("status = ? AND expires_at < ?", OfferStatusOffered, now).
+		Update("status", OfferStatusExpired).Error
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+func (r *pickupDispatchRepository) HasOpenOffer(ctx context.Context, requestID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.PickupDispatchOffer{}).
+		Where("request_id = ? AND status = ?", requestID, OfferStatusOffered).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *pickupDispatchRepository) GetOfferedCollectorIDs(ctx context.Context, requestID string) (map[string]bool, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&model.PickupDispatchOffer{}).
+		Where("request_id = ?", requestID).
+		Pluck("collector_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return seen, nil
+}
+
+func (r *pickupDispatchRepository) GetOpenOffersByCollector(ctx context.Context, collectorID string) ([]model.PickupDispatchOffer, error) {
+	var offers []model.PickupDispatchOffer
+	err := r.db.WithContext(ctx).
+		Where("collector_id = ? AND status = ? AND expires_at >= ?", collectorID, OfferStatusOffered, time.Now()).
+		Order("offered_at asc").
+		Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// GetDispatchablePickups returns automatic requests still waiting for a collector
+// that have not been handed over to admins yet.
+func (r *pickupDispatchRepository) GetDispatchablePickups(ctx context.Context) ([]model.RequestPickup, error) {
+	var pickups []model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Where("request_method = ? AND status_pickup = ? AND collector_id IS NULL AND dispatch_escalated_at IS NULL",
+			"otomatis", StatusWaitingCollector).
+		Order("created_at asc").
+		Find(&pickups).Error
+	if err != nil {
+		return nil, err
+	}
+	return pickups, nil
+}
+
+func (r *pickupDispatchRepository) MarkEscalated(ctx context.Context, requestID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.RequestPickup{}).
+		Where("id = ?", requestID).
+		Update("dispatch_escalated_at", at).Error
+}
+
+func (r *pickupDispatchRepository) GetEscalatedPickups(ctx context.Context) ([]model.RequestPickup, error) {
+	var pickups []model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Preload("Address").
+		Preload("RequestItems").
+		Where("dispatch_escalated_at IS NOT NULL AND status_pickup = ? AND collector_id IS NULL", StatusWaitingCollector).
+		Order("dispatch_escalated_at asc").
+		Find(&pickups).Error
+	if err != nil {
+		return nil, err
+	}
+	return pickups, nil
+}
+
+// AssignCollectorIfFree only succeeds when nobody else has been assigned in the meantime.
+func (r *pickupDispatchRepository) AssignCollectorIfFree(ctx context.Context, requestID, collectorID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RequestPickup{}).
+		Where("id = ? AND collector_id IS NULL AND status_pickup = ?", requestID, StatusWaitingCollector).
+		Update("collector_id", collectorID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,40 @@
+package requestpickup
+
+import (
+	"rijig/config"
+	"rijig/internal/collector"
+	"rijig/internal/trash"
+	"rijig/internal/wilayahindo"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewDefaultPickupDispatchService wires the dispatch service from the shared DB,
+// used by both the router and the background dispatch worker.
+func NewDefaultPickupDispatchService() PickupDispatchService {
+	pickupRepo := NewRequestPickupRepository()
+	historyRepo := NewPickupStatusHistoryRepository()
+	collectorRepo := collector.NewCollectorRepository(config.DB)
+	slotRepo := collector.NewCollectorSlotRepository(config.DB)
+	configRepo := NewMatchingConfigRepository()
+	wilayahRepo := wilayahindo.NewWilayahIndonesiaRepository(config.DB)
+	matchingService := NewPickupMatchingService(pickupRepo, collectorRepo, slotRepo, configRepo, wilayahRepo,
+		trash.NewDefaultTrashRegionalPriceService(config.DB))
+	statusMachine := NewPickupStatusMachine(config.DB, pickupRepo, historyRepo, collectorRepo, slotRepo)
+
+	return NewPickupDispatchService(config.DB, NewPickupDispatchRepository(), pickupRepo, collectorRepo, matchingService, statusMachine)
+}
+
+func PickupDispatchRouter(api fiber.Router) {
+	handler := NewPickupDispatchHandler(NewDefaultPickupDispatchService())
+
+	dispatch := api.Group("/pickup/dispatch")
+	dispatch.Use(middleware.AuthMiddleware())
+
+	dispatch.Get("/offers", middleware.RequireRoles(utils.RolePengepul), handler.GetMyOffers)
+	dispatch.Put("/offers/:offerID/accept", middleware.RequireRoles(utils.RolePengepul), handler.AcceptOffer)
+	dispatch.Put("/offers/:offerID/decline", middleware.RequireRoles(utils.RolePengepul), handler.DeclineOffer)
+	dispatch.Get("/escalated", middleware.RequireRoles(utils.RoleAdministrator), handler.GetEscalatedPickups)
+}
@@ -0,0 +1,323 @@
+package requestpickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"rijig/internal/collector"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultDispatchOfferTimeout = 2 * time.Minute
+
+type PickupDispatchService interface {
+	ProcessPendingDispatches(ctx context.Context) (*DispatchRunSummary, error)
+	GetMyOffers(ctx context.Context, userID string) ([]DispatchOfferResponseDTO, error)
+	AcceptOffer(ctx context.Context, offerID string, actor PickupActor) error
+	DeclineOffer(ctx context.Context, offerID string, actor PickupActor) error
+	GetEscalatedPickups(ctx context.Context) ([]EscalatedPickupDTO, error)
+}
+
+type pickupDispatchService struct {
+	db              *gorm.DB
+	dispatchRepo    PickupDispatchRepository
+	pickupRepo      RequestPickupRepository
+	collectorRepo   collector.CollectorRepository
+	matchingService PickupMatchingService
+	statusMachine   PickupStatusMachine
+	offerTimeout    time.Duration
+}
+
+func NewPickupDispatchService(db *gorm.DB, dispatchRepo PickupDispatchRepository, pickupRepo RequestPickupRepository,
+	collectorRepo collector.CollectorRepository, matchingService PickupMatchingService, statusMachine PickupStatusMachine) PickupDispatchService {
+	return &pickupDispatchService{
+		db:              db,
+		dispatchRepo:    dispatchRepo,
+		pickupRepo:      pickupRepo,
+		collectorRepo:   collectorRepo,
+		matchingService: matchingService,
+		statusMachine:   statusMachine,
+		offerTimeout:    dispatchOfferTimeout(),
+	}
+}
+
+// dispatchOfferTimeout reads DISPATCH_OFFER_TIMEOUT (e.g. "90s", "3m"), falling back to two minutes.
+func dispatchOfferTimeout() time.Duration {
+	raw := os.Getenv("DISPATCH_OFFER_TIMEOUT")
+	if raw == "" {
+		return defaultDispatchOfferTimeout
+	}
+
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		log.Printf("[DISPATCH] invalid DISPATCH_OFFER_TIMEOUT %q, using %s", raw, defaultDispatchOfferTimeout)
+		return defaultDispatchOfferTimeout
+	}
+	return timeout
+}
+
+func (s *pickupDispatchService) ProcessPendingDispatches(ctx context.Context) (*DispatchRunSummary, error) {
+	summary := &DispatchRunSummary{}
+
+	expired, err := s.dispatchRepo.ExpireOverdueOffers(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("gagal memproses offer kedaluwarsa: %w", err)
+	}
+	summary.Expired = len(expired)
+
+	pickups, err := s.dispatchRepo.GetDispatchablePickups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pickup otomatis: %w", err)
+	}
+
+	for _, pickup := range pickups {
+		result, err := s.dispatchPickup(ctx, pickup.ID)
+		if err != nil {
+			log.Printf("[DISPATCH] failed to dispatch pickup %s: %v", pickup.ID, err)
+			summary.Skipped++
+			continue
+		}
+
+		switch result {
+		case OfferStatusOffered:
+			summary.Offered++
+		case "escalated":
+			summary.Escalated++
+		default:
+			summary.Skipped++
+		}
+	}
+
+	return summary, nil
+}
+
+// dispatchPickup offers the pickup to the best ranked collector that has not seen it yet.
+// When every candidate has already declined or let the offer expire, the pickup is
+// escalated so an admin can assign it by hand.
+func (s *pickupDispatchService) dispatchPickup(ctx context.Context, pickupID string) (string, error) {
+	ranked, err := s.matchingService.RankCollectorsForPickup(ctx, pickupID)
+	if err != nil {
+		return "", err
+	}
+
+	result := ""
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pickup, err := s.pickupRepo.WithTx(tx).GetPickupByIDForUpdate(ctx, pickupID)
+		if err != nil {
+			return fmt.Errorf("failed to get pickup: %w", err)
+		}
+
+		if pickup.StatusPickup != StatusWaitingCollector || pickup.CollectorID != nil || pickup.DispatchEscalatedAt != nil {
+			return nil
+		}
+
+		dispatchRepo := s.dispatchRepo.WithTx(tx)
+
+		open, err := dispatchRepo.HasOpenOffer(ctx, pickup.ID)
+		if err != nil {
+			return err
+		}
+		if open {
+			return nil
+		}
+
+		offered, err := dispatchRepo.GetOfferedCollectorIDs(ctx, pickup.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, candidate := range ranked {
+			if offered[candidate.CollectorID] {
+				continue
+			}
+
+			now := time.Now()
+			if err := dispatchRepo.CreateOffer(ctx, &model.PickupDispatchOffer{
+				RequestID:   pickup.ID,
+				CollectorID: candidate.CollectorID,
+				Status:      OfferStatusOffered,
+				Score:       candidate.Score.Total,
+				DistanceKm:  candidate.DistanceKm,
+				OfferedAt:   now,
+				ExpiresAt:   now.Add(s.offerTimeout),
+			}); err != nil {
+				return fmt.Errorf("gagal membuat offer: %w", err)
+			}
+
+			result = OfferStatusOffered
+			return nil
+		}
+
+		if err := dispatchRepo.MarkEscalated(ctx, pickup.ID, time.Now()); err != nil {
+			return fmt.Errorf("gagal mengeskalasi pickup: %w", err)
+		}
+		log.Printf("[DISPATCH] pickup %s escalated to admin after %d offer(s)", pickup.ID, len(offered))
+		result = "escalated"
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *pickupDispatchService) GetMyOffers(ctx context.Context, userID string) ([]DispatchOfferResponseDTO, error) {
+	col, err := s.collectorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("collector for user %s not found", userID)
+	}
+
+	offers, err := s.dispatchRepo.GetOpenOffersByCollector(ctx, col.ID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil offer: %w", err)
+	}
+
+	results := make([]DispatchOfferResponseDTO, 0, len(offers))
+	for _, offer := range offers {
+		results = append(results, toDispatchOfferResponse(offer))
+	}
+	return results, nil
+}
+
+// AcceptOffer claims the pickup for the collector and confirms it in one transition,
+// so a confirmation that fails (e.g. the collector's slot filled up) leaves both the
+// offer and the pickup untouched.
+func (s *pickupDispatchService) AcceptOffer(ctx context.Context, offerID string, actor PickupActor) error {
+	offer, err := s.dispatchRepo.GetOfferByID(ctx, offerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("offer with id %s not found", offerID)
+		}
+		return fmt.Errorf("failed to get offer: %w", err)
+	}
+
+	confirmedAt := time.Now()
+	_, err = s.statusMachine.ClaimAndTransition(ctx, offer.RequestID, StatusConfirmedByCollector, actor, PickupReason{Code: "dispatch_offer_accepted"},
+		func(tx *gorm.DB, pickup *model.RequestPickup) error {
+			locked, err := s.lockOwnOffer(ctx, tx, offerID, actor)
+			if err != nil {
+				return err
+			}
+
+			assigned, err := s.dispatchRepo.WithTx(tx).AssignCollectorIfFree(ctx, pickup.ID, locked.CollectorID)
+			if err != nil {
+				return fmt.Errorf("gagal menugaskan collector: %w", err)
+			}
+			if !assigned {
+				return fmt.Errorf("offer tidak berlaku lagi, pickup sudah ditangani")
+			}
+			pickup.CollectorID = &locked.CollectorID
+
+			if err := s.dispatchRepo.WithTx(tx).UpdateOfferStatus(ctx, locked.ID, OfferStatusAccepted, &confirmedAt); err != nil {
+				return fmt.Errorf("gagal memperbarui offer: %w", err)
+			}
+			return nil
+		},
+		func(tx *gorm.DB, pickup *model.RequestPickup) error {
+			pickup.ConfirmedByCollectorAt = &confirmedAt
+			return s.pickupRepo.WithTx(tx).UpdatePickupFields(ctx, pickup.ID, map[string]interface{}{
+				"confirmed_by_collector_at": confirmedAt,
+			})
+		})
+	return err
+}
+
+func (s *pickupDispatchService) DeclineOffer(ctx context.Context, offerID string, actor PickupActor) error {
+	var pickupID string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		offer, err := s.lockOwnOffer(ctx, tx, offerID, actor)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := s.dispatchRepo.WithTx(tx).UpdateOfferStatus(ctx, offer.ID, OfferStatusDeclined, &now); err != nil {
+			return fmt.Errorf("gagal memperbarui offer: %w", err)
+		}
+
+		pickupID = offer.RequestID
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// no need to wait for the next worker tick, hand it to the next candidate right away
+	if _, err := s.dispatchPickup(ctx, pickupID); err != nil {
+		log.Printf("[DISPATCH] failed to re-dispatch pickup %s after decline: %v", pickupID, err)
+	}
+	return nil
+}
+
+// lockOwnOffer loads the offer row for update and makes sure it is still open and
+// belongs to the collector behind the actor.
+func (s *pickupDispatchService) lockOwnOffer(ctx context.Context, tx *gorm.DB, offerID string, actor PickupActor) (*model.PickupDispatchOffer, error) {
+	col, err := s.collectorRepo.WithTx(tx).GetByUserID(ctx, actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("collector for user %s not found", actor.UserID)
+	}
+
+	offer, err := s.dispatchRepo.WithTx(tx).GetOfferForUpdate(ctx, offerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("offer with id %s not found", offerID)
+		}
+		return nil, fmt.Errorf("failed to get offer: %w", err)
+	}
+
+	if offer.CollectorID != col.ID {
+		return nil, fmt.Errorf("forbidden: offer ini bukan untuk anda")
+	}
+	if offer.Status != OfferStatusOffered {
+		return nil, fmt.Errorf("offer tidak berlaku lagi, status saat ini '%s'", offer.Status)
+	}
+	if time.Now().After(offer.ExpiresAt) {
+		return nil, fmt.Errorf("offer tidak berlaku lagi, sudah kedaluwarsa")
+	}
+
+	return offer, nil
+}
+
+func (s *pickupDispatchService) GetEscalatedPickups(ctx context.Context) ([]EscalatedPickupDTO, error) {
+	pickups, err := s.dispatchRepo.GetEscalatedPickups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pickup eskalasi: %w", err)
+	}
+
+	results := make([]EscalatedPickupDTO, 0, len(pickups))
+	for _, p := range pickups {
+		dto := EscalatedPickupDTO{
+			PickupID:   p.ID,
+			UserID:     p.UserId,
+			AddressID:  p.AddressId,
+			TotalItems: len(p.RequestItems),
+			CreatedAt:  p.CreatedAt,
+		}
+		if p.DispatchEscalatedAt != nil {
+			dto.EscalatedAt = *p.DispatchEscalatedAt
+		}
+		if p.Address != nil {
+			dto.Regency = p.Address.Regency
+			dto.Latitude = p.Address.Latitude
+			dto.Longitude = p.Address.Longitude
+		}
+		results = append(results, dto)
+	}
+	return results, nil
+}
+
+func toDispatchOfferResponse(offer model.PickupDispatchOffer) DispatchOfferResponseDTO {
+	return DispatchOfferResponseDTO{
+		OfferID:    offer.ID,
+		PickupID:   offer.RequestID,
+		Status:     offer.Status,
+		Score:      offer.Score,
+		DistanceKm: offer.DistanceKm,
+		OfferedAt:  offer.OfferedAt,
+		ExpiresAt:  offer.ExpiresAt,
+	}
+}
@@ -4,26 +4,36 @@ import (
 	"context"
 	"rijig/config"
 	"rijig/model"
+
+	"gorm.io/gorm"
 )
 
 type PickupStatusHistoryRepository interface {
 	CreateStatusHistory(ctx context.Context, history model.PickupStatusHistory) error
 	GetStatusHistoryByRequestID(ctx context.Context, requestID string) ([]model.PickupStatusHistory, error)
+
+	WithTx(tx *gorm.DB) PickupStatusHistoryRepository
 }
 
-type pickupStatusHistoryRepository struct{}
+type pickupStatusHistoryRepository struct {
+	db *gorm.DB
+}
 
 func NewPickupStatusHistoryRepository() PickupStatusHistoryRepository {
-	return &pickupStatusHistoryRepository{}
+	return &pickupStatusHistoryRepository{db: config.DB}
+}
+
+func (r *pickupStatusHistoryRepository) WithTx(tx *gorm.DB) PickupStatusHistoryRepository {
+	return &pickupStatusHistoryRepository{db: tx}
 }
 
 func (r *pickupStatusHistoryRepository) CreateStatusHistory(ctx context.Context, history model.PickupStatusHistory) error {
-	return config.DB.WithContext(ctx).Create(&history).Error
+	return r.db.WithContext(ctx).Create(&history).Error
 }
 
 func (r *pickupStatusHistoryRepository) GetStatusHistoryByRequestID(ctx context.Context, requestID string) ([]model.PickupStatusHistory, error) {
 	var histories []model.PickupStatusHistory
-	err := config.DB.WithContext(ctx).
+	err := r.db.WithContext(ctx).
 		Where("request_id = ?", requestID).
 		Order("changed_at asc").
 		Find(&histories).Error
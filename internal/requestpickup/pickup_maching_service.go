@@ -2,35 +2,91 @@ package requestpickup
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"rijig/internal/collector"
+	"rijig/internal/geoindex"
+	"rijig/internal/trash"
+	"rijig/internal/wilayahindo"
+	"rijig/model"
 	"rijig/utils"
+
+	"gorm.io/gorm"
 )
 
 type PickupMatchingService interface {
-	FindNearbyCollectorsForPickup(ctx context.Context, pickupID string) ([]collector.NearbyCollectorDTO, error)
-	FindAvailableRequestsForCollector(ctx context.Context, collectorID string) ([]PickupRequestForCollectorDTO, error)
+	FindNearbyCollectorsForPickup(ctx context.Context, pickupID string, actor PickupActor) (*NearbyCollectorsResponseDTO, error)
+	FindAvailableRequestsForCollector(ctx context.Context, userID string) (*AvailableRequestsResponseDTO, error)
+	RankCollectorsForPickup(ctx context.Context, pickupID string) ([]RankedCollectorDTO, error)
+
+	ListMatchingConfigs(ctx context.Context) ([]model.MatchingConfig, error)
+	UpsertMatchingConfig(ctx context.Context, req MatchingConfigDTO) (*model.MatchingConfig, error)
+	DeleteMatchingConfig(ctx context.Context, regencyID string) error
 }
 
 type pickupMatchingService struct {
 	pickupRepo    RequestPickupRepository
 	collectorRepo collector.CollectorRepository
+	slotRepo      collector.CollectorSlotRepository
+	configRepo    MatchingConfigRepository
+	wilayahRepo   wilayahindo.WilayahIndonesiaRepository
+	regional      trash.TrashRegionalPriceServiceInterface
 }
 
 func NewPickupMatchingService(pickupRepo RequestPickupRepository,
-	collectorRepo collector.CollectorRepository) PickupMatchingService {
+	collectorRepo collector.CollectorRepository,
+	slotRepo collector.CollectorSlotRepository,
+	configRepo MatchingConfigRepository,
+	wilayahRepo wilayahindo.WilayahIndonesiaRepository,
+	regional trash.TrashRegionalPriceServiceInterface) PickupMatchingService {
 	return &pickupMatchingService{
 		pickupRepo:    pickupRepo,
 		collectorRepo: collectorRepo,
+		slotRepo:      slotRepo,
+		configRepo:    configRepo,
+		wilayahRepo:   wilayahRepo,
+		regional:      regional,
 	}
 }
 
-func (s *pickupMatchingService) FindNearbyCollectorsForPickup(ctx context.Context, pickupID string) ([]collector.NearbyCollectorDTO, error) {
+func (s *pickupMatchingService) FindNearbyCollectorsForPickup(ctx context.Context, pickupID string, actor PickupActor) (*NearbyCollectorsResponseDTO, error) {
 	pickup, err := s.pickupRepo.GetPickupWithItemsAndAddress(ctx, pickupID)
 	if err != nil {
-		return nil, fmt.Errorf("pickup tidak ditemukan: %w", err)
+		return nil, fmt.Errorf("pickup with id %s not found", pickupID)
+	}
+
+	if actor.Role != utils.RoleAdministrator && pickup.UserId != actor.UserID {
+		return nil, fmt.Errorf("forbidden: anda bukan pemilik request pickup ini")
 	}
 
+	if pickup.RequestMethod != "manual" || pickup.StatusPickup != StatusWaitingCollector {
+		return nil, fmt.Errorf("collector terdekat hanya tersedia untuk pickup manual yang masih menunggu collector")
+	}
+
+	weights := s.weightsForRegency(ctx, pickup.Address.Regency)
+	result, err := s.rankCollectors(ctx, pickup, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NearbyCollectorsResponseDTO{
+		PickupID:   pickup.ID,
+		Config:     weights,
+		Collectors: result,
+	}, nil
+}
+
+func (s *pickupMatchingService) RankCollectorsForPickup(ctx context.Context, pickupID string) ([]RankedCollectorDTO, error) {
+	pickup, err := s.pickupRepo.GetPickupWithItemsAndAddress(ctx, pickupID)
+	if err != nil {
+		return nil, fmt.Errorf("pickup with id %s not found", pickupID)
+	}
+
+	return s.rankCollectors(ctx, pickup, s.weightsForRegency(ctx, pickup.Address.Regency))
+}
+
+func (s *pickupMatchingService) rankCollectors(ctx context.Context, pickup *model.RequestPickup, weights MatchingWeights) ([]RankedCollectorDTO, error) {
 	userCoord := utils.Coord{
 		Lat: pickup.Address.Latitude,
 		Lon: pickup.Address.Longitude,
@@ -41,76 +97,109 @@ func (s *pickupMatchingService) FindNearbyCollectorsForPickup(ctx context.Contex
 		requestedTrash[item.TrashCategoryId] = true
 	}
 
-	collectors, err := s.collectorRepo.GetActiveCollectorsWithTrashAndAddress(ctx)
+	prices, err := s.regional.ResolveForRegion(ctx, pickup.Address.Province, pickup.Address.Regency)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil harga regional: %w", err)
+	}
+
+	collectors, err := s.collectorsWithinRadius(ctx, userCoord, weights.RadiusKm)
 	if err != nil {
 		return nil, fmt.Errorf("gagal mengambil data collector: %w", err)
 	}
 
-	var result []collector.NearbyCollectorDTO
+	collectors, err = s.collectorsFreeForWindow(ctx, collectors, windowOf(pickup))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memeriksa jadwal collector: %w", err)
+	}
+
+	collectorIDs := make([]string, 0, len(collectors))
 	for _, col := range collectors {
+		collectorIDs = append(collectorIDs, col.ID)
+	}
+	workloads, err := s.pickupRepo.CountActiveAssignments(ctx, collectorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung beban collector: %w", err)
+	}
+
+	result := make([]RankedCollectorDTO, 0)
+	for i := range collectors {
+		col := &collectors[i]
 		coord := utils.Coord{
 			Lat: col.Address.Latitude,
 			Lon: col.Address.Longitude,
 		}
 
 		_, km := utils.Distance(userCoord, coord)
-		if km > 10 {
+		if km > weights.RadiusKm {
 			continue
 		}
 
-		var matchedTrash []string
-		for _, item := range col.AvaibleTrashByCollector {
-			if requestedTrash[item.TrashCategoryID] {
-				matchedTrash = append(matchedTrash, item.TrashCategoryID)
-			}
-		}
-
+		avgOffered, avgRatio, matchedTrash := priceStats(col, requestedTrash, prices)
 		if len(matchedTrash) == 0 {
 			continue
 		}
 
-		result = append(result, collector.NearbyCollectorDTO{
-			CollectorID:  col.ID,
-			Name:         col.User.Name,
-			Phone:        col.User.Phone,
-			Rating:       col.Rating,
-			Latitude:     col.Address.Latitude,
-			Longitude:    col.Address.Longitude,
-			DistanceKm:   km,
-			MatchedTrash: matchedTrash,
+		score := scoreMatch(matchCandidate{
+			DistanceKm:     km,
+			RequestedCount: len(requestedTrash),
+			MatchedCount:   len(matchedTrash),
+			Rating:         col.Rating,
+			AvgPriceRatio:  avgRatio,
+			ActivePickups:  workloads[col.ID],
+		}, weights)
+
+		result = append(result, RankedCollectorDTO{
+			NearbyCollectorDTO: collector.NearbyCollectorDTO{
+				CollectorID:  col.ID,
+				Name:         col.User.Name,
+				Phone:        col.User.Phone,
+				Rating:       col.Rating,
+				Latitude:     col.Address.Latitude,
+				Longitude:    col.Address.Longitude,
+				DistanceKm:   round3(km),
+				MatchedTrash: matchedTrash,
+			},
+			AvgOfferedPrice: avgOffered,
+			ActivePickups:   workloads[col.ID],
+			Score:           score,
 		})
 	}
 
+	sortRankedCollectors(result)
 	return result, nil
 }
 
-// terdpaat error seperti ini: "undefined: dto.PickupRequestForCollectorDTO" dan seprti ini: s.collectorRepo.GetCollectorWithAddressAndTrash undefined (type repositories.CollectorRepository has no field or method GetCollectorWithAddressAndTrash) pada kode berikut:
-
-func (s *pickupMatchingService) FindAvailableRequestsForCollector(ctx context.Context, collectorID string) ([]PickupRequestForCollectorDTO, error) {
-	collector, err := s.collectorRepo.GetCollectorWithAddressAndTrash(ctx, collectorID)
+func (s *pickupMatchingService) FindAvailableRequestsForCollector(ctx context.Context, userID string) (*AvailableRequestsResponseDTO, error) {
+	collector, err := s.collectorRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("collector tidak ditemukan: %w", err)
+		return nil, fmt.Errorf("collector for user %s not found", userID)
 	}
 
-	pickupList, err := s.pickupRepo.GetAllAutomaticRequestsWithAddress(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("gagal mengambil pickup otomatis: %w", err)
+	if collector.JobStatus != "active" {
+		return nil, fmt.Errorf("collector harus berstatus active untuk melihat request otomatis")
 	}
 
+	weights := s.weightsForRegency(ctx, collector.Address.Regency)
+
 	collectorCoord := utils.Coord{
 		Lat: collector.Address.Latitude,
 		Lon: collector.Address.Longitude,
 	}
 
-	// map trash collector
-	collectorTrash := make(map[string]bool)
-	for _, t := range collector.AvaibleTrashByCollector {
-		collectorTrash[t.TrashCategoryID] = true
+	pickupList, err := s.pickupsWithinRadius(ctx, collectorCoord, weights.RadiusKm)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pickup otomatis: %w", err)
+	}
+
+	workloads, err := s.pickupRepo.CountActiveAssignments(ctx, []string{collector.ID})
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung beban collector: %w", err)
 	}
 
-	var results []PickupRequestForCollectorDTO
+	regionalPrices := make(map[string]*trash.RegionalPrices)
+	results := make([]PickupRequestForCollectorDTO, 0)
 	for _, p := range pickupList {
-		if p.StatusPickup != "waiting_collector" {
+		if p.StatusPickup != StatusWaitingCollector {
 			continue
 		}
 		coord := utils.Coord{
@@ -118,29 +207,174 @@ func (s *pickupMatchingService) FindAvailableRequestsForCollector(ctx context.Co
 			Lon: p.Address.Longitude,
 		}
 		_, km := utils.Distance(collectorCoord, coord)
-		if km > 10 {
+		if km > weights.RadiusKm {
 			continue
 		}
 
-		match := false
-		var matchedTrash []string
+		requested := make(map[string]bool)
 		for _, item := range p.RequestItems {
-			if collectorTrash[item.TrashCategoryId] {
-				match = true
-				matchedTrash = append(matchedTrash, item.TrashCategoryId)
+			requested[item.TrashCategoryId] = true
+		}
+
+		// pickups of one region share their prices
+		regionKey := normalizeRegency(p.Address.Province) + "|" + normalizeRegency(p.Address.Regency)
+		prices, ok := regionalPrices[regionKey]
+		if !ok {
+			prices, err = s.regional.ResolveForRegion(ctx, p.Address.Province, p.Address.Regency)
+			if err != nil {
+				return nil, fmt.Errorf("gagal mengambil harga regional: %w", err)
 			}
+			regionalPrices[regionKey] = prices
 		}
-		if match {
-			results = append(results, PickupRequestForCollectorDTO{
-				PickupID:     p.ID,
-				UserID:       p.UserId,
-				Latitude:     p.Address.Latitude,
-				Longitude:    p.Address.Longitude,
-				DistanceKm:   km,
-				MatchedTrash: matchedTrash,
-			})
+
+		_, avgRatio, matchedTrash := priceStats(collector, requested, prices)
+		if len(matchedTrash) == 0 {
+			continue
+		}
+
+		if window := windowOf(&p); window != nil {
+			free, err := s.slotRepo.GetFreeCollectorIDs(ctx, []string{collector.ID}, window.Weekday, window.From, window.To, window.Date)
+			if err != nil {
+				return nil, fmt.Errorf("gagal memeriksa jadwal collector: %w", err)
+			}
+			if !free[collector.ID] {
+				continue
+			}
 		}
+
+		score := scoreMatch(matchCandidate{
+			DistanceKm:     km,
+			RequestedCount: len(requested),
+			MatchedCount:   len(matchedTrash),
+			Rating:         collector.Rating,
+			AvgPriceRatio:  avgRatio,
+			ActivePickups:  workloads[collector.ID],
+		}, weights)
+
+		results = append(results, PickupRequestForCollectorDTO{
+			PickupID:       p.ID,
+			UserID:         p.UserId,
+			Latitude:       p.Address.Latitude,
+			Longitude:      p.Address.Longitude,
+			DistanceKm:     round3(km),
+			MatchedTrash:   matchedTrash,
+			Score:          score,
+			ScheduledStart: p.ScheduledStart,
+			ScheduledEnd:   p.ScheduledEnd,
+		})
 	}
 
-	return results, nil
+	sortRankedRequests(results)
+
+	return &AvailableRequestsResponseDTO{
+		CollectorID: collector.ID,
+		Config:      weights,
+		Requests:    results,
+	}, nil
+}
+
+// collectorsWithinRadius asks the geo index for candidates inside the radius and only
+// loads those rows. If Redis is unavailable it falls back to scanning every active collector;
+// the distance filter in the caller keeps the result identical either way.
+func (s *pickupMatchingService) collectorsWithinRadius(ctx context.Context, origin utils.Coord, radiusKm float64) ([]model.Collector, error) {
+	hits, err := geoindex.NearbyCollectors(ctx, origin.Lat, origin.Lon, radiusKm)
+	if err != nil {
+		log.Printf("[MATCHING] geo index unavailable, falling back to full scan: %v", err)
+		return s.collectorRepo.GetActiveCollectorsWithTrashAndAddress(ctx)
+	}
+
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return s.collectorRepo.GetActiveCollectorsWithTrashAndAddressByIDs(ctx, ids)
+}
+
+// collectorsFreeForWindow keeps only collectors with an open slot covering a scheduled
+// pickup; "as soon as possible" pickups leave the list untouched.
+func (s *pickupMatchingService) collectorsFreeForWindow(ctx context.Context, collectors []model.Collector, window *pickupWindow) ([]model.Collector, error) {
+	if window == nil || len(collectors) == 0 {
+		return collectors, nil
+	}
+
+	ids := make([]string, 0, len(collectors))
+	for _, col := range collectors {
+		ids = append(ids, col.ID)
+	}
+
+	free, err := s.slotRepo.GetFreeCollectorIDs(ctx, ids, window.Weekday, window.From, window.To, window.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]model.Collector, 0, len(free))
+	for _, col := range collectors {
+		if free[col.ID] {
+			available = append(available, col)
+		}
+	}
+	return available, nil
+}
+
+func (s *pickupMatchingService) pickupsWithinRadius(ctx context.Context, origin utils.Coord, radiusKm float64) ([]model.RequestPickup, error) {
+	hits, err := geoindex.NearbyPickups(ctx, origin.Lat, origin.Lon, radiusKm)
+	if err != nil {
+		log.Printf("[MATCHING] geo index unavailable, falling back to full scan: %v", err)
+		return s.pickupRepo.GetAllAutomaticRequestsWithAddress(ctx)
+	}
+
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return s.pickupRepo.GetAutomaticRequestsWithAddressByIDs(ctx, ids)
+}
+
+func (s *pickupMatchingService) ListMatchingConfigs(ctx context.Context) ([]model.MatchingConfig, error) {
+	return s.configRepo.ListConfigs(ctx)
+}
+
+func (s *pickupMatchingService) UpsertMatchingConfig(ctx context.Context, req MatchingConfigDTO) (*model.MatchingConfig, error) {
+	regency, _, err := s.wilayahRepo.FindRegencyByID(ctx, req.RegencyID, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("regency with id %s not found", req.RegencyID)
+	}
+
+	cfg := &model.MatchingConfig{
+		RegencyID:      regency.ID,
+		RegencyName:    regency.Name,
+		RadiusKm:       req.RadiusKm,
+		MaxWorkload:    req.MaxWorkload,
+		WeightDistance: req.WeightDistance,
+		WeightCategory: req.WeightCategory,
+		WeightRating:   req.WeightRating,
+		WeightPrice:    req.WeightPrice,
+		WeightWorkload: req.WeightWorkload,
+	}
+
+	if err := s.configRepo.UpsertConfig(ctx, cfg); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan konfigurasi matching: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *pickupMatchingService) DeleteMatchingConfig(ctx context.Context, regencyID string) error {
+	if err := s.configRepo.DeleteConfigByRegencyID(ctx, regencyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("matching config for regency %s not found", regencyID)
+		}
+		return err
+	}
+	return nil
+}
+
+// weightsForRegency falls back to the national defaults when the regency has no override.
+func (s *pickupMatchingService) weightsForRegency(ctx context.Context, regencyName string) MatchingWeights {
+	cfg, err := s.configRepo.GetConfigByRegencyName(ctx, regencyName)
+	if err != nil {
+		weights := DefaultMatchingWeights()
+		weights.Regency = regencyName
+		return weights
+	}
+	return matchingWeightsFromConfig(cfg)
 }
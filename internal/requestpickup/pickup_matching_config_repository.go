@@ -0,0 +1,78 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+)
+
+type MatchingConfigRepository interface {
+	UpsertConfig(ctx context.Context, cfg *model.MatchingConfig) error
+	GetConfigByRegencyName(ctx context.Context, regencyName string) (*model.MatchingConfig, error)
+	GetConfigByRegencyID(ctx context.Context, regencyID string) (*model.MatchingConfig, error)
+	ListConfigs(ctx context.Context) ([]model.MatchingConfig, error)
+	DeleteConfigByRegencyID(ctx context.Context, regencyID string) error
+}
+
+type matchingConfigRepository struct {
+	db *gorm.DB
+}
+
+func NewMatchingConfigRepository() MatchingConfigRepository {
+	return &matchingConfigRepository{db: config.DB}
+}
+
+func (r *matchingConfigRepository) UpsertConfig(ctx context.Context, cfg *model.MatchingConfig) error {
+	var existing model.MatchingConfig
+	err := r.db.WithContext(ctx).Where("regency_id = ?", cfg.RegencyID).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return r.db.WithContext(ctx).Create(cfg).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg.ID = existing.ID
+	cfg.CreatedAt = existing.CreatedAt
+	return r.db.WithContext(ctx).Save(cfg).Error
+}
+
+func (r *matchingConfigRepository) GetConfigByRegencyName(ctx context.Context, regencyName string) (*model.MatchingConfig, error) {
+	var cfg model.MatchingConfig
+	err := r.db.WithContext(ctx).
+		Where("LOWER(regency_name) = ?", normalizeRegency(regencyName)).
+		First(&cfg).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (r *matchingConfigRepository) GetConfigByRegencyID(ctx context.Context, regencyID string) (*model.MatchingConfig, error) {
+	var cfg model.MatchingConfig
+	if err := r.db.WithContext(ctx).Where("regency_id = ?", regencyID).First(&cfg).Error; err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (r *matchingConfigRepository) ListConfigs(ctx context.Context) ([]model.MatchingConfig, error) {
+	var configs []model.MatchingConfig
+	if err := r.db.WithContext(ctx).Order("regency_name asc").Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+func (r *matchingConfigRepository) DeleteConfigByRegencyID(ctx context.Context, regencyID string) error {
+	result := r.db.WithContext(ctx).Where("regency_id = ?", regencyID).Delete(&model.MatchingConfig{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
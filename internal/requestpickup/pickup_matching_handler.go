@@ -4,6 +4,7 @@ import (
 	"context"
 	"rijig/middleware"
 	"rijig/utils"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,6 +20,11 @@ func NewPickupMatchingHandler(service PickupMatchingService) *PickupMatchingHand
 }
 
 func (h *PickupMatchingHandler) GetNearbyCollectorsForPickup(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	pickupID := c.Params("pickupID")
 	if pickupID == "" {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
@@ -26,9 +32,9 @@ func (h *PickupMatchingHandler) GetNearbyCollectorsForPickup(c *fiber.Ctx) error
 		})
 	}
 
-	collectors, err := h.service.FindNearbyCollectorsForPickup(context.Background(), pickupID)
+	collectors, err := h.service.FindNearbyCollectorsForPickup(context.Background(), pickupID, pickupActorFromClaims(claims))
 	if err != nil {
-		return utils.InternalServerError(c, err.Error())
+		return handleMatchingError(c, err)
 	}
 
 	return utils.SuccessWithData(c, "Data collector terdekat berhasil diambil", collectors)
@@ -42,8 +48,70 @@ func (h *PickupMatchingHandler) GetAvailablePickupForCollector(c *fiber.Ctx) err
 
 	pickups, err := h.service.FindAvailableRequestsForCollector(context.Background(), claims.UserID)
 	if err != nil {
-		return utils.InternalServerError(c, err.Error())
+		return handleMatchingError(c, err)
 	}
 
 	return utils.SuccessWithData(c, "Data request pickup otomatis berhasil diambil", pickups)
 }
+
+func (h *PickupMatchingHandler) ListMatchingConfigs(c *fiber.Ctx) error {
+	configs, err := h.service.ListMatchingConfigs(context.Background())
+	if err != nil {
+		return utils.InternalServerError(c, err.Error())
+	}
+
+	return utils.SuccessWithData(c, "Konfigurasi matching berhasil diambil", fiber.Map{
+		"default": DefaultMatchingWeights(),
+		"regions": configs,
+	})
+}
+
+func (h *PickupMatchingHandler) UpsertMatchingConfig(c *fiber.Ctx) error {
+	var req MatchingConfigDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	req.RegencyID = c.Params("regencyID")
+
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	cfg, err := h.service.UpsertMatchingConfig(context.Background(), req)
+	if err != nil {
+		return handleMatchingError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Konfigurasi matching berhasil disimpan", cfg)
+}
+
+func (h *PickupMatchingHandler) DeleteMatchingConfig(c *fiber.Ctx) error {
+	regencyID := c.Params("regencyID")
+	if regencyID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"regency_id": {"regency ID harus disertakan"},
+		})
+	}
+
+	if err := h.service.DeleteMatchingConfig(context.Background(), regencyID); err != nil {
+		return handleMatchingError(c, err)
+	}
+
+	return utils.Success(c, "Konfigurasi matching berhasil dihapus, regency kembali memakai default")
+}
+
+func handleMatchingError(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	case strings.HasPrefix(msg, "forbidden"):
+		return utils.Forbidden(c, msg)
+	case strings.Contains(msg, "hanya tersedia") || strings.Contains(msg, "harus berstatus"):
+		return utils.BadRequest(c, msg)
+	default:
+		return utils.InternalServerError(c, msg)
+	}
+}
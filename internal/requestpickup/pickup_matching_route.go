@@ -3,7 +3,10 @@ package requestpickup
 import (
 	"rijig/config"
 	"rijig/internal/collector"
+	"rijig/internal/trash"
+	"rijig/internal/wilayahindo"
 	"rijig/middleware"
+	"rijig/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -11,14 +14,24 @@ import (
 func PickupMatchingRouter(api fiber.Router) {
 	pickupRepo := NewRequestPickupRepository()
 	collectorRepo := collector.NewCollectorRepository(config.DB)
-	service := NewPickupMatchingService(pickupRepo, collectorRepo)
+	slotRepo := collector.NewCollectorSlotRepository(config.DB)
+	configRepo := NewMatchingConfigRepository()
+	wilayahRepo := wilayahindo.NewWilayahIndonesiaRepository(config.DB)
+	service := NewPickupMatchingService(pickupRepo, collectorRepo, slotRepo, configRepo, wilayahRepo,
+		trash.NewDefaultTrashRegionalPriceService(config.DB))
 	handler := NewPickupMatchingHandler(service)
 
 	manual := api.Group("/pickup/manual")
-	manual.Use(middleware.AuthMiddleware)
-	manual.Get("/:pickupID/nearby-collectors", handler.GetNearbyCollectorsForPickup)
+	manual.Use(middleware.AuthMiddleware())
+	manual.Get("/:pickupID/nearby-collectors", middleware.RequireRoles(utils.RoleMasyarakat, utils.RoleAdministrator), handler.GetNearbyCollectorsForPickup)
 
 	auto := api.Group("/pickup/otomatis")
-	auto.Use(middleware.AuthMiddleware)
-	auto.Get("/available-requests", handler.GetAvailablePickupForCollector)
+	auto.Use(middleware.AuthMiddleware())
+	auto.Get("/available-requests", middleware.RequireRoles(utils.RolePengepul), handler.GetAvailablePickupForCollector)
+
+	matchingConfig := api.Group("/pickup/matching-config")
+	matchingConfig.Use(middleware.AuthMiddleware(), middleware.RequireAdminRole())
+	matchingConfig.Get("/", handler.ListMatchingConfigs)
+	matchingConfig.Put("/:regencyID", handler.UpsertMatchingConfig)
+	matchingConfig.Delete("/:regencyID", handler.DeleteMatchingConfig)
 }
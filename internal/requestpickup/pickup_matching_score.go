@@ -0,0 +1,150 @@
+package requestpickup
+
+import (
+	"math"
+	"rijig/internal/trash"
+	"rijig/model"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultMatchingRadiusKm = 10.0
+	defaultMaxWorkload      = 5
+
+	// offered prices are compared to the category's estimated price and capped
+	// at this ratio so one generous category can't dominate the score.
+	maxPriceRatio = 1.5
+)
+
+// MatchingWeights is the effective radius and weight set for one regency.
+type MatchingWeights struct {
+	Regency        string  `json:"regency"`
+	RadiusKm       float64 `json:"radius_km"`
+	MaxWorkload    int     `json:"max_workload"`
+	WeightDistance float64 `json:"weight_distance"`
+	WeightCategory float64 `json:"weight_category"`
+	WeightRating   float64 `json:"weight_rating"`
+	WeightPrice    float64 `json:"weight_price"`
+	WeightWorkload float64 `json:"weight_workload"`
+}
+
+func DefaultMatchingWeights() MatchingWeights {
+	return MatchingWeights{
+		RadiusKm:       defaultMatchingRadiusKm,
+		MaxWorkload:    defaultMaxWorkload,
+		WeightDistance: 0.35,
+		WeightCategory: 0.25,
+		WeightRating:   0.2,
+		WeightPrice:    0.1,
+		WeightWorkload: 0.1,
+	}
+}
+
+func matchingWeightsFromConfig(cfg *model.MatchingConfig) MatchingWeights {
+	return MatchingWeights{
+		Regency:        cfg.RegencyName,
+		RadiusKm:       cfg.RadiusKm,
+		MaxWorkload:    cfg.MaxWorkload,
+		WeightDistance: cfg.WeightDistance,
+		WeightCategory: cfg.WeightCategory,
+		WeightRating:   cfg.WeightRating,
+		WeightPrice:    cfg.WeightPrice,
+		WeightWorkload: cfg.WeightWorkload,
+	}
+}
+
+// matchCandidate holds the raw inputs for scoring one collector/request pair.
+type matchCandidate struct {
+	DistanceKm     float64
+	RequestedCount int
+	MatchedCount   int
+	Rating         float32
+	AvgPriceRatio  float64
+	ActivePickups  int64
+}
+
+func scoreMatch(c matchCandidate, w MatchingWeights) MatchScoreBreakdownDTO {
+	score := MatchScoreBreakdownDTO{}
+
+	if w.RadiusKm > 0 {
+		score.Distance = clamp01(1 - c.DistanceKm/w.RadiusKm)
+	}
+	if c.RequestedCount > 0 {
+		score.CategoryMatch = clamp01(float64(c.MatchedCount) / float64(c.RequestedCount))
+	}
+	score.Rating = clamp01(float64(c.Rating) / 5)
+	score.Price = clamp01(c.AvgPriceRatio / maxPriceRatio)
+	if w.MaxWorkload > 0 {
+		score.Workload = clamp01(1 - float64(c.ActivePickups)/float64(w.MaxWorkload))
+	}
+
+	totalWeight := w.WeightDistance + w.WeightCategory + w.WeightRating + w.WeightPrice + w.WeightWorkload
+	if totalWeight > 0 {
+		score.Total = (score.Distance*w.WeightDistance +
+			score.CategoryMatch*w.WeightCategory +
+			score.Rating*w.WeightRating +
+			score.Price*w.WeightPrice +
+			score.Workload*w.WeightWorkload) / totalWeight
+	}
+
+	score.Distance = round3(score.Distance)
+	score.CategoryMatch = round3(score.CategoryMatch)
+	score.Rating = round3(score.Rating)
+	score.Price = round3(score.Price)
+	score.Workload = round3(score.Workload)
+	score.Total = round3(score.Total)
+	return score
+}
+
+// priceStats averages the collector's offered price and its ratio to the price in
+// force at the pickup address over the matched categories.
+func priceStats(col *model.Collector, requested map[string]bool, prices *trash.RegionalPrices) (avgOffered, avgRatio float64, matched []string) {
+	var sumOffered, sumRatio float64
+	for _, item := range col.AvaibleTrashByCollector {
+		if !requested[item.TrashCategoryID] {
+			continue
+		}
+		matched = append(matched, item.TrashCategoryID)
+		sumOffered += float64(item.Price)
+		if estimated, _ := prices.PriceFor(&item.TrashCategory); estimated > 0 {
+			sumRatio += math.Min(float64(item.Price)/estimated, maxPriceRatio)
+		}
+	}
+
+	if len(matched) == 0 {
+		return 0, 0, nil
+	}
+	n := float64(len(matched))
+	return sumOffered / n, sumRatio / n, matched
+}
+
+func sortRankedCollectors(list []RankedCollectorDTO) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Score.Total != list[j].Score.Total {
+			return list[i].Score.Total > list[j].Score.Total
+		}
+		return list[i].DistanceKm < list[j].DistanceKm
+	})
+}
+
+func sortRankedRequests(list []PickupRequestForCollectorDTO) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Score.Total != list[j].Score.Total {
+			return list[i].Score.Total > list[j].Score.Total
+		}
+		return list[i].DistanceKm < list[j].DistanceKm
+	})
+}
+
+func normalizeRegency(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
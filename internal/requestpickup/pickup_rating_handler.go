@@ -0,0 +1,134 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/middleware"
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PickupRatingHandler struct {
+	service PickupRatingService
+}
+
+func NewPickupRatingHandler(service PickupRatingService) *PickupRatingHandler {
+	return &PickupRatingHandler{service: service}
+}
+
+func (h *PickupRatingHandler) RatePickup(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("pickupID")
+	if pickupID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"pickup_id": {"pickup ID tidak boleh kosong"},
+		})
+	}
+
+	var req CreatePickupRatingDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errs)
+	}
+
+	rating, err := h.service.RatePickup(context.Background(), pickupID, pickupActorFromClaims(claims), req)
+	if err != nil {
+		return handleRatingError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Rating berhasil disimpan", rating)
+}
+
+func (h *PickupRatingHandler) DeleteRating(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	ratingID := c.Params("ratingID")
+	if ratingID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"rating_id": {"rating ID tidak boleh kosong"},
+		})
+	}
+
+	if err := h.service.DeleteRating(context.Background(), ratingID, pickupActorFromClaims(claims)); err != nil {
+		return handleRatingError(c, err)
+	}
+
+	return utils.Success(c, "Rating berhasil dihapus")
+}
+
+func (h *PickupRatingHandler) GetRatingByPickup(c *fiber.Ctx) error {
+	pickupID := c.Params("pickupID")
+	if pickupID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"pickup_id": {"pickup ID tidak boleh kosong"},
+		})
+	}
+
+	rating, err := h.service.GetRatingByPickup(context.Background(), pickupID)
+	if err != nil {
+		return handleRatingError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Rating pickup berhasil diambil", rating)
+}
+
+func (h *PickupRatingHandler) GetCollectorRatings(c *fiber.Ctx) error {
+	collectorID := c.Params("collectorID")
+	if collectorID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"collector_id": {"collector ID tidak boleh kosong"},
+		})
+	}
+
+	limit, offset, page := utils.ParsePagination(c, 10)
+	summary, err := h.service.GetCollectorRatings(context.Background(), collectorID, limit, offset)
+	if err != nil {
+		return handleRatingError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Rating collector berhasil diambil", summary, page, limit, int(summary.TotalRatings))
+}
+
+func (h *PickupRatingHandler) GetMyCollectorRatings(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	limit, offset, page := utils.ParsePagination(c, 10)
+	summary, err := h.service.GetMyCollectorRatings(context.Background(), claims.UserID, limit, offset)
+	if err != nil {
+		return handleRatingError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Feedback untuk collector berhasil diambil", summary, page, limit, int(summary.TotalRatings))
+}
+
+func handleRatingError(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	case strings.HasPrefix(msg, "forbidden"):
+		return utils.Forbidden(c, msg)
+	case strings.Contains(msg, "sudah pernah"):
+		return utils.ResponseMeta(c, fiber.StatusConflict, msg)
+	case strings.Contains(msg, "hanya dapat"):
+		return utils.BadRequest(c, msg)
+	default:
+		return utils.InternalServerError(c, msg)
+	}
+}
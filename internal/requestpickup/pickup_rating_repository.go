@@ -0,0 +1,105 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PickupRatingRepository interface {
+	CreateRating(ctx context.Context, rating *model.PickupRating) error
+	GetRatingByID(ctx context.Context, id string) (*model.PickupRating, error)
+	GetRatingByRequestID(ctx context.Context, requestID string) (*model.PickupRating, error)
+	DeleteRating(ctx context.Context, id string) error
+	GetRatingsByCollectorID(ctx context.Context, collectorID string, limit, offset int) ([]model.PickupRating, int64, error)
+	GetCollectorRatingStats(ctx context.Context, collectorID string) (count int64, sum float64, err error)
+	LockCollector(ctx context.Context, collectorID string) error
+
+	WithTx(tx *gorm.DB) PickupRatingRepository
+}
+
+type pickupRatingRepository struct {
+	db *gorm.DB
+}
+
+func NewPickupRatingRepository() PickupRatingRepository {
+	return &pickupRatingRepository{db: config.DB}
+}
+
+func (r *pickupRatingRepository) WithTx(tx *gorm.DB) PickupRatingRepository {
+	return &pickupRatingRepository{db: tx}
+}
+
+func (r *pickupRatingRepository) CreateRating(ctx context.Context, rating *model.PickupRating) error {
+	return r.db.WithContext(ctx).Create(rating).Error
+}
+
+func (r *pickupRatingRepository) GetRatingByID(ctx context.Context, id string) (*model.PickupRating, error) {
+	var rating model.PickupRating
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&rating).Error; err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+func (r *pickupRatingRepository) GetRatingByRequestID(ctx context.Context, requestID string) (*model.PickupRating, error) {
+	var rating model.PickupRating
+	if err := r.db.WithContext(ctx).Where("request_id = ?", requestID).First(&rating).Error; err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+func (r *pickupRatingRepository) DeleteRating(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.PickupRating{}).Error
+}
+
+func (r *pickupRatingRepository) GetRatingsByCollectorID(ctx context.Context, collectorID string, limit, offset int) ([]model.PickupRating, int64, error) {
+	var ratings []model.PickupRating
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.PickupRating{}).Where("collector_id = ?", collectorID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&ratings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return ratings, total, nil
+}
+
+func (r *pickupRatingRepository) GetCollectorRatingStats(ctx context.Context, collectorID string) (int64, float64, error) {
+	var stats struct {
+		Count int64
+		Sum   float64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.PickupRating{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(rating), 0) AS sum").
+		Where("collector_id = ?", collectorID).
+		Scan(&stats).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return stats.Count, stats.Sum, nil
+}
+
+// LockCollector serialises rating writes for one collector so the recomputed
+// average always sees every committed rating.
+func (r *pickupRatingRepository) LockCollector(ctx context.Context, collectorID string) error {
+	var col model.Collector
+	return r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", collectorID).
+		First(&col).Error
+}
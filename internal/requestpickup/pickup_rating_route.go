@@ -0,0 +1,27 @@
+package requestpickup
+
+import (
+	"rijig/config"
+	"rijig/internal/collector"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PickupRatingRouter(api fiber.Router) {
+	ratingRepo := NewPickupRatingRepository()
+	pickupRepo := NewRequestPickupRepository()
+	collectorRepo := collector.NewCollectorRepository(config.DB)
+	service := NewPickupRatingService(config.DB, ratingRepo, pickupRepo, collectorRepo)
+	handler := NewPickupRatingHandler(service)
+
+	rating := api.Group("/pickup/rating")
+	rating.Use(middleware.AuthMiddleware())
+
+	rating.Get("/collector/me", middleware.RequireRoles(utils.RolePengepul), handler.GetMyCollectorRatings)
+	rating.Get("/collector/:collectorID", handler.GetCollectorRatings)
+	rating.Post("/:pickupID", middleware.RequireRoles(utils.RoleMasyarakat), handler.RatePickup)
+	rating.Get("/:pickupID", handler.GetRatingByPickup)
+	rating.Delete("/id/:ratingID", middleware.RequireRoles(utils.RoleMasyarakat, utils.RoleAdministrator), handler.DeleteRating)
+}
@@ -0,0 +1,202 @@
+package requestpickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rijig/internal/collector"
+	"rijig/model"
+	"rijig/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Collector.Rating is a Bayesian average: every collector starts with
+// ratingPriorWeight virtual ratings of ratingPriorMean, so a single review
+// cannot swing a new collector to the extremes.
+const (
+	ratingPriorMean   = 5.0
+	ratingPriorWeight = 3.0
+)
+
+type PickupRatingService interface {
+	RatePickup(ctx context.Context, pickupID string, actor PickupActor, req CreatePickupRatingDTO) (*PickupRatingResponseDTO, error)
+	DeleteRating(ctx context.Context, ratingID string, actor PickupActor) error
+	GetRatingByPickup(ctx context.Context, pickupID string) (*PickupRatingResponseDTO, error)
+	GetCollectorRatings(ctx context.Context, collectorID string, limit, offset int) (*CollectorRatingSummaryDTO, error)
+	GetMyCollectorRatings(ctx context.Context, userID string, limit, offset int) (*CollectorRatingSummaryDTO, error)
+}
+
+type pickupRatingService struct {
+	db            *gorm.DB
+	ratingRepo    PickupRatingRepository
+	pickupRepo    RequestPickupRepository
+	collectorRepo collector.CollectorRepository
+}
+
+func NewPickupRatingService(db *gorm.DB, ratingRepo PickupRatingRepository, pickupRepo RequestPickupRepository, collectorRepo collector.CollectorRepository) PickupRatingService {
+	return &pickupRatingService{
+		db:            db,
+		ratingRepo:    ratingRepo,
+		pickupRepo:    pickupRepo,
+		collectorRepo: collectorRepo,
+	}
+}
+
+func (s *pickupRatingService) RatePickup(ctx context.Context, pickupID string, actor PickupActor, req CreatePickupRatingDTO) (*PickupRatingResponseDTO, error) {
+	var rating *model.PickupRating
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pickup, err := s.pickupRepo.WithTx(tx).GetPickupByIDForUpdate(ctx, pickupID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("pickup with id %s not found", pickupID)
+			}
+			return err
+		}
+
+		if pickup.UserId != actor.UserID {
+			return fmt.Errorf("forbidden: hanya pemilik request yang dapat memberi rating")
+		}
+		if pickup.StatusPickup != StatusCompleted || pickup.CollectorID == nil {
+			return fmt.Errorf("rating hanya dapat diberikan untuk pickup yang sudah selesai")
+		}
+
+		ratingRepo := s.ratingRepo.WithTx(tx)
+		if _, err := ratingRepo.GetRatingByRequestID(ctx, pickupID); err == nil {
+			return fmt.Errorf("pickup ini sudah pernah diberi rating")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := ratingRepo.LockCollector(ctx, *pickup.CollectorID); err != nil {
+			return fmt.Errorf("failed to lock collector: %w", err)
+		}
+
+		rating = &model.PickupRating{
+			RequestID:   pickupID,
+			UserID:      actor.UserID,
+			CollectorID: *pickup.CollectorID,
+			Rating:      req.Rating,
+			Feedback:    req.Feedback,
+			CreatedAt:   time.Now(),
+		}
+		if err := ratingRepo.CreateRating(ctx, rating); err != nil {
+			return fmt.Errorf("failed to save rating: %w", err)
+		}
+
+		return s.recomputeCollectorRating(ctx, tx, rating.CollectorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toPickupRatingResponse(rating), nil
+}
+
+func (s *pickupRatingService) DeleteRating(ctx context.Context, ratingID string, actor PickupActor) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ratingRepo := s.ratingRepo.WithTx(tx)
+
+		rating, err := ratingRepo.GetRatingByID(ctx, ratingID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("rating with id %s not found", ratingID)
+			}
+			return err
+		}
+
+		if actor.Role != utils.RoleAdministrator && rating.UserID != actor.UserID {
+			return fmt.Errorf("forbidden: rating ini bukan milik anda")
+		}
+
+		if err := ratingRepo.LockCollector(ctx, rating.CollectorID); err != nil {
+			return fmt.Errorf("failed to lock collector: %w", err)
+		}
+
+		if err := ratingRepo.DeleteRating(ctx, rating.ID); err != nil {
+			return fmt.Errorf("failed to delete rating: %w", err)
+		}
+
+		return s.recomputeCollectorRating(ctx, tx, rating.CollectorID)
+	})
+}
+
+func (s *pickupRatingService) GetRatingByPickup(ctx context.Context, pickupID string) (*PickupRatingResponseDTO, error) {
+	rating, err := s.ratingRepo.GetRatingByRequestID(ctx, pickupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rating for pickup %s not found", pickupID)
+		}
+		return nil, err
+	}
+	return toPickupRatingResponse(rating), nil
+}
+
+func (s *pickupRatingService) GetCollectorRatings(ctx context.Context, collectorID string, limit, offset int) (*CollectorRatingSummaryDTO, error) {
+	col, err := s.collectorRepo.GetByID(ctx, collectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings, total, err := s.ratingRepo.GetRatingsByCollectorID(ctx, col.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ratings: %w", err)
+	}
+
+	_, sum, err := s.ratingRepo.GetCollectorRatingStats(ctx, col.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating stats: %w", err)
+	}
+
+	summary := &CollectorRatingSummaryDTO{
+		CollectorID:  col.ID,
+		Rating:       col.Rating,
+		TotalRatings: total,
+		Ratings:      make([]PickupRatingResponseDTO, 0, len(ratings)),
+	}
+	if total > 0 {
+		summary.AverageRating = float32(sum / float64(total))
+	}
+	for i := range ratings {
+		summary.Ratings = append(summary.Ratings, *toPickupRatingResponse(&ratings[i]))
+	}
+
+	return summary, nil
+}
+
+func (s *pickupRatingService) GetMyCollectorRatings(ctx context.Context, userID string, limit, offset int) (*CollectorRatingSummaryDTO, error) {
+	col, err := s.collectorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCollectorRatings(ctx, col.ID, limit, offset)
+}
+
+func (s *pickupRatingService) recomputeCollectorRating(ctx context.Context, tx *gorm.DB, collectorID string) error {
+	count, sum, err := s.ratingRepo.WithTx(tx).GetCollectorRatingStats(ctx, collectorID)
+	if err != nil {
+		return fmt.Errorf("failed to get rating stats: %w", err)
+	}
+
+	rating := bayesianRating(count, sum)
+	return s.collectorRepo.WithTx(tx).UpdateRating(ctx, collectorID, rating)
+}
+
+func bayesianRating(count int64, sum float64) float32 {
+	score := (ratingPriorMean*ratingPriorWeight + sum) / (ratingPriorWeight + float64(count))
+	return float32(score)
+}
+
+func toPickupRatingResponse(rating *model.PickupRating) *PickupRatingResponseDTO {
+	return &PickupRatingResponseDTO{
+		ID:          rating.ID,
+		RequestID:   rating.RequestID,
+		UserID:      rating.UserID,
+		CollectorID: rating.CollectorID,
+		Rating:      rating.Rating,
+		Feedback:    rating.Feedback,
+		CreatedAt:   rating.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,52 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/middleware"
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PickupRouteHandler struct {
+	service PickupRouteService
+}
+
+func NewPickupRouteHandler(service PickupRouteService) *PickupRouteHandler {
+	return &PickupRouteHandler{
+		service: service,
+	}
+}
+
+func (h *PickupRouteHandler) GetMyRoutePlan(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	query := RoutePlanQuery{
+		Date:      c.Query("date"),
+		StartTime: c.Query("start_time"),
+		RoundTrip: c.QueryBool("round_trip", false),
+	}
+
+	plan, err := h.service.PlanMyRoute(context.Background(), claims.UserID, query)
+	if err != nil {
+		return handleRoutePlanError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Rute pickup berhasil disusun", plan)
+}
+
+func handleRoutePlanError(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	case strings.Contains(msg, "tidak valid"):
+		return utils.BadRequest(c, msg)
+	default:
+		return utils.InternalServerError(c, msg)
+	}
+}
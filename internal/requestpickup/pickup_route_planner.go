@@ -0,0 +1,182 @@
+package requestpickup
+
+import (
+	"time"
+
+	"rijig/utils"
+)
+
+// routeStop is one pickup to visit. A zero WindowStart/WindowEnd means the stop can
+// be visited at any time.
+type routeStop struct {
+	PickupID    string
+	Coord       utils.Coord
+	WindowStart time.Time
+	WindowEnd   time.Time
+}
+
+type routeOptions struct {
+	Departure   time.Time
+	SpeedKmh    float64
+	ServiceTime time.Duration
+	RoundTrip   bool
+}
+
+type routeLeg struct {
+	Stop         routeStop
+	DistanceKm   float64
+	Arrival      time.Time
+	ServiceStart time.Time
+	Late         bool
+}
+
+type routeResult struct {
+	Legs       []routeLeg
+	ReturnKm   float64
+	TotalKm    float64
+	FinishedAt time.Time
+	LateStops  int
+}
+
+// planRoute orders the stops with a nearest-neighbour pass from the depot and then
+// improves the order with 2-opt. Orders are compared on the number of stops that
+// miss their window first and on total distance second, so distance is never bought
+// with an extra late arrival.
+func planRoute(depot utils.Coord, stops []routeStop, opts routeOptions) routeResult {
+	if len(stops) == 0 {
+		return routeResult{FinishedAt: opts.Departure}
+	}
+
+	order := nearestNeighbourOrder(depot, stops, opts)
+	best := evaluateRoute(depot, stops, order, opts)
+
+	improved := true
+	for improved {
+		improved = false
+		for i := 0; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				candidate := twoOptSwap(order, i, j)
+				result := evaluateRoute(depot, stops, candidate, opts)
+				if betterRoute(result, best) {
+					order = candidate
+					best = result
+					improved = true
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+func nearestNeighbourOrder(depot utils.Coord, stops []routeStop, opts routeOptions) []int {
+	visited := make([]bool, len(stops))
+	order := make([]int, 0, len(stops))
+
+	position := depot
+	clock := opts.Departure
+	for len(order) < len(stops) {
+		bestFeasible, bestAny := -1, -1
+		bestFeasibleKm, bestAnyKm := 0.0, 0.0
+
+		for i, stop := range stops {
+			if visited[i] {
+				continue
+			}
+			_, km := utils.Distance(position, stop.Coord)
+			if bestAny == -1 || km < bestAnyKm {
+				bestAny, bestAnyKm = i, km
+			}
+
+			arrival := clock.Add(travelTime(km, opts.SpeedKmh))
+			if !stop.WindowEnd.IsZero() && arrival.After(stop.WindowEnd) {
+				continue
+			}
+			if bestFeasible == -1 || km < bestFeasibleKm {
+				bestFeasible, bestFeasibleKm = i, km
+			}
+		}
+
+		next, km := bestAny, bestAnyKm
+		if bestFeasible != -1 {
+			next, km = bestFeasible, bestFeasibleKm
+		}
+
+		visited[next] = true
+		order = append(order, next)
+		clock = serviceStart(clock.Add(travelTime(km, opts.SpeedKmh)), stops[next]).Add(opts.ServiceTime)
+		position = stops[next].Coord
+	}
+
+	return order
+}
+
+func evaluateRoute(depot utils.Coord, stops []routeStop, order []int, opts routeOptions) routeResult {
+	result := routeResult{Legs: make([]routeLeg, 0, len(order))}
+
+	position := depot
+	clock := opts.Departure
+	for _, idx := range order {
+		stop := stops[idx]
+		_, km := utils.Distance(position, stop.Coord)
+
+		arrival := clock.Add(travelTime(km, opts.SpeedKmh))
+		start := serviceStart(arrival, stop)
+		late := !stop.WindowEnd.IsZero() && arrival.After(stop.WindowEnd)
+		if late {
+			result.LateStops++
+		}
+
+		result.Legs = append(result.Legs, routeLeg{
+			Stop:         stop,
+			DistanceKm:   km,
+			Arrival:      arrival,
+			ServiceStart: start,
+			Late:         late,
+		})
+		result.TotalKm += km
+
+		clock = start.Add(opts.ServiceTime)
+		position = stop.Coord
+	}
+
+	if opts.RoundTrip {
+		_, km := utils.Distance(position, depot)
+		result.ReturnKm = km
+		result.TotalKm += km
+		clock = clock.Add(travelTime(km, opts.SpeedKmh))
+	}
+	result.FinishedAt = clock
+
+	return result
+}
+
+func betterRoute(candidate, current routeResult) bool {
+	if candidate.LateStops != current.LateStops {
+		return candidate.LateStops < current.LateStops
+	}
+	// ignore float noise so 2-opt terminates
+	return candidate.TotalKm < current.TotalKm-1e-9
+}
+
+// twoOptSwap reverses order[i..j], the classic 2-opt move.
+func twoOptSwap(order []int, i, j int) []int {
+	swapped := make([]int, len(order))
+	copy(swapped, order)
+	for left, right := i, j; left < right; left, right = left+1, right-1 {
+		swapped[left], swapped[right] = swapped[right], swapped[left]
+	}
+	return swapped
+}
+
+// serviceStart waits at the stop until its window opens.
+func serviceStart(arrival time.Time, stop routeStop) time.Time {
+	if !stop.WindowStart.IsZero() && arrival.Before(stop.WindowStart) {
+		return stop.WindowStart
+	}
+	return arrival
+}
+
+func travelTime(km, speedKmh float64) time.Duration {
+	return time.Duration(km / speedKmh * float64(time.Hour))
+}
@@ -0,0 +1,20 @@
+package requestpickup
+
+import (
+	"rijig/config"
+	"rijig/internal/collector"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PickupRoutePlanRouter(api fiber.Router) {
+	service := NewPickupRouteService(NewRequestPickupRepository(), collector.NewCollectorRepository(config.DB))
+	handler := NewPickupRouteHandler(service)
+
+	routePlan := api.Group("/pickup/route-plan")
+	routePlan.Use(middleware.AuthMiddleware(), middleware.RequireRoles(utils.RolePengepul))
+
+	routePlan.Get("/", handler.GetMyRoutePlan)
+}
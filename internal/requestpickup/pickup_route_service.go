@@ -0,0 +1,188 @@
+package requestpickup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"rijig/internal/collector"
+	"rijig/model"
+	"rijig/utils"
+	"time"
+)
+
+const (
+	defaultRouteServiceTime = 10 * time.Minute
+	defaultRouteDeparture   = "08:00"
+)
+
+type RoutePlanQuery struct {
+	Date      string
+	StartTime string
+	RoundTrip bool
+}
+
+type PickupRouteService interface {
+	PlanMyRoute(ctx context.Context, userID string, query RoutePlanQuery) (*RoutePlanDTO, error)
+}
+
+type pickupRouteService struct {
+	pickupRepo    RequestPickupRepository
+	collectorRepo collector.CollectorRepository
+}
+
+func NewPickupRouteService(pickupRepo RequestPickupRepository, collectorRepo collector.CollectorRepository) PickupRouteService {
+	return &pickupRouteService{
+		pickupRepo:    pickupRepo,
+		collectorRepo: collectorRepo,
+	}
+}
+
+// routeServiceTime reads ROUTE_SERVICE_TIME, the time a collector is expected to spend
+// at each stop weighing and loading.
+func routeServiceTime() time.Duration {
+	raw := os.Getenv("ROUTE_SERVICE_TIME")
+	if raw == "" {
+		return defaultRouteServiceTime
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		log.Printf("[ROUTE] invalid ROUTE_SERVICE_TIME %q, using %s", raw, defaultRouteServiceTime)
+		return defaultRouteServiceTime
+	}
+	return d
+}
+
+func (s *pickupRouteService) PlanMyRoute(ctx context.Context, userID string, query RoutePlanQuery) (*RoutePlanDTO, error) {
+	col, err := s.collectorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("collector not found")
+	}
+
+	depot := utils.Coord{Lat: col.Address.Latitude, Lon: col.Address.Longitude}
+	if !validRouteCoord(depot) {
+		return nil, fmt.Errorf("lokasi alamat collector tidak valid")
+	}
+
+	day, departure, err := resolveRouteDeparture(query)
+	if err != nil {
+		return nil, err
+	}
+
+	pickups, err := s.pickupRepo.GetRoutablePickupsForCollector(ctx, col.ID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil pickup collector: %v", err)
+	}
+
+	stops := make([]routeStop, 0, len(pickups))
+	byID := make(map[string]*model.RequestPickup, len(pickups))
+	skipped := []string{}
+	for i := range pickups {
+		p := &pickups[i]
+		coord := utils.Coord{Lat: p.Address.Latitude, Lon: p.Address.Longitude}
+		if !validRouteCoord(coord) {
+			skipped = append(skipped, p.ID)
+			continue
+		}
+
+		stop := routeStop{PickupID: p.ID, Coord: coord}
+		if p.ScheduledStart != nil && p.ScheduledEnd != nil {
+			stop.WindowStart = *p.ScheduledStart
+			stop.WindowEnd = *p.ScheduledEnd
+		}
+		stops = append(stops, stop)
+		byID[p.ID] = p
+	}
+
+	result := planRoute(depot, stops, routeOptions{
+		Departure:   departure,
+		SpeedKmh:    collectorSpeedKmh(),
+		ServiceTime: routeServiceTime(),
+		RoundTrip:   query.RoundTrip,
+	})
+
+	plan := &RoutePlanDTO{
+		CollectorID:      col.ID,
+		Date:             day.Format("2006-01-02"),
+		DepotAddressID:   col.AddressID,
+		DepotLatitude:    depot.Lat,
+		DepotLongitude:   depot.Lon,
+		Departure:        departure,
+		RoundTrip:        query.RoundTrip,
+		Stops:            make([]RoutePlanStopDTO, 0, len(result.Legs)),
+		SkippedPickupIDs: skipped,
+		ReturnDistanceKm: roundKm(result.ReturnKm),
+		TotalDistanceKm:  roundKm(result.TotalKm),
+		EstimatedFinish:  result.FinishedAt,
+		LateStops:        result.LateStops,
+	}
+
+	cumulative := 0.0
+	for i, leg := range result.Legs {
+		p := byID[leg.Stop.PickupID]
+		cumulative += leg.DistanceKm
+
+		stop := RoutePlanStopDTO{
+			Sequence:             i + 1,
+			PickupID:             p.ID,
+			UserName:             p.User.Name,
+			StatusPickup:         p.StatusPickup,
+			AddressDetail:        p.Address.Detail,
+			Latitude:             leg.Stop.Coord.Lat,
+			Longitude:            leg.Stop.Coord.Lon,
+			LegDistanceKm:        roundKm(leg.DistanceKm),
+			CumulativeDistanceKm: roundKm(cumulative),
+			EstimatedArrival:     leg.Arrival,
+			ServiceStart:         leg.ServiceStart,
+			Late:                 leg.Late,
+		}
+		if window := windowOf(p); window != nil {
+			stop.WindowStart = window.From
+			stop.WindowEnd = window.To
+		}
+		plan.Stops = append(plan.Stops, stop)
+	}
+
+	return plan, nil
+}
+
+// resolveRouteDeparture picks the planning day and departure time. Today's plan departs
+// now unless a start time is given; other days default to 08:00 WIB.
+func resolveRouteDeparture(query RoutePlanQuery) (time.Time, time.Time, error) {
+	loc := utils.IndonesianLocation()
+	now := time.Now().In(loc)
+
+	day := localDate(now)
+	if query.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", query.Date, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("format tanggal tidak valid, gunakan YYYY-MM-DD")
+		}
+		day = parsed
+	}
+
+	if query.StartTime != "" {
+		if !collector.IsValidClock(query.StartTime) {
+			return time.Time{}, time.Time{}, fmt.Errorf("format jam mulai tidak valid, gunakan HH:MM")
+		}
+		return day, clockOn(day, query.StartTime), nil
+	}
+
+	if day.Equal(localDate(now)) {
+		return day, now, nil
+	}
+	return day, clockOn(day, defaultRouteDeparture), nil
+}
+
+func validRouteCoord(c utils.Coord) bool {
+	if c.Lat == 0 && c.Lon == 0 {
+		return false
+	}
+	return c.Lat >= -90 && c.Lat <= 90 && c.Lon >= -180 && c.Lon <= 180
+}
+
+func roundKm(km float64) float64 {
+	return math.Round(km*100) / 100
+}
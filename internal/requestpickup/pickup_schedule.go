@@ -0,0 +1,117 @@
+package requestpickup
+
+import (
+	"fmt"
+	"rijig/model"
+	"rijig/utils"
+	"time"
+)
+
+const maxScheduleAheadDays = 30
+
+// pickupWindow is a scheduled pickup expressed the way collector slots are stored:
+// a weekday and "HH:MM" bounds in WIB, plus the calendar date used for bookings.
+type pickupWindow struct {
+	Start   time.Time
+	End     time.Time
+	Date    time.Time
+	Weekday int
+	From    string
+	To      string
+}
+
+func windowOf(pickup *model.RequestPickup) *pickupWindow {
+	if pickup.ScheduledStart == nil || pickup.ScheduledEnd == nil {
+		return nil
+	}
+	return newPickupWindow(*pickup.ScheduledStart, *pickup.ScheduledEnd)
+}
+
+func newPickupWindow(start, end time.Time) *pickupWindow {
+	loc := utils.IndonesianLocation()
+	localStart := start.In(loc)
+	localEnd := end.In(loc)
+
+	return &pickupWindow{
+		Start:   start,
+		End:     end,
+		Date:    time.Date(localStart.Year(), localStart.Month(), localStart.Day(), 0, 0, 0, 0, loc),
+		Weekday: int(localStart.Weekday()),
+		From:    localStart.Format("15:04"),
+		To:      localEnd.Format("15:04"),
+	}
+}
+
+// parsePickupWindow turns the request's date and clock strings into an absolute window.
+func parsePickupWindow(date, from, to string) (*pickupWindow, error) {
+	loc := utils.IndonesianLocation()
+
+	start, err := time.ParseInLocation("2006-01-02 15:04", date+" "+from, loc)
+	if err != nil {
+		return nil, fmt.Errorf("format jadwal tidak valid")
+	}
+	end, err := time.ParseInLocation("2006-01-02 15:04", date+" "+to, loc)
+	if err != nil {
+		return nil, fmt.Errorf("format jadwal tidak valid")
+	}
+
+	return newPickupWindow(start, end), nil
+}
+
+const (
+	SubscriptionWeekly   = "weekly"
+	SubscriptionBiweekly = "biweekly"
+	SubscriptionMonthly  = "monthly"
+
+	SubscriptionActive = "active"
+	SubscriptionPaused = "paused"
+	SubscriptionEnded  = "ended"
+)
+
+// localDate truncates t to midnight WIB.
+func localDate(t time.Time) time.Time {
+	local := t.In(utils.IndonesianLocation())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
+
+// clockOn places an "HH:MM" clock on the WIB calendar day of date.
+func clockOn(date time.Time, clock string) time.Time {
+	parsed, _ := time.Parse("15:04", clock)
+	day := localDate(date)
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, day.Location())
+}
+
+func matchesRecurrence(frequency string, weekday, dayOfMonth int, day time.Time) bool {
+	if frequency == SubscriptionMonthly {
+		return day.Day() == dayOfMonth
+	}
+	return int(day.Weekday()) == weekday
+}
+
+// firstOccurrence finds the earliest window start after from that fits the rule.
+// Monthly rules are limited to day 1-28, so two months of days always contain a hit.
+func firstOccurrence(frequency string, weekday, dayOfMonth int, windowStart string, from time.Time) time.Time {
+	day := localDate(from)
+	for i := 0; i < 62; i++ {
+		candidate := day.AddDate(0, 0, i)
+		if !matchesRecurrence(frequency, weekday, dayOfMonth, candidate) {
+			continue
+		}
+		if start := clockOn(candidate, windowStart); start.After(from) {
+			return start
+		}
+	}
+	return clockOn(day.AddDate(0, 0, 62), windowStart)
+}
+
+func advanceOccurrence(frequency string, current time.Time) time.Time {
+	local := current.In(utils.IndonesianLocation())
+	switch frequency {
+	case SubscriptionBiweekly:
+		return local.AddDate(0, 0, 14)
+	case SubscriptionMonthly:
+		return local.AddDate(0, 1, 0)
+	default:
+		return local.AddDate(0, 0, 7)
+	}
+}
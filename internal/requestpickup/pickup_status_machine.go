@@ -0,0 +1,407 @@
+package requestpickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rijig/internal/collector"
+	"rijig/internal/geoindex"
+	"rijig/model"
+	"rijig/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusWaitingCollector     = "waiting_collector"
+	StatusConfirmedByCollector = "confirmed_by_collector"
+	StatusCollectorPickingUp   = "collector_are_picking_up"
+	StatusCompleted            = "completed"
+	StatusCanceled             = "canceled"
+)
+
+const (
+	ErrCodeInvalidTransition = "INVALID_STATUS_TRANSITION"
+	ErrCodeActorNotAllowed   = "ACTOR_NOT_ALLOWED"
+	ErrCodeNotParticipant    = "NOT_PICKUP_PARTICIPANT"
+	ErrCodeSlotUnavailable   = "COLLECTOR_SLOT_UNAVAILABLE"
+	ErrCodeAlreadyAssigned   = "COLLECTOR_ALREADY_ASSIGNED"
+	ErrCodeCollectorInactive = "COLLECTOR_INACTIVE"
+)
+
+// ReasonCollectorSelected marks the history row written when the requester picks a collector.
+const ReasonCollectorSelected = "collector_selected"
+
+// PickupActor is whoever triggers a status change, taken from the JWT claims.
+type PickupActor struct {
+	UserID string
+	Role   string
+}
+
+// PickupReason explains why a transition happened; it is stored on the history row.
+type PickupReason struct {
+	Code  string
+	Notes string
+}
+
+// PickupTransitionError is returned when a status change is rejected by the state machine.
+type PickupTransitionError struct {
+	Code    string `json:"error"`
+	Message string `json:"message"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Role    string `json:"role"`
+}
+
+func (e *PickupTransitionError) Error() string {
+	return e.Message
+}
+
+// pickupTransitions lists every legal move and the roles allowed to make it.
+// A waiting_collector -> waiting_collector move is a collector declining the
+// assignment, which sends the request back to the matching pool.
+var pickupTransitions = map[string]map[string][]string{
+	StatusWaitingCollector: {
+		StatusWaitingCollector:     {utils.RolePengepul, utils.RoleAdministrator},
+		StatusConfirmedByCollector: {utils.RolePengepul, utils.RoleAdministrator},
+		StatusCanceled:             {utils.RoleMasyarakat, utils.RoleAdministrator},
+	},
+	StatusConfirmedByCollector: {
+		StatusWaitingCollector:   {utils.RolePengepul, utils.RoleAdministrator},
+		StatusCollectorPickingUp: {utils.RolePengepul, utils.RoleAdministrator},
+		StatusCanceled:           {utils.RoleAdministrator},
+	},
+	StatusCollectorPickingUp: {
+		StatusCompleted: {utils.RolePengepul, utils.RoleAdministrator},
+		StatusCanceled:  {utils.RoleAdministrator},
+	},
+}
+
+// PickupApplyFunc runs inside the transition transaction, after the checks and
+// before the status is written, to persist extra fields for the move.
+type PickupApplyFunc func(tx *gorm.DB, pickup *model.RequestPickup) error
+
+type PickupStatusMachine interface {
+	CanTransition(from, to, role string) error
+	Transition(ctx context.Context, pickupID, to string, actor PickupActor, reason PickupReason, apply PickupApplyFunc) (*model.RequestPickup, error)
+	ClaimAndTransition(ctx context.Context, pickupID, to string, actor PickupActor, reason PickupReason, claim, apply PickupApplyFunc) (*model.RequestPickup, error)
+	AssignCollector(ctx context.Context, pickupID, collectorID string, actor PickupActor, check PickupApplyFunc) (*model.RequestPickup, error)
+	RecordInitialStatus(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, actor PickupActor) error
+	EnsureParticipant(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, actor PickupActor) error
+}
+
+type pickupStatusMachine struct {
+	db            *gorm.DB
+	pickupRepo    RequestPickupRepository
+	historyRepo   PickupStatusHistoryRepository
+	collectorRepo collector.CollectorRepository
+	slotRepo      collector.CollectorSlotRepository
+}
+
+func NewPickupStatusMachine(db *gorm.DB, pickupRepo RequestPickupRepository, historyRepo PickupStatusHistoryRepository,
+	collectorRepo collector.CollectorRepository, slotRepo collector.CollectorSlotRepository) PickupStatusMachine {
+	return &pickupStatusMachine{
+		db:            db,
+		pickupRepo:    pickupRepo,
+		historyRepo:   historyRepo,
+		collectorRepo: collectorRepo,
+		slotRepo:      slotRepo,
+	}
+}
+
+func (m *pickupStatusMachine) CanTransition(from, to, role string) error {
+	targets, ok := pickupTransitions[from]
+	if !ok {
+		return &PickupTransitionError{
+			Code:    ErrCodeInvalidTransition,
+			Message: fmt.Sprintf("status '%s' tidak dapat diubah lagi", from),
+			From:    from,
+			To:      to,
+			Role:    role,
+		}
+	}
+
+	roles, ok := targets[to]
+	if !ok {
+		return &PickupTransitionError{
+			Code:    ErrCodeInvalidTransition,
+			Message: fmt.Sprintf("perubahan status dari '%s' ke '%s' tidak diizinkan", from, to),
+			From:    from,
+			To:      to,
+			Role:    role,
+		}
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return nil
+		}
+	}
+
+	return &PickupTransitionError{
+		Code:    ErrCodeActorNotAllowed,
+		Message: fmt.Sprintf("role '%s' tidak boleh mengubah status dari '%s' ke '%s'", role, from, to),
+		From:    from,
+		To:      to,
+		Role:    role,
+	}
+}
+
+func (m *pickupStatusMachine) Transition(ctx context.Context, pickupID, to string, actor PickupActor, reason PickupReason, apply PickupApplyFunc) (*model.RequestPickup, error) {
+	return m.transition(ctx, pickupID, to, actor, reason, nil, apply)
+}
+
+// ClaimAndTransition is Transition for an actor who becomes a participant as part of
+// the move, such as a collector accepting a dispatch offer. claim runs on the locked
+// pickup before the participant check and must make the actor a participant; it shares
+// the transaction with the status change, so a rejected move also undoes the claim.
+func (m *pickupStatusMachine) ClaimAndTransition(ctx context.Context, pickupID, to string, actor PickupActor, reason PickupReason, claim, apply PickupApplyFunc) (*model.RequestPickup, error) {
+	return m.transition(ctx, pickupID, to, actor, reason, claim, apply)
+}
+
+func (m *pickupStatusMachine) transition(ctx context.Context, pickupID, to string, actor PickupActor, reason PickupReason, claim, apply PickupApplyFunc) (*model.RequestPickup, error) {
+	var pickup *model.RequestPickup
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		pickup, err = m.pickupRepo.WithTx(tx).GetPickupByIDForUpdate(ctx, pickupID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("pickup with id %s not found", pickupID)
+			}
+			return fmt.Errorf("failed to get pickup: %w", err)
+		}
+
+		from := pickup.StatusPickup
+		if err := m.CanTransition(from, to, actor.Role); err != nil {
+			return err
+		}
+
+		if claim != nil {
+			if err := claim(tx, pickup); err != nil {
+				return err
+			}
+		}
+
+		if err := m.ensureParticipant(ctx, tx, pickup, actor, from, to); err != nil {
+			return err
+		}
+
+		if apply != nil {
+			if err := apply(tx, pickup); err != nil {
+				return err
+			}
+		}
+
+		if err := m.syncSlotBooking(ctx, tx, pickup, from, to, actor); err != nil {
+			return err
+		}
+
+		if err := m.pickupRepo.WithTx(tx).UpdatePickupStatus(ctx, pickup.ID, to); err != nil {
+			return fmt.Errorf("failed to update pickup status: %w", err)
+		}
+		pickup.StatusPickup = to
+
+		return m.historyRepo.WithTx(tx).CreateStatusHistory(ctx, model.PickupStatusHistory{
+			RequestID:     pickup.ID,
+			Status:        to,
+			ChangedAt:     time.Now(),
+			ChangedByID:   actor.UserID,
+			ChangedByRole: actor.Role,
+			ReasonCode:    reason.Code,
+			Notes:         reason.Notes,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	geoindex.SyncPickup(ctx, m.db, pickup.ID)
+	PublishPickupStatus(ctx, pickup)
+	return pickup, nil
+}
+
+// AssignCollector lets the requester (or an admin) pick the collector of a waiting
+// pickup. The pickup row stays locked while the collector is validated and check runs,
+// and the assignment only lands if nobody was assigned in the meantime. The status
+// does not change, but the choice is kept in the history like any other move.
+func (m *pickupStatusMachine) AssignCollector(ctx context.Context, pickupID, collectorID string, actor PickupActor, check PickupApplyFunc) (*model.RequestPickup, error) {
+	var pickup *model.RequestPickup
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		pickup, err = m.pickupRepo.WithTx(tx).GetPickupByIDForUpdate(ctx, pickupID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("pickup with id %s not found", pickupID)
+			}
+			return fmt.Errorf("failed to get pickup: %w", err)
+		}
+
+		rejected := func(code, message string) error {
+			return &PickupTransitionError{
+				Code:    code,
+				Message: message,
+				From:    pickup.StatusPickup,
+				To:      pickup.StatusPickup,
+				Role:    actor.Role,
+			}
+		}
+
+		if actor.Role != utils.RoleAdministrator && pickup.UserId != actor.UserID {
+			return rejected(ErrCodeNotParticipant, "anda bukan pemilik request pickup ini")
+		}
+		if pickup.StatusPickup != StatusWaitingCollector {
+			return rejected(ErrCodeInvalidTransition, fmt.Sprintf("collector hanya dapat dipilih saat status '%s'", StatusWaitingCollector))
+		}
+		if pickup.CollectorID != nil {
+			return rejected(ErrCodeAlreadyAssigned, "request pickup ini sudah memiliki collector")
+		}
+
+		col, err := m.collectorRepo.WithTx(tx).GetByID(ctx, collectorID)
+		if err != nil {
+			return err
+		}
+		if col.JobStatus != "active" {
+			return rejected(ErrCodeCollectorInactive, "collector sedang tidak aktif")
+		}
+
+		if check != nil {
+			if err := check(tx, pickup); err != nil {
+				return err
+			}
+		}
+
+		assigned, err := m.pickupRepo.WithTx(tx).UpdateCollectorID(ctx, pickup.ID, col.ID)
+		if err != nil {
+			return fmt.Errorf("failed to assign collector: %w", err)
+		}
+		if !assigned {
+			return rejected(ErrCodeAlreadyAssigned, "request pickup ini sudah memiliki collector")
+		}
+		pickup.CollectorID = &col.ID
+
+		return m.historyRepo.WithTx(tx).CreateStatusHistory(ctx, model.PickupStatusHistory{
+			RequestID:     pickup.ID,
+			Status:        pickup.StatusPickup,
+			ChangedAt:     time.Now(),
+			ChangedByID:   actor.UserID,
+			ChangedByRole: actor.Role,
+			ReasonCode:    ReasonCollectorSelected,
+			Notes:         col.ID,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	geoindex.SyncPickup(ctx, m.db, pickup.ID)
+	PublishPickupStatus(ctx, pickup)
+	return pickup, nil
+}
+
+func (m *pickupStatusMachine) RecordInitialStatus(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, actor PickupActor) error {
+	return m.historyRepo.WithTx(tx).CreateStatusHistory(ctx, model.PickupStatusHistory{
+		RequestID:     pickup.ID,
+		Status:        pickup.StatusPickup,
+		ChangedAt:     time.Now(),
+		ChangedByID:   actor.UserID,
+		ChangedByRole: actor.Role,
+	})
+}
+
+func (m *pickupStatusMachine) EnsureParticipant(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, actor PickupActor) error {
+	return m.ensureParticipant(ctx, tx, pickup, actor, pickup.StatusPickup, pickup.StatusPickup)
+}
+
+// ensureParticipant checks that a non-admin actor is actually part of this pickup:
+// the requester for masyarakat, the assigned collector for pengepul.
+func (m *pickupStatusMachine) ensureParticipant(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, actor PickupActor, from, to string) error {
+	notParticipant := &PickupTransitionError{
+		Code:    ErrCodeNotParticipant,
+		Message: "anda bukan bagian dari request pickup ini",
+		From:    from,
+		To:      to,
+		Role:    actor.Role,
+	}
+
+	switch actor.Role {
+	case utils.RoleAdministrator:
+		return nil
+	case utils.RoleMasyarakat:
+		if pickup.UserId != actor.UserID {
+			return notParticipant
+		}
+		return nil
+	case utils.RolePengepul:
+		col, err := m.collectorRepo.WithTx(tx).GetByUserID(ctx, actor.UserID)
+		if err != nil {
+			return notParticipant
+		}
+		if pickup.CollectorID == nil || *pickup.CollectorID != col.ID {
+			return notParticipant
+		}
+		return nil
+	default:
+		return notParticipant
+	}
+}
+
+// syncSlotBooking ties scheduled pickups to collector slots. Confirming books one unit
+// of the covering slot while the slot row is locked, which is what stops two pickups
+// from taking the same place; going back to the pool or cancelling gives it back.
+func (m *pickupStatusMachine) syncSlotBooking(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, from, to string, actor PickupActor) error {
+	window := windowOf(pickup)
+	if window == nil {
+		return nil
+	}
+
+	slotRepo := m.slotRepo.WithTx(tx)
+
+	switch to {
+	case StatusWaitingCollector, StatusCanceled:
+		return slotRepo.DeleteBookingByRequest(ctx, pickup.ID)
+	case StatusConfirmedByCollector:
+	default:
+		return nil
+	}
+
+	slotUnavailable := func(message string) error {
+		return &PickupTransitionError{
+			Code:    ErrCodeSlotUnavailable,
+			Message: message,
+			From:    from,
+			To:      to,
+			Role:    actor.Role,
+		}
+	}
+
+	if pickup.CollectorID == nil {
+		return slotUnavailable("pickup terjadwal harus memiliki collector sebelum dikonfirmasi")
+	}
+
+	slot, err := slotRepo.FindCoveringSlotForUpdate(ctx, *pickup.CollectorID, window.Weekday, window.From, window.To)
+	if err != nil {
+		return slotUnavailable(fmt.Sprintf("collector tidak memiliki slot aktif untuk %s %s-%s", window.Date.Format("2006-01-02"), window.From, window.To))
+	}
+
+	booked, err := slotRepo.CountBookings(ctx, slot.ID, window.Date)
+	if err != nil {
+		return err
+	}
+	if booked >= int64(slot.Capacity) {
+		return slotUnavailable(fmt.Sprintf("slot collector %s-%s pada %s sudah penuh", slot.StartTime, slot.EndTime, window.Date.Format("2006-01-02")))
+	}
+
+	return slotRepo.CreateBooking(ctx, &model.CollectorSlotBooking{
+		SlotID:      slot.ID,
+		CollectorID: slot.CollectorID,
+		RequestID:   pickup.ID,
+		SlotDate:    window.Date,
+		WindowStart: window.Start,
+		WindowEnd:   window.End,
+	})
+}
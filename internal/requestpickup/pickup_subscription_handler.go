@@ -0,0 +1,154 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/middleware"
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PickupSubscriptionHandler struct {
+	service PickupSubscriptionService
+}
+
+func NewPickupSubscriptionHandler(service PickupSubscriptionService) *PickupSubscriptionHandler {
+	return &PickupSubscriptionHandler{
+		service: service,
+	}
+}
+
+func (h *PickupSubscriptionHandler) CreateSubscription(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreatePickupSubscriptionDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	sub, err := h.service.CreateSubscription(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Langganan pickup berhasil dibuat", sub)
+}
+
+func (h *PickupSubscriptionHandler) ListMySubscriptions(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	subs, err := h.service.ListMySubscriptions(context.Background(), claims.UserID)
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Data langganan pickup berhasil diambil", subs)
+}
+
+func (h *PickupSubscriptionHandler) GetSubscription(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sub, err := h.service.GetSubscription(context.Background(), claims.UserID, c.Params("id"))
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Data langganan pickup berhasil diambil", sub)
+}
+
+func (h *PickupSubscriptionHandler) PauseSubscription(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sub, err := h.service.PauseSubscription(context.Background(), claims.UserID, c.Params("id"))
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Langganan pickup berhasil dijeda", sub)
+}
+
+func (h *PickupSubscriptionHandler) ResumeSubscription(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sub, err := h.service.ResumeSubscription(context.Background(), claims.UserID, c.Params("id"))
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Langganan pickup berhasil dilanjutkan", sub)
+}
+
+func (h *PickupSubscriptionHandler) SkipOccurrence(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req SkipSubscriptionOccurrenceDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+				"body": {"format JSON tidak valid"},
+			})
+		}
+	}
+
+	sub, err := h.service.SkipOccurrence(context.Background(), claims.UserID, c.Params("id"), req)
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Jadwal pickup berhasil dilewati", sub)
+}
+
+func (h *PickupSubscriptionHandler) EndSubscription(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sub, err := h.service.EndSubscription(context.Background(), claims.UserID, c.Params("id"))
+	if err != nil {
+		return handleSubscriptionError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Langganan pickup berhasil diakhiri", sub)
+}
+
+func handleSubscriptionError(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	case strings.HasPrefix(msg, "forbidden"):
+		return utils.Forbidden(c, msg)
+	case strings.Contains(msg, "tidak valid"):
+		return utils.BadRequest(c, msg)
+	case strings.Contains(msg, "tidak dapat") || strings.Contains(msg, "sudah"):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, msg, nil)
+	default:
+		return utils.InternalServerError(c, msg)
+	}
+}
@@ -0,0 +1,120 @@
+package requestpickup
+
+import (
+	"context"
+	"rijig/config"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PickupSubscriptionRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.PickupSubscription) error
+	GetSubscriptionByID(ctx context.Context, id string) (*model.PickupSubscription, error)
+	GetSubscriptionByIDForUpdate(ctx context.Context, id string) (*model.PickupSubscription, error)
+	ListSubscriptionsByUser(ctx context.Context, userID string) ([]model.PickupSubscription, error)
+	UpdateSubscriptionFields(ctx context.Context, id string, fields map[string]interface{}) error
+	GetDueSubscriptionIDs(ctx context.Context, until time.Time) ([]string, error)
+	CreateSkip(ctx context.Context, skip *model.PickupSubscriptionSkip) error
+	IsOccurrenceSkipped(ctx context.Context, subscriptionID string, date time.Time) (bool, error)
+
+	WithTx(tx *gorm.DB) PickupSubscriptionRepository
+}
+
+type pickupSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewPickupSubscriptionRepository() PickupSubscriptionRepository {
+	return &pickupSubscriptionRepository{db: config.DB}
+}
+
+func (r *pickupSubscriptionRepository) WithTx(tx *gorm.DB) PickupSubscriptionRepository {
+	return &pickupSubscriptionRepository{db: tx}
+}
+
+func (r *pickupSubscriptionRepository) CreateSubscription(ctx context.Context, sub *model.PickupSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+func (r *pickupSubscriptionRepository) GetSubscriptionByID(ctx context.Context, id string) (*model.PickupSubscription, error) {
+	var sub model.PickupSubscription
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("Skips", func(db *gorm.DB) *gorm.DB {
+			return db.Order("occurrence_date asc")
+		}).
+		Where("id = ?", id).
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *pickupSubscriptionRepository) GetSubscriptionByIDForUpdate(ctx context.Context, id string) (*model.PickupSubscription, error) {
+	var sub model.PickupSubscription
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Where("subscription_id = ?", id).Find(&sub.Items).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *pickupSubscriptionRepository) ListSubscriptionsByUser(ctx context.Context, userID string) ([]model.PickupSubscription, error) {
+	var subs []model.PickupSubscription
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("Skips", func(db *gorm.DB) *gorm.DB {
+			return db.Order("occurrence_date asc")
+		}).
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&subs).Error
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *pickupSubscriptionRepository) UpdateSubscriptionFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&model.PickupSubscription{}).
+		Where("id = ?", id).
+		Updates(fields).Error
+}
+
+func (r *pickupSubscriptionRepository) GetDueSubscriptionIDs(ctx context.Context, until time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&model.PickupSubscription{}).
+		Where("status = ? AND next_occurrence <= ?", SubscriptionActive, until).
+		Order("next_occurrence asc").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *pickupSubscriptionRepository) CreateSkip(ctx context.Context, skip *model.PickupSubscriptionSkip) error {
+	return r.db.WithContext(ctx).Create(skip).Error
+}
+
+func (r *pickupSubscriptionRepository) IsOccurrenceSkipped(ctx context.Context, subscriptionID string, date time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.PickupSubscriptionSkip{}).
+		Where("subscription_id = ? AND occurrence_date = ?", subscriptionID, date.Format("2006-01-02")).
+		Count(&count).Error
+	return count > 0, err
+}
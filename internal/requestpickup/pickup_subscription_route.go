@@ -0,0 +1,41 @@
+package requestpickup
+
+import (
+	"rijig/config"
+	"rijig/internal/address"
+	"rijig/internal/collector"
+	"rijig/internal/trash"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewDefaultPickupSubscriptionService wires the subscription service from the shared DB,
+// used by both the router and the background subscription worker.
+func NewDefaultPickupSubscriptionService() PickupSubscriptionService {
+	pickupRepo := NewRequestPickupRepository()
+	historyRepo := NewPickupStatusHistoryRepository()
+	collectorRepo := collector.NewCollectorRepository(config.DB)
+	slotRepo := collector.NewCollectorSlotRepository(config.DB)
+	statusMachine := NewPickupStatusMachine(config.DB, pickupRepo, historyRepo, collectorRepo, slotRepo)
+
+	return NewPickupSubscriptionService(config.DB, NewPickupSubscriptionRepository(), pickupRepo,
+		trash.NewTrashRepository(config.DB), address.NewAddressRepository(config.DB),
+		trash.NewDefaultTrashRegionalPriceService(config.DB), statusMachine)
+}
+
+func PickupSubscriptionRouter(api fiber.Router) {
+	handler := NewPickupSubscriptionHandler(NewDefaultPickupSubscriptionService())
+
+	subscriptions := api.Group("/pickup/subscriptions")
+	subscriptions.Use(middleware.AuthMiddleware(), middleware.RequireRoles(utils.RoleMasyarakat))
+
+	subscriptions.Post("/", handler.CreateSubscription)
+	subscriptions.Get("/", handler.ListMySubscriptions)
+	subscriptions.Get("/:id", handler.GetSubscription)
+	subscriptions.Put("/:id/pause", handler.PauseSubscription)
+	subscriptions.Put("/:id/resume", handler.ResumeSubscription)
+	subscriptions.Post("/:id/skip", handler.SkipOccurrence)
+	subscriptions.Put("/:id/end", handler.EndSubscription)
+}
@@ -0,0 +1,460 @@
+package requestpickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"rijig/internal/address"
+	"rijig/internal/geoindex"
+	"rijig/internal/trash"
+	"rijig/model"
+	"rijig/utils"
+
+	"gorm.io/gorm"
+)
+
+const defaultSubscriptionLeadTime = 24 * time.Hour
+
+type PickupSubscriptionService interface {
+	CreateSubscription(ctx context.Context, userID string, req CreatePickupSubscriptionDTO) (*PickupSubscriptionResponseDTO, error)
+	ListMySubscriptions(ctx context.Context, userID string) ([]PickupSubscriptionResponseDTO, error)
+	GetSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error)
+	PauseSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error)
+	ResumeSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error)
+	SkipOccurrence(ctx context.Context, userID, id string, req SkipSubscriptionOccurrenceDTO) (*PickupSubscriptionResponseDTO, error)
+	EndSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error)
+	MaterializeDueSubscriptions(ctx context.Context) (int, error)
+}
+
+type pickupSubscriptionService struct {
+	db            *gorm.DB
+	subRepo       PickupSubscriptionRepository
+	pickupRepo    RequestPickupRepository
+	trashRepo     trash.TrashRepositoryInterface
+	addressRepo   address.AddressRepository
+	regional      trash.TrashRegionalPriceServiceInterface
+	statusMachine PickupStatusMachine
+	leadTime      time.Duration
+}
+
+func NewPickupSubscriptionService(db *gorm.DB, subRepo PickupSubscriptionRepository, pickupRepo RequestPickupRepository,
+	trashRepo trash.TrashRepositoryInterface, addressRepo address.AddressRepository, regional trash.TrashRegionalPriceServiceInterface,
+	statusMachine PickupStatusMachine) PickupSubscriptionService {
+	return &pickupSubscriptionService{
+		db:            db,
+		subRepo:       subRepo,
+		pickupRepo:    pickupRepo,
+		trashRepo:     trashRepo,
+		addressRepo:   addressRepo,
+		regional:      regional,
+		statusMachine: statusMachine,
+		leadTime:      subscriptionLeadTime(),
+	}
+}
+
+// subscriptionLeadTime reads SUBSCRIPTION_LEAD_TIME, how long before the window a
+// pickup is materialised so matching and dispatch have time to find a collector.
+func subscriptionLeadTime() time.Duration {
+	raw := os.Getenv("SUBSCRIPTION_LEAD_TIME")
+	if raw == "" {
+		return defaultSubscriptionLeadTime
+	}
+
+	lead, err := time.ParseDuration(raw)
+	if err != nil || lead < 0 {
+		log.Printf("[SUBSCRIPTION] invalid SUBSCRIPTION_LEAD_TIME %q, using %s", raw, defaultSubscriptionLeadTime)
+		return defaultSubscriptionLeadTime
+	}
+	return lead
+}
+
+func (s *pickupSubscriptionService) CreateSubscription(ctx context.Context, userID string, req CreatePickupSubscriptionDTO) (*PickupSubscriptionResponseDTO, error) {
+	addr, err := s.addressRepo.FindAddressByID(ctx, req.AddressID)
+	if err != nil {
+		return nil, fmt.Errorf("address with id %s not found", req.AddressID)
+	}
+	if addr.UserID != userID {
+		return nil, fmt.Errorf("forbidden: alamat bukan milik anda")
+	}
+
+	items := make([]model.PickupSubscriptionItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		category, err := s.trashRepo.GetTrashCategoryByID(ctx, item.TrashCategoryID)
+		if err != nil {
+			return nil, fmt.Errorf("trash category with id %s not found", item.TrashCategoryID)
+		}
+		if err := trash.ValidateQuantity(category, item.EstimatedAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, model.PickupSubscriptionItem{
+			TrashCategoryID: item.TrashCategoryID,
+			EstimatedAmount: item.EstimatedAmount,
+		})
+	}
+
+	weekday := 0
+	if req.Weekday != nil {
+		weekday = *req.Weekday
+	}
+
+	from := time.Now()
+	if req.StartDate != "" {
+		startDate, _ := time.ParseInLocation("2006-01-02", req.StartDate, utils.IndonesianLocation())
+		if startDate.After(from) {
+			from = startDate
+		}
+	}
+
+	sub := &model.PickupSubscription{
+		UserID:         userID,
+		AddressID:      req.AddressID,
+		RequestMethod:  req.RequestMethod,
+		Notes:          req.Notes,
+		Frequency:      req.Frequency,
+		Weekday:        weekday,
+		DayOfMonth:     req.DayOfMonth,
+		WindowStart:    req.WindowStart,
+		WindowEnd:      req.WindowEnd,
+		Status:         SubscriptionActive,
+		NextOccurrence: firstOccurrence(req.Frequency, weekday, req.DayOfMonth, req.WindowStart, from),
+		Items:          items,
+	}
+
+	if err := s.subRepo.CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan langganan: %w", err)
+	}
+
+	return s.GetSubscription(ctx, userID, sub.ID)
+}
+
+func (s *pickupSubscriptionService) ListMySubscriptions(ctx context.Context, userID string) ([]PickupSubscriptionResponseDTO, error) {
+	subs, err := s.subRepo.ListSubscriptionsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil langganan: %w", err)
+	}
+
+	results := make([]PickupSubscriptionResponseDTO, 0, len(subs))
+	for i := range subs {
+		results = append(results, toSubscriptionResponse(&subs[i]))
+	}
+	return results, nil
+}
+
+func (s *pickupSubscriptionService) GetSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error) {
+	sub, err := s.subRepo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("subscription with id %s not found", id)
+		}
+		return nil, fmt.Errorf("gagal mengambil langganan: %w", err)
+	}
+	if sub.UserID != userID {
+		return nil, fmt.Errorf("forbidden: langganan bukan milik anda")
+	}
+
+	response := toSubscriptionResponse(sub)
+	return &response, nil
+}
+
+func (s *pickupSubscriptionService) PauseSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error) {
+	err := s.updateOwnSubscription(ctx, userID, id, func(tx *gorm.DB, sub *model.PickupSubscription) error {
+		if sub.Status != SubscriptionActive {
+			return fmt.Errorf("langganan berstatus '%s' tidak dapat dijeda", sub.Status)
+		}
+		return s.subRepo.WithTx(tx).UpdateSubscriptionFields(ctx, sub.ID, map[string]interface{}{
+			"status": SubscriptionPaused,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(ctx, userID, id)
+}
+
+func (s *pickupSubscriptionService) ResumeSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error) {
+	err := s.updateOwnSubscription(ctx, userID, id, func(tx *gorm.DB, sub *model.PickupSubscription) error {
+		if sub.Status != SubscriptionPaused {
+			return fmt.Errorf("langganan berstatus '%s' tidak dapat dilanjutkan", sub.Status)
+		}
+
+		// occurrences that fell inside the pause are dropped, not backfilled
+		next := sub.NextOccurrence
+		if !next.After(time.Now()) {
+			next = firstOccurrence(sub.Frequency, sub.Weekday, sub.DayOfMonth, sub.WindowStart, time.Now())
+		}
+
+		return s.subRepo.WithTx(tx).UpdateSubscriptionFields(ctx, sub.ID, map[string]interface{}{
+			"status":          SubscriptionActive,
+			"next_occurrence": next,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(ctx, userID, id)
+}
+
+func (s *pickupSubscriptionService) SkipOccurrence(ctx context.Context, userID, id string, req SkipSubscriptionOccurrenceDTO) (*PickupSubscriptionResponseDTO, error) {
+	err := s.updateOwnSubscription(ctx, userID, id, func(tx *gorm.DB, sub *model.PickupSubscription) error {
+		if sub.Status == SubscriptionEnded {
+			return fmt.Errorf("langganan sudah berakhir dan tidak dapat dilewati")
+		}
+
+		date := localDate(sub.NextOccurrence)
+		if strings.TrimSpace(req.OccurrenceDate) != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", req.OccurrenceDate, utils.IndonesianLocation())
+			if err != nil {
+				return fmt.Errorf("occurrence_date tidak valid, gunakan format YYYY-MM-DD")
+			}
+			date = parsed
+		}
+
+		if !isUpcomingOccurrence(sub, date) {
+			return fmt.Errorf("tanggal %s tidak valid, bukan jadwal langganan yang akan datang", date.Format("2006-01-02"))
+		}
+
+		skipped, err := s.subRepo.WithTx(tx).IsOccurrenceSkipped(ctx, sub.ID, date)
+		if err != nil {
+			return err
+		}
+		if skipped {
+			return fmt.Errorf("jadwal %s sudah dilewati sebelumnya", date.Format("2006-01-02"))
+		}
+
+		return s.subRepo.WithTx(tx).CreateSkip(ctx, &model.PickupSubscriptionSkip{
+			SubscriptionID: sub.ID,
+			OccurrenceDate: date,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(ctx, userID, id)
+}
+
+func (s *pickupSubscriptionService) EndSubscription(ctx context.Context, userID, id string) (*PickupSubscriptionResponseDTO, error) {
+	err := s.updateOwnSubscription(ctx, userID, id, func(tx *gorm.DB, sub *model.PickupSubscription) error {
+		if sub.Status == SubscriptionEnded {
+			return fmt.Errorf("langganan sudah berakhir")
+		}
+		return s.subRepo.WithTx(tx).UpdateSubscriptionFields(ctx, sub.ID, map[string]interface{}{
+			"status":   SubscriptionEnded,
+			"ended_at": time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSubscription(ctx, userID, id)
+}
+
+func (s *pickupSubscriptionService) updateOwnSubscription(ctx context.Context, userID, id string, apply func(tx *gorm.DB, sub *model.PickupSubscription) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sub, err := s.subRepo.WithTx(tx).GetSubscriptionByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("subscription with id %s not found", id)
+			}
+			return fmt.Errorf("gagal mengambil langganan: %w", err)
+		}
+		if sub.UserID != userID {
+			return fmt.Errorf("forbidden: langganan bukan milik anda")
+		}
+		return apply(tx, sub)
+	})
+}
+
+// isUpcomingOccurrence checks that date is one of the subscription's future occurrences,
+// walking forward from NextOccurrence so biweekly rules keep their phase.
+func isUpcomingOccurrence(sub *model.PickupSubscription, date time.Time) bool {
+	target := localDate(date)
+	occurrence := sub.NextOccurrence
+	for i := 0; i < 60; i++ {
+		day := localDate(occurrence)
+		if day.Equal(target) {
+			return true
+		}
+		if day.After(target) {
+			return false
+		}
+		occurrence = advanceOccurrence(sub.Frequency, occurrence)
+	}
+	return false
+}
+
+// MaterializeDueSubscriptions turns every occurrence that entered the lead window into a
+// scheduled RequestPickup. Each subscription is handled in its own transaction with the
+// row locked, and the (subscription_id, scheduled_start) unique index makes a repeat a no-op.
+func (s *pickupSubscriptionService) MaterializeDueSubscriptions(ctx context.Context) (int, error) {
+	horizon := time.Now().Add(s.leadTime)
+
+	ids, err := s.subRepo.GetDueSubscriptionIDs(ctx, horizon)
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil langganan jatuh tempo: %w", err)
+	}
+
+	created := 0
+	for _, id := range ids {
+		pickupID, err := s.materializeSubscription(ctx, id, horizon)
+		if err != nil {
+			log.Printf("[SUBSCRIPTION] failed to materialise subscription %s: %v", id, err)
+			continue
+		}
+		if pickupID != "" {
+			geoindex.SyncPickup(ctx, s.db, pickupID)
+			created++
+		}
+	}
+	return created, nil
+}
+
+func (s *pickupSubscriptionService) materializeSubscription(ctx context.Context, id string, horizon time.Time) (string, error) {
+	var pickupID string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		subRepo := s.subRepo.WithTx(tx)
+
+		sub, err := subRepo.GetSubscriptionByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if sub.Status != SubscriptionActive || sub.NextOccurrence.After(horizon) {
+			return nil
+		}
+
+		now := time.Now()
+		next := sub.NextOccurrence
+		for !next.After(horizon) {
+			occurrence := next
+			next = advanceOccurrence(sub.Frequency, occurrence)
+
+			// windows that already closed (worker downtime) are dropped, not created late
+			if !clockOn(occurrence, sub.WindowEnd).After(now) {
+				continue
+			}
+
+			skipped, err := subRepo.IsOccurrenceSkipped(ctx, sub.ID, localDate(occurrence))
+			if err != nil {
+				return err
+			}
+			if skipped {
+				continue
+			}
+
+			pickup, err := s.buildSubscriptionPickup(ctx, sub, occurrence)
+			if err != nil {
+				return err
+			}
+			if pickup == nil {
+				continue
+			}
+
+			if err := s.pickupRepo.WithTx(tx).CreateRequestPickup(ctx, pickup); err != nil {
+				return fmt.Errorf("gagal menyimpan request pickup: %w", err)
+			}
+			if err := s.statusMachine.RecordInitialStatus(ctx, tx, pickup, PickupActor{UserID: sub.UserID, Role: utils.RoleMasyarakat}); err != nil {
+				return err
+			}
+
+			pickupID = pickup.ID
+			break
+		}
+
+		fields := map[string]interface{}{
+			"next_occurrence": next,
+		}
+		if pickupID != "" {
+			fields["last_pickup_id"] = pickupID
+		}
+		return subRepo.UpdateSubscriptionFields(ctx, sub.ID, fields)
+	})
+
+	return pickupID, err
+}
+
+// buildSubscriptionPickup prices the subscription template at today's estimated prices
+// for the subscription's address. Categories removed since the subscription was made
+// are left out; if nothing is left the occurrence is dropped.
+func (s *pickupSubscriptionService) buildSubscriptionPickup(ctx context.Context, sub *model.PickupSubscription, occurrence time.Time) (*model.RequestPickup, error) {
+	prices, err := s.regional.ResolveForAddress(ctx, sub.UserID, sub.AddressID)
+	if err != nil {
+		log.Printf("[SUBSCRIPTION] regional prices unavailable for subscription %s, using national prices: %v", sub.ID, err)
+		prices = nil
+	}
+
+	var items []model.RequestPickupItem
+	for _, item := range sub.Items {
+		category, err := s.trashRepo.GetTrashCategoryByID(ctx, item.TrashCategoryID)
+		if err != nil {
+			continue
+		}
+		price, _ := prices.PriceFor(category)
+		items = append(items, model.RequestPickupItem{
+			TrashCategoryId:        item.TrashCategoryID,
+			EstimatedAmount:        item.EstimatedAmount,
+			Unit:                   trash.UnitOf(category),
+			EstimatedPricePerKg:    price,
+			EstimatedSubtotalPrice: item.EstimatedAmount * price,
+		})
+	}
+
+	if len(items) == 0 {
+		log.Printf("[SUBSCRIPTION] subscription %s has no valid items left, skipping %s", sub.ID, occurrence.Format(time.RFC3339))
+		return nil, nil
+	}
+
+	start := occurrence
+	end := clockOn(occurrence, sub.WindowEnd)
+	subscriptionID := sub.ID
+
+	return &model.RequestPickup{
+		UserId:         sub.UserID,
+		AddressId:      sub.AddressID,
+		RequestMethod:  sub.RequestMethod,
+		Notes:          sub.Notes,
+		StatusPickup:   StatusWaitingCollector,
+		RequestItems:   items,
+		ScheduledStart: &start,
+		ScheduledEnd:   &end,
+		SubscriptionID: &subscriptionID,
+	}, nil
+}
+
+func toSubscriptionResponse(sub *model.PickupSubscription) PickupSubscriptionResponseDTO {
+	response := PickupSubscriptionResponseDTO{
+		ID:            sub.ID,
+		AddressID:     sub.AddressID,
+		RequestMethod: sub.RequestMethod,
+		Notes:         sub.Notes,
+		Frequency:     sub.Frequency,
+		Weekday:       sub.Weekday,
+		DayOfMonth:    sub.DayOfMonth,
+		WindowStart:   sub.WindowStart,
+		WindowEnd:     sub.WindowEnd,
+		Status:        sub.Status,
+		LastPickupID:  sub.LastPickupID,
+		SkippedDates:  make([]string, 0, len(sub.Skips)),
+		Items:         make([]PickupSubscriptionItemDTO, 0, len(sub.Items)),
+		CreatedAt:     sub.CreatedAt,
+	}
+
+	if sub.Status != SubscriptionEnded {
+		next := sub.NextOccurrence
+		response.NextOccurrence = &next
+	}
+	for _, skip := range sub.Skips {
+		response.SkippedDates = append(response.SkippedDates, skip.OccurrenceDate.Format("2006-01-02"))
+	}
+	for _, item := range sub.Items {
+		response.Items = append(response.Items, PickupSubscriptionItemDTO{
+			TrashCategoryID: item.TrashCategoryID,
+			EstimatedAmount: item.EstimatedAmount,
+		})
+	}
+	return response
+}
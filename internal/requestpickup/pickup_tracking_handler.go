@@ -0,0 +1,184 @@
+package requestpickup
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+const trackingSessionLocal = "tracking_session"
+
+type PickupTrackingHandler struct {
+	service PickupTrackingService
+}
+
+func NewPickupTrackingHandler(service PickupTrackingService) *PickupTrackingHandler {
+	return &PickupTrackingHandler{
+		service: service,
+	}
+}
+
+// HandshakeToken moves the access token of a socket handshake into the Authorization
+// header for AuthMiddleware. Browsers cannot set headers on a WebSocket, so the token
+// may come as the second entry of Sec-WebSocket-Protocol after "bearer", or as the
+// token query parameter.
+func (h *PickupTrackingHandler) HandshakeToken(c *fiber.Ctx) error {
+	if c.Get(fiber.HeaderAuthorization) != "" || !websocket.IsWebSocketUpgrade(c) {
+		return c.Next()
+	}
+
+	token := c.Query("token")
+	protocols := strings.Split(c.Get(fiber.HeaderSecWebSocketProtocol), ",")
+	if len(protocols) == 2 && strings.TrimSpace(protocols[0]) == trackingSubprotocol {
+		token = strings.TrimSpace(protocols[1])
+	}
+	if token != "" {
+		c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	return c.Next()
+}
+
+// Upgrade authorises the socket before the handshake completes, so a rejected
+// participant gets a normal HTTP error instead of a socket that closes right away.
+func (h *PickupTrackingHandler) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := h.service.OpenSession(context.Background(), c.Params("pickupID"), pickupActorFromClaims(claims))
+	if err != nil {
+		return handleTrackingError(c, err)
+	}
+
+	c.Locals(trackingSessionLocal, session)
+	return c.Next()
+}
+
+func (h *PickupTrackingHandler) Stream(conn *websocket.Conn) {
+	session, ok := conn.Locals(trackingSessionLocal).(*TrackingSession)
+	if !ok {
+		conn.Close()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, err := h.service.Subscribe(ctx, session.PickupID)
+	if err != nil {
+		log.Printf("[TRACKING] %v", err)
+		conn.Close()
+		return
+	}
+	defer sub.Close()
+
+	var writeMu sync.Mutex
+	write := func(event TrackingEventDTO) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(event)
+	}
+
+	if err := write(TrackingEventDTO{
+		Type:     TrackingEventStatus,
+		PickupID: session.PickupID,
+		Status:   session.Status(),
+		At:       time.Now(),
+	}); err != nil {
+		return
+	}
+	if last, err := h.service.LastLocation(ctx, session.PickupID); err == nil && last != nil {
+		if err := write(*last); err != nil {
+			return
+		}
+	}
+
+	closeWith := func(code int, reason string) {
+		writeMu.Lock()
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+		writeMu.Unlock()
+		conn.Close()
+	}
+
+	go func() {
+		for msg := range sub.Channel() {
+			var event TrackingEventDTO
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+
+			if event.Type == TrackingEventStatus {
+				session.SetStatus(event.Status)
+			}
+			if err := write(event); err != nil {
+				conn.Close()
+				return
+			}
+
+			if event.Type != TrackingEventStatus {
+				continue
+			}
+			if !isTrackable(event.Status) {
+				closeWith(websocket.CloseNormalClosure, event.Status)
+				return
+			}
+			// a collector who was released or replaced is no longer part of the pickup
+			if session.IsCollector && event.CollectorID != session.CollectorID {
+				closeWith(websocket.ClosePolicyViolation, "collector_reassigned")
+				return
+			}
+		}
+	}()
+
+	for {
+		var req TrackingLocationDTO
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		if errs, ok := req.Validate(); !ok {
+			if err := write(trackingErrorEvent(session.PickupID, "Validasi gagal", errs)); err != nil {
+				return
+			}
+			continue
+		}
+
+		// the sender gets its own update back through the subscription
+		if _, err := h.service.PublishLocation(ctx, session, req); err != nil {
+			if err := write(trackingErrorEvent(session.PickupID, err.Error(), nil)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func trackingErrorEvent(pickupID, message string, errs map[string][]string) TrackingEventDTO {
+	return TrackingEventDTO{
+		Type:     TrackingEventError,
+		PickupID: pickupID,
+		Message:  message,
+		Errors:   errs,
+		At:       time.Now(),
+	}
+}
+
+func handleTrackingError(c *fiber.Ctx, err error) error {
+	if strings.Contains(err.Error(), "tracking tidak tersedia") {
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	}
+	return handlePickupError(c, err)
+}
@@ -0,0 +1,32 @@
+package requestpickup
+
+import (
+	"rijig/config"
+	"rijig/internal/collector"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PickupTrackingRouter must be mounted outside the API key middleware: browsers cannot
+// set x-api-key on a WebSocket handshake, so the socket authenticates with its JWT.
+func PickupTrackingRouter(router fiber.Router) {
+	pickupRepo := NewRequestPickupRepository()
+	historyRepo := NewPickupStatusHistoryRepository()
+	collectorRepo := collector.NewCollectorRepository(config.DB)
+	slotRepo := collector.NewCollectorSlotRepository(config.DB)
+	statusMachine := NewPickupStatusMachine(config.DB, pickupRepo, historyRepo, collectorRepo, slotRepo)
+	service := NewPickupTrackingService(config.DB, pickupRepo, statusMachine)
+	handler := NewPickupTrackingHandler(service)
+
+	tracking := router.Group("/pickup/tracking")
+	tracking.Use(handler.HandshakeToken, middleware.AuthMiddleware())
+
+	tracking.Get("/:pickupID/ws",
+		middleware.RequireRoles(utils.RoleMasyarakat, utils.RolePengepul, utils.RoleAdministrator),
+		handler.Upgrade,
+		websocket.New(handler.Stream, websocket.Config{Subprotocols: []string{trackingSubprotocol}}),
+	)
+}
@@ -0,0 +1,241 @@
+package requestpickup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"rijig/config"
+	"rijig/model"
+	"rijig/utils"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	TrackingEventLocation = "location"
+	TrackingEventStatus   = "status"
+	TrackingEventError    = "error"
+
+	trackingChannelPattern      = "pickup:tracking:%s"
+	trackingLastLocationPattern = "pickup:tracking:%s:last"
+	trackingLastLocationTTL     = 6 * time.Hour
+
+	// trackingSubprotocol carries the access token for clients that cannot set headers
+	// on the handshake: Sec-WebSocket-Protocol: bearer, <token>.
+	trackingSubprotocol = "bearer"
+
+	defaultCollectorSpeedKmh = 20.0
+)
+
+// TrackingSession is one open socket on a pickup. Status follows the status events
+// received on the channel so location updates can be gated without a DB round trip.
+type TrackingSession struct {
+	PickupID    string
+	Actor       PickupActor
+	IsCollector bool
+	CollectorID string
+	Destination utils.Coord
+
+	mu     sync.RWMutex
+	status string
+}
+
+func (s *TrackingSession) Status() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+func (s *TrackingSession) SetStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+type PickupTrackingService interface {
+	OpenSession(ctx context.Context, pickupID string, actor PickupActor) (*TrackingSession, error)
+	PublishLocation(ctx context.Context, session *TrackingSession, req TrackingLocationDTO) (*TrackingEventDTO, error)
+	LastLocation(ctx context.Context, pickupID string) (*TrackingEventDTO, error)
+	Subscribe(ctx context.Context, pickupID string) (*redis.PubSub, error)
+}
+
+type pickupTrackingService struct {
+	db            *gorm.DB
+	pickupRepo    RequestPickupRepository
+	statusMachine PickupStatusMachine
+	speedKmh      float64
+}
+
+func NewPickupTrackingService(db *gorm.DB, pickupRepo RequestPickupRepository, statusMachine PickupStatusMachine) PickupTrackingService {
+	return &pickupTrackingService{
+		db:            db,
+		pickupRepo:    pickupRepo,
+		statusMachine: statusMachine,
+		speedKmh:      collectorSpeedKmh(),
+	}
+}
+
+// collectorSpeedKmh reads TRACKING_AVG_SPEED_KMH, the average speed used for ETA estimates.
+func collectorSpeedKmh() float64 {
+	raw := os.Getenv("TRACKING_AVG_SPEED_KMH")
+	if raw == "" {
+		return defaultCollectorSpeedKmh
+	}
+
+	speed, err := strconv.ParseFloat(raw, 64)
+	if err != nil || speed <= 0 {
+		log.Printf("[TRACKING] invalid TRACKING_AVG_SPEED_KMH %q, using %.0f", raw, defaultCollectorSpeedKmh)
+		return defaultCollectorSpeedKmh
+	}
+	return speed
+}
+
+func trackingChannel(pickupID string) string {
+	return fmt.Sprintf(trackingChannelPattern, pickupID)
+}
+
+func trackingLastLocationKey(pickupID string) string {
+	return fmt.Sprintf(trackingLastLocationPattern, pickupID)
+}
+
+func (s *pickupTrackingService) OpenSession(ctx context.Context, pickupID string, actor PickupActor) (*TrackingSession, error) {
+	pickup, err := s.pickupRepo.GetPickupWithItemsAndAddress(ctx, pickupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pickup with id %s not found", pickupID)
+		}
+		return nil, fmt.Errorf("failed to get pickup: %w", err)
+	}
+
+	if err := s.statusMachine.EnsureParticipant(ctx, s.db, pickup, actor); err != nil {
+		return nil, err
+	}
+
+	if !isTrackable(pickup.StatusPickup) {
+		return nil, fmt.Errorf("tracking tidak tersedia untuk pickup berstatus '%s'", pickup.StatusPickup)
+	}
+
+	session := &TrackingSession{
+		PickupID:    pickup.ID,
+		Actor:       actor,
+		IsCollector: actor.Role == utils.RolePengepul,
+		status:      pickup.StatusPickup,
+	}
+	if pickup.CollectorID != nil {
+		session.CollectorID = *pickup.CollectorID
+	}
+	if pickup.Address != nil {
+		session.Destination = utils.Coord{
+			Lat: pickup.Address.Latitude,
+			Lon: pickup.Address.Longitude,
+		}
+	}
+
+	return session, nil
+}
+
+func (s *pickupTrackingService) PublishLocation(ctx context.Context, session *TrackingSession, req TrackingLocationDTO) (*TrackingEventDTO, error) {
+	if !session.IsCollector {
+		return nil, fmt.Errorf("forbidden: hanya collector yang dapat mengirim lokasi")
+	}
+	if status := session.Status(); status != StatusCollectorPickingUp {
+		return nil, fmt.Errorf("lokasi hanya dapat dikirim saat status '%s', status saat ini '%s'", StatusCollectorPickingUp, status)
+	}
+
+	_, km := utils.Distance(utils.Coord{Lat: req.Latitude, Lon: req.Longitude}, session.Destination)
+
+	event := &TrackingEventDTO{
+		Type:       TrackingEventLocation,
+		PickupID:   session.PickupID,
+		Status:     StatusCollectorPickingUp,
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+		DistanceKm: round3(km),
+		EtaMinutes: round3(km / s.speedKmh * 60),
+		At:         time.Now(),
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode location: %w", err)
+	}
+
+	// keep the latest fix so a requester who connects later sees the collector immediately
+	if err := config.RedisClient.Set(ctx, trackingLastLocationKey(session.PickupID), payload, trackingLastLocationTTL).Err(); err != nil {
+		log.Printf("[TRACKING] failed to store last location for pickup %s: %v", session.PickupID, err)
+	}
+
+	if err := config.RedisClient.Publish(ctx, trackingChannel(session.PickupID), payload).Err(); err != nil {
+		return nil, fmt.Errorf("failed to publish location: %w", err)
+	}
+
+	return event, nil
+}
+
+func (s *pickupTrackingService) LastLocation(ctx context.Context, pickupID string) (*TrackingEventDTO, error) {
+	raw, err := config.RedisClient.Get(ctx, trackingLastLocationKey(pickupID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var event TrackingEventDTO
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *pickupTrackingService) Subscribe(ctx context.Context, pickupID string) (*redis.PubSub, error) {
+	sub := config.RedisClient.Subscribe(ctx, trackingChannel(pickupID))
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to pickup %s: %w", pickupID, err)
+	}
+	return sub, nil
+}
+
+// isTrackable reports whether sockets may stay open on a pickup in status.
+func isTrackable(status string) bool {
+	return status != StatusCompleted && status != StatusCanceled
+}
+
+// PublishPickupStatus fans a committed status or collector change out to every
+// tracking socket of the pickup, on whichever API instance they are connected to.
+func PublishPickupStatus(ctx context.Context, pickup *model.RequestPickup) {
+	event := TrackingEventDTO{
+		Type:     TrackingEventStatus,
+		PickupID: pickup.ID,
+		Status:   pickup.StatusPickup,
+		At:       time.Now(),
+	}
+	if pickup.CollectorID != nil {
+		event.CollectorID = *pickup.CollectorID
+	}
+	pickupID, status := pickup.ID, pickup.StatusPickup
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[TRACKING] failed to encode status for pickup %s: %v", pickupID, err)
+		return
+	}
+
+	if err := config.RedisClient.Publish(ctx, trackingChannel(pickupID), payload).Err(); err != nil {
+		log.Printf("[TRACKING] failed to publish status for pickup %s: %v", pickupID, err)
+	}
+
+	// a released collector's last fix no longer says where the pickup's collector is
+	if !isTrackable(status) || event.CollectorID == "" {
+		config.RedisClient.Del(ctx, trackingLastLocationKey(pickupID))
+	}
+}
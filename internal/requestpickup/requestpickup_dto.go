@@ -1,7 +1,10 @@
 package requestpickup
 
 import (
+	"fmt"
+	"rijig/internal/collector"
 	"strings"
+	"time"
 )
 
 type SelectCollectorDTO struct {
@@ -31,33 +34,51 @@ func (r *SelectCollectorDTO) Validate() (map[string][]string, bool) {
 }
 
 type AssignedPickupDTO struct {
-	PickupID     string   `json:"pickup_id"`
-	UserID       string   `json:"user_id"`
-	UserName     string   `json:"user_name"`
-	Latitude     float64  `json:"latitude"`
-	Longitude    float64  `json:"longitude"`
-	Notes        string   `json:"notes"`
-	MatchedTrash []string `json:"matched_trash"`
+	PickupID       string     `json:"pickup_id"`
+	UserID         string     `json:"user_id"`
+	UserName       string     `json:"user_name"`
+	Latitude       float64    `json:"latitude"`
+	Longitude      float64    `json:"longitude"`
+	Notes          string     `json:"notes"`
+	MatchedTrash   []string   `json:"matched_trash"`
+	ScheduledStart *time.Time `json:"scheduled_start,omitempty"`
+	ScheduledEnd   *time.Time `json:"scheduled_end,omitempty"`
 }
 
 type PickupRequestForCollectorDTO struct {
-	PickupID     string   `json:"pickup_id"`
-	UserID       string   `json:"user_id"`
-	Latitude     float64  `json:"latitude"`
-	Longitude    float64  `json:"longitude"`
-	DistanceKm   float64  `json:"distance_km"`
-	MatchedTrash []string `json:"matched_trash"`
+	PickupID       string                 `json:"pickup_id"`
+	UserID         string                 `json:"user_id"`
+	Latitude       float64                `json:"latitude"`
+	Longitude      float64                `json:"longitude"`
+	DistanceKm     float64                `json:"distance_km"`
+	MatchedTrash   []string               `json:"matched_trash"`
+	Score          MatchScoreBreakdownDTO `json:"score"`
+	ScheduledStart *time.Time             `json:"scheduled_start,omitempty"`
+	ScheduledEnd   *time.Time             `json:"scheduled_end,omitempty"`
 }
 
 type RequestPickupDTO struct {
+	QuoteID       string `json:"quote_id"`
 	AddressID     string `json:"address_id"`
 	RequestMethod string `json:"request_method"`
 	Notes         string `json:"notes,omitempty"`
+	ScheduledDate string `json:"scheduled_date,omitempty"`
+	WindowStart   string `json:"window_start,omitempty"`
+	WindowEnd     string `json:"window_end,omitempty"`
+}
+
+// IsScheduled reports whether the request books a time window instead of "as soon as possible".
+func (r *RequestPickupDTO) IsScheduled() bool {
+	return r.ScheduledDate != "" || r.WindowStart != "" || r.WindowEnd != ""
 }
 
 func (r *RequestPickupDTO) Validate() (map[string][]string, bool) {
 	errors := make(map[string][]string)
 
+	if strings.TrimSpace(r.QuoteID) == "" {
+		errors["quote_id"] = append(errors["quote_id"], "penawaran harga harus dibuat terlebih dahulu")
+	}
+
 	if strings.TrimSpace(r.AddressID) == "" {
 		errors["address_id"] = append(errors["address_id"], "alamat harus dipilih")
 	}
@@ -67,8 +88,472 @@ func (r *RequestPickupDTO) Validate() (map[string][]string, bool) {
 		errors["request_method"] = append(errors["request_method"], "harus manual atau otomatis")
 	}
 
+	if r.IsScheduled() {
+		r.validateSchedule(errors)
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+func (r *RequestPickupDTO) validateSchedule(errors map[string][]string) {
+	if r.ScheduledDate == "" || r.WindowStart == "" || r.WindowEnd == "" {
+		errors["schedule"] = append(errors["schedule"], "scheduled_date, window_start dan window_end harus diisi bersamaan")
+		return
+	}
+	if !collector.IsValidClock(r.WindowStart) {
+		errors["window_start"] = append(errors["window_start"], "format jam harus HH:MM")
+	}
+	if !collector.IsValidClock(r.WindowEnd) {
+		errors["window_end"] = append(errors["window_end"], "format jam harus HH:MM")
+	}
+	if len(errors) > 0 {
+		return
+	}
+
+	window, err := parsePickupWindow(r.ScheduledDate, r.WindowStart, r.WindowEnd)
+	if err != nil {
+		errors["scheduled_date"] = append(errors["scheduled_date"], "format tanggal harus YYYY-MM-DD")
+		return
+	}
+
+	if !window.End.After(window.Start) {
+		errors["window_end"] = append(errors["window_end"], "jam selesai harus setelah jam mulai")
+	}
+	if !window.Start.After(time.Now()) {
+		errors["window_start"] = append(errors["window_start"], "jadwal harus di masa depan")
+	}
+	if window.Start.After(time.Now().AddDate(0, 0, maxScheduleAheadDays)) {
+		errors["scheduled_date"] = append(errors["scheduled_date"], fmt.Sprintf("jadwal maksimal %d hari ke depan", maxScheduleAheadDays))
+	}
+}
+
+var cancelReasonCodes = map[string]bool{
+	"changed_mind":     true,
+	"wrong_address":    true,
+	"sold_elsewhere":   true,
+	"collector_absent": true,
+	"other":            true,
+}
+
+var declineReasonCodes = map[string]bool{
+	"too_far":           true,
+	"unsupported_trash": true,
+	"schedule_conflict": true,
+	"vehicle_full":      true,
+	"other":             true,
+}
+
+type CancelPickupDTO struct {
+	ReasonCode string `json:"reason_code"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+type DeclinePickupDTO struct {
+	ReasonCode string `json:"reason_code"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+func (r *CancelPickupDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.ReasonCode = strings.ToLower(strings.TrimSpace(r.ReasonCode))
+	if !cancelReasonCodes[r.ReasonCode] {
+		errors["reason_code"] = append(errors["reason_code"], "harus salah satu dari changed_mind, wrong_address, sold_elsewhere, collector_absent, other")
+	}
+
+	if r.ReasonCode == "other" && strings.TrimSpace(r.Notes) == "" {
+		errors["notes"] = append(errors["notes"], "catatan wajib diisi jika alasan 'other'")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+func (r *DeclinePickupDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.ReasonCode = strings.ToLower(strings.TrimSpace(r.ReasonCode))
+	if !declineReasonCodes[r.ReasonCode] {
+		errors["reason_code"] = append(errors["reason_code"], "harus salah satu dari too_far, unsupported_trash, schedule_conflict, vehicle_full, other")
+	}
+
+	if r.ReasonCode == "other" && strings.TrimSpace(r.Notes) == "" {
+		errors["notes"] = append(errors["notes"], "catatan wajib diisi jika alasan 'other'")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type ReceiptPartyDTO struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+}
+
+type ReceiptItemDTO struct {
+	ItemID              string  `json:"item_id"`
+	TrashCategoryID     string  `json:"trash_category_id"`
+	TrashName           string  `json:"trash_name"`
+	EstimatedAmount     float64 `json:"estimated_amount"`
+	ActualAmount        float64 `json:"actual_amount"`
+	Unit                string  `json:"unit"`
+	PricePerKg          float64 `json:"price_per_kg"`
+	EstimatedSubtotal   float64 `json:"estimated_subtotal"`
+	ActualSubtotalPrice float64 `json:"actual_subtotal_price"`
+}
+
+// PickupReceiptDTO.TotalWeight only counts items measured in kg; the actual totals
+// per unit are in TotalsByUnit.
+type PickupReceiptDTO struct {
+	ReceiptNumber  string             `json:"receipt_number"`
+	PickupID       string             `json:"pickup_id"`
+	RequestMethod  string             `json:"request_method"`
+	Status         string             `json:"status"`
+	Requester      ReceiptPartyDTO    `json:"requester"`
+	Collector      *ReceiptPartyDTO   `json:"collector,omitempty"`
+	Address        string             `json:"address"`
+	Items          []ReceiptItemDTO   `json:"items"`
+	TotalEstimated float64            `json:"total_estimated"`
+	TotalWeight    float64            `json:"total_weight"`
+	TotalsByUnit   map[string]float64 `json:"totals_by_unit"`
+	FinalPrice     float64            `json:"final_price"`
+	CreatedAt      string             `json:"created_at"`
+	CompletedAt    string             `json:"completed_at"`
+}
+
+type CreatePickupRatingDTO struct {
+	Rating   float32 `json:"rating"`
+	Feedback string  `json:"feedback,omitempty"`
+}
+
+func (r *CreatePickupRatingDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if r.Rating < 1 || r.Rating > 5 {
+		errors["rating"] = append(errors["rating"], "rating harus antara 1 sampai 5")
+	}
+
+	r.Feedback = strings.TrimSpace(r.Feedback)
+	if len(r.Feedback) > 500 {
+		errors["feedback"] = append(errors["feedback"], "feedback maksimal 500 karakter")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type PickupRatingResponseDTO struct {
+	ID          string  `json:"id"`
+	RequestID   string  `json:"request_id"`
+	UserID      string  `json:"user_id"`
+	CollectorID string  `json:"collector_id"`
+	Rating      float32 `json:"rating"`
+	Feedback    string  `json:"feedback,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+type CollectorRatingSummaryDTO struct {
+	CollectorID   string                    `json:"collector_id"`
+	Rating        float32                   `json:"rating"`
+	AverageRating float32                   `json:"average_rating"`
+	TotalRatings  int64                     `json:"total_ratings"`
+	Ratings       []PickupRatingResponseDTO `json:"ratings"`
+}
+
+type MatchScoreBreakdownDTO struct {
+	Distance      float64 `json:"distance"`
+	CategoryMatch float64 `json:"category_match"`
+	Rating        float64 `json:"rating"`
+	Price         float64 `json:"price"`
+	Workload      float64 `json:"workload"`
+	Total         float64 `json:"total"`
+}
+
+type RankedCollectorDTO struct {
+	collector.NearbyCollectorDTO
+	AvgOfferedPrice float64                `json:"avg_offered_price"`
+	ActivePickups   int64                  `json:"active_pickups"`
+	Score           MatchScoreBreakdownDTO `json:"score"`
+}
+
+type NearbyCollectorsResponseDTO struct {
+	PickupID   string               `json:"pickup_id"`
+	Config     MatchingWeights      `json:"config"`
+	Collectors []RankedCollectorDTO `json:"collectors"`
+}
+
+type AvailableRequestsResponseDTO struct {
+	CollectorID string                         `json:"collector_id"`
+	Config      MatchingWeights                `json:"config"`
+	Requests    []PickupRequestForCollectorDTO `json:"requests"`
+}
+
+type MatchingConfigDTO struct {
+	RegencyID      string  `json:"regency_id"`
+	RadiusKm       float64 `json:"radius_km"`
+	MaxWorkload    int     `json:"max_workload"`
+	WeightDistance float64 `json:"weight_distance"`
+	WeightCategory float64 `json:"weight_category"`
+	WeightRating   float64 `json:"weight_rating"`
+	WeightPrice    float64 `json:"weight_price"`
+	WeightWorkload float64 `json:"weight_workload"`
+}
+
+func (r *MatchingConfigDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.RegencyID) == "" {
+		errors["regency_id"] = append(errors["regency_id"], "regency_id wajib diisi")
+	}
+
+	if r.RadiusKm <= 0 || r.RadiusKm > 100 {
+		errors["radius_km"] = append(errors["radius_km"], "radius_km harus antara 0 dan 100")
+	}
+
+	if r.MaxWorkload <= 0 {
+		errors["max_workload"] = append(errors["max_workload"], "max_workload harus lebih dari 0")
+	}
+
+	weights := map[string]float64{
+		"weight_distance": r.WeightDistance,
+		"weight_category": r.WeightCategory,
+		"weight_rating":   r.WeightRating,
+		"weight_price":    r.WeightPrice,
+		"weight_workload": r.WeightWorkload,
+	}
+	var total float64
+	for field, w := range weights {
+		if w < 0 {
+			errors[field] = append(errors[field], "bobot tidak boleh negatif")
+		}
+		total += w
+	}
+	if total <= 0 {
+		errors["weights"] = append(errors["weights"], "minimal satu bobot harus lebih dari 0")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type DispatchOfferResponseDTO struct {
+	OfferID    string    `json:"offer_id"`
+	PickupID   string    `json:"pickup_id"`
+	Status     string    `json:"status"`
+	Score      float64   `json:"score"`
+	DistanceKm float64   `json:"distance_km"`
+	OfferedAt  time.Time `json:"offered_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type EscalatedPickupDTO struct {
+	PickupID    string    `json:"pickup_id"`
+	UserID      string    `json:"user_id"`
+	AddressID   string    `json:"address_id"`
+	Regency     string    `json:"regency"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	TotalItems  int       `json:"total_items"`
+	CreatedAt   time.Time `json:"created_at"`
+	EscalatedAt time.Time `json:"escalated_at"`
+}
+
+// DispatchRunSummary reports what a single dispatch pass did, mostly for worker logs.
+type DispatchRunSummary struct {
+	Expired   int `json:"expired"`
+	Offered   int `json:"offered"`
+	Escalated int `json:"escalated"`
+	Skipped   int `json:"skipped"`
+}
+
+type TrackingLocationDTO struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func (r *TrackingLocationDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if r.Latitude < -90 || r.Latitude > 90 {
+		errors["latitude"] = append(errors["latitude"], "latitude harus di antara -90 dan 90")
+	}
+	if r.Longitude < -180 || r.Longitude > 180 {
+		errors["longitude"] = append(errors["longitude"], "longitude harus di antara -180 dan 180")
+	}
+	if r.Latitude == 0 && r.Longitude == 0 {
+		errors["location"] = append(errors["location"], "lokasi tidak valid")
+	}
+
+	return errors, len(errors) == 0
+}
+
+// TrackingEventDTO is the single message shape pushed to tracking sockets; which
+// fields are set depends on Type.
+type TrackingEventDTO struct {
+	Type        string              `json:"type"`
+	PickupID    string              `json:"pickup_id"`
+	Status      string              `json:"status,omitempty"`
+	CollectorID string              `json:"collector_id,omitempty"`
+	Latitude    float64             `json:"latitude,omitempty"`
+	Longitude   float64             `json:"longitude,omitempty"`
+	DistanceKm  float64             `json:"distance_km,omitempty"`
+	EtaMinutes  float64             `json:"eta_minutes,omitempty"`
+	Message     string              `json:"message,omitempty"`
+	Errors      map[string][]string `json:"errors,omitempty"`
+	At          time.Time           `json:"at"`
+}
+
+type PickupSubscriptionItemDTO struct {
+	TrashCategoryID string  `json:"trash_category_id"`
+	EstimatedAmount float64 `json:"estimated_amount"`
+}
+
+type CreatePickupSubscriptionDTO struct {
+	AddressID     string                      `json:"address_id"`
+	RequestMethod string                      `json:"request_method"`
+	Notes         string                      `json:"notes,omitempty"`
+	Frequency     string                      `json:"frequency"`
+	Weekday       *int                        `json:"weekday,omitempty"`
+	DayOfMonth    int                         `json:"day_of_month,omitempty"`
+	WindowStart   string                      `json:"window_start"`
+	WindowEnd     string                      `json:"window_end"`
+	StartDate     string                      `json:"start_date,omitempty"`
+	Items         []PickupSubscriptionItemDTO `json:"items"`
+}
+
+func (r *CreatePickupSubscriptionDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.AddressID) == "" {
+		errors["address_id"] = append(errors["address_id"], "alamat harus dipilih")
+	}
+
+	r.RequestMethod = strings.ToLower(strings.TrimSpace(r.RequestMethod))
+	if r.RequestMethod == "" {
+		r.RequestMethod = "otomatis"
+	}
+	if r.RequestMethod != "manual" && r.RequestMethod != "otomatis" {
+		errors["request_method"] = append(errors["request_method"], "harus manual atau otomatis")
+	}
+
+	r.Frequency = strings.ToLower(strings.TrimSpace(r.Frequency))
+	switch r.Frequency {
+	case SubscriptionWeekly, SubscriptionBiweekly:
+		if r.Weekday == nil || *r.Weekday < 0 || *r.Weekday > 6 {
+			errors["weekday"] = append(errors["weekday"], "weekday harus di antara 0 (Minggu) dan 6 (Sabtu)")
+		}
+	case SubscriptionMonthly:
+		if r.DayOfMonth < 1 || r.DayOfMonth > 28 {
+			errors["day_of_month"] = append(errors["day_of_month"], "day_of_month harus di antara 1 dan 28")
+		}
+	default:
+		errors["frequency"] = append(errors["frequency"], "frequency harus weekly, biweekly atau monthly")
+	}
+
+	startOK := collector.IsValidClock(r.WindowStart)
+	endOK := collector.IsValidClock(r.WindowEnd)
+	if !startOK {
+		errors["window_start"] = append(errors["window_start"], "format jam harus HH:MM")
+	}
+	if !endOK {
+		errors["window_end"] = append(errors["window_end"], "format jam harus HH:MM")
+	}
+	if startOK && endOK && r.WindowStart >= r.WindowEnd {
+		errors["window_end"] = append(errors["window_end"], "jam selesai harus setelah jam mulai")
+	}
+
+	if r.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", r.StartDate); err != nil {
+			errors["start_date"] = append(errors["start_date"], "format tanggal harus YYYY-MM-DD")
+		}
+	}
+
+	if len(r.Items) == 0 {
+		errors["items"] = append(errors["items"], "minimal satu jenis sampah")
+	}
+	seen := make(map[string]bool)
+	for i, item := range r.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		if strings.TrimSpace(item.TrashCategoryID) == "" {
+			errors[field+".trash_category_id"] = append(errors[field+".trash_category_id"], "kategori sampah harus diisi")
+		} else if seen[item.TrashCategoryID] {
+			errors[field+".trash_category_id"] = append(errors[field+".trash_category_id"], "kategori sampah duplikat")
+		}
+		seen[item.TrashCategoryID] = true
+		if item.EstimatedAmount <= 0 {
+			errors[field+".estimated_amount"] = append(errors[field+".estimated_amount"], "jumlah harus lebih dari 0")
+		}
+	}
+
 	if len(errors) > 0 {
 		return errors, false
 	}
 	return nil, true
 }
+
+type SkipSubscriptionOccurrenceDTO struct {
+	OccurrenceDate string `json:"occurrence_date,omitempty"`
+}
+
+type PickupSubscriptionResponseDTO struct {
+	ID             string                      `json:"id"`
+	AddressID      string                      `json:"address_id"`
+	RequestMethod  string                      `json:"request_method"`
+	Notes          string                      `json:"notes"`
+	Frequency      string                      `json:"frequency"`
+	Weekday        int                         `json:"weekday"`
+	DayOfMonth     int                         `json:"day_of_month,omitempty"`
+	WindowStart    string                      `json:"window_start"`
+	WindowEnd      string                      `json:"window_end"`
+	Status         string                      `json:"status"`
+	NextOccurrence *time.Time                  `json:"next_occurrence,omitempty"`
+	LastPickupID   *string                     `json:"last_pickup_id,omitempty"`
+	SkippedDates   []string                    `json:"skipped_dates"`
+	Items          []PickupSubscriptionItemDTO `json:"items"`
+	CreatedAt      time.Time                   `json:"created_at"`
+}
+
+type RoutePlanStopDTO struct {
+	Sequence             int       `json:"sequence"`
+	PickupID             string    `json:"pickup_id"`
+	UserName             string    `json:"user_name"`
+	StatusPickup         string    `json:"status_pickup"`
+	AddressDetail        string    `json:"address_detail"`
+	Latitude             float64   `json:"latitude"`
+	Longitude            float64   `json:"longitude"`
+	WindowStart          string    `json:"window_start,omitempty"`
+	WindowEnd            string    `json:"window_end,omitempty"`
+	LegDistanceKm        float64   `json:"leg_distance_km"`
+	CumulativeDistanceKm float64   `json:"cumulative_distance_km"`
+	EstimatedArrival     time.Time `json:"estimated_arrival"`
+	ServiceStart         time.Time `json:"service_start"`
+	Late                 bool      `json:"late"`
+}
+
+type RoutePlanDTO struct {
+	CollectorID      string             `json:"collector_id"`
+	Date             string             `json:"date"`
+	DepotAddressID   string             `json:"depot_address_id"`
+	DepotLatitude    float64            `json:"depot_latitude"`
+	DepotLongitude   float64            `json:"depot_longitude"`
+	Departure        time.Time          `json:"departure"`
+	RoundTrip        bool               `json:"round_trip"`
+	Stops            []RoutePlanStopDTO `json:"stops"`
+	SkippedPickupIDs []string           `json:"skipped_pickup_ids"`
+	ReturnDistanceKm float64            `json:"return_distance_km"`
+	TotalDistanceKm  float64            `json:"total_distance_km"`
+	EstimatedFinish  time.Time          `json:"estimated_finish"`
+	LateStops        int                `json:"late_stops"`
+}
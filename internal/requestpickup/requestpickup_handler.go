@@ -2,8 +2,12 @@ package requestpickup
 
 import (
 	"context"
+	"errors"
+	"rijig/internal/cart"
+	"rijig/internal/trash"
 	"rijig/middleware"
 	"rijig/utils"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +20,10 @@ type RequestPickupHandler interface {
 	ConfirmPickup(c *fiber.Ctx) error
 	UpdatePickupStatus(c *fiber.Ctx) error
 	UpdatePickupItemActualAmount(c *fiber.Ctx) error
+	CompletePickup(c *fiber.Ctx) error
+	GetPickupReceipt(c *fiber.Ctx) error
+	CancelPickup(c *fiber.Ctx) error
+	DeclinePickup(c *fiber.Ctx) error
 }
 
 type requestPickupHandler struct {
@@ -42,14 +50,30 @@ func (h *requestPickupHandler) CreateRequestPickup(c *fiber.Ctx) error {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errors)
 	}
 
-	if err := h.service.ConvertCartToRequestPickup(context.Background(), claims.UserID, req); err != nil {
-		return utils.InternalServerError(c, err.Error())
+	if err := h.service.ConvertCartToRequestPickup(context.Background(), pickupActorFromClaims(claims), req); err != nil {
+		return handleConvertCartError(c, err)
 	}
 
 	return utils.Success(c, "Request pickup berhasil dibuat")
 }
 
+func handleConvertCartError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, cart.ErrQuoteNotFound), errors.Is(err, cart.ErrQuoteExpired),
+		errors.Is(err, cart.ErrQuoteUsed), errors.Is(err, cart.ErrQuoteCartChanged), errors.Is(err, cart.ErrQuoteAddressMismatch),
+		errors.Is(err, cart.ErrCartEmpty):
+		return cart.HandleQuoteError(c, err)
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
+
 func (h *requestPickupHandler) SelectCollector(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	pickupID := c.Params("id")
 	if pickupID == "" {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
@@ -68,8 +92,8 @@ func (h *requestPickupHandler) SelectCollector(c *fiber.Ctx) error {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errs)
 	}
 
-	if err := h.service.AssignCollectorToRequest(context.Background(), pickupID, req); err != nil {
-		return utils.InternalServerError(c, err.Error())
+	if err := h.service.AssignCollectorToRequest(context.Background(), pickupID, pickupActorFromClaims(claims), req); err != nil {
+		return handlePickupError(c, err)
 	}
 
 	return utils.Success(c, "Collector berhasil dipilih untuk pickup")
@@ -89,6 +113,11 @@ func (h *requestPickupHandler) GetAssignedPickup(c *fiber.Ctx) error {
 }
 
 func (h *requestPickupHandler) ConfirmPickup(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	pickupID := c.Params("id")
 	if pickupID == "" {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
@@ -96,14 +125,19 @@ func (h *requestPickupHandler) ConfirmPickup(c *fiber.Ctx) error {
 		})
 	}
 
-	err := h.service.ConfirmPickupByCollector(context.Background(), pickupID, time.Now())
+	err = h.service.ConfirmPickupByCollector(context.Background(), pickupID, pickupActorFromClaims(claims), time.Now())
 	if err != nil {
-		return utils.InternalServerError(c, err.Error())
+		return handlePickupError(c, err)
 	}
 	return utils.Success(c, "Pickup berhasil dikonfirmasi oleh collector")
 }
 
 func (h *requestPickupHandler) UpdatePickupStatus(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	pickupID := c.Params("id")
 	if pickupID == "" {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
@@ -111,14 +145,19 @@ func (h *requestPickupHandler) UpdatePickupStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.UpdatePickupStatusToPickingUp(context.Background(), pickupID); err != nil {
-		return utils.InternalServerError(c, err.Error())
+	if err := h.service.UpdatePickupStatusToPickingUp(context.Background(), pickupID, pickupActorFromClaims(claims)); err != nil {
+		return handlePickupError(c, err)
 	}
 
 	return utils.Success(c, "Status pickup berhasil diperbarui menjadi 'collector_are_picking_up'")
 }
 
 func (h *requestPickupHandler) UpdatePickupItemActualAmount(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
 	pickupID := c.Params("id")
 	if pickupID == "" {
 		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
@@ -140,16 +179,147 @@ func (h *requestPickupHandler) UpdatePickupItemActualAmount(c *fiber.Ctx) error
 	}
 
 	for _, item := range req.Items {
-		if item.ItemID == "" || item.Amount <= 0 {
+		if item.ItemID == "" || item.Amount < 0 {
 			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
-				"item": {"item_id harus valid dan amount > 0"},
+				"item": {"item_id harus valid dan amount tidak boleh negatif"},
 			})
 		}
 	}
 
-	if err := h.service.UpdateActualPickupItems(context.Background(), pickupID, req.Items); err != nil {
-		return utils.InternalServerError(c, err.Error())
+	if err := h.service.UpdateActualPickupItems(context.Background(), pickupID, pickupActorFromClaims(claims), req.Items); err != nil {
+		return handlePickupError(c, err)
+	}
+
+	return utils.Success(c, "Berat aktual berhasil diperbarui")
+}
+
+func (h *requestPickupHandler) CompletePickup(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("id")
+	if pickupID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"pickup_id": {"pickup ID tidak boleh kosong"},
+		})
+	}
+
+	receipt, err := h.service.CompletePickup(context.Background(), pickupID, pickupActorFromClaims(claims))
+	if err != nil {
+		return handlePickupError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pickup berhasil diselesaikan", receipt)
+}
+
+func (h *requestPickupHandler) GetPickupReceipt(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("id")
+	if pickupID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"pickup_id": {"pickup ID tidak boleh kosong"},
+		})
+	}
+
+	receipt, err := h.service.GetPickupReceipt(context.Background(), pickupID, pickupActorFromClaims(claims))
+	if err != nil {
+		return handlePickupError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Struk pickup berhasil diambil", receipt)
+}
+
+func (h *requestPickupHandler) CancelPickup(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("id")
+	if pickupID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"pickup_id": {"pickup ID tidak boleh kosong"},
+		})
+	}
+
+	var req CancelPickupDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errs)
+	}
+
+	if err := h.service.CancelPickup(context.Background(), pickupID, pickupActorFromClaims(claims), req); err != nil {
+		return handlePickupError(c, err)
+	}
+
+	return utils.Success(c, "Request pickup berhasil dibatalkan")
+}
+
+func (h *requestPickupHandler) DeclinePickup(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("id")
+	if pickupID == "" {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"pickup_id": {"pickup ID tidak boleh kosong"},
+		})
+	}
+
+	var req DeclinePickupDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", errs)
+	}
+
+	if err := h.service.DeclinePickup(context.Background(), pickupID, pickupActorFromClaims(claims), req); err != nil {
+		return handlePickupError(c, err)
+	}
+
+	return utils.Success(c, "Penugasan pickup berhasil ditolak, request dikembalikan ke antrean")
+}
+
+func pickupActorFromClaims(claims *utils.JWTClaims) PickupActor {
+	return PickupActor{
+		UserID: claims.UserID,
+		Role:   claims.Role,
+	}
+}
+
+func handlePickupError(c *fiber.Ctx, err error) error {
+	var transitionErr *PickupTransitionError
+	if errors.As(err, &transitionErr) {
+		status := fiber.StatusConflict
+		if transitionErr.Code == ErrCodeActorNotAllowed || transitionErr.Code == ErrCodeNotParticipant {
+			status = fiber.StatusForbidden
+		}
+		return utils.ResponseErrorData(c, status, transitionErr.Message, transitionErr)
+	}
+
+	if strings.Contains(err.Error(), "not found") {
+		return utils.NotFound(c, err.Error())
+	}
+	if errors.Is(err, trash.ErrInvalidQuantity) || strings.Contains(err.Error(), "belum") || strings.Contains(err.Error(), "tidak memiliki harga") {
+		return utils.BadRequest(c, err.Error())
 	}
 
-	return utils.Success(c, "Berat aktual dan harga berhasil diperbarui")
+	return utils.InternalServerError(c, err.Error())
 }
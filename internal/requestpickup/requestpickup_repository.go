@@ -2,35 +2,55 @@ package requestpickup
 
 import (
 	"context"
+	"fmt"
 	"rijig/config"
 	"rijig/model"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RequestPickupRepository interface {
 	CreateRequestPickup(ctx context.Context, pickup *model.RequestPickup) error
 	GetPickupWithItemsAndAddress(ctx context.Context, id string) (*model.RequestPickup, error)
 	GetAllAutomaticRequestsWithAddress(ctx context.Context) ([]model.RequestPickup, error)
-	UpdateCollectorID(ctx context.Context, pickupID, collectorID string) error
+	GetAutomaticRequestsWithAddressByIDs(ctx context.Context, ids []string) ([]model.RequestPickup, error)
+	UpdateCollectorID(ctx context.Context, pickupID, collectorID string) (bool, error)
 	GetRequestsAssignedToCollector(ctx context.Context, collectorID string) ([]model.RequestPickup, error)
-	UpdatePickupStatusAndConfirmationTime(ctx context.Context, pickupID string, status string, confirmedAt time.Time) error
 	UpdatePickupStatus(ctx context.Context, pickupID string, status string) error
-	UpdateRequestPickupItemsAmountAndPrice(ctx context.Context, pickupID string, items []UpdateRequestPickupItemDTO) error
+	UpdateRequestPickupItemsActualAmount(ctx context.Context, pickupID string, items []UpdateRequestPickupItemDTO) error
+	GetPickupItems(ctx context.Context, pickupID string) ([]model.RequestPickupItem, error)
+	GetCollectorPrices(ctx context.Context, collectorID string) (map[string]float64, error)
+	UpdatePickupItemSettlement(ctx context.Context, itemID string, pricePerKg, subtotal float64) error
+	GetPickupForReceipt(ctx context.Context, id string) (*model.RequestPickup, error)
+	CountActiveAssignments(ctx context.Context, collectorIDs []string) (map[string]int64, error)
+	GetRoutablePickupsForCollector(ctx context.Context, collectorID string, dayStart, dayEnd time.Time) ([]model.RequestPickup, error)
+	GetPickupByIDForUpdate(ctx context.Context, id string) (*model.RequestPickup, error)
+	UpdatePickupFields(ctx context.Context, pickupID string, fields map[string]interface{}) error
+
+	WithTx(tx *gorm.DB) RequestPickupRepository
 }
 
-type requestPickupRepository struct{}
+type requestPickupRepository struct {
+	db *gorm.DB
+}
 
 func NewRequestPickupRepository() RequestPickupRepository {
-	return &requestPickupRepository{}
+	return &requestPickupRepository{db: config.DB}
+}
+
+func (r *requestPickupRepository) WithTx(tx *gorm.DB) RequestPickupRepository {
+	return &requestPickupRepository{db: tx}
 }
 
 func (r *requestPickupRepository) CreateRequestPickup(ctx context.Context, pickup *model.RequestPickup) error {
-	return config.DB.WithContext(ctx).Create(pickup).Error
+	return r.db.WithContext(ctx).Create(pickup).Error
 }
 
 func (r *requestPickupRepository) GetPickupWithItemsAndAddress(ctx context.Context, id string) (*model.RequestPickup, error) {
 	var pickup model.RequestPickup
-	err := config.DB.WithContext(ctx).
+	err := r.db.WithContext(ctx).
 		Preload("RequestItems").
 		Preload("Address").
 		Where("id = ?", id).
@@ -42,20 +62,64 @@ func (r *requestPickupRepository) GetPickupWithItemsAndAddress(ctx context.Conte
 	return &pickup, nil
 }
 
-func (r *requestPickupRepository) UpdateCollectorID(ctx context.Context, pickupID, collectorID string) error {
-	return config.DB.WithContext(ctx).
+func (r *requestPickupRepository) GetPickupByIDForUpdate(ctx context.Context, id string) (*model.RequestPickup, error) {
+	var pickup model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&pickup).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &pickup, nil
+}
+
+func (r *requestPickupRepository) UpdatePickupFields(ctx context.Context, pickupID string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).
 		Model(&model.RequestPickup{}).
 		Where("id = ?", pickupID).
-		Update("collector_id", collectorID).
+		Updates(fields).
 		Error
 }
 
+// UpdateCollectorID sets the collector of a pickup that is still waiting and has none;
+// it reports false when the pickup was taken or moved on in the meantime.
+func (r *requestPickupRepository) UpdateCollectorID(ctx context.Context, pickupID, collectorID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RequestPickup{}).
+		Where("id = ? AND collector_id IS NULL AND status_pickup = ?", pickupID, StatusWaitingCollector).
+		Update("collector_id", collectorID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *requestPickupRepository) GetAllAutomaticRequestsWithAddress(ctx context.Context) ([]model.RequestPickup, error) {
 	var pickups []model.RequestPickup
-	err := config.DB.WithContext(ctx).
+	err := r.db.WithContext(ctx).
 		Preload("RequestItems").
 		Preload("Address").
-		Where("request_method = ?", "otomatis").
+		Where("request_method = ? AND status_pickup = ? AND collector_id IS NULL", "otomatis", StatusWaitingCollector).
+		Find(&pickups).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return pickups, nil
+}
+
+func (r *requestPickupRepository) GetAutomaticRequestsWithAddressByIDs(ctx context.Context, ids []string) ([]model.RequestPickup, error) {
+	if len(ids) == 0 {
+		return []model.RequestPickup{}, nil
+	}
+
+	var pickups []model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Preload("RequestItems").
+		Preload("Address").
+		Where("request_method = ? AND status_pickup = ? AND collector_id IS NULL AND id IN ?", "otomatis", StatusWaitingCollector, ids).
 		Find(&pickups).Error
 
 	if err != nil {
@@ -66,7 +130,7 @@ func (r *requestPickupRepository) GetAllAutomaticRequestsWithAddress(ctx context
 
 func (r *requestPickupRepository) GetRequestsAssignedToCollector(ctx context.Context, collectorID string) ([]model.RequestPickup, error) {
 	var pickups []model.RequestPickup
-	err := config.DB.WithContext(ctx).
+	err := r.db.WithContext(ctx).
 		Preload("User").
 		Preload("Address").
 		Preload("RequestItems").
@@ -79,64 +143,126 @@ func (r *requestPickupRepository) GetRequestsAssignedToCollector(ctx context.Con
 	return pickups, nil
 }
 
-func (r *requestPickupRepository) UpdatePickupStatusAndConfirmationTime(ctx context.Context, pickupID string, status string, confirmedAt time.Time) error {
-	return config.DB.WithContext(ctx).
-		Model(&model.RequestPickup{}).
-		Where("id = ?", pickupID).
-		Updates(map[string]interface{}{
-			"status_pickup":             status,
-			"confirmed_by_collector_at": confirmedAt,
-		}).Error
+// GetRoutablePickupsForCollector returns the collector's confirmed and in-progress pickups
+// scheduled within [dayStart, dayEnd), plus unscheduled ones that are still open.
+func (r *requestPickupRepository) GetRoutablePickupsForCollector(ctx context.Context, collectorID string, dayStart, dayEnd time.Time) ([]model.RequestPickup, error) {
+	var pickups []model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Address").
+		Where("collector_id = ? AND status_pickup IN ?", collectorID,
+			[]string{StatusConfirmedByCollector, StatusCollectorPickingUp}).
+		Where("scheduled_start IS NULL OR (scheduled_start >= ? AND scheduled_start < ?)", dayStart, dayEnd).
+		Order("created_at ASC").
+		Find(&pickups).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return pickups, nil
 }
 
 func (r *requestPickupRepository) UpdatePickupStatus(ctx context.Context, pickupID string, status string) error {
-	return config.DB.WithContext(ctx).
+	return r.db.WithContext(ctx).
 		Model(&model.RequestPickup{}).
 		Where("id = ?", pickupID).
 		Update("status_pickup", status).
 		Error
 }
 
-func (r *requestPickupRepository) UpdateRequestPickupItemsAmountAndPrice(ctx context.Context, pickupID string, items []UpdateRequestPickupItemDTO) error {
-	// ambil collector_id dulu dari pickup
-	var pickup model.RequestPickup
-	if err := config.DB.WithContext(ctx).
-		Select("collector_id").
-		Where("id = ?", pickupID).
-		First(&pickup).Error; err != nil {
-		return err
-	}
-
+func (r *requestPickupRepository) UpdateRequestPickupItemsActualAmount(ctx context.Context, pickupID string, items []UpdateRequestPickupItemDTO) error {
 	for _, item := range items {
-		var pickupItem model.RequestPickupItem
-		err := config.DB.WithContext(ctx).
+		result := r.db.WithContext(ctx).
+			Model(&model.RequestPickupItem{}).
 			Where("id = ? AND request_pickup_id = ?", item.ItemID, pickupID).
-			First(&pickupItem).Error
-		if err != nil {
-			return err
+			Update("actual_amount", item.Amount)
+		if result.Error != nil {
+			return result.Error
 		}
-
-		var price float64
-		err = config.DB.WithContext(ctx).
-			Model(&model.AvaibleTrashByCollector{}).
-			Where("collector_id = ? AND trash_category_id = ?", pickup.CollectorID, pickupItem.TrashCategoryId).
-			Select("price").
-			Scan(&price).Error
-		if err != nil {
-			return err
-		}
-
-		finalPrice := item.Amount * price
-		err = config.DB.WithContext(ctx).
-			Model(&model.RequestPickupItem{}).
-			Where("id = ?", item.ItemID).
-			Updates(map[string]interface{}{
-				"estimated_amount": item.Amount,
-				"final_price":      finalPrice,
-			}).Error
-		if err != nil {
-			return err
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("pickup item with id %s not found", item.ItemID)
 		}
 	}
 	return nil
 }
+
+func (r *requestPickupRepository) GetPickupItems(ctx context.Context, pickupID string) ([]model.RequestPickupItem, error) {
+	var items []model.RequestPickupItem
+	err := r.db.WithContext(ctx).
+		Where("request_pickup_id = ?", pickupID).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *requestPickupRepository) GetCollectorPrices(ctx context.Context, collectorID string) (map[string]float64, error) {
+	var available []model.AvaibleTrashByCollector
+	err := r.db.WithContext(ctx).
+		Where("collector_id = ?", collectorID).
+		Find(&available).Error
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(available))
+	for _, a := range available {
+		prices[a.TrashCategoryID] = float64(a.Price)
+	}
+	return prices, nil
+}
+
+func (r *requestPickupRepository) UpdatePickupItemSettlement(ctx context.Context, itemID string, pricePerKg, subtotal float64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.RequestPickupItem{}).
+		Where("id = ?", itemID).
+		Updates(map[string]interface{}{
+			"actual_price_per_kg":   pricePerKg,
+			"actual_subtotal_price": subtotal,
+		}).Error
+}
+
+func (r *requestPickupRepository) GetPickupForReceipt(ctx context.Context, id string) (*model.RequestPickup, error) {
+	var pickup model.RequestPickup
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Address").
+		Preload("RequestItems.TrashCategory").
+		Preload("Collector.User").
+		Where("id = ?", id).
+		First(&pickup).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &pickup, nil
+}
+
+// CountActiveAssignments returns how many unfinished pickups each collector currently holds.
+func (r *requestPickupRepository) CountActiveAssignments(ctx context.Context, collectorIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(collectorIDs))
+	if len(collectorIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CollectorID string
+		Total       int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.RequestPickup{}).
+		Select("collector_id, COUNT(*) AS total").
+		Where("collector_id IN ? AND status_pickup IN ?", collectorIDs,
+			[]string{StatusWaitingCollector, StatusConfirmedByCollector, StatusCollectorPickingUp}).
+		Group("collector_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.CollectorID] = row.Total
+	}
+	return counts, nil
+}
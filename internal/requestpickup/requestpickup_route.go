@@ -3,8 +3,14 @@ package requestpickup
 import (
 	"rijig/config"
 	"rijig/internal/cart"
+	"rijig/internal/collector"
+	"rijig/internal/impact"
+	"rijig/internal/points"
+	"rijig/internal/trace"
 	"rijig/internal/trash"
+	"rijig/internal/wallet"
 	"rijig/middleware"
+	"rijig/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -14,11 +20,19 @@ func RequestPickupRouter(api fiber.Router) {
 	pickupRepo := NewRequestPickupRepository()
 	historyRepo := NewPickupStatusHistoryRepository()
 	trashRepo := trash.NewTrashRepository(config.DB)
+	collectorRepo := collector.NewCollectorRepository(config.DB)
+	slotRepo := collector.NewCollectorSlotRepository(config.DB)
 
-	cartService := cart.NewCartService(cartRepo, trashRepo)
+	cartService := cart.NewCartService(cartRepo, trashRepo, trash.NewDefaultTrashRegionalPriceService(config.DB))
+	quoteService := cart.NewCartQuoteService(cart.NewCartQuoteRepository(), cartService)
 	historyService := NewPickupStatusHistoryService(historyRepo)
+	statusMachine := NewPickupStatusMachine(config.DB, pickupRepo, historyRepo, collectorRepo, slotRepo)
+	pointsService := points.NewPointsService(config.DB, points.NewPointsRepository())
+	walletService := wallet.NewWalletService(config.DB, wallet.NewWalletRepository())
+	traceService := trace.NewTraceService(config.DB, trace.NewTraceRepository())
+	impactService := impact.NewImpactService(config.DB, impact.NewImpactRepository())
 
-	pickupService := NewRequestPickupService(trashRepo, pickupRepo, cartService, historyRepo)
+	pickupService := NewRequestPickupService(config.DB, trashRepo, pickupRepo, slotRepo, cartService, quoteService, statusMachine, pointsService, walletService, traceService, impactService)
 	pickupHandler := NewRequestPickupHandler(pickupService)
 	statuspickupHandler := NewPickupStatusHistoryHandler(historyService)
 
@@ -29,6 +43,11 @@ func RequestPickupRouter(api fiber.Router) {
 	reqpickup.Get("/pickup/:id/history", statuspickupHandler.GetStatusHistory)
 	reqpickup.Post("/otomatis", pickupHandler.CreateRequestPickup)
 	reqpickup.Put("/:id/select-collector", pickupHandler.SelectCollector)
+	reqpickup.Put("/pickup/:id/confirm", middleware.RequireRoles(utils.RolePengepul, utils.RoleAdministrator), pickupHandler.ConfirmPickup)
 	reqpickup.Put("/pickup/:id/status", pickupHandler.UpdatePickupStatus)
-	reqpickup.Put("/pickup/:id/item/update-actual", pickupHandler.UpdatePickupItemActualAmount)
+	reqpickup.Put("/pickup/:id/cancel", middleware.RequireRoles(utils.RoleMasyarakat, utils.RoleAdministrator), pickupHandler.CancelPickup)
+	reqpickup.Put("/pickup/:id/decline", middleware.RequireRoles(utils.RolePengepul, utils.RoleAdministrator), pickupHandler.DeclinePickup)
+	reqpickup.Put("/pickup/:id/item/update-actual", middleware.RequireRoles(utils.RolePengepul, utils.RoleAdministrator), pickupHandler.UpdatePickupItemActualAmount)
+	reqpickup.Put("/pickup/:id/complete", middleware.RequireRoles(utils.RolePengepul, utils.RoleAdministrator), pickupHandler.CompletePickup)
+	reqpickup.Get("/pickup/:id/receipt", pickupHandler.GetPickupReceipt)
 }
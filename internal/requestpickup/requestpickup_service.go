@@ -2,77 +2,123 @@ package requestpickup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"rijig/internal/cart"
+	"rijig/internal/collector"
+	"rijig/internal/geoindex"
+	"rijig/internal/impact"
+	"rijig/internal/points"
+	"rijig/internal/trace"
 	"rijig/internal/trash"
+	"rijig/internal/wallet"
 	"rijig/model"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type RequestPickupService interface {
-	ConvertCartToRequestPickup(ctx context.Context, userID string, req RequestPickupDTO) error
-	AssignCollectorToRequest(ctx context.Context, pickupID string, req SelectCollectorDTO) error
+	ConvertCartToRequestPickup(ctx context.Context, actor PickupActor, req RequestPickupDTO) error
+	AssignCollectorToRequest(ctx context.Context, pickupID string, actor PickupActor, req SelectCollectorDTO) error
 	FindRequestsAssignedToCollector(ctx context.Context, collectorID string) ([]AssignedPickupDTO, error)
-	ConfirmPickupByCollector(ctx context.Context, pickupID string, confirmedAt time.Time) error
-	UpdatePickupStatusToPickingUp(ctx context.Context, pickupID string) error
-	UpdateActualPickupItems(ctx context.Context, pickupID string, items []UpdateRequestPickupItemDTO) error
+	ConfirmPickupByCollector(ctx context.Context, pickupID string, actor PickupActor, confirmedAt time.Time) error
+	UpdatePickupStatusToPickingUp(ctx context.Context, pickupID string, actor PickupActor) error
+	UpdateActualPickupItems(ctx context.Context, pickupID string, actor PickupActor, items []UpdateRequestPickupItemDTO) error
+	CompletePickup(ctx context.Context, pickupID string, actor PickupActor) (*PickupReceiptDTO, error)
+	GetPickupReceipt(ctx context.Context, pickupID string, actor PickupActor) (*PickupReceiptDTO, error)
+	CancelPickup(ctx context.Context, pickupID string, actor PickupActor, req CancelPickupDTO) error
+	DeclinePickup(ctx context.Context, pickupID string, actor PickupActor, req DeclinePickupDTO) error
 }
 
 type requestPickupService struct {
-	trashRepo   trash.TrashRepositoryInterface
-	pickupRepo  RequestPickupRepository
-	cartService cart.CartService
-	historyRepo PickupStatusHistoryRepository
+	db            *gorm.DB
+	trashRepo     trash.TrashRepositoryInterface
+	pickupRepo    RequestPickupRepository
+	slotRepo      collector.CollectorSlotRepository
+	cartService   cart.CartService
+	quoteService  cart.CartQuoteService
+	statusMachine PickupStatusMachine
+	pointsService points.PointsService
+	walletService wallet.WalletService
+	traceService  trace.TraceService
+	impactService impact.ImpactService
 }
 
-func NewRequestPickupService(trashRepo trash.TrashRepositoryInterface, pickupRepo RequestPickupRepository, cartService cart.CartService, historyRepo PickupStatusHistoryRepository) RequestPickupService {
+func NewRequestPickupService(db *gorm.DB, trashRepo trash.TrashRepositoryInterface, pickupRepo RequestPickupRepository,
+	slotRepo collector.CollectorSlotRepository, cartService cart.CartService, quoteService cart.CartQuoteService,
+	statusMachine PickupStatusMachine, pointsService points.PointsService, walletService wallet.WalletService,
+	traceService trace.TraceService, impactService impact.ImpactService) RequestPickupService {
 	return &requestPickupService{
-		trashRepo:   trashRepo,
-		pickupRepo:  pickupRepo,
-		cartService: cartService,
-		historyRepo: historyRepo,
+		db:            db,
+		trashRepo:     trashRepo,
+		pickupRepo:    pickupRepo,
+		slotRepo:      slotRepo,
+		cartService:   cartService,
+		quoteService:  quoteService,
+		statusMachine: statusMachine,
+		pointsService: pointsService,
+		walletService: walletService,
+		traceService:  traceService,
+		impactService: impactService,
 	}
 }
 
-func (s *requestPickupService) ConvertCartToRequestPickup(ctx context.Context, userID string, req RequestPickupDTO) error {
-	cart, err := s.cartService.GetCart(ctx, userID)
-	if err != nil || len(cart.CartItems) == 0 {
-		return fmt.Errorf("cart kosong atau tidak ditemukan")
-	}
-
-	var requestItems []model.RequestPickupItem
-	for _, item := range cart.CartItems {
-		trash, err := s.trashRepo.GetTrashCategoryByID(ctx, item.TrashID)
-		if err != nil {
-			continue
-		}
-		subtotal := float64(item.Amount) * trash.EstimatedPrice
-
-		requestItems = append(requestItems, model.RequestPickupItem{
-			TrashCategoryId:        item.TrashID,
-			EstimatedAmount:        float64(item.Amount),
-			EstimatedPricePerKg:    trash.EstimatedPrice,
-			EstimatedSubtotalPrice: subtotal,
-		})
-	}
-
-	if len(requestItems) == 0 {
-		return fmt.Errorf("tidak ada item valid dalam cart")
-	}
+func (s *requestPickupService) ConvertCartToRequestPickup(ctx context.Context, actor PickupActor, req RequestPickupDTO) error {
+	userID := actor.UserID
+	quoteID := req.QuoteID
 
 	pickup := model.RequestPickup{
 		UserId:        userID,
 		AddressId:     req.AddressID,
 		RequestMethod: req.RequestMethod,
 		Notes:         req.Notes,
-		StatusPickup:  "waiting_collector",
-		RequestItems:  requestItems,
+		StatusPickup:  StatusWaitingCollector,
+		QuoteID:       &quoteID,
 	}
 
-	if err := s.pickupRepo.CreateRequestPickup(ctx, &pickup); err != nil {
-		return fmt.Errorf("gagal menyimpan request pickup: %w", err)
+	if req.IsScheduled() {
+		window, err := parsePickupWindow(req.ScheduledDate, req.WindowStart, req.WindowEnd)
+		if err != nil {
+			return err
+		}
+		pickup.ScheduledStart = &window.Start
+		pickup.ScheduledEnd = &window.End
 	}
 
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// prices come from the quote the user accepted, not from the current category prices
+		quote, err := s.quoteService.ClaimQuote(ctx, tx, userID, quoteID, req.AddressID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range quote.Items {
+			pickup.RequestItems = append(pickup.RequestItems, model.RequestPickupItem{
+				TrashCategoryId:        item.TrashCategoryID,
+				EstimatedAmount:        item.Amount,
+				Unit:                   item.Unit,
+				EstimatedPricePerKg:    item.PricePerKg,
+				EstimatedSubtotalPrice: item.SubTotalEstimatedPrice,
+			})
+		}
+
+		if len(pickup.RequestItems) == 0 {
+			return fmt.Errorf("tidak ada item valid dalam cart")
+		}
+
+		if err := s.pickupRepo.WithTx(tx).CreateRequestPickup(ctx, &pickup); err != nil {
+			return fmt.Errorf("gagal menyimpan request pickup: %w", err)
+		}
+		return s.statusMachine.RecordInitialStatus(ctx, tx, &pickup, actor)
+	})
+	if err != nil {
+		return err
+	}
+
+	geoindex.SyncPickup(ctx, s.db, pickup.ID)
+
 	if err := s.cartService.ClearCart(ctx, userID); err != nil {
 		return fmt.Errorf("request berhasil, tapi gagal hapus cart: %w", err)
 	}
@@ -80,11 +126,35 @@ func (s *requestPickupService) ConvertCartToRequestPickup(ctx context.Context, u
 	return nil
 }
 
-func (s *requestPickupService) AssignCollectorToRequest(ctx context.Context, pickupID string, req SelectCollectorDTO) error {
+func (s *requestPickupService) AssignCollectorToRequest(ctx context.Context, pickupID string, actor PickupActor, req SelectCollectorDTO) error {
 	if req.CollectorID == "" {
 		return fmt.Errorf("collector_id tidak boleh kosong")
 	}
-	return s.pickupRepo.UpdateCollectorID(ctx, pickupID, req.CollectorID)
+
+	// the binding booking happens when the collector confirms; this only stops the
+	// requester from picking someone who is obviously not working in that window
+	_, err := s.statusMachine.AssignCollector(ctx, pickupID, req.CollectorID, actor, func(tx *gorm.DB, pickup *model.RequestPickup) error {
+		window := windowOf(pickup)
+		if window == nil {
+			return nil
+		}
+
+		free, err := s.slotRepo.WithTx(tx).GetFreeCollectorIDs(ctx, []string{req.CollectorID}, window.Weekday, window.From, window.To, window.Date)
+		if err != nil {
+			return fmt.Errorf("gagal memeriksa jadwal collector: %w", err)
+		}
+		if !free[req.CollectorID] {
+			return &PickupTransitionError{
+				Code:    ErrCodeSlotUnavailable,
+				Message: fmt.Sprintf("collector tidak tersedia pada %s %s-%s", window.Date.Format("2006-01-02"), window.From, window.To),
+				From:    pickup.StatusPickup,
+				To:      pickup.StatusPickup,
+				Role:    actor.Role,
+			}
+		}
+		return nil
+	})
+	return err
 }
 
 func (s *requestPickupService) FindRequestsAssignedToCollector(ctx context.Context, collectorID string) ([]AssignedPickupDTO, error) {
@@ -101,37 +171,262 @@ func (s *requestPickupService) FindRequestsAssignedToCollector(ctx context.Conte
 		}
 
 		result = append(result, AssignedPickupDTO{
-			PickupID:     p.ID,
-			UserID:       p.UserId,
-			UserName:     p.User.Name,
-			Latitude:     p.Address.Latitude,
-			Longitude:    p.Address.Longitude,
-			Notes:        p.Notes,
-			MatchedTrash: matchedTrash,
+			PickupID:       p.ID,
+			UserID:         p.UserId,
+			UserName:       p.User.Name,
+			Latitude:       p.Address.Latitude,
+			Longitude:      p.Address.Longitude,
+			Notes:          p.Notes,
+			MatchedTrash:   matchedTrash,
+			ScheduledStart: p.ScheduledStart,
+			ScheduledEnd:   p.ScheduledEnd,
 		})
 	}
 
 	return result, nil
 }
 
-func (s *requestPickupService) ConfirmPickupByCollector(ctx context.Context, pickupID string, confirmedAt time.Time) error {
-	return s.pickupRepo.UpdatePickupStatusAndConfirmationTime(ctx, pickupID, "confirmed_by_collector", confirmedAt)
+func (s *requestPickupService) ConfirmPickupByCollector(ctx context.Context, pickupID string, actor PickupActor, confirmedAt time.Time) error {
+	_, err := s.statusMachine.Transition(ctx, pickupID, StatusConfirmedByCollector, actor, PickupReason{}, func(tx *gorm.DB, pickup *model.RequestPickup) error {
+		pickup.ConfirmedByCollectorAt = &confirmedAt
+		return s.pickupRepo.WithTx(tx).UpdatePickupFields(ctx, pickup.ID, map[string]interface{}{
+			"confirmed_by_collector_at": confirmedAt,
+		})
+	})
+	return err
+}
+
+func (s *requestPickupService) UpdatePickupStatusToPickingUp(ctx context.Context, pickupID string, actor PickupActor) error {
+	_, err := s.statusMachine.Transition(ctx, pickupID, StatusCollectorPickingUp, actor, PickupReason{}, nil)
+	return err
 }
 
-func (s *requestPickupService) UpdatePickupStatusToPickingUp(ctx context.Context, pickupID string) error {
-	err := s.pickupRepo.UpdatePickupStatus(ctx, pickupID, "collector_are_picking_up")
+func (s *requestPickupService) UpdateActualPickupItems(ctx context.Context, pickupID string, actor PickupActor, items []UpdateRequestPickupItemDTO) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pickup, err := s.pickupRepo.WithTx(tx).GetPickupByIDForUpdate(ctx, pickupID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("pickup with id %s not found", pickupID)
+			}
+			return err
+		}
+
+		if pickup.StatusPickup != StatusCollectorPickingUp {
+			return &PickupTransitionError{
+				Code:    ErrCodeInvalidTransition,
+				Message: fmt.Sprintf("berat aktual hanya dapat diubah saat status '%s'", StatusCollectorPickingUp),
+				From:    pickup.StatusPickup,
+				To:      pickup.StatusPickup,
+				Role:    actor.Role,
+			}
+		}
+
+		if err := s.statusMachine.EnsureParticipant(ctx, tx, pickup, actor); err != nil {
+			return err
+		}
+
+		if err := s.validateActualAmounts(ctx, tx, pickupID, items); err != nil {
+			return err
+		}
+
+		return s.pickupRepo.WithTx(tx).UpdateRequestPickupItemsActualAmount(ctx, pickupID, items)
+	})
+}
+
+// validateActualAmounts rejects fractional amounts for items counted in pieces.
+func (s *requestPickupService) validateActualAmounts(ctx context.Context, tx *gorm.DB, pickupID string, items []UpdateRequestPickupItemDTO) error {
+	pickupItems, err := s.pickupRepo.WithTx(tx).GetPickupItems(ctx, pickupID)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to get pickup items: %w", err)
+	}
+
+	units := make(map[string]string, len(pickupItems))
+	for _, item := range pickupItems {
+		units[item.ID] = item.Unit
+	}
+	for _, item := range items {
+		if err := trash.ValidateWholeUnits(units[item.ItemID], item.Amount); err != nil {
+			return err
+		}
 	}
-	return s.historyRepo.CreateStatusHistory(ctx, model.PickupStatusHistory{
-		RequestID:     pickupID,
-		Status:        "collector_are_picking_up",
-		ChangedAt:     time.Now(),
-		ChangedByID:   "collector",
-		ChangedByRole: "collector",
+	return nil
+}
+
+// CompletePickup locks the actual weights, prices every item with the assigned
+// collector's buy price and stores the settled FinalPrice.
+func (s *requestPickupService) CompletePickup(ctx context.Context, pickupID string, actor PickupActor) (*PickupReceiptDTO, error) {
+	_, err := s.statusMachine.Transition(ctx, pickupID, StatusCompleted, actor, PickupReason{}, func(tx *gorm.DB, pickup *model.RequestPickup) error {
+		if pickup.CollectorID == nil {
+			return fmt.Errorf("pickup belum memiliki collector")
+		}
+
+		repo := s.pickupRepo.WithTx(tx)
+		items, err := repo.GetPickupItems(ctx, pickup.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get pickup items: %w", err)
+		}
+
+		prices, err := repo.GetCollectorPrices(ctx, *pickup.CollectorID)
+		if err != nil {
+			return fmt.Errorf("failed to get collector prices: %w", err)
+		}
+
+		var finalPrice float64
+		for _, item := range items {
+			if item.ActualAmount == nil {
+				return fmt.Errorf("berat aktual untuk item %s belum diisi", item.ID)
+			}
+
+			price, ok := prices[item.TrashCategoryId]
+			if !ok {
+				return fmt.Errorf("collector tidak memiliki harga untuk kategori %s", item.TrashCategoryId)
+			}
+
+			subtotal := *item.ActualAmount * price
+			if err := repo.UpdatePickupItemSettlement(ctx, item.ID, price, subtotal); err != nil {
+				return fmt.Errorf("failed to settle pickup item: %w", err)
+			}
+			finalPrice += subtotal
+		}
+
+		now := time.Now()
+		pickup.FinalPrice = finalPrice
+		pickup.CompletedAt = &now
+		if err := repo.UpdatePickupFields(ctx, pickup.ID, map[string]interface{}{
+			"final_price":  finalPrice,
+			"completed_at": now,
+		}); err != nil {
+			return err
+		}
+
+		// points, the collector's payable, the source lots and the environmental impact
+		// are recorded in the same transaction, so a pickup is never completed without
+		// them or booked twice
+		if _, err := s.pointsService.CreditPickup(ctx, tx, pickup, items); err != nil {
+			return err
+		}
+		if _, err := s.walletService.RecordPickupSettlement(ctx, tx, pickup); err != nil {
+			return err
+		}
+		if err := s.traceService.RecordPickupLots(ctx, tx, pickup, items); err != nil {
+			return err
+		}
+		return s.impactService.RecordPickupImpact(ctx, tx, pickup, items)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPickupReceipt(ctx, pickupID, actor)
+}
+
+func (s *requestPickupService) GetPickupReceipt(ctx context.Context, pickupID string, actor PickupActor) (*PickupReceiptDTO, error) {
+	pickup, err := s.pickupRepo.GetPickupForReceipt(ctx, pickupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pickup with id %s not found", pickupID)
+		}
+		return nil, err
+	}
+
+	if err := s.statusMachine.EnsureParticipant(ctx, s.db, pickup, actor); err != nil {
+		return nil, err
+	}
+
+	if pickup.StatusPickup != StatusCompleted {
+		return nil, &PickupTransitionError{
+			Code:    ErrCodeInvalidTransition,
+			Message: "struk hanya tersedia untuk pickup yang sudah selesai",
+			From:    pickup.StatusPickup,
+			To:      pickup.StatusPickup,
+			Role:    actor.Role,
+		}
+	}
+
+	return buildPickupReceipt(pickup), nil
 }
 
-func (s *requestPickupService) UpdateActualPickupItems(ctx context.Context, pickupID string, items []UpdateRequestPickupItemDTO) error {
-	return s.pickupRepo.UpdateRequestPickupItemsAmountAndPrice(ctx, pickupID, items)
+func buildPickupReceipt(pickup *model.RequestPickup) *PickupReceiptDTO {
+	receipt := &PickupReceiptDTO{
+		ReceiptNumber: fmt.Sprintf("RJG-%s-%s", pickup.CompletedAt.Format("20060102"), strings.ToUpper(pickup.ID[:8])),
+		PickupID:      pickup.ID,
+		RequestMethod: pickup.RequestMethod,
+		Status:        pickup.StatusPickup,
+		FinalPrice:    pickup.FinalPrice,
+		Items:         make([]ReceiptItemDTO, 0, len(pickup.RequestItems)),
+		TotalsByUnit:  make(map[string]float64),
+		CreatedAt:     pickup.CreatedAt.Format(time.RFC3339),
+		CompletedAt:   pickup.CompletedAt.Format(time.RFC3339),
+	}
+
+	if pickup.User != nil {
+		receipt.Requester = ReceiptPartyDTO{ID: pickup.User.ID, Name: pickup.User.Name, Phone: pickup.User.Phone}
+	}
+
+	if pickup.Collector != nil {
+		receipt.Collector = &ReceiptPartyDTO{
+			ID:    pickup.Collector.ID,
+			Name:  pickup.Collector.User.Name,
+			Phone: pickup.Collector.User.Phone,
+		}
+	}
+
+	if pickup.Address != nil {
+		receipt.Address = fmt.Sprintf("%s, %s, %s, %s, %s %s", pickup.Address.Detail, pickup.Address.Village,
+			pickup.Address.District, pickup.Address.Regency, pickup.Address.Province, pickup.Address.PostalCode)
+	}
+
+	for _, item := range pickup.RequestItems {
+		var actual float64
+		if item.ActualAmount != nil {
+			actual = *item.ActualAmount
+		}
+
+		receiptItem := ReceiptItemDTO{
+			ItemID:              item.ID,
+			TrashCategoryID:     item.TrashCategoryId,
+			EstimatedAmount:     item.EstimatedAmount,
+			ActualAmount:        actual,
+			Unit:                item.Unit,
+			PricePerKg:          item.ActualPricePerKg,
+			EstimatedSubtotal:   item.EstimatedSubtotalPrice,
+			ActualSubtotalPrice: item.ActualSubtotalPrice,
+		}
+		if item.TrashCategory != nil {
+			receiptItem.TrashName = item.TrashCategory.Name
+		}
+
+		receipt.Items = append(receipt.Items, receiptItem)
+		receipt.TotalEstimated += item.EstimatedSubtotalPrice
+		receipt.TotalsByUnit[item.Unit] += actual
+		if item.Unit == trash.UnitKilogram {
+			receipt.TotalWeight += actual
+		}
+	}
+
+	return receipt
+}
+
+func (s *requestPickupService) CancelPickup(ctx context.Context, pickupID string, actor PickupActor, req CancelPickupDTO) error {
+	reason := PickupReason{Code: req.ReasonCode, Notes: req.Notes}
+	_, err := s.statusMachine.Transition(ctx, pickupID, StatusCanceled, actor, reason, s.releaseCollector(ctx))
+	return err
+}
+
+func (s *requestPickupService) DeclinePickup(ctx context.Context, pickupID string, actor PickupActor, req DeclinePickupDTO) error {
+	reason := PickupReason{Code: req.ReasonCode, Notes: req.Notes}
+	_, err := s.statusMachine.Transition(ctx, pickupID, StatusWaitingCollector, actor, reason, s.releaseCollector(ctx))
+	return err
+}
+
+// releaseCollector clears the collector assignment so the request can be matched again.
+func (s *requestPickupService) releaseCollector(ctx context.Context) PickupApplyFunc {
+	return func(tx *gorm.DB, pickup *model.RequestPickup) error {
+		pickup.CollectorID = nil
+		pickup.ConfirmedByCollectorAt = nil
+		return s.pickupRepo.WithTx(tx).UpdatePickupFields(ctx, pickup.ID, map[string]interface{}{
+			"collector_id":              nil,
+			"confirmed_by_collector_at": nil,
+		})
+	}
 }
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	PaymentPoints = "points"
+	PaymentCash   = "cash"
+	// PaymentOnline orders are paid through the payment gateway before the store
+	// prepares them.
+	PaymentOnline = "online"
+)
+
+type RequestStoreDTO struct {
+	StoreName      string `json:"store_name"`
+	StoreLogo      string `json:"store_logo"`
+	StoreBanner    string `json:"store_banner"`
+	StoreInfo      string `json:"store_info"`
+	StoreAddressID string `json:"store_address_id"`
+}
+
+func (r *RequestStoreDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.StoreName) == "" {
+		errors["store_name"] = append(errors["store_name"], "nama toko wajib diisi")
+	}
+	if strings.TrimSpace(r.StoreInfo) == "" {
+		errors["store_info"] = append(errors["store_info"], "info toko wajib diisi")
+	}
+	if strings.TrimSpace(r.StoreAddressID) == "" {
+		errors["store_address_id"] = append(errors["store_address_id"], "alamat toko wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type StoreResponseDTO struct {
+	ID             string `json:"id"`
+	UserID         string `json:"user_id"`
+	StoreName      string `json:"store_name"`
+	StoreLogo      string `json:"store_logo"`
+	StoreBanner    string `json:"store_banner"`
+	StoreInfo      string `json:"store_info"`
+	StoreAddressID string `json:"store_address_id"`
+	Followers      int    `json:"followers"`
+	TotalProduct   int    `json:"total_product"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+type RequestProductDTO struct {
+	ProductName string   `json:"product_name"`
+	Description string   `json:"description"`
+	PointPrice  int64    `json:"point_price"`
+	Price       float64  `json:"price"`
+	Quantity    int      `json:"quantity"`
+	Images      []string `json:"images"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+func (r *RequestProductDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.ProductName) == "" {
+		errors["product_name"] = append(errors["product_name"], "nama produk wajib diisi")
+	}
+	if r.PointPrice < 0 {
+		errors["point_price"] = append(errors["point_price"], "harga poin tidak boleh negatif")
+	}
+	if r.Price < 0 {
+		errors["price"] = append(errors["price"], "harga tidak boleh negatif")
+	}
+	if r.PointPrice == 0 && r.Price == 0 {
+		errors["price"] = append(errors["price"], "produk harus memiliki harga poin atau harga uang")
+	}
+	if r.Quantity < 0 {
+		errors["quantity"] = append(errors["quantity"], "stok tidak boleh negatif")
+	}
+	for i, image := range r.Images {
+		if strings.TrimSpace(image) == "" {
+			errors[fmt.Sprintf("images[%d]", i)] = append(errors[fmt.Sprintf("images[%d]", i)], "url gambar tidak boleh kosong")
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type ProductResponseDTO struct {
+	ID          string   `json:"id"`
+	StoreID     string   `json:"store_id"`
+	ProductName string   `json:"product_name"`
+	Description string   `json:"description,omitempty"`
+	PointPrice  int64    `json:"point_price"`
+	Price       float64  `json:"price"`
+	Quantity    int      `json:"quantity"`
+	Saled       int      `json:"saled"`
+	IsActive    bool     `json:"is_active"`
+	Images      []string `json:"images"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type StoreOrderItemRequestDTO struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type PlaceStoreOrderDTO struct {
+	StoreID       string                     `json:"store_id"`
+	PaymentMethod string                     `json:"payment_method"`
+	Items         []StoreOrderItemRequestDTO `json:"items"`
+	Notes         string                     `json:"notes,omitempty"`
+}
+
+func (r *PlaceStoreOrderDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.StoreID) == "" {
+		errors["store_id"] = append(errors["store_id"], "store_id wajib diisi")
+	}
+	if r.PaymentMethod != PaymentPoints && r.PaymentMethod != PaymentCash && r.PaymentMethod != PaymentOnline {
+		errors["payment_method"] = append(errors["payment_method"], "metode pembayaran harus points, cash, atau online")
+	}
+	if len(r.Items) == 0 {
+		errors["items"] = append(errors["items"], "minimal satu produk harus dipesan")
+	}
+	for i, item := range r.Items {
+		if strings.TrimSpace(item.ProductID) == "" {
+			errors[fmt.Sprintf("items[%d].product_id", i)] = append(errors[fmt.Sprintf("items[%d].product_id", i)], "product_id wajib diisi")
+		}
+		if item.Quantity <= 0 {
+			errors[fmt.Sprintf("items[%d].quantity", i)] = append(errors[fmt.Sprintf("items[%d].quantity", i)], "jumlah harus lebih dari 0")
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type CompleteRefundDTO struct {
+	Reference string `json:"reference"`
+}
+
+func (r *CompleteRefundDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.Reference) == "" {
+		errors["reference"] = append(errors["reference"], "referensi pengembalian dana wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type CancelStoreOrderDTO struct {
+	Reason string `json:"reason"`
+}
+
+func (r *CancelStoreOrderDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.Reason) == "" {
+		errors["reason"] = append(errors["reason"], "alasan pembatalan wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type StoreOrderItemDTO struct {
+	ProductID      string  `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	Quantity       int     `json:"quantity"`
+	PointPrice     int64   `json:"point_price"`
+	Price          float64 `json:"price"`
+	SubtotalPoints int64   `json:"subtotal_points"`
+	SubtotalPrice  float64 `json:"subtotal_price"`
+}
+
+type StoreOrderResponseDTO struct {
+	ID            string              `json:"id"`
+	StoreID       string              `json:"store_id"`
+	StoreName     string              `json:"store_name,omitempty"`
+	UserID        string              `json:"user_id"`
+	Status        string              `json:"status"`
+	PaymentMethod string              `json:"payment_method"`
+	TotalPoints   int64               `json:"total_points"`
+	TotalPrice    float64             `json:"total_price"`
+	Notes         string              `json:"notes,omitempty"`
+	Items         []StoreOrderItemDTO `json:"items"`
+	CancelReason  string              `json:"cancel_reason,omitempty"`
+	PaidAt        string              `json:"paid_at,omitempty"`
+	ReadyAt       string              `json:"ready_at,omitempty"`
+	HandedOverAt  string              `json:"handed_over_at,omitempty"`
+	CancelledAt   string              `json:"cancelled_at,omitempty"`
+	// Refund fields are set on cancelled online orders that had been paid.
+	RefundStatus    string  `json:"refund_status,omitempty"`
+	RefundAmount    float64 `json:"refund_amount,omitempty"`
+	RefundReference string  `json:"refund_reference,omitempty"`
+	RefundedAt      string  `json:"refunded_at,omitempty"`
+	CreatedAt       string  `json:"created_at"`
+}
@@ -0,0 +1,366 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"rijig/internal/points"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StoreHandler struct {
+	storeService StoreService
+	orderService StoreOrderService
+}
+
+func NewStoreHandler(storeService StoreService, orderService StoreOrderService) *StoreHandler {
+	return &StoreHandler{storeService: storeService, orderService: orderService}
+}
+
+func (h *StoreHandler) CreateStore(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequestStoreDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	store, err := h.storeService.CreateStore(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Toko berhasil dibuat", store)
+}
+
+func (h *StoreHandler) GetMyStore(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	store, err := h.storeService.GetMyStore(context.Background(), claims.UserID)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Toko berhasil diambil", store)
+}
+
+func (h *StoreHandler) UpdateMyStore(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequestStoreDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	store, err := h.storeService.UpdateMyStore(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Toko berhasil diperbarui", store)
+}
+
+func (h *StoreHandler) ListStores(c *fiber.Ctx) error {
+	limit, offset, page := utils.ParsePagination(c, 10)
+
+	stores, total, err := h.storeService.ListStores(context.Background(), c.Query("q"), limit, offset)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar toko berhasil diambil", stores, page, limit, int(total))
+}
+
+func (h *StoreHandler) GetStore(c *fiber.Ctx) error {
+	store, err := h.storeService.GetStore(context.Background(), c.Params("store_id"))
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Toko berhasil diambil", store)
+}
+
+func (h *StoreHandler) ListStoreProducts(c *fiber.Ctx) error {
+	products, err := h.storeService.ListStoreProducts(context.Background(), c.Params("store_id"))
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Daftar produk berhasil diambil", products)
+}
+
+func (h *StoreHandler) ListMyProducts(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	products, err := h.storeService.ListMyProducts(context.Background(), claims.UserID)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Daftar produk berhasil diambil", products)
+}
+
+func (h *StoreHandler) GetProduct(c *fiber.Ctx) error {
+	product, err := h.storeService.GetProduct(context.Background(), c.Params("product_id"))
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Produk berhasil diambil", product)
+}
+
+func (h *StoreHandler) CreateProduct(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequestProductDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	product, err := h.storeService.CreateProduct(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Produk berhasil ditambahkan", product)
+}
+
+func (h *StoreHandler) UpdateProduct(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequestProductDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	product, err := h.storeService.UpdateProduct(context.Background(), claims.UserID, c.Params("product_id"), req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Produk berhasil diperbarui", product)
+}
+
+func (h *StoreHandler) DeactivateProduct(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.storeService.DeactivateProduct(context.Background(), claims.UserID, c.Params("product_id")); err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.Success(c, "Produk berhasil dinonaktifkan")
+}
+
+func (h *StoreHandler) PlaceOrder(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req PlaceStoreOrderDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	order, err := h.orderService.PlaceOrder(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Pesanan berhasil dibuat", order)
+}
+
+func (h *StoreHandler) GetOrder(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := h.orderService.GetOrder(context.Background(), orderActorFromClaims(claims), c.Params("order_id"))
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pesanan berhasil diambil", order)
+}
+
+func (h *StoreHandler) ListMyOrders(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	limit, offset, page := utils.ParsePagination(c, 10)
+	orders, total, err := h.orderService.ListMyOrders(context.Background(), claims.UserID, c.Query("status"), limit, offset)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar pesanan berhasil diambil", orders, page, limit, int(total))
+}
+
+func (h *StoreHandler) ListStoreOrders(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	limit, offset, page := utils.ParsePagination(c, 10)
+	orders, total, err := h.orderService.ListStoreOrders(context.Background(), claims.UserID, c.Query("status"), limit, offset)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar pesanan toko berhasil diambil", orders, page, limit, int(total))
+}
+
+func (h *StoreHandler) MarkOrderReady(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := h.orderService.MarkReady(context.Background(), orderActorFromClaims(claims), c.Params("order_id"))
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pesanan siap diambil", order)
+}
+
+func (h *StoreHandler) HandOverOrder(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := h.orderService.HandOver(context.Background(), orderActorFromClaims(claims), c.Params("order_id"))
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pesanan berhasil diserahkan", order)
+}
+
+func (h *StoreHandler) CancelOrder(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CancelStoreOrderDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	order, err := h.orderService.CancelOrder(context.Background(), orderActorFromClaims(claims), c.Params("order_id"), req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pesanan berhasil dibatalkan", order)
+}
+
+func (h *StoreHandler) ListRefunds(c *fiber.Ctx) error {
+	limit, offset, page := utils.ParsePagination(c, 10)
+	orders, total, err := h.orderService.ListRefunds(context.Background(), c.Query("refund_status"), limit, offset)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar pengembalian dana berhasil diambil", orders, page, limit, int(total))
+}
+
+func (h *StoreHandler) CompleteRefund(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CompleteRefundDTO
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c)
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	order, err := h.orderService.CompleteRefund(context.Background(), claims.UserID, c.Params("order_id"), req)
+	if err != nil {
+		return handleStoreError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Pengembalian dana berhasil dicatat", order)
+}
+
+func orderActorFromClaims(claims *utils.JWTClaims) OrderActor {
+	return OrderActor{UserID: claims.UserID, Role: claims.Role}
+}
+
+func invalidBody(c *fiber.Ctx) error {
+	return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+		"body": {"format JSON tidak valid"},
+	})
+}
+
+func handleStoreError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrStoreNotFound), errors.Is(err, ErrProductNotFound), errors.Is(err, ErrOrderNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrOrderForbidden):
+		return utils.Forbidden(c, err.Error())
+	case errors.Is(err, ErrStoreAlreadyExists), errors.Is(err, ErrOutOfStock), errors.Is(err, ErrInvalidOrderTransition),
+		errors.Is(err, ErrOrderNotPayable), errors.Is(err, ErrOrderUnpaid), errors.Is(err, ErrRefundNotPending):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	case errors.Is(err, points.ErrInsufficientPoints), errors.Is(err, ErrStoreAddress), errors.Is(err, ErrOwnStore), errors.Is(err, ErrProductUnavailable),
+		errors.Is(err, ErrProductNotRedeemable), errors.Is(err, ErrProductNotForSale):
+		return utils.BadRequest(c, err.Error())
+	case strings.HasPrefix(err.Error(), "forbidden"):
+		return utils.Forbidden(c, err.Error())
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
@@ -0,0 +1,511 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"rijig/internal/points"
+	"rijig/model"
+	"rijig/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OrderPlaced     = "placed"
+	OrderReady      = "ready"
+	OrderHandedOver = "handed_over"
+	OrderCancelled  = "cancelled"
+
+	RefundPending   = "pending"
+	RefundCompleted = "refunded"
+)
+
+var (
+	ErrOrderNotFound          = errors.New("pesanan tidak ditemukan")
+	ErrOrderForbidden         = errors.New("forbidden: anda tidak memiliki akses ke pesanan ini")
+	ErrOwnStore               = errors.New("tidak dapat memesan dari toko sendiri")
+	ErrProductUnavailable     = errors.New("produk tidak tersedia di toko ini")
+	ErrProductNotRedeemable   = errors.New("produk tidak dapat ditukar dengan poin")
+	ErrProductNotForSale      = errors.New("produk tidak dijual dengan uang")
+	ErrOutOfStock             = errors.New("stok produk tidak mencukupi")
+	ErrInvalidOrderTransition = errors.New("status pesanan tidak dapat diubah")
+	ErrOrderNotPayable        = errors.New("pesanan tidak dapat dibayar secara online")
+	ErrOrderUnpaid            = errors.New("pesanan online belum dibayar")
+	ErrRefundNotPending       = errors.New("pesanan tidak memiliki pengembalian dana yang tertunda")
+)
+
+// OrderActor is whoever acts on an order: the buyer, the store owner or an admin.
+type OrderActor struct {
+	UserID string
+	Role   string
+}
+
+// orderTransitions lists, per current status, the statuses an order may move to.
+var orderTransitions = map[string][]string{
+	OrderPlaced: {OrderReady, OrderCancelled},
+	OrderReady:  {OrderHandedOver, OrderCancelled},
+}
+
+// OrderPaymentCanceller withdraws the open gateway charges of an order. The payment
+// package implements it; store cannot import payment, which depends on store.
+type OrderPaymentCanceller interface {
+	CancelOrderPayments(ctx context.Context, orderID string) error
+}
+
+type StoreOrderService interface {
+	PlaceOrder(ctx context.Context, userID string, req PlaceStoreOrderDTO) (*StoreOrderResponseDTO, error)
+	GetOrder(ctx context.Context, actor OrderActor, orderID string) (*StoreOrderResponseDTO, error)
+	ListMyOrders(ctx context.Context, userID, status string, limit, offset int) ([]StoreOrderResponseDTO, int64, error)
+	ListStoreOrders(ctx context.Context, ownerID, status string, limit, offset int) ([]StoreOrderResponseDTO, int64, error)
+	MarkReady(ctx context.Context, actor OrderActor, orderID string) (*StoreOrderResponseDTO, error)
+	HandOver(ctx context.Context, actor OrderActor, orderID string) (*StoreOrderResponseDTO, error)
+	CancelOrder(ctx context.Context, actor OrderActor, orderID string, req CancelStoreOrderDTO) (*StoreOrderResponseDTO, error)
+	GetPayableOrder(ctx context.Context, userID, orderID string) (*model.StoreOrder, error)
+	MarkOrderPaid(ctx context.Context, tx *gorm.DB, orderID, reference string, paidAt time.Time) error
+	ListRefunds(ctx context.Context, refundStatus string, limit, offset int) ([]StoreOrderResponseDTO, int64, error)
+	CompleteRefund(ctx context.Context, adminID, orderID string, req CompleteRefundDTO) (*StoreOrderResponseDTO, error)
+}
+
+type storeOrderService struct {
+	db            *gorm.DB
+	repo          StoreRepository
+	pointsService points.PointsService
+	payments      OrderPaymentCanceller
+}
+
+// NewStoreOrderService builds the order service. payments may be nil when the caller
+// never cancels orders.
+func NewStoreOrderService(db *gorm.DB, repo StoreRepository, pointsService points.PointsService, payments OrderPaymentCanceller) StoreOrderService {
+	return &storeOrderService{db: db, repo: repo, pointsService: pointsService, payments: payments}
+}
+
+// PlaceOrder reserves stock for every item and, for redemptions, holds the points, all
+// in one transaction: if anything is short, nothing is reserved.
+func (s *storeOrderService) PlaceOrder(ctx context.Context, userID string, req PlaceStoreOrderDTO) (*StoreOrderResponseDTO, error) {
+	quantities := make(map[string]int)
+	for _, item := range req.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	productIDs := make([]string, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	// reserve in a fixed order so concurrent orders lock products the same way
+	sort.Strings(productIDs)
+
+	order := &model.StoreOrder{
+		StoreID:       req.StoreID,
+		UserID:        userID,
+		Status:        OrderPlaced,
+		PaymentMethod: req.PaymentMethod,
+		Notes:         req.Notes,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		store, err := repo.GetStoreByID(ctx, req.StoreID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStoreNotFound
+			}
+			return err
+		}
+		if store.UserID == userID {
+			return ErrOwnStore
+		}
+
+		for _, productID := range productIDs {
+			quantity := quantities[productID]
+
+			product, err := repo.GetProductByID(ctx, productID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrProductNotFound
+				}
+				return err
+			}
+			if product.StoreID != store.ID || !product.IsActive {
+				return fmt.Errorf("%w: %s", ErrProductUnavailable, product.ProductName)
+			}
+			if req.PaymentMethod == PaymentPoints && product.PointPrice <= 0 {
+				return fmt.Errorf("%w: %s", ErrProductNotRedeemable, product.ProductName)
+			}
+			if req.PaymentMethod != PaymentPoints && product.Price <= 0 {
+				return fmt.Errorf("%w: %s", ErrProductNotForSale, product.ProductName)
+			}
+
+			reserved, err := repo.ReserveStock(ctx, productID, quantity)
+			if err != nil {
+				return err
+			}
+			if !reserved {
+				return fmt.Errorf("%w: %s", ErrOutOfStock, product.ProductName)
+			}
+
+			item := model.StoreOrderItem{
+				ProductID:   productID,
+				ProductName: product.ProductName,
+				Quantity:    quantity,
+			}
+			if req.PaymentMethod == PaymentPoints {
+				item.PointPrice = product.PointPrice
+				item.SubtotalPoints = product.PointPrice * int64(quantity)
+			} else {
+				item.Price = product.Price
+				item.SubtotalPrice = product.Price * float64(quantity)
+			}
+			order.Items = append(order.Items, item)
+			order.TotalPoints += item.SubtotalPoints
+			order.TotalPrice += item.SubtotalPrice
+		}
+
+		if err := repo.CreateOrder(ctx, order); err != nil {
+			return err
+		}
+
+		if order.PaymentMethod == PaymentPoints {
+			if _, err := s.pointsService.HoldPoints(ctx, tx, userID, order.ID, order.TotalPoints); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getOrder(ctx, order.ID)
+}
+
+func (s *storeOrderService) GetOrder(ctx context.Context, actor OrderActor, orderID string) (*StoreOrderResponseDTO, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if actor.Role != utils.RoleAdministrator && order.UserID != actor.UserID &&
+		(order.Store == nil || order.Store.UserID != actor.UserID) {
+		return nil, ErrOrderForbidden
+	}
+	return toStoreOrderResponse(order), nil
+}
+
+func (s *storeOrderService) ListMyOrders(ctx context.Context, userID, status string, limit, offset int) ([]StoreOrderResponseDTO, int64, error) {
+	return s.listOrders(ctx, StoreOrderFilter{UserID: userID, Status: status}, limit, offset)
+}
+
+func (s *storeOrderService) ListStoreOrders(ctx context.Context, ownerID, status string, limit, offset int) ([]StoreOrderResponseDTO, int64, error) {
+	store, err := s.repo.GetStoreByUserID(ctx, ownerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrStoreNotFound
+		}
+		return nil, 0, err
+	}
+	return s.listOrders(ctx, StoreOrderFilter{StoreID: store.ID, Status: status}, limit, offset)
+}
+
+func (s *storeOrderService) listOrders(ctx context.Context, filter StoreOrderFilter, limit, offset int) ([]StoreOrderResponseDTO, int64, error) {
+	orders, total, err := s.repo.ListOrders(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]StoreOrderResponseDTO, 0, len(orders))
+	for i := range orders {
+		responses = append(responses, *toStoreOrderResponse(&orders[i]))
+	}
+	return responses, total, nil
+}
+
+func (s *storeOrderService) MarkReady(ctx context.Context, actor OrderActor, orderID string) (*StoreOrderResponseDTO, error) {
+	return s.transition(ctx, actor, orderID, OrderReady, func(tx *gorm.DB, order *model.StoreOrder, now time.Time) (map[string]interface{}, error) {
+		if order.PaymentMethod == PaymentOnline && order.PaidAt == nil {
+			return nil, ErrOrderUnpaid
+		}
+		return map[string]interface{}{"ready_at": now}, nil
+	})
+}
+
+// HandOver completes the order; the held points are only now counted as redeemed.
+func (s *storeOrderService) HandOver(ctx context.Context, actor OrderActor, orderID string) (*StoreOrderResponseDTO, error) {
+	return s.transition(ctx, actor, orderID, OrderHandedOver, func(tx *gorm.DB, order *model.StoreOrder, now time.Time) (map[string]interface{}, error) {
+		if order.PaymentMethod == PaymentPoints {
+			if _, err := s.pointsService.CapturePoints(ctx, tx, order.ID, order.TotalPoints); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]interface{}{"handed_over_at": now}, nil
+	})
+}
+
+// CancelOrder puts the reserved stock back and refunds held points. The open charges
+// of an unpaid online order are withdrawn once the cancellation is committed; one the
+// buyer pays anyway is recorded as a refund by MarkOrderPaid.
+func (s *storeOrderService) CancelOrder(ctx context.Context, actor OrderActor, orderID string, req CancelStoreOrderDTO) (*StoreOrderResponseDTO, error) {
+	order, err := s.transition(ctx, actor, orderID, OrderCancelled, func(tx *gorm.DB, order *model.StoreOrder, now time.Time) (map[string]interface{}, error) {
+		repo := s.repo.WithTx(tx)
+		for _, item := range order.Items {
+			if err := repo.ReleaseStock(ctx, item.ProductID, item.Quantity); err != nil {
+				return nil, err
+			}
+		}
+
+		if order.PaymentMethod == PaymentPoints {
+			if _, err := s.pointsService.ReleasePoints(ctx, tx, order.UserID, order.ID, order.TotalPoints); err != nil {
+				return nil, err
+			}
+		}
+
+		fields := map[string]interface{}{
+			"cancelled_at":  now,
+			"cancelled_by":  actor.UserID,
+			"cancel_reason": strings.TrimSpace(req.Reason),
+		}
+
+		// the gateway has no refund call, so the money owed back is recorded and an
+		// admin settles it with the buyer
+		if order.PaymentMethod == PaymentOnline && order.PaidAt != nil {
+			fields["refund_status"] = RefundPending
+			fields["refund_amount"] = order.TotalPrice
+		}
+		return fields, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if order.PaymentMethod == PaymentOnline && order.PaidAt == "" && s.payments != nil {
+		if err := s.payments.CancelOrderPayments(ctx, order.ID); err != nil {
+			log.Printf("[STORE] failed to cancel open payments of order %s: %v", order.ID, err)
+		}
+	}
+	return order, nil
+}
+
+// ListRefunds lists cancelled online orders by refund status, pending first by default.
+func (s *storeOrderService) ListRefunds(ctx context.Context, refundStatus string, limit, offset int) ([]StoreOrderResponseDTO, int64, error) {
+	if refundStatus == "" {
+		refundStatus = RefundPending
+	}
+	return s.listOrders(ctx, StoreOrderFilter{Status: OrderCancelled, RefundStatus: refundStatus}, limit, offset)
+}
+
+// CompleteRefund records that the money of a cancelled paid order was returned to the
+// buyer outside the gateway, under the reference of that transfer.
+func (s *storeOrderService) CompleteRefund(ctx context.Context, adminID, orderID string, req CompleteRefundDTO) (*StoreOrderResponseDTO, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		order, err := repo.GetOrderForUpdate(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if order.RefundStatus != RefundPending {
+			return ErrRefundNotPending
+		}
+
+		now := time.Now()
+		return repo.UpdateOrderFields(ctx, order.ID, map[string]interface{}{
+			"refund_status":    RefundCompleted,
+			"refund_reference": strings.TrimSpace(req.Reference),
+			"refunded_by":      adminID,
+			"refunded_at":      now,
+			"updated_at":       now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getOrder(ctx, orderID)
+}
+
+// GetPayableOrder returns an unpaid online order of the buyer that can still be paid.
+func (s *storeOrderService) GetPayableOrder(ctx context.Context, userID, orderID string) (*model.StoreOrder, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderForbidden
+	}
+	if order.PaymentMethod != PaymentOnline || order.PaidAt != nil || order.Status != OrderPlaced {
+		return nil, ErrOrderNotPayable
+	}
+	return order, nil
+}
+
+// MarkOrderPaid records a confirmed online payment. It runs inside the caller's
+// transaction and fails if the order was paid in the meantime. A payment that lands
+// on an order cancelled in the meantime is still recorded, as a pending refund, since
+// the buyer's money has arrived for stock that was already released.
+func (s *storeOrderService) MarkOrderPaid(ctx context.Context, tx *gorm.DB, orderID, reference string, paidAt time.Time) error {
+	repo := s.repo.WithTx(tx)
+
+	order, err := repo.GetOrderForUpdate(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrderNotFound
+		}
+		return err
+	}
+	if order.PaymentMethod != PaymentOnline || order.PaidAt != nil {
+		return ErrOrderNotPayable
+	}
+
+	fields := map[string]interface{}{
+		"paid_at":           paidAt,
+		"payment_reference": reference,
+		"updated_at":        time.Now(),
+	}
+	switch order.Status {
+	case OrderPlaced:
+	case OrderCancelled:
+		fields["refund_status"] = RefundPending
+		fields["refund_amount"] = order.TotalPrice
+	default:
+		return ErrOrderNotPayable
+	}
+	return repo.UpdateOrderFields(ctx, order.ID, fields)
+}
+
+// transition moves a locked order to the target status after checking who may do it.
+// The store owner drives the order forward; the buyer may only cancel an order that
+// is not prepared yet; an admin may cancel at any open stage.
+func (s *storeOrderService) transition(ctx context.Context, actor OrderActor, orderID, target string,
+	apply func(tx *gorm.DB, order *model.StoreOrder, now time.Time) (map[string]interface{}, error)) (*StoreOrderResponseDTO, error) {
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		order, err := repo.GetOrderForUpdate(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		store, err := repo.GetStoreByID(ctx, order.StoreID)
+		if err != nil {
+			return err
+		}
+
+		isOwner := store.UserID == actor.UserID
+		isBuyer := order.UserID == actor.UserID
+		isAdmin := actor.Role == utils.RoleAdministrator
+		switch {
+		case target == OrderCancelled && (isOwner || isAdmin):
+		case target == OrderCancelled && isBuyer:
+			if order.Status != OrderPlaced {
+				return fmt.Errorf("%w: pesanan yang sudah disiapkan hanya dapat dibatalkan oleh toko", ErrInvalidOrderTransition)
+			}
+		case target != OrderCancelled && isOwner:
+		default:
+			return ErrOrderForbidden
+		}
+
+		if !canTransition(order.Status, target) {
+			return fmt.Errorf("%w: %s ke %s", ErrInvalidOrderTransition, order.Status, target)
+		}
+
+		now := time.Now()
+		fields, err := apply(tx, order, now)
+		if err != nil {
+			return err
+		}
+		fields["status"] = target
+		fields["updated_at"] = now
+		return repo.UpdateOrderFields(ctx, order.ID, fields)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getOrder(ctx, orderID)
+}
+
+func canTransition(from, to string) bool {
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *storeOrderService) getOrder(ctx context.Context, orderID string) (*StoreOrderResponseDTO, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return toStoreOrderResponse(order), nil
+}
+
+func toStoreOrderResponse(order *model.StoreOrder) *StoreOrderResponseDTO {
+	response := &StoreOrderResponseDTO{
+		ID:            order.ID,
+		StoreID:       order.StoreID,
+		UserID:        order.UserID,
+		Status:        order.Status,
+		PaymentMethod: order.PaymentMethod,
+		TotalPoints:   order.TotalPoints,
+		TotalPrice:    order.TotalPrice,
+		Notes:         order.Notes,
+		Items:         make([]StoreOrderItemDTO, 0, len(order.Items)),
+		CancelReason:  order.CancelReason,
+		CreatedAt:     order.CreatedAt.Format(time.RFC3339),
+	}
+	if order.Store != nil {
+		response.StoreName = order.Store.StoreName
+	}
+	if order.PaidAt != nil {
+		response.PaidAt = order.PaidAt.Format(time.RFC3339)
+	}
+	if order.ReadyAt != nil {
+		response.ReadyAt = order.ReadyAt.Format(time.RFC3339)
+	}
+	if order.HandedOverAt != nil {
+		response.HandedOverAt = order.HandedOverAt.Format(time.RFC3339)
+	}
+	if order.CancelledAt != nil {
+		response.CancelledAt = order.CancelledAt.Format(time.RFC3339)
+	}
+	if order.RefundStatus != "" {
+		response.RefundStatus = order.RefundStatus
+		response.RefundAmount = order.RefundAmount
+		response.RefundReference = order.RefundReference
+	}
+	if order.RefundedAt != nil {
+		response.RefundedAt = order.RefundedAt.Format(time.RFC3339)
+	}
+	for _, item := range order.Items {
+		response.Items = append(response.Items, StoreOrderItemDTO{
+			ProductID:      item.ProductID,
+			ProductName:    item.ProductName,
+			Quantity:       item.Quantity,
+			PointPrice:     item.PointPrice,
+			Price:          item.Price,
+			SubtotalPoints: item.SubtotalPoints,
+			SubtotalPrice:  item.SubtotalPrice,
+		})
+	}
+	return response
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"context"
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoreRepository interface {
+	CreateStore(ctx context.Context, store *model.Store) error
+	GetStoreByID(ctx context.Context, id string) (*model.Store, error)
+	GetStoreByUserID(ctx context.Context, userID string) (*model.Store, error)
+	UpdateStoreFields(ctx context.Context, id string, fields map[string]interface{}) error
+	ListStores(ctx context.Context, search string, limit, offset int) ([]model.Store, int64, error)
+	RefreshTotalProduct(ctx context.Context, storeID string) error
+
+	CreateProduct(ctx context.Context, product *model.Product) error
+	GetProductByID(ctx context.Context, id string) (*model.Product, error)
+	GetProductForUpdate(ctx context.Context, id string) (*model.Product, error)
+	UpdateProductFields(ctx context.Context, id string, fields map[string]interface{}) error
+	ReplaceProductImages(ctx context.Context, productID string, urls []string) error
+	ListProductsByStore(ctx context.Context, storeID string, onlyActive bool) ([]model.Product, error)
+	ReserveStock(ctx context.Context, productID string, quantity int) (bool, error)
+	ReleaseStock(ctx context.Context, productID string, quantity int) error
+
+	CreateOrder(ctx context.Context, order *model.StoreOrder) error
+	GetOrderByID(ctx context.Context, id string) (*model.StoreOrder, error)
+	GetOrderForUpdate(ctx context.Context, id string) (*model.StoreOrder, error)
+	UpdateOrderFields(ctx context.Context, id string, fields map[string]interface{}) error
+	ListOrders(ctx context.Context, filter StoreOrderFilter, limit, offset int) ([]model.StoreOrder, int64, error)
+
+	WithTx(tx *gorm.DB) StoreRepository
+}
+
+// StoreOrderFilter narrows ListOrders to a buyer or a store and optionally a status
+// or refund status.
+type StoreOrderFilter struct {
+	UserID       string
+	StoreID      string
+	Status       string
+	RefundStatus string
+}
+
+type storeRepository struct {
+	db *gorm.DB
+}
+
+func NewStoreRepository() StoreRepository {
+	return &storeRepository{db: config.DB}
+}
+
+func (r *storeRepository) WithTx(tx *gorm.DB) StoreRepository {
+	return &storeRepository{db: tx}
+}
+
+func (r *storeRepository) CreateStore(ctx context.Context, store *model.Store) error {
+	return r.db.WithContext(ctx).Create(store).Error
+}
+
+func (r *storeRepository) GetStoreByID(ctx context.Context, id string) (*model.Store, error) {
+	var store model.Store
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&store).Error; err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *storeRepository) GetStoreByUserID(ctx context.Context, userID string) (*model.Store, error) {
+	var store model.Store
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&store).Error; err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *storeRepository) UpdateStoreFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Store{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *storeRepository) ListStores(ctx context.Context, search string, limit, offset int) ([]model.Store, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.Store{})
+	if search != "" {
+		query = query.Where("store_name ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var stores []model.Store
+	if err := query.Order("store_name ASC").Limit(limit).Offset(offset).Find(&stores).Error; err != nil {
+		return nil, 0, err
+	}
+	return stores, total, nil
+}
+
+func (r *storeRepository) RefreshTotalProduct(ctx context.Context, storeID string) error {
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE stores SET total_product = (
+			SELECT COUNT(*) FROM products WHERE store_id = ? AND is_active = TRUE
+		), updated_at = NOW()
+		WHERE id = ?`, storeID, storeID).Error
+}
+
+func (r *storeRepository) CreateProduct(ctx context.Context, product *model.Product) error {
+	return r.db.WithContext(ctx).Create(product).Error
+}
+
+func (r *storeRepository) GetProductByID(ctx context.Context, id string) (*model.Product, error) {
+	var product model.Product
+	if err := r.db.WithContext(ctx).Preload("ProductImages").Where("id = ?", id).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *storeRepository) GetProductForUpdate(ctx context.Context, id string) (*model.Product, error) {
+	var product model.Product
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *storeRepository) UpdateProductFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *storeRepository) ReplaceProductImages(ctx context.Context, productID string, urls []string) error {
+	if err := r.db.WithContext(ctx).Where("product_id = ?", productID).Delete(&model.ProductImage{}).Error; err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	images := make([]model.ProductImage, 0, len(urls))
+	for _, url := range urls {
+		images = append(images, model.ProductImage{ProductID: productID, ImageURL: url})
+	}
+	return r.db.WithContext(ctx).Create(&images).Error
+}
+
+func (r *storeRepository) ListProductsByStore(ctx context.Context, storeID string, onlyActive bool) ([]model.Product, error) {
+	query := r.db.WithContext(ctx).Preload("ProductImages").Where("store_id = ?", storeID)
+	if onlyActive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var products []model.Product
+	if err := query.Order("product_name ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// ReserveStock moves quantity from the available stock to the sold counter in a single
+// conditional update, so two orders can never reserve the same unit.
+func (r *storeRepository) ReserveStock(ctx context.Context, productID string, quantity int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Product{}).
+		Where("id = ? AND is_active = ? AND quantity >= ?", productID, true, quantity).
+		Updates(map[string]interface{}{
+			"quantity": gorm.Expr("quantity - ?", quantity),
+			"saled":    gorm.Expr("saled + ?", quantity),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *storeRepository) ReleaseStock(ctx context.Context, productID string, quantity int) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Product{}).
+		Where("id = ?", productID).
+		Updates(map[string]interface{}{
+			"quantity": gorm.Expr("quantity + ?", quantity),
+			"saled":    gorm.Expr("GREATEST(saled - ?, 0)", quantity),
+		}).Error
+}
+
+func (r *storeRepository) CreateOrder(ctx context.Context, order *model.StoreOrder) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+func (r *storeRepository) GetOrderByID(ctx context.Context, id string) (*model.StoreOrder, error) {
+	var order model.StoreOrder
+	if err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("Store").
+		Where("id = ?", id).
+		First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *storeRepository) GetOrderForUpdate(ctx context.Context, id string) (*model.StoreOrder, error) {
+	var order model.StoreOrder
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&order).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("order_id = ?", id).Find(&order.Items).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *storeRepository) UpdateOrderFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.StoreOrder{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *storeRepository) ListOrders(ctx context.Context, filter StoreOrderFilter, limit, offset int) ([]model.StoreOrder, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.StoreOrder{})
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.StoreID != "" {
+		query = query.Where("store_id = ?", filter.StoreID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.RefundStatus != "" {
+		query = query.Where("refund_status = ?", filter.RefundStatus)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []model.StoreOrder
+	if err := query.Preload("Items").Preload("Store").
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
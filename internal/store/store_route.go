@@ -0,0 +1,52 @@
+package store
+
+import (
+	"rijig/config"
+	"rijig/internal/address"
+	"rijig/internal/points"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StoreRouter takes the payments of online orders from the caller, as the payment
+// package depends on store.
+func StoreRouter(api fiber.Router, payments OrderPaymentCanceller) {
+	repo := NewStoreRepository()
+	storeService := NewStoreService(config.DB, repo, address.NewAddressRepository(config.DB))
+	pointsService := points.NewPointsService(config.DB, points.NewPointsRepository())
+	orderService := NewStoreOrderService(config.DB, repo, pointsService, payments)
+	handler := NewStoreHandler(storeService, orderService)
+
+	stores := api.Group("/stores")
+	stores.Use(middleware.AuthMiddleware())
+
+	// the pengelola running the store
+	stores.Post("/", middleware.RequireRoles(utils.RolePengelola), handler.CreateStore)
+	stores.Get("/me", middleware.RequireRoles(utils.RolePengelola), handler.GetMyStore)
+	stores.Put("/me", middleware.RequireRoles(utils.RolePengelola), handler.UpdateMyStore)
+	stores.Get("/me/products", middleware.RequireRoles(utils.RolePengelola), handler.ListMyProducts)
+	stores.Post("/me/products", middleware.RequireRoles(utils.RolePengelola), handler.CreateProduct)
+	stores.Put("/me/products/:product_id", middleware.RequireRoles(utils.RolePengelola), handler.UpdateProduct)
+	stores.Delete("/me/products/:product_id", middleware.RequireRoles(utils.RolePengelola), handler.DeactivateProduct)
+	stores.Get("/me/orders", middleware.RequireRoles(utils.RolePengelola), handler.ListStoreOrders)
+
+	// browsing
+	stores.Get("/", handler.ListStores)
+	stores.Get("/products/:product_id", handler.GetProduct)
+	stores.Get("/:store_id", handler.GetStore)
+	stores.Get("/:store_id/products", handler.ListStoreProducts)
+
+	orders := api.Group("/store-orders")
+	orders.Use(middleware.AuthMiddleware())
+
+	orders.Post("/", middleware.RequireRoles(utils.RoleMasyarakat), handler.PlaceOrder)
+	orders.Get("/me", middleware.RequireRoles(utils.RoleMasyarakat), handler.ListMyOrders)
+	orders.Get("/refunds", middleware.RequireRoles(utils.RoleAdministrator), handler.ListRefunds)
+	orders.Get("/:order_id", handler.GetOrder)
+	orders.Patch("/:order_id/ready", middleware.RequireRoles(utils.RolePengelola), handler.MarkOrderReady)
+	orders.Patch("/:order_id/handover", middleware.RequireRoles(utils.RolePengelola), handler.HandOverOrder)
+	orders.Patch("/:order_id/cancel", middleware.RequireRoles(utils.RoleMasyarakat, utils.RolePengelola, utils.RoleAdministrator), handler.CancelOrder)
+	orders.Patch("/:order_id/refund", middleware.RequireRoles(utils.RoleAdministrator), handler.CompleteRefund)
+}
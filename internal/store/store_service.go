@@ -0,0 +1,342 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"rijig/internal/address"
+	"rijig/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrStoreNotFound      = errors.New("toko tidak ditemukan")
+	ErrStoreAlreadyExists = errors.New("anda sudah memiliki toko")
+	ErrStoreAddress       = errors.New("alamat toko tidak ditemukan")
+	ErrProductNotFound    = errors.New("produk tidak ditemukan")
+)
+
+type StoreService interface {
+	CreateStore(ctx context.Context, userID string, req RequestStoreDTO) (*StoreResponseDTO, error)
+	GetMyStore(ctx context.Context, userID string) (*StoreResponseDTO, error)
+	UpdateMyStore(ctx context.Context, userID string, req RequestStoreDTO) (*StoreResponseDTO, error)
+	GetStore(ctx context.Context, storeID string) (*StoreResponseDTO, error)
+	ListStores(ctx context.Context, search string, limit, offset int) ([]StoreResponseDTO, int64, error)
+
+	CreateProduct(ctx context.Context, userID string, req RequestProductDTO) (*ProductResponseDTO, error)
+	UpdateProduct(ctx context.Context, userID, productID string, req RequestProductDTO) (*ProductResponseDTO, error)
+	DeactivateProduct(ctx context.Context, userID, productID string) error
+	GetProduct(ctx context.Context, productID string) (*ProductResponseDTO, error)
+	ListStoreProducts(ctx context.Context, storeID string) ([]ProductResponseDTO, error)
+	ListMyProducts(ctx context.Context, userID string) ([]ProductResponseDTO, error)
+}
+
+type storeService struct {
+	db          *gorm.DB
+	repo        StoreRepository
+	addressRepo address.AddressRepository
+}
+
+func NewStoreService(db *gorm.DB, repo StoreRepository, addressRepo address.AddressRepository) StoreService {
+	return &storeService{db: db, repo: repo, addressRepo: addressRepo}
+}
+
+func (s *storeService) CreateStore(ctx context.Context, userID string, req RequestStoreDTO) (*StoreResponseDTO, error) {
+	if _, err := s.repo.GetStoreByUserID(ctx, userID); err == nil {
+		return nil, ErrStoreAlreadyExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := s.checkAddress(ctx, userID, req.StoreAddressID); err != nil {
+		return nil, err
+	}
+
+	store := &model.Store{
+		UserID:         userID,
+		StoreName:      strings.TrimSpace(req.StoreName),
+		StoreLogo:      req.StoreLogo,
+		StoreBanner:    req.StoreBanner,
+		StoreInfo:      req.StoreInfo,
+		StoreAddressID: req.StoreAddressID,
+	}
+	if err := s.repo.CreateStore(ctx, store); err != nil {
+		return nil, err
+	}
+	return toStoreResponse(store), nil
+}
+
+func (s *storeService) GetMyStore(ctx context.Context, userID string) (*StoreResponseDTO, error) {
+	store, err := s.ownStore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return toStoreResponse(store), nil
+}
+
+func (s *storeService) UpdateMyStore(ctx context.Context, userID string, req RequestStoreDTO) (*StoreResponseDTO, error) {
+	store, err := s.ownStore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAddress(ctx, userID, req.StoreAddressID); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.UpdateStoreFields(ctx, store.ID, map[string]interface{}{
+		"store_name":       strings.TrimSpace(req.StoreName),
+		"store_logo":       req.StoreLogo,
+		"store_banner":     req.StoreBanner,
+		"store_info":       req.StoreInfo,
+		"store_address_id": req.StoreAddressID,
+		"updated_at":       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetStore(ctx, store.ID)
+}
+
+func (s *storeService) GetStore(ctx context.Context, storeID string) (*StoreResponseDTO, error) {
+	store, err := s.repo.GetStoreByID(ctx, storeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStoreNotFound
+		}
+		return nil, err
+	}
+	return toStoreResponse(store), nil
+}
+
+func (s *storeService) ListStores(ctx context.Context, search string, limit, offset int) ([]StoreResponseDTO, int64, error) {
+	stores, total, err := s.repo.ListStores(ctx, strings.TrimSpace(search), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]StoreResponseDTO, 0, len(stores))
+	for i := range stores {
+		responses = append(responses, *toStoreResponse(&stores[i]))
+	}
+	return responses, total, nil
+}
+
+func (s *storeService) CreateProduct(ctx context.Context, userID string, req RequestProductDTO) (*ProductResponseDTO, error) {
+	store, err := s.ownStore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	product := &model.Product{
+		StoreID:     store.ID,
+		ProductName: strings.TrimSpace(req.ProductName),
+		Description: req.Description,
+		PointPrice:  req.PointPrice,
+		Price:       req.Price,
+		Quantity:    req.Quantity,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.CreateProduct(ctx, product); err != nil {
+			return err
+		}
+		// gorm skips the false zero value and lets the column default win
+		if !product.IsActive {
+			if err := repo.UpdateProductFields(ctx, product.ID, map[string]interface{}{"is_active": false}); err != nil {
+				return err
+			}
+		}
+		if err := repo.ReplaceProductImages(ctx, product.ID, req.Images); err != nil {
+			return err
+		}
+		return repo.RefreshTotalProduct(ctx, store.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProduct(ctx, product.ID)
+}
+
+// UpdateProduct replaces the product details. Quantity is the new available stock; it
+// is written under a row lock so it cannot interleave with a reservation.
+func (s *storeService) UpdateProduct(ctx context.Context, userID, productID string, req RequestProductDTO) (*ProductResponseDTO, error) {
+	store, err := s.ownStore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		product, err := repo.GetProductForUpdate(ctx, productID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		if product.StoreID != store.ID {
+			return ErrProductNotFound
+		}
+
+		fields := map[string]interface{}{
+			"product_name": strings.TrimSpace(req.ProductName),
+			"description":  req.Description,
+			"point_price":  req.PointPrice,
+			"price":        req.Price,
+			"quantity":     req.Quantity,
+			"updated_at":   time.Now(),
+		}
+		if req.IsActive != nil {
+			fields["is_active"] = *req.IsActive
+		}
+		if err := repo.UpdateProductFields(ctx, productID, fields); err != nil {
+			return err
+		}
+		if req.Images != nil {
+			if err := repo.ReplaceProductImages(ctx, productID, req.Images); err != nil {
+				return err
+			}
+		}
+		return repo.RefreshTotalProduct(ctx, store.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProduct(ctx, productID)
+}
+
+// DeactivateProduct hides a product from the store. Products are never deleted because
+// past orders still refer to them.
+func (s *storeService) DeactivateProduct(ctx context.Context, userID, productID string) error {
+	store, err := s.ownStore(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	if product.StoreID != store.ID {
+		return ErrProductNotFound
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.UpdateProductFields(ctx, productID, map[string]interface{}{
+			"is_active":  false,
+			"updated_at": time.Now(),
+		}); err != nil {
+			return err
+		}
+		return repo.RefreshTotalProduct(ctx, store.ID)
+	})
+}
+
+func (s *storeService) GetProduct(ctx context.Context, productID string) (*ProductResponseDTO, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return toProductResponse(product), nil
+}
+
+func (s *storeService) ListStoreProducts(ctx context.Context, storeID string) ([]ProductResponseDTO, error) {
+	if _, err := s.GetStore(ctx, storeID); err != nil {
+		return nil, err
+	}
+	return s.listProducts(ctx, storeID, true)
+}
+
+func (s *storeService) ListMyProducts(ctx context.Context, userID string) ([]ProductResponseDTO, error) {
+	store, err := s.ownStore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.listProducts(ctx, store.ID, false)
+}
+
+func (s *storeService) listProducts(ctx context.Context, storeID string, onlyActive bool) ([]ProductResponseDTO, error) {
+	products, err := s.repo.ListProductsByStore(ctx, storeID, onlyActive)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]ProductResponseDTO, 0, len(products))
+	for i := range products {
+		responses = append(responses, *toProductResponse(&products[i]))
+	}
+	return responses, nil
+}
+
+func (s *storeService) ownStore(ctx context.Context, userID string) (*model.Store, error) {
+	store, err := s.repo.GetStoreByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStoreNotFound
+		}
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *storeService) checkAddress(ctx context.Context, userID, addressID string) error {
+	addr, err := s.addressRepo.FindAddressByID(ctx, addressID)
+	if err != nil || addr.UserID != userID {
+		return ErrStoreAddress
+	}
+	return nil
+}
+
+func toStoreResponse(store *model.Store) *StoreResponseDTO {
+	return &StoreResponseDTO{
+		ID:             store.ID,
+		UserID:         store.UserID,
+		StoreName:      store.StoreName,
+		StoreLogo:      store.StoreLogo,
+		StoreBanner:    store.StoreBanner,
+		StoreInfo:      store.StoreInfo,
+		StoreAddressID: store.StoreAddressID,
+		Followers:      store.Followers,
+		TotalProduct:   store.TotalProduct,
+		CreatedAt:      store.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      store.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toProductResponse(product *model.Product) *ProductResponseDTO {
+	response := &ProductResponseDTO{
+		ID:          product.ID,
+		StoreID:     product.StoreID,
+		ProductName: product.ProductName,
+		Description: product.Description,
+		PointPrice:  product.PointPrice,
+		Price:       product.Price,
+		Quantity:    product.Quantity,
+		Saled:       product.Saled,
+		IsActive:    product.IsActive,
+		Images:      make([]string, 0, len(product.ProductImages)),
+		CreatedAt:   product.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, image := range product.ProductImages {
+		response.Images = append(response.Images, image.ImageURL)
+	}
+	return response
+}
@@ -0,0 +1,96 @@
+package trace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"rijig/model"
+)
+
+// traceChainLockKey is the Postgres advisory lock serialising appends to the ledger.
+const traceChainLockKey = 724_001
+
+var genesisHash = strings.Repeat("0", 64)
+
+type payloadParent struct {
+	LotID    string  `json:"lot_id"`
+	Quantity float64 `json:"quantity"`
+}
+
+// recordPayload is what a trace record commits to. Field order is fixed by the struct
+// and map keys are sorted by encoding/json, so the same event always hashes the same.
+type recordPayload struct {
+	Event            string                 `json:"event"`
+	LotID            string                 `json:"lot_id"`
+	LotCode          string                 `json:"lot_code"`
+	Stage            string                 `json:"stage"`
+	TrashCategoryID  string                 `json:"trash_category_id"`
+	Quantity         float64                `json:"quantity"`
+	Unit             string                 `json:"unit"`
+	ReferenceType    string                 `json:"reference_type"`
+	ReferenceID      *string                `json:"reference_id,omitempty"`
+	CollectorID      *string                `json:"collector_id,omitempty"`
+	CompanyProfileID *string                `json:"company_profile_id,omitempty"`
+	Parents          []payloadParent        `json:"parents,omitempty"`
+	Details          map[string]interface{} `json:"details,omitempty"`
+	Actor            *string                `json:"actor,omitempty"`
+	OccurredAt       string                 `json:"occurred_at"`
+}
+
+func encodePayload(payload recordPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodePayload(raw string) (recordPayload, error) {
+	var payload recordPayload
+	err := json.Unmarshal([]byte(raw), &payload)
+	return payload, err
+}
+
+// lotPayloadMismatch names the first lot column that differs from the lot's latest
+// ledger payload, or returns "" when the row matches what was recorded.
+func lotPayloadMismatch(lot *model.TraceLot, payload recordPayload) string {
+	switch {
+	case lot.ID != payload.LotID:
+		return "id"
+	case lot.Code != payload.LotCode:
+		return "code"
+	case lot.Stage != payload.Stage:
+		return "stage"
+	case lot.TrashCategoryID != payload.TrashCategoryID:
+		return "trash_category_id"
+	case roundQuantity(lot.Quantity) != roundQuantity(payload.Quantity):
+		return "quantity"
+	case lot.Unit != payload.Unit:
+		return "unit"
+	case lot.ReferenceType != payload.ReferenceType:
+		return "reference_type"
+	case !sameID(lot.ReferenceID, payload.ReferenceID):
+		return "reference_id"
+	case !sameID(lot.CollectorID, payload.CollectorID):
+		return "collector_id"
+	case !sameID(lot.CompanyProfileID, payload.CompanyProfileID):
+		return "company_profile_id"
+	}
+	return ""
+}
+
+func sameID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// chainHash links a record to the one before it.
+func chainHash(prevHash string, sequence int64, payload string) string {
+	sum := sha256.Sum256([]byte(prevHash + "\n" + strconv.FormatInt(sequence, 10) + "\n" + payload))
+	return hex.EncodeToString(sum[:])
+}
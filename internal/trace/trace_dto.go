@@ -0,0 +1,157 @@
+package trace
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ProcessRecycling    = "recycling"
+	ProcessComposting   = "composting"
+	ProcessReuse        = "reuse"
+	ProcessIncineration = "incineration"
+	ProcessLandfill     = "landfill"
+
+	DirectionForward  = "forward"
+	DirectionBackward = "backward"
+)
+
+func isValidProcessMethod(method string) bool {
+	switch method {
+	case ProcessRecycling, ProcessComposting, ProcessReuse, ProcessIncineration, ProcessLandfill:
+		return true
+	}
+	return false
+}
+
+func validateLotIDs(errors map[string][]string, lotIDs []string, minimum int) {
+	if len(lotIDs) < minimum {
+		errors["lot_ids"] = append(errors["lot_ids"], fmt.Sprintf("minimal %d lot harus dipilih", minimum))
+	}
+
+	seen := make(map[string]bool)
+	for i, id := range lotIDs {
+		id = strings.TrimSpace(id)
+		key := fmt.Sprintf("lot_ids[%d]", i)
+		if id == "" {
+			errors[key] = append(errors[key], "ID lot wajib diisi")
+			continue
+		}
+		if seen[id] {
+			errors[key] = append(errors[key], "ID lot duplikat")
+		}
+		seen[id] = true
+		lotIDs[i] = id
+	}
+}
+
+// AggregateLotsDTO combines lots a collector holds into one load.
+type AggregateLotsDTO struct {
+	LotIDs []string `json:"lot_ids"`
+}
+
+func (r *AggregateLotsDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	validateLotIDs(errors, r.LotIDs, 2)
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+// ProcessLotsDTO records the final processing of received lots. OutputQuantity is what
+// came out of processing and may be less than the input because of losses.
+type ProcessLotsDTO struct {
+	LotIDs         []string `json:"lot_ids"`
+	Method         string   `json:"method"`
+	OutputQuantity float64  `json:"output_quantity"`
+	Notes          string   `json:"notes,omitempty"`
+}
+
+func (r *ProcessLotsDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Method = strings.ToLower(strings.TrimSpace(r.Method))
+
+	validateLotIDs(errors, r.LotIDs, 1)
+	if !isValidProcessMethod(r.Method) {
+		errors["method"] = append(errors["method"], "metode harus recycling, composting, reuse, incineration, atau landfill")
+	}
+	if r.OutputQuantity <= 0 {
+		errors["output_quantity"] = append(errors["output_quantity"], "jumlah hasil harus lebih dari 0")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type TraceRecordDTO struct {
+	Sequence  int64  `json:"sequence"`
+	Event     string `json:"event"`
+	Payload   string `json:"payload"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+	Verified  bool   `json:"verified"`
+	CreatedAt string `json:"created_at"`
+}
+
+type TraceLotDTO struct {
+	ID                string           `json:"id"`
+	Code              string           `json:"code"`
+	Stage             string           `json:"stage"`
+	Status            string           `json:"status"`
+	TrashCategoryID   string           `json:"trash_category_id"`
+	TrashCategoryName string           `json:"trash_category_name,omitempty"`
+	Quantity          float64          `json:"quantity"`
+	Unit              string           `json:"unit"`
+	ReferenceType     string           `json:"reference_type"`
+	ReferenceID       *string          `json:"reference_id,omitempty"`
+	CollectorID       *string          `json:"collector_id,omitempty"`
+	CompanyProfileID  *string          `json:"company_profile_id,omitempty"`
+	ProcessMethod     string           `json:"process_method,omitempty"`
+	Depth             *int             `json:"depth,omitempty"`
+	Records           []TraceRecordDTO `json:"records,omitempty"`
+	Verified          *bool            `json:"verified,omitempty"`
+	CreatedAt         string           `json:"created_at"`
+}
+
+type TraceLinkDTO struct {
+	ParentLotID string  `json:"parent_lot_id"`
+	ChildLotID  string  `json:"child_lot_id"`
+	Quantity    float64 `json:"quantity"`
+	Verified    bool    `json:"verified"`
+}
+
+type TraceWalkDTO struct {
+	Root      string         `json:"root"`
+	Direction string         `json:"direction"`
+	Lots      []TraceLotDTO  `json:"lots"`
+	Links     []TraceLinkDTO `json:"links"`
+}
+
+// ChainVerificationDTO reports the first record whose hash does not match, if any, and
+// the lots and links whose rows disagree with what the ledger recorded.
+type ChainVerificationDTO struct {
+	Valid          bool               `json:"valid"`
+	RecordsChecked int64              `json:"records_checked"`
+	LastSequence   int64              `json:"last_sequence"`
+	BrokenAt       *int64             `json:"broken_at,omitempty"`
+	Reason         string             `json:"reason,omitempty"`
+	LotsChecked    int64              `json:"lots_checked"`
+	LinksChecked   int64              `json:"links_checked"`
+	MismatchCount  int                `json:"mismatch_count"`
+	Mismatches     []TraceMismatchDTO `json:"mismatches,omitempty"`
+}
+
+// TraceMismatchDTO is a lot or link row that differs from the ledger. ParentLotID is
+// set for links; Field names the lot column that differs.
+type TraceMismatchDTO struct {
+	LotID       string `json:"lot_id"`
+	ParentLotID string `json:"parent_lot_id,omitempty"`
+	Field       string `json:"field,omitempty"`
+	Reason      string `json:"reason"`
+}
@@ -0,0 +1,129 @@
+package trace
+
+import (
+	"context"
+	"errors"
+
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TraceHandler struct {
+	service TraceService
+}
+
+func NewTraceHandler(service TraceService) *TraceHandler {
+	return &TraceHandler{service: service}
+}
+
+func (h *TraceHandler) GetLot(c *fiber.Ctx) error {
+	lot, err := h.service.GetLot(context.Background(), c.Params("lot"))
+	if err != nil {
+		return handleTraceError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Lot berhasil diambil", lot)
+}
+
+func (h *TraceHandler) WalkLot(c *fiber.Ctx) error {
+	direction := c.Query("direction", DirectionForward)
+	if direction != DirectionForward && direction != DirectionBackward {
+		return utils.BadRequest(c, "direction harus forward atau backward")
+	}
+
+	walk, err := h.service.Walk(context.Background(), c.Params("lot"), direction)
+	if err != nil {
+		return handleTraceError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Jejak lot berhasil diambil", walk)
+}
+
+func (h *TraceHandler) GetPickupLots(c *fiber.Ctx) error {
+	pickupID := c.Params("pickup_id")
+	if pickupID == "" {
+		return utils.BadRequest(c, "ID pickup wajib diisi")
+	}
+
+	lots, err := h.service.GetPickupLots(context.Background(), pickupID)
+	if err != nil {
+		return handleTraceError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Lot pickup berhasil diambil", lots)
+}
+
+func (h *TraceHandler) AggregateLots(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req AggregateLotsDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	lot, err := h.service.AggregateLots(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleTraceError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Lot berhasil digabungkan", lot)
+}
+
+func (h *TraceHandler) ProcessLots(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req ProcessLotsDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	lot, err := h.service.ProcessLots(context.Background(), claims.UserID, req)
+	if err != nil {
+		return handleTraceError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Pengolahan lot berhasil dicatat", lot)
+}
+
+func (h *TraceHandler) VerifyChain(c *fiber.Ctx) error {
+	result, err := h.service.VerifyChain(context.Background())
+	if err != nil {
+		return handleTraceError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Verifikasi ledger selesai", result)
+}
+
+func handleTraceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrLotNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrLotNotOwned), errors.Is(err, ErrCollectorRequired), errors.Is(err, ErrCompanyProfileRequired):
+		return utils.Forbidden(c, err.Error())
+	case errors.Is(err, ErrLotMismatch), errors.Is(err, ErrLotStage), errors.Is(err, ErrLotNotWeighed), errors.Is(err, ErrOutputExceedsInput),
+		errors.Is(err, ErrLotNotKilogram):
+		return utils.BadRequest(c, err.Error())
+	case errors.Is(err, ErrLotConsumed):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
@@ -0,0 +1,272 @@
+package trace
+
+import (
+	"context"
+	"errors"
+
+	"rijig/config"
+	"rijig/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WalkRow is a lot reached by a walk and how many hops away from the start it is.
+type WalkRow struct {
+	LotID string
+	Depth int
+}
+
+type TraceRepository interface {
+	LockChain(ctx context.Context) error
+	GetLastRecord(ctx context.Context) (*model.TraceRecord, error)
+	CreateRecord(ctx context.Context, record *model.TraceRecord) error
+	GetRecordsForLots(ctx context.Context, lotIDs []string) ([]model.TraceRecord, error)
+	GetRecordsAfter(ctx context.Context, sequence int64, limit int) ([]model.TraceRecord, error)
+
+	CreateLot(ctx context.Context, lot *model.TraceLot) error
+	CreateLinks(ctx context.Context, links []model.TraceLink) error
+	GetLotByID(ctx context.Context, id string) (*model.TraceLot, error)
+	GetLotByCode(ctx context.Context, code string) (*model.TraceLot, error)
+	GetLotByReference(ctx context.Context, referenceType, referenceID string) (*model.TraceLot, error)
+	GetLotsForUpdate(ctx context.Context, ids []string) ([]model.TraceLot, error)
+	GetOpenPickupLotIDs(ctx context.Context, pickupIDs []string, trashCategoryID, collectorID string) ([]string, error)
+	GetPickupLots(ctx context.Context, pickupID string) ([]model.TraceLot, error)
+	UpdateLotFields(ctx context.Context, id string, fields map[string]interface{}) error
+	MarkLotsConsumed(ctx context.Context, ids []string) error
+
+	Walk(ctx context.Context, lotID string, forward bool, maxDepth int) ([]WalkRow, error)
+	GetLotsByIDs(ctx context.Context, ids []string) ([]model.TraceLot, error)
+	GetLinksBetween(ctx context.Context, ids []string) ([]model.TraceLink, error)
+	GetLotsAfter(ctx context.Context, afterID string, limit int) ([]model.TraceLot, error)
+	GetLinksAfter(ctx context.Context, afterID string, limit int) ([]model.TraceLink, error)
+
+	GetCollectorByUserID(ctx context.Context, userID string) (*model.Collector, error)
+	GetCompanyByUserID(ctx context.Context, userID string) (*model.CompanyProfile, error)
+
+	WithTx(tx *gorm.DB) TraceRepository
+}
+
+type traceRepository struct {
+	db *gorm.DB
+}
+
+func NewTraceRepository() TraceRepository {
+	return &traceRepository{db: config.DB}
+}
+
+func (r *traceRepository) WithTx(tx *gorm.DB) TraceRepository {
+	return &traceRepository{db: tx}
+}
+
+// LockChain takes a transaction-scoped advisory lock, released on commit or rollback.
+func (r *traceRepository) LockChain(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", traceChainLockKey).Error
+}
+
+func (r *traceRepository) GetLastRecord(ctx context.Context) (*model.TraceRecord, error) {
+	var record model.TraceRecord
+	err := r.db.WithContext(ctx).Order("sequence DESC").First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *traceRepository) CreateRecord(ctx context.Context, record *model.TraceRecord) error {
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+func (r *traceRepository) GetRecordsForLots(ctx context.Context, lotIDs []string) ([]model.TraceRecord, error) {
+	var records []model.TraceRecord
+	err := r.db.WithContext(ctx).
+		Where("lot_id IN ?", lotIDs).
+		Order("sequence ASC").
+		Find(&records).Error
+	return records, err
+}
+
+func (r *traceRepository) GetRecordsAfter(ctx context.Context, sequence int64, limit int) ([]model.TraceRecord, error) {
+	var records []model.TraceRecord
+	err := r.db.WithContext(ctx).
+		Where("sequence > ?", sequence).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+func (r *traceRepository) CreateLot(ctx context.Context, lot *model.TraceLot) error {
+	return r.db.WithContext(ctx).Create(lot).Error
+}
+
+func (r *traceRepository) CreateLinks(ctx context.Context, links []model.TraceLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&links).Error
+}
+
+func (r *traceRepository) GetLotByID(ctx context.Context, id string) (*model.TraceLot, error) {
+	var lot model.TraceLot
+	if err := r.db.WithContext(ctx).Preload("TrashCategory").Where("id = ?", id).First(&lot).Error; err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *traceRepository) GetLotByCode(ctx context.Context, code string) (*model.TraceLot, error) {
+	var lot model.TraceLot
+	if err := r.db.WithContext(ctx).Preload("TrashCategory").Where("code = ?", code).First(&lot).Error; err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *traceRepository) GetLotByReference(ctx context.Context, referenceType, referenceID string) (*model.TraceLot, error) {
+	var lot model.TraceLot
+	err := r.db.WithContext(ctx).
+		Where("reference_type = ? AND reference_id = ?", referenceType, referenceID).
+		First(&lot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// GetLotsForUpdate locks in id order so two requests using the same lots cannot deadlock.
+func (r *traceRepository) GetLotsForUpdate(ctx context.Context, ids []string) ([]model.TraceLot, error) {
+	var lots []model.TraceLot
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&lots).Error
+	return lots, err
+}
+
+// GetOpenPickupLotIDs returns the unconsumed source lots of the pickups' items of one
+// category that the collector still holds.
+func (r *traceRepository) GetOpenPickupLotIDs(ctx context.Context, pickupIDs []string, trashCategoryID, collectorID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Table("trace_lots tl").
+		Joins("JOIN request_pickup_items rpi ON rpi.id = tl.reference_id").
+		Where("tl.reference_type = ? AND rpi.request_pickup_id IN ?", ReferencePickupItem, pickupIDs).
+		Where("tl.trash_category_id = ? AND tl.collector_id = ? AND tl.status = ?", trashCategoryID, collectorID, LotOpen).
+		Pluck("tl.id", &ids).Error
+	return ids, err
+}
+
+func (r *traceRepository) GetPickupLots(ctx context.Context, pickupID string) ([]model.TraceLot, error) {
+	var lots []model.TraceLot
+	err := r.db.WithContext(ctx).
+		Preload("TrashCategory").
+		Joins("JOIN request_pickup_items rpi ON rpi.id = trace_lots.reference_id").
+		Where("trace_lots.reference_type = ? AND rpi.request_pickup_id = ?", ReferencePickupItem, pickupID).
+		Order("trace_lots.created_at ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+func (r *traceRepository) UpdateLotFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.TraceLot{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *traceRepository) MarkLotsConsumed(ctx context.Context, ids []string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.TraceLot{}).
+		Where("id IN ?", ids).
+		Update("status", LotConsumed).Error
+}
+
+// Walk follows links from a lot towards its children (forward) or its parents
+// (backward) and returns every lot reached with its shortest distance.
+func (r *traceRepository) Walk(ctx context.Context, lotID string, forward bool, maxDepth int) ([]WalkRow, error) {
+	from, to := "parent_lot_id", "child_lot_id"
+	if !forward {
+		from, to = to, from
+	}
+
+	var rows []WalkRow
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE walk(lot_id, depth) AS (
+			SELECT CAST(? AS uuid), 0
+			UNION
+			SELECT l.`+to+`, w.depth + 1
+			FROM trace_links l
+			JOIN walk w ON l.`+from+` = w.lot_id
+			WHERE w.depth < ?
+		)
+		SELECT lot_id, MIN(depth) AS depth FROM walk GROUP BY lot_id ORDER BY depth, lot_id`, lotID, maxDepth).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *traceRepository) GetLotsByIDs(ctx context.Context, ids []string) ([]model.TraceLot, error) {
+	var lots []model.TraceLot
+	err := r.db.WithContext(ctx).Preload("TrashCategory").Where("id IN ?", ids).Find(&lots).Error
+	return lots, err
+}
+
+func (r *traceRepository) GetLinksBetween(ctx context.Context, ids []string) ([]model.TraceLink, error) {
+	var links []model.TraceLink
+	err := r.db.WithContext(ctx).
+		Where("parent_lot_id IN ? AND child_lot_id IN ?", ids, ids).
+		Find(&links).Error
+	return links, err
+}
+
+// GetLotsAfter pages through every lot in id order, starting after afterID.
+func (r *traceRepository) GetLotsAfter(ctx context.Context, afterID string, limit int) ([]model.TraceLot, error) {
+	query := r.db.WithContext(ctx).Order("id ASC").Limit(limit)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+
+	var lots []model.TraceLot
+	err := query.Find(&lots).Error
+	return lots, err
+}
+
+// GetLinksAfter pages through every link in id order, starting after afterID.
+func (r *traceRepository) GetLinksAfter(ctx context.Context, afterID string, limit int) ([]model.TraceLink, error) {
+	query := r.db.WithContext(ctx).Order("id ASC").Limit(limit)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+
+	var links []model.TraceLink
+	err := query.Find(&links).Error
+	return links, err
+}
+
+func (r *traceRepository) GetCollectorByUserID(ctx context.Context, userID string) (*model.Collector, error) {
+	var collector model.Collector
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&collector).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &collector, nil
+}
+
+func (r *traceRepository) GetCompanyByUserID(ctx context.Context, userID string) (*model.CompanyProfile, error) {
+	var company model.CompanyProfile
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&company).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
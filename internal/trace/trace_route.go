@@ -0,0 +1,25 @@
+package trace
+
+import (
+	"rijig/config"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TraceRouter(api fiber.Router) {
+	service := NewTraceService(config.DB, NewTraceRepository())
+	handler := NewTraceHandler(service)
+
+	trace := api.Group("/trace")
+	trace.Use(middleware.AuthMiddleware())
+
+	trace.Get("/lots/:lot", handler.GetLot)
+	trace.Get("/lots/:lot/walk", handler.WalkLot)
+	trace.Get("/pickups/:pickup_id/lots", handler.GetPickupLots)
+
+	trace.Post("/aggregations", middleware.RequireRoles(utils.RolePengepul), handler.AggregateLots)
+	trace.Post("/processing", middleware.RequireRoles(utils.RolePengelola), handler.ProcessLots)
+	trace.Get("/verify", middleware.RequireRoles(utils.RoleAdministrator), handler.VerifyChain)
+}
@@ -0,0 +1,835 @@
+package trace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"rijig/internal/trash"
+	"rijig/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	StageSource     = "source"
+	StageAggregated = "aggregated"
+	StageIntake     = "intake"
+	StageProcessed  = "processed"
+
+	LotOpen     = "open"
+	LotConsumed = "consumed"
+
+	ReferencePickupItem     = "pickup_item"
+	ReferenceAggregation    = "collector_aggregation"
+	ReferenceFacilityIntake = "facility_intake"
+	ReferenceProcessing     = "processing"
+
+	EventSourceRecorded = "source_recorded"
+	EventAggregated     = "aggregated"
+	EventReceived       = "received"
+	EventWeighed        = "weighed"
+	EventProcessed      = "processed"
+
+	maxWalkDepth          = 20
+	verificationBatch     = 500
+	maxReportedMismatches = 100
+)
+
+var (
+	ErrLotNotFound            = errors.New("lot tidak ditemukan")
+	ErrLotConsumed            = errors.New("lot sudah digunakan pada tahap berikutnya")
+	ErrLotNotOwned            = errors.New("lot tidak dipegang oleh pihak ini")
+	ErrLotMismatch            = errors.New("lot harus memiliki kategori dan satuan yang sama")
+	ErrLotStage               = errors.New("lot tidak dapat digunakan pada tahap ini")
+	ErrLotNotWeighed          = errors.New("lot penerimaan belum selesai ditimbang")
+	ErrLotNotKilogram         = errors.New("hanya lot dalam kg yang dapat ditimbang di fasilitas")
+	ErrCollectorRequired      = errors.New("akun belum terdaftar sebagai pengepul")
+	ErrCompanyProfileRequired = errors.New("profil perusahaan belum dibuat")
+	ErrOutputExceedsInput     = errors.New("jumlah hasil melebihi jumlah lot yang diproses")
+)
+
+type TraceService interface {
+	RecordPickupLots(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, items []model.RequestPickupItem) error
+	RecordIntakeLot(ctx context.Context, tx *gorm.DB, intake *model.FacilityIntake, lotIDs, pickupIDs []string) error
+	RecordIntakeWeighing(ctx context.Context, tx *gorm.DB, intake *model.FacilityIntake, actorID string) error
+	AggregateLots(ctx context.Context, userID string, req AggregateLotsDTO) (*TraceLotDTO, error)
+	ProcessLots(ctx context.Context, userID string, req ProcessLotsDTO) (*TraceLotDTO, error)
+	GetLot(ctx context.Context, idOrCode string) (*TraceLotDTO, error)
+	GetPickupLots(ctx context.Context, pickupID string) ([]TraceLotDTO, error)
+	Walk(ctx context.Context, idOrCode, direction string) (*TraceWalkDTO, error)
+	VerifyChain(ctx context.Context) (*ChainVerificationDTO, error)
+}
+
+type traceService struct {
+	db   *gorm.DB
+	repo TraceRepository
+}
+
+func NewTraceService(db *gorm.DB, repo TraceRepository) TraceService {
+	return &traceService{db: db, repo: repo}
+}
+
+func roundQuantity(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func newLotCode(now time.Time) string {
+	return fmt.Sprintf("LOT-%s-%s", now.Format("20060102"), strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:10]))
+}
+
+// appendRecord adds the next record to the ledger. The chain lock is held until the
+// surrounding transaction ends, so sequences have no gaps and every record points to
+// the one committed before it.
+func (s *traceService) appendRecord(ctx context.Context, repo TraceRepository, lot *model.TraceLot, event string,
+	parents []payloadParent, details map[string]interface{}, actor *string) error {
+
+	if err := repo.LockChain(ctx); err != nil {
+		return fmt.Errorf("failed to lock trace ledger: %w", err)
+	}
+
+	last, err := repo.GetLastRecord(ctx)
+	if err != nil {
+		return err
+	}
+	prevHash, sequence := genesisHash, int64(1)
+	if last != nil {
+		prevHash, sequence = last.Hash, last.Sequence+1
+	}
+
+	payload, err := encodePayload(recordPayload{
+		Event:            event,
+		LotID:            lot.ID,
+		LotCode:          lot.Code,
+		Stage:            lot.Stage,
+		TrashCategoryID:  lot.TrashCategoryID,
+		Quantity:         lot.Quantity,
+		Unit:             lot.Unit,
+		ReferenceType:    lot.ReferenceType,
+		ReferenceID:      lot.ReferenceID,
+		CollectorID:      lot.CollectorID,
+		CompanyProfileID: lot.CompanyProfileID,
+		Parents:          parents,
+		Details:          details,
+		Actor:            actor,
+		OccurredAt:       time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+
+	return repo.CreateRecord(ctx, &model.TraceRecord{
+		Sequence: sequence,
+		LotID:    lot.ID,
+		Event:    event,
+		Payload:  payload,
+		PrevHash: prevHash,
+		Hash:     chainHash(prevHash, sequence, payload),
+	})
+}
+
+// createLot stores a lot made from whole parent lots, consumes the parents and records
+// the event on the ledger.
+func (s *traceService) createLot(ctx context.Context, repo TraceRepository, lot *model.TraceLot, parents []model.TraceLot,
+	event string, details map[string]interface{}) error {
+
+	lot.Code = newLotCode(time.Now())
+	lot.Status = LotOpen
+	if err := repo.CreateLot(ctx, lot); err != nil {
+		return err
+	}
+
+	links := make([]model.TraceLink, 0, len(parents))
+	payloadParents := make([]payloadParent, 0, len(parents))
+	parentIDs := make([]string, 0, len(parents))
+	for _, parent := range parents {
+		links = append(links, model.TraceLink{ParentLotID: parent.ID, ChildLotID: lot.ID, Quantity: parent.Quantity})
+		payloadParents = append(payloadParents, payloadParent{LotID: parent.ID, Quantity: parent.Quantity})
+		parentIDs = append(parentIDs, parent.ID)
+	}
+	if err := repo.CreateLinks(ctx, links); err != nil {
+		return err
+	}
+	if len(parentIDs) > 0 {
+		if err := repo.MarkLotsConsumed(ctx, parentIDs); err != nil {
+			return err
+		}
+	}
+
+	return s.appendRecord(ctx, repo, lot, event, payloadParents, details, lot.CreatedBy)
+}
+
+// lockOpenLots locks the lots and checks that every one exists and is still open.
+func (s *traceService) lockOpenLots(ctx context.Context, repo TraceRepository, ids []string) ([]model.TraceLot, error) {
+	lots, err := repo.GetLotsForUpdate(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(lots) != len(ids) {
+		return nil, ErrLotNotFound
+	}
+	for _, lot := range lots {
+		if lot.Status != LotOpen {
+			return nil, fmt.Errorf("%w: %s", ErrLotConsumed, lot.Code)
+		}
+	}
+	return lots, nil
+}
+
+// RecordPickupLots gives every settled item of a completed pickup its source lot. It
+// runs inside the completion transaction and skips items that already have a lot.
+func (s *traceService) RecordPickupLots(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup, items []model.RequestPickupItem) error {
+	repo := s.repo.WithTx(tx)
+
+	for _, item := range items {
+		if item.ActualAmount == nil || *item.ActualAmount <= 0 {
+			continue
+		}
+
+		existing, err := repo.GetLotByReference(ctx, ReferencePickupItem, item.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		unit := item.Unit
+		if unit == "" {
+			unit = trash.UnitKilogram
+		}
+		itemID := item.ID
+		lot := &model.TraceLot{
+			Stage:           StageSource,
+			TrashCategoryID: item.TrashCategoryId,
+			Quantity:        roundQuantity(*item.ActualAmount),
+			Unit:            unit,
+			ReferenceType:   ReferencePickupItem,
+			ReferenceID:     &itemID,
+			CollectorID:     pickup.CollectorID,
+		}
+		details := map[string]interface{}{
+			"pickup_id":    pickup.ID,
+			"household_id": pickup.UserId,
+		}
+		if err := s.createLot(ctx, repo, lot, nil, EventSourceRecorded, details); err != nil {
+			return fmt.Errorf("failed to record pickup lot: %w", err)
+		}
+	}
+	return nil
+}
+
+// AggregateLots combines open lots of one category held by the collector into a
+// single lot, as when sorted loads from several pickups are bagged together.
+func (s *traceService) AggregateLots(ctx context.Context, userID string, req AggregateLotsDTO) (*TraceLotDTO, error) {
+	collector, err := s.repo.GetCollectorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if collector == nil {
+		return nil, ErrCollectorRequired
+	}
+
+	var lot *model.TraceLot
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		parents, err := s.lockOpenLots(ctx, repo, req.LotIDs)
+		if err != nil {
+			return err
+		}
+
+		var quantity float64
+		for _, parent := range parents {
+			if parent.Stage != StageSource && parent.Stage != StageAggregated {
+				return fmt.Errorf("%w: %s", ErrLotStage, parent.Code)
+			}
+			if parent.CollectorID == nil || *parent.CollectorID != collector.ID {
+				return fmt.Errorf("%w: %s", ErrLotNotOwned, parent.Code)
+			}
+			if parent.TrashCategoryID != parents[0].TrashCategoryID || parent.Unit != parents[0].Unit {
+				return ErrLotMismatch
+			}
+			quantity += parent.Quantity
+		}
+
+		lot = &model.TraceLot{
+			Stage:           StageAggregated,
+			TrashCategoryID: parents[0].TrashCategoryID,
+			Quantity:        roundQuantity(quantity),
+			Unit:            parents[0].Unit,
+			ReferenceType:   ReferenceAggregation,
+			CollectorID:     &collector.ID,
+			CreatedBy:       &userID,
+		}
+		return s.createLot(ctx, repo, lot, parents, EventAggregated, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetLot(ctx, lot.ID)
+}
+
+// RecordIntakeLot creates the lot of a facility intake. Its parents are the lots given
+// explicitly plus the open source lots of the linked pickups for the intake category;
+// all must still be held by the delivering collector and be measured in kg, since the
+// intake lot takes its quantity from the weighbridge. It runs inside the intake
+// transaction. The lot quantity is the net weight, or zero until the tare is known.
+func (s *traceService) RecordIntakeLot(ctx context.Context, tx *gorm.DB, intake *model.FacilityIntake, lotIDs, pickupIDs []string) error {
+	repo := s.repo.WithTx(tx)
+
+	parentIDs := append([]string{}, lotIDs...)
+	if len(pickupIDs) > 0 {
+		pickupLotIDs, err := repo.GetOpenPickupLotIDs(ctx, pickupIDs, intake.TrashCategoryID, intake.CollectorID)
+		if err != nil {
+			return err
+		}
+		parentIDs = append(parentIDs, pickupLotIDs...)
+	}
+	parentIDs = uniqueStrings(parentIDs)
+
+	var parents []model.TraceLot
+	if len(parentIDs) > 0 {
+		var err error
+		if parents, err = s.lockOpenLots(ctx, repo, parentIDs); err != nil {
+			return err
+		}
+	}
+	for _, parent := range parents {
+		if parent.Stage != StageSource && parent.Stage != StageAggregated {
+			return fmt.Errorf("%w: %s", ErrLotStage, parent.Code)
+		}
+		if parent.CollectorID == nil || *parent.CollectorID != intake.CollectorID {
+			return fmt.Errorf("%w: %s", ErrLotNotOwned, parent.Code)
+		}
+		if parent.TrashCategoryID != intake.TrashCategoryID {
+			return fmt.Errorf("%w: %s", ErrLotMismatch, parent.Code)
+		}
+		if parent.Unit != trash.UnitKilogram {
+			return fmt.Errorf("%w: %s", ErrLotNotKilogram, parent.Code)
+		}
+	}
+
+	intakeID := intake.ID
+	collectorID := intake.CollectorID
+	companyID := intake.CompanyProfileID
+	lot := &model.TraceLot{
+		Stage:            StageIntake,
+		TrashCategoryID:  intake.TrashCategoryID,
+		Quantity:         intake.NetWeight,
+		Unit:             trash.UnitKilogram,
+		ReferenceType:    ReferenceFacilityIntake,
+		ReferenceID:      &intakeID,
+		CollectorID:      &collectorID,
+		CompanyProfileID: &companyID,
+		CreatedBy:        &intake.RecordedBy,
+	}
+	if err := s.createLot(ctx, repo, lot, parents, EventReceived, intakeDetails(intake)); err != nil {
+		return fmt.Errorf("failed to record intake lot: %w", err)
+	}
+	return nil
+}
+
+// RecordIntakeWeighing sets the intake lot to the net weight once the tare is recorded.
+func (s *traceService) RecordIntakeWeighing(ctx context.Context, tx *gorm.DB, intake *model.FacilityIntake, actorID string) error {
+	repo := s.repo.WithTx(tx)
+
+	lot, err := repo.GetLotByReference(ctx, ReferenceFacilityIntake, intake.ID)
+	if err != nil {
+		return err
+	}
+	if lot == nil {
+		return ErrLotNotFound
+	}
+
+	lot.Quantity = intake.NetWeight
+	if err := repo.UpdateLotFields(ctx, lot.ID, map[string]interface{}{
+		"quantity":   lot.Quantity,
+		"updated_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+	return s.appendRecord(ctx, repo, lot, EventWeighed, nil, intakeDetails(intake), &actorID)
+}
+
+func intakeDetails(intake *model.FacilityIntake) map[string]interface{} {
+	details := map[string]interface{}{
+		"gross_weight": intake.GrossWeight,
+		"net_weight":   intake.NetWeight,
+		"grade":        intake.Grade,
+	}
+	if intake.TareWeight != nil {
+		details["tare_weight"] = *intake.TareWeight
+	}
+	if intake.TicketNumber != nil {
+		details["ticket_number"] = *intake.TicketNumber
+	}
+	return details
+}
+
+// ProcessLots records the final processing of intake lots at the pengelola's facility.
+func (s *traceService) ProcessLots(ctx context.Context, userID string, req ProcessLotsDTO) (*TraceLotDTO, error) {
+	company, err := s.repo.GetCompanyByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if company == nil {
+		return nil, ErrCompanyProfileRequired
+	}
+
+	var lot *model.TraceLot
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		parents, err := s.lockOpenLots(ctx, repo, req.LotIDs)
+		if err != nil {
+			return err
+		}
+
+		var input float64
+		for _, parent := range parents {
+			if parent.Stage != StageIntake {
+				return fmt.Errorf("%w: %s", ErrLotStage, parent.Code)
+			}
+			if parent.CompanyProfileID == nil || *parent.CompanyProfileID != company.ID {
+				return fmt.Errorf("%w: %s", ErrLotNotOwned, parent.Code)
+			}
+			if parent.Quantity <= 0 {
+				return fmt.Errorf("%w: %s", ErrLotNotWeighed, parent.Code)
+			}
+			if parent.TrashCategoryID != parents[0].TrashCategoryID {
+				return ErrLotMismatch
+			}
+			input += parent.Quantity
+		}
+		if roundQuantity(req.OutputQuantity) > roundQuantity(input) {
+			return ErrOutputExceedsInput
+		}
+
+		lot = &model.TraceLot{
+			Stage:            StageProcessed,
+			TrashCategoryID:  parents[0].TrashCategoryID,
+			Quantity:         roundQuantity(req.OutputQuantity),
+			Unit:             trash.UnitKilogram,
+			ReferenceType:    ReferenceProcessing,
+			CompanyProfileID: &company.ID,
+			ProcessMethod:    req.Method,
+			CreatedBy:        &userID,
+		}
+		details := map[string]interface{}{
+			"method":         req.Method,
+			"input_quantity": roundQuantity(input),
+		}
+		if notes := strings.TrimSpace(req.Notes); notes != "" {
+			details["notes"] = notes
+		}
+		return s.createLot(ctx, repo, lot, parents, EventProcessed, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetLot(ctx, lot.ID)
+}
+
+func (s *traceService) resolveLot(ctx context.Context, idOrCode string) (*model.TraceLot, error) {
+	var lot *model.TraceLot
+	var err error
+	if _, parseErr := uuid.Parse(idOrCode); parseErr == nil {
+		lot, err = s.repo.GetLotByID(ctx, idOrCode)
+	} else {
+		lot, err = s.repo.GetLotByCode(ctx, strings.ToUpper(idOrCode))
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLotNotFound
+		}
+		return nil, err
+	}
+	return lot, nil
+}
+
+func (s *traceService) GetLot(ctx context.Context, idOrCode string) (*TraceLotDTO, error) {
+	lot, err := s.resolveLot(ctx, idOrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.repo.GetRecordsForLots(ctx, []string{lot.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toTraceLotDTO(lot)
+	for _, record := range records {
+		response.Records = append(response.Records, toTraceRecordDTO(record))
+	}
+	verified, _ := checkLotRecords(lot, records)
+	response.Verified = &verified
+	return response, nil
+}
+
+func (s *traceService) GetPickupLots(ctx context.Context, pickupID string) ([]TraceLotDTO, error) {
+	lots, err := s.repo.GetPickupLots(ctx, pickupID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]TraceLotDTO, 0, len(lots))
+	for i := range lots {
+		response = append(response, *toTraceLotDTO(&lots[i]))
+	}
+	return response, nil
+}
+
+// Walk returns every lot reachable from the start lot in one direction: forward
+// follows the load towards processing, backward goes back to the source pickups.
+// Each lot carries its ledger records, and every lot and link is checked against the
+// ledger payloads, so a row edited without a matching record shows as unverified.
+func (s *traceService) Walk(ctx context.Context, idOrCode, direction string) (*TraceWalkDTO, error) {
+	root, err := s.resolveLot(ctx, idOrCode)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.Walk(ctx, root.ID, direction != DirectionBackward, maxWalkDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	depths := make(map[string]int, len(rows))
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		depths[row.LotID] = row.Depth
+		ids = append(ids, row.LotID)
+	}
+
+	lots, err := s.repo.GetLotsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.repo.GetLinksBetween(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.repo.GetRecordsForLots(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	recordsByLot := make(map[string][]model.TraceRecord)
+	for _, record := range records {
+		recordsByLot[record.LotID] = append(recordsByLot[record.LotID], record)
+	}
+	parentsByLot := make(map[string]map[string]float64, len(lots))
+
+	walk := &TraceWalkDTO{
+		Root:      root.ID,
+		Direction: DirectionForward,
+		Lots:      make([]TraceLotDTO, 0, len(lots)),
+		Links:     make([]TraceLinkDTO, 0, len(links)),
+	}
+	if direction == DirectionBackward {
+		walk.Direction = DirectionBackward
+	}
+	for i := range lots {
+		dto := toTraceLotDTO(&lots[i])
+		depth := depths[lots[i].ID]
+		dto.Depth = &depth
+		for _, record := range recordsByLot[lots[i].ID] {
+			dto.Records = append(dto.Records, toTraceRecordDTO(record))
+		}
+		verified, parents := checkLotRecords(&lots[i], recordsByLot[lots[i].ID])
+		dto.Verified = &verified
+		parentsByLot[lots[i].ID] = parents
+		walk.Lots = append(walk.Lots, *dto)
+	}
+	sort.SliceStable(walk.Lots, func(i, j int) bool {
+		return *walk.Lots[i].Depth < *walk.Lots[j].Depth
+	})
+	for _, link := range links {
+		recorded, ok := parentsByLot[link.ChildLotID][link.ParentLotID]
+		walk.Links = append(walk.Links, TraceLinkDTO{
+			ParentLotID: link.ParentLotID,
+			ChildLotID:  link.ChildLotID,
+			Quantity:    link.Quantity,
+			Verified:    ok && roundQuantity(recorded) == roundQuantity(link.Quantity),
+		})
+	}
+	return walk, nil
+}
+
+// checkLotRecords reports whether every record of the lot still matches its hash and
+// the lot row matches the latest one, and returns the parents the lot was recorded as
+// made from. Records must be in sequence order.
+func checkLotRecords(lot *model.TraceLot, records []model.TraceRecord) (bool, map[string]float64) {
+	parents := make(map[string]float64)
+	if len(records) == 0 {
+		return false, parents
+	}
+
+	verified := true
+	var latest recordPayload
+	for i, record := range records {
+		payload, err := decodePayload(record.Payload)
+		if err != nil || chainHash(record.PrevHash, record.Sequence, record.Payload) != record.Hash {
+			verified = false
+			continue
+		}
+		if i == 0 {
+			for _, parent := range payload.Parents {
+				parents[parent.LotID] = parent.Quantity
+			}
+		}
+		latest = payload
+	}
+	return verified && lotPayloadMismatch(lot, latest) == "", parents
+}
+
+// ledgerLot is what the ledger says about one lot: its latest payload, the parents
+// its first record lists and whether a later lot was made from it.
+type ledgerLot struct {
+	payload  recordPayload
+	parents  map[string]float64
+	consumed bool
+	seen     bool
+	linked   map[string]bool
+}
+
+// VerifyChain rechecks the whole ledger in sequence order and stops at the first
+// record that was altered, removed or inserted out of place. Walks and lots are served
+// from the lot and link rows, so once the chain holds those rows are compared with
+// the state replayed from the payloads. Everything is read from one snapshot.
+func (s *traceService) VerifyChain(ctx context.Context) (*ChainVerificationDTO, error) {
+	result := &ChainVerificationDTO{Valid: true}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		ledger, err := s.replayChain(ctx, repo, result)
+		if err != nil || !result.Valid {
+			return err
+		}
+		return s.compareWithLedger(ctx, repo, ledger, result)
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// replayChain checks the hashes and builds the ledger state of every lot.
+func (s *traceService) replayChain(ctx context.Context, repo TraceRepository, result *ChainVerificationDTO) (map[string]*ledgerLot, error) {
+	ledger := make(map[string]*ledgerLot)
+	prevHash, lastSequence := genesisHash, int64(0)
+
+	for {
+		records, err := repo.GetRecordsAfter(ctx, lastSequence, verificationBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			payload, decodeErr := decodePayload(record.Payload)
+
+			reason := ""
+			switch {
+			case record.Sequence != lastSequence+1:
+				reason = fmt.Sprintf("urutan %d hilang", lastSequence+1)
+			case record.PrevHash != prevHash:
+				reason = "hash sebelumnya tidak cocok"
+			case chainHash(record.PrevHash, record.Sequence, record.Payload) != record.Hash:
+				reason = "isi catatan tidak cocok dengan hash"
+			case decodeErr != nil:
+				reason = "isi catatan tidak dapat dibaca"
+			case payload.LotID != record.LotID || payload.Event != record.Event:
+				reason = "lot atau event catatan tidak cocok dengan isinya"
+			}
+			if reason != "" {
+				sequence := record.Sequence
+				result.Valid = false
+				result.BrokenAt = &sequence
+				result.Reason = reason
+				return nil, nil
+			}
+
+			lot, ok := ledger[payload.LotID]
+			if !ok {
+				lot = &ledgerLot{parents: make(map[string]float64), linked: make(map[string]bool)}
+				for _, parent := range payload.Parents {
+					lot.parents[parent.LotID] = parent.Quantity
+				}
+				ledger[payload.LotID] = lot
+			}
+			lot.payload = payload
+			for _, parent := range payload.Parents {
+				if parentLot, ok := ledger[parent.LotID]; ok {
+					parentLot.consumed = true
+				}
+			}
+
+			result.RecordsChecked++
+			prevHash, lastSequence = record.Hash, record.Sequence
+			result.LastSequence = lastSequence
+		}
+
+		if len(records) < verificationBatch {
+			return ledger, nil
+		}
+	}
+}
+
+// compareWithLedger checks every lot and link row against the replayed ledger and
+// reports rows that differ, rows the ledger never recorded and recorded rows that
+// are gone.
+func (s *traceService) compareWithLedger(ctx context.Context, repo TraceRepository, ledger map[string]*ledgerLot, result *ChainVerificationDTO) error {
+	var mismatches []TraceMismatchDTO
+
+	for afterID := ""; ; {
+		lots, err := repo.GetLotsAfter(ctx, afterID, verificationBatch)
+		if err != nil {
+			return err
+		}
+
+		for i := range lots {
+			lot := &lots[i]
+			result.LotsChecked++
+
+			recorded, ok := ledger[lot.ID]
+			if !ok {
+				mismatches = append(mismatches, TraceMismatchDTO{LotID: lot.ID, Reason: "lot tidak tercatat di ledger"})
+				continue
+			}
+			recorded.seen = true
+
+			status := LotOpen
+			if recorded.consumed {
+				status = LotConsumed
+			}
+			if field := lotPayloadMismatch(lot, recorded.payload); field != "" {
+				mismatches = append(mismatches, TraceMismatchDTO{LotID: lot.ID, Field: field, Reason: "lot tidak cocok dengan catatan ledger"})
+			} else if lot.Status != status {
+				mismatches = append(mismatches, TraceMismatchDTO{LotID: lot.ID, Field: "status", Reason: "lot tidak cocok dengan catatan ledger"})
+			}
+		}
+
+		if len(lots) < verificationBatch {
+			break
+		}
+		afterID = lots[len(lots)-1].ID
+	}
+
+	for afterID := ""; ; {
+		links, err := repo.GetLinksAfter(ctx, afterID, verificationBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			result.LinksChecked++
+
+			recorded, ok := ledger[link.ChildLotID]
+			var quantity float64
+			if ok {
+				quantity, ok = recorded.parents[link.ParentLotID]
+			}
+			if !ok {
+				mismatches = append(mismatches, TraceMismatchDTO{LotID: link.ChildLotID, ParentLotID: link.ParentLotID, Reason: "tautan tidak tercatat di ledger"})
+				continue
+			}
+			recorded.linked[link.ParentLotID] = true
+
+			if roundQuantity(quantity) != roundQuantity(link.Quantity) {
+				mismatches = append(mismatches, TraceMismatchDTO{LotID: link.ChildLotID, ParentLotID: link.ParentLotID, Field: "quantity", Reason: "tautan tidak cocok dengan catatan ledger"})
+			}
+		}
+
+		if len(links) < verificationBatch {
+			break
+		}
+		afterID = links[len(links)-1].ID
+	}
+
+	for lotID, recorded := range ledger {
+		if !recorded.seen {
+			mismatches = append(mismatches, TraceMismatchDTO{LotID: lotID, Reason: "lot tercatat di ledger tetapi tidak ditemukan"})
+		}
+		for parentID := range recorded.parents {
+			if !recorded.linked[parentID] {
+				mismatches = append(mismatches, TraceMismatchDTO{LotID: lotID, ParentLotID: parentID, Reason: "tautan tercatat di ledger tetapi tidak ditemukan"})
+			}
+		}
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].LotID != mismatches[j].LotID {
+			return mismatches[i].LotID < mismatches[j].LotID
+		}
+		return mismatches[i].ParentLotID < mismatches[j].ParentLotID
+	})
+	result.Valid = false
+	result.Reason = "data lot atau tautan tidak cocok dengan ledger"
+	result.MismatchCount = len(mismatches)
+	if len(mismatches) > maxReportedMismatches {
+		mismatches = mismatches[:maxReportedMismatches]
+	}
+	result.Mismatches = mismatches
+	return nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func toTraceLotDTO(lot *model.TraceLot) *TraceLotDTO {
+	response := &TraceLotDTO{
+		ID:               lot.ID,
+		Code:             lot.Code,
+		Stage:            lot.Stage,
+		Status:           lot.Status,
+		TrashCategoryID:  lot.TrashCategoryID,
+		Quantity:         lot.Quantity,
+		Unit:             lot.Unit,
+		ReferenceType:    lot.ReferenceType,
+		ReferenceID:      lot.ReferenceID,
+		CollectorID:      lot.CollectorID,
+		CompanyProfileID: lot.CompanyProfileID,
+		ProcessMethod:    lot.ProcessMethod,
+		CreatedAt:        lot.CreatedAt.Format(time.RFC3339),
+	}
+	if lot.TrashCategory != nil {
+		response.TrashCategoryName = lot.TrashCategory.Name
+	}
+	return response
+}
+
+func toTraceRecordDTO(record model.TraceRecord) TraceRecordDTO {
+	return TraceRecordDTO{
+		Sequence:  record.Sequence,
+		Event:     record.Event,
+		Payload:   record.Payload,
+		PrevHash:  record.PrevHash,
+		Hash:      record.Hash,
+		Verified:  chainHash(record.PrevHash, record.Sequence, record.Payload) == record.Hash,
+		CreatedAt: record.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,136 @@
+package trash
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"rijig/model"
+)
+
+var errCategoryCycle = errors.New("invalid parent: a category cannot be moved under itself or one of its subcategories")
+
+// categoryTree indexes the whole category table so paths and subtrees can be resolved
+// without a query per level. The table is small enough to load in one go.
+type categoryTree struct {
+	byID     map[string]*model.TrashCategory
+	children map[string][]*model.TrashCategory
+	roots    []*model.TrashCategory
+}
+
+func newCategoryTree(categories []model.TrashCategory) *categoryTree {
+	tree := &categoryTree{
+		byID:     make(map[string]*model.TrashCategory, len(categories)),
+		children: make(map[string][]*model.TrashCategory),
+	}
+	for i := range categories {
+		tree.byID[categories[i].ID] = &categories[i]
+	}
+	for i := range categories {
+		category := &categories[i]
+		if category.ParentID != nil && tree.byID[*category.ParentID] != nil {
+			tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category)
+			continue
+		}
+		tree.roots = append(tree.roots, category)
+	}
+
+	byName := func(list []*model.TrashCategory) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	byName(tree.roots)
+	for _, list := range tree.children {
+		byName(list)
+	}
+	return tree
+}
+
+// ancestors returns the chain from the root down to, but excluding, the category. The
+// walk stops at a repeated node so a corrupt parent link cannot loop forever.
+func (t *categoryTree) ancestors(category *model.TrashCategory) []*model.TrashCategory {
+	var chain []*model.TrashCategory
+	seen := map[string]bool{category.ID: true}
+	for current := category; current.ParentID != nil; {
+		parent, ok := t.byID[*current.ParentID]
+		if !ok || seen[parent.ID] {
+			break
+		}
+		seen[parent.ID] = true
+		chain = append([]*model.TrashCategory{parent}, chain...)
+		current = parent
+	}
+	return chain
+}
+
+func (t *categoryTree) path(category *model.TrashCategory) []string {
+	ancestors := t.ancestors(category)
+	path := make([]string, 0, len(ancestors)+1)
+	for _, ancestor := range ancestors {
+		path = append(path, ancestor.Name)
+	}
+	return append(path, category.Name)
+}
+
+// checkParent reports whether parentID may become the parent of categoryID. An empty
+// categoryID means the category is being created and cannot be part of a cycle yet.
+func (t *categoryTree) checkParent(categoryID, parentID string) error {
+	parent, ok := t.byID[parentID]
+	if !ok {
+		return errors.New("parent category not found")
+	}
+	if categoryID == "" {
+		return nil
+	}
+	if parent.ID == categoryID {
+		return errCategoryCycle
+	}
+	for _, ancestor := range t.ancestors(parent) {
+		if ancestor.ID == categoryID {
+			return errCategoryCycle
+		}
+	}
+	return nil
+}
+
+// search matches the query against each category name and the names of its ancestors,
+// so "plastic" also finds "Clear PET" filed under Plastic → PET bottle.
+func (t *categoryTree) search(query string) []categoryMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var matches []categoryMatch
+	var walk func(list []*model.TrashCategory)
+	walk = func(list []*model.TrashCategory) {
+		for _, category := range list {
+			if match, ok := t.match(category, query); ok {
+				matches = append(matches, match)
+			}
+			walk(t.children[category.ID])
+		}
+	}
+	walk(t.roots)
+
+	// direct hits first, then hits through an ancestor; the walk order is kept otherwise
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Direct && !matches[j].Direct
+	})
+	return matches
+}
+
+func (t *categoryTree) match(category *model.TrashCategory, query string) (categoryMatch, bool) {
+	if strings.Contains(strings.ToLower(category.Name), query) {
+		return categoryMatch{Category: category, MatchedOn: category.Name, Direct: true}, true
+	}
+	ancestors := t.ancestors(category)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(ancestors[i].Name), query) {
+			return categoryMatch{Category: category, MatchedOn: ancestors[i].Name}, true
+		}
+	}
+	return categoryMatch{}, false
+}
+
+type categoryMatch struct {
+	Category  *model.TrashCategory
+	MatchedOn string
+	Direct    bool
+}
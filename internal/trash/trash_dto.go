@@ -5,10 +5,15 @@ import (
 )
 
 type RequestTrashCategoryDTO struct {
-	Name           string  `json:"name"`
-	EstimatedPrice float64 `json:"estimated_price"`
-	IconTrash      string  `json:"icon_trash,omitempty"`
-	Variety        string  `json:"variety"`
+	ParentID       *string  `json:"parent_id,omitempty"`
+	Name           string   `json:"name"`
+	EstimatedPrice float64  `json:"estimated_price"`
+	IconTrash      string   `json:"icon_trash,omitempty"`
+	Variety        string   `json:"variety"`
+	Unit           string   `json:"unit,omitempty"`
+	MinQuantity    float64  `json:"min_quantity,omitempty"`
+	MaxQuantity    *float64 `json:"max_quantity,omitempty"`
+	EmissionFactor float64  `json:"emission_factor,omitempty"`
 }
 
 type RequestTrashDetailDTO struct {
@@ -19,14 +24,24 @@ type RequestTrashDetailDTO struct {
 }
 
 type ResponseTrashCategoryDTO struct {
-	ID             string                   `json:"id,omitempty"`
-	TrashName      string                   `json:"trash_name,omitempty"`
-	TrashIcon      string                   `json:"trash_icon,omitempty"`
-	EstimatedPrice float64                  `json:"estimated_price"`
-	Variety        string                   `json:"variety,omitempty"`
-	CreatedAt      string                   `json:"created_at,omitempty"`
-	UpdatedAt      string                   `json:"updated_at,omitempty"`
-	TrashDetail    []ResponseTrashDetailDTO `json:"trash_detail,omitempty"`
+	ID             string                     `json:"id,omitempty"`
+	ParentID       string                     `json:"parent_id,omitempty"`
+	Path           []string                   `json:"path,omitempty"`
+	TrashName      string                     `json:"trash_name,omitempty"`
+	TrashIcon      string                     `json:"trash_icon,omitempty"`
+	EstimatedPrice float64                    `json:"estimated_price"`
+	NationalPrice  float64                    `json:"national_price,omitempty"`
+	PriceRegion    string                     `json:"price_region,omitempty"`
+	Unit           string                     `json:"unit,omitempty"`
+	MinQuantity    float64                    `json:"min_quantity"`
+	MaxQuantity    *float64                   `json:"max_quantity,omitempty"`
+	EmissionFactor float64                    `json:"emission_factor"`
+	Variety        string                     `json:"variety,omitempty"`
+	MatchedOn      string                     `json:"matched_on,omitempty"`
+	CreatedAt      string                     `json:"created_at,omitempty"`
+	UpdatedAt      string                     `json:"updated_at,omitempty"`
+	TrashDetail    []ResponseTrashDetailDTO   `json:"trash_detail,omitempty"`
+	Children       []ResponseTrashCategoryDTO `json:"children,omitempty"`
 }
 
 type ResponseTrashDetailDTO struct {
@@ -51,6 +66,30 @@ func (r *RequestTrashCategoryDTO) ValidateRequestTrashCategoryDTO() (map[string]
 	if strings.TrimSpace(r.Variety) == "" {
 		errors["variety"] = append(errors["variety"], "variety is required")
 	}
+	if r.Unit != "" && !IsValidUnit(r.Unit) {
+		errors["unit"] = append(errors["unit"], "unit must be kg, piece or litre")
+	}
+	if r.MinQuantity < 0 {
+		errors["min_quantity"] = append(errors["min_quantity"], "min quantity cannot be negative")
+	}
+	if r.MaxQuantity != nil {
+		if *r.MaxQuantity <= 0 {
+			errors["max_quantity"] = append(errors["max_quantity"], "max quantity must be greater than 0")
+		} else if *r.MaxQuantity < r.MinQuantity {
+			errors["max_quantity"] = append(errors["max_quantity"], "max quantity cannot be less than min quantity")
+		}
+	}
+	if r.EmissionFactor < 0 {
+		errors["emission_factor"] = append(errors["emission_factor"], "emission factor cannot be negative")
+	}
+	if r.Unit == UnitPiece {
+		if ValidateWholeUnits(r.Unit, r.MinQuantity) != nil {
+			errors["min_quantity"] = append(errors["min_quantity"], "min quantity must be a whole number for piece")
+		}
+		if r.MaxQuantity != nil && ValidateWholeUnits(r.Unit, *r.MaxQuantity) != nil {
+			errors["max_quantity"] = append(errors["max_quantity"], "max quantity must be a whole number for piece")
+		}
+	}
 
 	if len(errors) > 0 {
 		return errors, false
@@ -73,3 +112,99 @@ func (r *RequestTrashDetailDTO) ValidateRequestTrashDetailDTO() (map[string][]st
 	}
 	return nil, true
 }
+
+type RequestSchedulePriceDTO struct {
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"`
+	Note          string  `json:"note,omitempty"`
+}
+
+type ResponseTrashPriceChangeDTO struct {
+	ID            string  `json:"id"`
+	CategoryID    string  `json:"category_id"`
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"`
+	Status        string  `json:"status"`
+	Source        string  `json:"source"`
+	Note          string  `json:"note,omitempty"`
+	CreatedBy     string  `json:"created_by,omitempty"`
+	AppliedAt     string  `json:"applied_at,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+type ResponseTrashPricePointDTO struct {
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"`
+	EffectiveTo   string  `json:"effective_to,omitempty"`
+	Source        string  `json:"source"`
+}
+
+type ResponseTrashPriceCurveDTO struct {
+	CategoryID   string                        `json:"category_id"`
+	CategoryName string                        `json:"category_name"`
+	CurrentPrice float64                       `json:"current_price"`
+	From         string                        `json:"from"`
+	To           string                        `json:"to"`
+	Points       []ResponseTrashPricePointDTO  `json:"points"`
+	Scheduled    []ResponseTrashPriceChangeDTO `json:"scheduled"`
+}
+
+type ResponseTrashPriceAtDTO struct {
+	CategoryID    string  `json:"category_id"`
+	At            string  `json:"at"`
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from,omitempty"`
+	Source        string  `json:"source"`
+}
+
+func (r *RequestSchedulePriceDTO) ValidateRequestSchedulePriceDTO() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if r.Price <= 0 {
+		errors["price"] = append(errors["price"], "price must be greater than 0")
+	}
+	if strings.TrimSpace(r.EffectiveFrom) == "" {
+		errors["effective_from"] = append(errors["effective_from"], "effective_from is required")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type RequestRegionalPriceDTO struct {
+	RegionLevel string  `json:"region_level"`
+	RegionID    string  `json:"region_id"`
+	Price       float64 `json:"price"`
+}
+
+type ResponseRegionalPriceDTO struct {
+	ID           string  `json:"id"`
+	CategoryID   string  `json:"category_id"`
+	RegionLevel  string  `json:"region_level"`
+	RegionID     string  `json:"region_id"`
+	RegionName   string  `json:"region_name"`
+	ProvinceName string  `json:"province_name,omitempty"`
+	Price        float64 `json:"price"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+func (r *RequestRegionalPriceDTO) ValidateRequestRegionalPriceDTO() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if r.RegionLevel != "province" && r.RegionLevel != "regency" {
+		errors["region_level"] = append(errors["region_level"], "region_level must be province or regency")
+	}
+	if strings.TrimSpace(r.RegionID) == "" {
+		errors["region_id"] = append(errors["region_id"], "region_id is required")
+	}
+	if r.Price <= 0 {
+		errors["price"] = append(errors["price"], "price must be greater than 0")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
@@ -1,6 +1,8 @@
 package trash
 
 import (
+	"errors"
+	"rijig/middleware"
 	"rijig/utils"
 	"strconv"
 	"strings"
@@ -9,12 +11,14 @@ import (
 )
 
 type TrashHandler struct {
-	trashService TrashServiceInterface
+	trashService    TrashServiceInterface
+	regionalService TrashRegionalPriceServiceInterface
 }
 
-func NewTrashHandler(trashService TrashServiceInterface) *TrashHandler {
+func NewTrashHandler(trashService TrashServiceInterface, regionalService TrashRegionalPriceServiceInterface) *TrashHandler {
 	return &TrashHandler{
-		trashService: trashService,
+		trashService:    trashService,
+		regionalService: regionalService,
 	}
 }
 
@@ -29,6 +33,9 @@ func (h *TrashHandler) CreateTrashCategory(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "validation failed") {
 			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(err.Error()))
 		}
+		if isCategoryHierarchyError(err) {
+			return utils.BadRequest(c, err.Error())
+		}
 		return utils.InternalServerError(c, "Failed to create trash category")
 	}
 
@@ -40,6 +47,11 @@ func (h *TrashHandler) CreateTrashCategoryWithIcon(c *fiber.Ctx) error {
 
 	req.Name = c.FormValue("name")
 	req.Variety = c.FormValue("variety")
+	req.Unit = c.FormValue("unit")
+
+	if parentID := c.FormValue("parent_id"); parentID != "" {
+		req.ParentID = &parentID
+	}
 
 	if estimatedPriceStr := c.FormValue("estimated_price"); estimatedPriceStr != "" {
 		if price, err := strconv.ParseFloat(estimatedPriceStr, 64); err == nil {
@@ -47,6 +59,24 @@ func (h *TrashHandler) CreateTrashCategoryWithIcon(c *fiber.Ctx) error {
 		}
 	}
 
+	if minQuantityStr := c.FormValue("min_quantity"); minQuantityStr != "" {
+		if minQuantity, err := strconv.ParseFloat(minQuantityStr, 64); err == nil {
+			req.MinQuantity = minQuantity
+		}
+	}
+
+	if maxQuantityStr := c.FormValue("max_quantity"); maxQuantityStr != "" {
+		if maxQuantity, err := strconv.ParseFloat(maxQuantityStr, 64); err == nil {
+			req.MaxQuantity = &maxQuantity
+		}
+	}
+
+	if emissionFactorStr := c.FormValue("emission_factor"); emissionFactorStr != "" {
+		if emissionFactor, err := strconv.ParseFloat(emissionFactorStr, 64); err == nil {
+			req.EmissionFactor = emissionFactor
+		}
+	}
+
 	iconFile, err := c.FormFile("icon")
 	if err != nil && err.Error() != "there is no uploaded file associated with the given key" {
 		return utils.BadRequest(c, "Invalid icon file")
@@ -57,6 +87,9 @@ func (h *TrashHandler) CreateTrashCategoryWithIcon(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "validation failed") {
 			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(err.Error()))
 		}
+		if isCategoryHierarchyError(err) {
+			return utils.BadRequest(c, err.Error())
+		}
 		if strings.Contains(err.Error(), "invalid file type") {
 			return utils.BadRequest(c, err.Error())
 		}
@@ -81,6 +114,9 @@ func (h *TrashHandler) CreateTrashCategoryWithDetails(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "validation failed") {
 			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(err.Error()))
 		}
+		if isCategoryHierarchyError(err) {
+			return utils.BadRequest(c, err.Error())
+		}
 		return utils.InternalServerError(c, "Failed to create trash category with details")
 	}
 
@@ -103,6 +139,9 @@ func (h *TrashHandler) UpdateTrashCategory(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "validation failed") {
 			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(err.Error()))
 		}
+		if isCategoryHierarchyError(err) {
+			return utils.BadRequest(c, err.Error())
+		}
 		if strings.Contains(err.Error(), "not found") {
 			return utils.NotFound(c, "Trash category not found")
 		}
@@ -122,6 +161,11 @@ func (h *TrashHandler) UpdateTrashCategoryWithIcon(c *fiber.Ctx) error {
 
 	req.Name = c.FormValue("name")
 	req.Variety = c.FormValue("variety")
+	req.Unit = c.FormValue("unit")
+
+	if parentID := c.FormValue("parent_id"); parentID != "" {
+		req.ParentID = &parentID
+	}
 
 	if estimatedPriceStr := c.FormValue("estimated_price"); estimatedPriceStr != "" {
 		if price, err := strconv.ParseFloat(estimatedPriceStr, 64); err == nil {
@@ -129,6 +173,24 @@ func (h *TrashHandler) UpdateTrashCategoryWithIcon(c *fiber.Ctx) error {
 		}
 	}
 
+	if minQuantityStr := c.FormValue("min_quantity"); minQuantityStr != "" {
+		if minQuantity, err := strconv.ParseFloat(minQuantityStr, 64); err == nil {
+			req.MinQuantity = minQuantity
+		}
+	}
+
+	if maxQuantityStr := c.FormValue("max_quantity"); maxQuantityStr != "" {
+		if maxQuantity, err := strconv.ParseFloat(maxQuantityStr, 64); err == nil {
+			req.MaxQuantity = &maxQuantity
+		}
+	}
+
+	if emissionFactorStr := c.FormValue("emission_factor"); emissionFactorStr != "" {
+		if emissionFactor, err := strconv.ParseFloat(emissionFactorStr, 64); err == nil {
+			req.EmissionFactor = emissionFactor
+		}
+	}
+
 	iconFile, _ := c.FormFile("icon")
 
 	response, err := h.trashService.UpdateTrashCategoryWithIcon(c.Context(), id, req, iconFile)
@@ -136,6 +198,9 @@ func (h *TrashHandler) UpdateTrashCategoryWithIcon(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "validation failed") {
 			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(err.Error()))
 		}
+		if isCategoryHierarchyError(err) {
+			return utils.BadRequest(c, err.Error())
+		}
 		if strings.Contains(err.Error(), "not found") {
 			return utils.NotFound(c, "Trash category not found")
 		}
@@ -151,17 +216,50 @@ func (h *TrashHandler) UpdateTrashCategoryWithIcon(c *fiber.Ctx) error {
 func (h *TrashHandler) GetAllTrashCategories(c *fiber.Ctx) error {
 	withDetails := c.Query("with_details", "false")
 
+	var response []ResponseTrashCategoryDTO
+	var err error
 	if withDetails == "true" {
-		response, err := h.trashService.GetAllTrashCategoriesWithDetails(c.Context())
+		response, err = h.trashService.GetAllTrashCategoriesWithDetails(c.Context())
+	} else {
+		response, err = h.trashService.GetAllTrashCategories(c.Context())
+	}
+	if err != nil {
+		return utils.InternalServerError(c, "Failed to get trash categories")
+	}
+
+	// prices follow the region of the chosen address, when one is given
+	if addressID := c.Query("address_id"); addressID != "" {
+		claims, err := middleware.GetUserFromContext(c)
 		if err != nil {
-			return utils.InternalServerError(c, "Failed to get trash categories")
+			return err
+		}
+		if err := h.regionalService.ApplyToCategories(c.Context(), claims.UserID, addressID, response); err != nil {
+			if errors.Is(err, ErrPriceAddressNotFound) {
+				return utils.NotFound(c, "Address not found")
+			}
+			return utils.InternalServerError(c, "Failed to resolve regional prices")
 		}
-		return utils.SuccessWithData(c, "Trash categories retrieved successfully", response)
 	}
 
-	response, err := h.trashService.GetAllTrashCategories(c.Context())
+	return utils.SuccessWithData(c, "Trash categories retrieved successfully", response)
+}
+
+func (h *TrashHandler) GetTrashCategoryTree(c *fiber.Ctx) error {
+	response, err := h.trashService.GetTrashCategoryTree(c.Context())
 	if err != nil {
-		return utils.InternalServerError(c, "Failed to get trash categories")
+		return utils.InternalServerError(c, "Failed to get trash category tree")
+	}
+
+	return utils.SuccessWithData(c, "Trash category tree retrieved successfully", response)
+}
+
+func (h *TrashHandler) SearchTrashCategories(c *fiber.Ctx) error {
+	response, err := h.trashService.SearchTrashCategories(c.Context(), c.Query("q"))
+	if err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(err.Error()))
+		}
+		return utils.InternalServerError(c, "Failed to search trash categories")
 	}
 
 	return utils.SuccessWithData(c, "Trash categories retrieved successfully", response)
@@ -208,6 +306,9 @@ func (h *TrashHandler) DeleteTrashCategory(c *fiber.Ctx) error {
 		if strings.Contains(err.Error(), "not found") {
 			return utils.NotFound(c, "Trash category not found")
 		}
+		if strings.Contains(err.Error(), "subcategories") {
+			return utils.ResponseErrorData(c, fiber.StatusConflict, "Trash category still has subcategories", nil)
+		}
 		return utils.InternalServerError(c, "Failed to delete trash category")
 	}
 
@@ -512,6 +613,11 @@ func (h *TrashHandler) ReorderTrashDetails(c *fiber.Ctx) error {
 	return utils.Success(c, "Trash details reordered successfully")
 }
 
+// isCategoryHierarchyError reports a parent that is missing or would create a cycle.
+func isCategoryHierarchyError(err error) bool {
+	return errors.Is(err, errCategoryCycle) || strings.Contains(err.Error(), "parent category not found")
+}
+
 func extractValidationErrors(errMsg string) interface{} {
 
 	if strings.Contains(errMsg, "validation failed:") {
@@ -0,0 +1,89 @@
+package trash
+
+import (
+	"rijig/middleware"
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TrashPriceHandler struct {
+	priceService TrashPriceServiceInterface
+}
+
+func NewTrashPriceHandler(priceService TrashPriceServiceInterface) *TrashPriceHandler {
+	return &TrashPriceHandler{
+		priceService: priceService,
+	}
+}
+
+func (h *TrashPriceHandler) SchedulePriceChange(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequestSchedulePriceDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(c, "Invalid request body")
+	}
+
+	response, err := h.priceService.SchedulePriceChange(c.Context(), c.Params("id"), claims.UserID, req)
+	if err != nil {
+		return handleTrashPriceError(c, err, "Failed to schedule price change")
+	}
+
+	return utils.CreateSuccessWithData(c, "Price change scheduled successfully", response)
+}
+
+func (h *TrashPriceHandler) GetScheduledPrices(c *fiber.Ctx) error {
+	response, err := h.priceService.GetScheduledPrices(c.Context(), c.Params("id"))
+	if err != nil {
+		return handleTrashPriceError(c, err, "Failed to get scheduled price changes")
+	}
+
+	return utils.SuccessWithData(c, "Scheduled price changes retrieved successfully", response)
+}
+
+func (h *TrashPriceHandler) CancelScheduledPrice(c *fiber.Ctx) error {
+	if err := h.priceService.CancelScheduledPrice(c.Context(), c.Params("priceId")); err != nil {
+		return handleTrashPriceError(c, err, "Failed to cancel price change")
+	}
+
+	return utils.Success(c, "Price change canceled successfully")
+}
+
+func (h *TrashPriceHandler) GetPriceCurve(c *fiber.Ctx) error {
+	response, err := h.priceService.GetPriceCurve(c.Context(), c.Params("id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		return handleTrashPriceError(c, err, "Failed to get price curve")
+	}
+
+	return utils.SuccessWithData(c, "Price curve retrieved successfully", response)
+}
+
+func (h *TrashPriceHandler) GetPriceAt(c *fiber.Ctx) error {
+	response, err := h.priceService.GetPriceAt(c.Context(), c.Params("id"), c.Query("at"))
+	if err != nil {
+		return handleTrashPriceError(c, err, "Failed to get price")
+	}
+
+	return utils.SuccessWithData(c, "Price retrieved successfully", response)
+}
+
+func handleTrashPriceError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "validation failed"):
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(msg))
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	case strings.HasPrefix(msg, "invalid"):
+		return utils.BadRequest(c, msg)
+	case strings.Contains(msg, "no longer scheduled"):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, msg, nil)
+	default:
+		return utils.InternalServerError(c, fallback)
+	}
+}
@@ -0,0 +1,259 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PriceStatusScheduled = "scheduled"
+	PriceStatusApplied   = "applied"
+	PriceStatusCanceled  = "canceled"
+
+	PriceSourceInitial   = "initial"
+	PriceSourceUpdate    = "update"
+	PriceSourceScheduled = "scheduled"
+)
+
+type TrashPriceRepository interface {
+	CreateScheduledPrice(ctx context.Context, price *model.TrashCategoryPrice) error
+	GetPriceByID(ctx context.Context, id string) (*model.TrashCategoryPrice, error)
+	CancelScheduledPrice(ctx context.Context, id string) error
+	GetScheduledPrices(ctx context.Context, categoryID string) ([]model.TrashCategoryPrice, error)
+	GetAppliedPrices(ctx context.Context, categoryID string, from, to time.Time) ([]model.TrashCategoryPrice, error)
+	GetPriceAt(ctx context.Context, categoryID string, at time.Time) (*model.TrashCategoryPrice, error)
+	ApplyDuePrices(ctx context.Context, now time.Time) ([]model.TrashCategoryPrice, error)
+}
+
+type trashPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashPriceRepository(db *gorm.DB) TrashPriceRepository {
+	return &trashPriceRepository{db: db}
+}
+
+// recordPriceChange appends an applied entry to the category's history inside tx.
+func recordPriceChange(tx *gorm.DB, category *model.TrashCategory, newPrice float64, at time.Time, source string) error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if source != PriceSourceInitial {
+		if err := ensureBaselinePrice(tx, category, at); err != nil {
+			return err
+		}
+	}
+
+	err := tx.Create(&model.TrashCategoryPrice{
+		TrashCategoryID: category.ID,
+		Price:           newPrice,
+		EffectiveFrom:   at,
+		Status:          PriceStatusApplied,
+		Source:          source,
+		AppliedAt:       &at,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+	return nil
+}
+
+func (r *trashPriceRepository) CreateScheduledPrice(ctx context.Context, price *model.TrashCategoryPrice) error {
+	price.Status = PriceStatusScheduled
+	price.Source = PriceSourceScheduled
+	if err := r.db.WithContext(ctx).Create(price).Error; err != nil {
+		return fmt.Errorf("failed to schedule price change: %w", err)
+	}
+	return nil
+}
+
+func (r *trashPriceRepository) GetPriceByID(ctx context.Context, id string) (*model.TrashCategoryPrice, error) {
+	var price model.TrashCategoryPrice
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&price).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("price change not found")
+		}
+		return nil, fmt.Errorf("failed to get price change: %w", err)
+	}
+	return &price, nil
+}
+
+func (r *trashPriceRepository) CancelScheduledPrice(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.TrashCategoryPrice{}).
+		Where("id = ? AND status = ?", id, PriceStatusScheduled).
+		Update("status", PriceStatusCanceled)
+	if result.Error != nil {
+		return fmt.Errorf("failed to cancel price change: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("price change is no longer scheduled")
+	}
+	return nil
+}
+
+func (r *trashPriceRepository) GetScheduledPrices(ctx context.Context, categoryID string) ([]model.TrashCategoryPrice, error) {
+	var prices []model.TrashCategoryPrice
+	err := r.db.WithContext(ctx).
+		Where("trash_category_id = ? AND status = ?", categoryID, PriceStatusScheduled).
+		Order("effective_from ASC").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled prices: %w", err)
+	}
+	return prices, nil
+}
+
+// GetAppliedPrices returns the entries effective inside [from, to], plus the entry in
+// force at from so the curve starts with a known price.
+func (r *trashPriceRepository) GetAppliedPrices(ctx context.Context, categoryID string, from, to time.Time) ([]model.TrashCategoryPrice, error) {
+	var prices []model.TrashCategoryPrice
+
+	opening, err := r.GetPriceAt(ctx, categoryID, from)
+	if err != nil {
+		return nil, err
+	}
+	if opening != nil {
+		prices = append(prices, *opening)
+	}
+
+	var inRange []model.TrashCategoryPrice
+	err = r.db.WithContext(ctx).
+		Where("trash_category_id = ? AND status = ? AND effective_from > ? AND effective_from <= ?",
+			categoryID, PriceStatusApplied, from, to).
+		Order("effective_from ASC, created_at ASC").
+		Find(&inRange).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	return append(prices, inRange...), nil
+}
+
+func (r *trashPriceRepository) GetPriceAt(ctx context.Context, categoryID string, at time.Time) (*model.TrashCategoryPrice, error) {
+	var price model.TrashCategoryPrice
+	err := r.db.WithContext(ctx).
+		Where("trash_category_id = ? AND status = ? AND effective_from <= ?", categoryID, PriceStatusApplied, at).
+		Order("effective_from DESC, created_at DESC").
+		First(&price).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+	return &price, nil
+}
+
+// ApplyDuePrices moves every scheduled change whose time has come onto its category.
+// An applied change takes effect from now, when the category price actually moves, so
+// the history agrees with the price charged in between. When several changes of one
+// category fell due since the last run only the latest is applied; the ones it
+// supersedes never took effect and are canceled. Rows locked by another instance are
+// skipped and picked up on its next run.
+func (r *trashPriceRepository) ApplyDuePrices(ctx context.Context, now time.Time) ([]model.TrashCategoryPrice, error) {
+	var applied []model.TrashCategoryPrice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var due []model.TrashCategoryPrice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND effective_from <= ?", PriceStatusScheduled, now).
+			Order("effective_from ASC, created_at ASC").
+			Find(&due).Error; err != nil {
+			return fmt.Errorf("failed to get due price changes: %w", err)
+		}
+
+		latest := make(map[string]int, len(due))
+		for i, price := range due {
+			latest[price.TrashCategoryID] = i
+		}
+
+		for i, price := range due {
+			if latest[price.TrashCategoryID] != i {
+				if err := tx.Model(&model.TrashCategoryPrice{}).Where("id = ?", price.ID).
+					Update("status", PriceStatusCanceled).Error; err != nil {
+					return fmt.Errorf("failed to cancel superseded price change: %w", err)
+				}
+				continue
+			}
+
+			var category model.TrashCategory
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", price.TrashCategoryID).
+				First(&category).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Model(&model.TrashCategoryPrice{}).Where("id = ?", price.ID).
+					Update("status", PriceStatusCanceled).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to lock trash category: %w", err)
+			}
+
+			if err := ensureBaselinePrice(tx, &category, now); err != nil {
+				return err
+			}
+
+			if err := tx.Model(&model.TrashCategory{}).Where("id = ?", category.ID).
+				Updates(map[string]interface{}{
+					"estimated_price": price.Price,
+					"updated_at":      now,
+				}).Error; err != nil {
+				return fmt.Errorf("failed to update trash category price: %w", err)
+			}
+
+			if err := tx.Model(&model.TrashCategoryPrice{}).Where("id = ?", price.ID).
+				Updates(map[string]interface{}{
+					"status":         PriceStatusApplied,
+					"effective_from": now,
+					"applied_at":     now,
+				}).Error; err != nil {
+				return fmt.Errorf("failed to mark price change applied: %w", err)
+			}
+
+			price.Status = PriceStatusApplied
+			price.EffectiveFrom = now
+			price.AppliedAt = &now
+			applied = append(applied, price)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// ensureBaselinePrice records the category's current price, effective from its creation,
+// when the category predates price history; otherwise the curve would start at the first
+// change and lose the price before it.
+func ensureBaselinePrice(tx *gorm.DB, category *model.TrashCategory, at time.Time) error {
+	var count int64
+	if err := tx.Model(&model.TrashCategoryPrice{}).
+		Where("trash_category_id = ? AND status = ?", category.ID, PriceStatusApplied).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check price history: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	err := tx.Create(&model.TrashCategoryPrice{
+		TrashCategoryID: category.ID,
+		Price:           category.EstimatedPrice,
+		EffectiveFrom:   category.CreatedAt,
+		Status:          PriceStatusApplied,
+		Source:          PriceSourceInitial,
+		AppliedAt:       &at,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record baseline price: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rijig/model"
+	"rijig/utils"
+	"time"
+)
+
+const defaultPriceCurveRange = 90 * 24 * time.Hour
+
+type TrashPriceServiceInterface interface {
+	SchedulePriceChange(ctx context.Context, categoryID, adminID string, req RequestSchedulePriceDTO) (*ResponseTrashPriceChangeDTO, error)
+	GetScheduledPrices(ctx context.Context, categoryID string) ([]ResponseTrashPriceChangeDTO, error)
+	CancelScheduledPrice(ctx context.Context, priceID string) error
+	GetPriceCurve(ctx context.Context, categoryID, from, to string) (*ResponseTrashPriceCurveDTO, error)
+	GetPriceAt(ctx context.Context, categoryID, at string) (*ResponseTrashPriceAtDTO, error)
+	ApplyDuePriceChanges(ctx context.Context) (int, error)
+}
+
+type TrashPriceService struct {
+	trashRepo TrashRepositoryInterface
+	priceRepo TrashPriceRepository
+}
+
+func NewTrashPriceService(trashRepo TrashRepositoryInterface, priceRepo TrashPriceRepository) TrashPriceServiceInterface {
+	return &TrashPriceService{
+		trashRepo: trashRepo,
+		priceRepo: priceRepo,
+	}
+}
+
+func (s *TrashPriceService) SchedulePriceChange(ctx context.Context, categoryID, adminID string, req RequestSchedulePriceDTO) (*ResponseTrashPriceChangeDTO, error) {
+	if errors, valid := req.ValidateRequestSchedulePriceDTO(); !valid {
+		return nil, fmt.Errorf("validation failed: %v", errors)
+	}
+
+	effectiveFrom, err := parsePriceTime(req.EffectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+	if !effectiveFrom.After(time.Now()) {
+		return nil, errors.New("invalid effective_from: must be in the future")
+	}
+
+	if _, err := s.trashRepo.GetTrashCategoryByID(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	price := &model.TrashCategoryPrice{
+		TrashCategoryID: categoryID,
+		Price:           req.Price,
+		EffectiveFrom:   effectiveFrom,
+		Note:            req.Note,
+		CreatedBy:       adminID,
+	}
+	if err := s.priceRepo.CreateScheduledPrice(ctx, price); err != nil {
+		return nil, err
+	}
+
+	return convertTrashPriceChangeToResponseDTO(price), nil
+}
+
+func (s *TrashPriceService) GetScheduledPrices(ctx context.Context, categoryID string) ([]ResponseTrashPriceChangeDTO, error) {
+	if _, err := s.trashRepo.GetTrashCategoryByID(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	prices, err := s.priceRepo.GetScheduledPrices(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]ResponseTrashPriceChangeDTO, len(prices))
+	for i := range prices {
+		responses[i] = *convertTrashPriceChangeToResponseDTO(&prices[i])
+	}
+	return responses, nil
+}
+
+func (s *TrashPriceService) CancelScheduledPrice(ctx context.Context, priceID string) error {
+	if _, err := s.priceRepo.GetPriceByID(ctx, priceID); err != nil {
+		return err
+	}
+	return s.priceRepo.CancelScheduledPrice(ctx, priceID)
+}
+
+// GetPriceCurve returns the category's price as consecutive segments over [from, to].
+// Both bounds are optional and default to the last 90 days.
+func (s *TrashPriceService) GetPriceCurve(ctx context.Context, categoryID, from, to string) (*ResponseTrashPriceCurveDTO, error) {
+	category, err := s.trashRepo.GetTrashCategoryByID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if to != "" {
+		if end, err = parsePriceTime(to); err != nil {
+			return nil, err
+		}
+	}
+	start := end.Add(-defaultPriceCurveRange)
+	if from != "" {
+		if start, err = parsePriceTime(from); err != nil {
+			return nil, err
+		}
+	}
+	if start.After(end) {
+		return nil, errors.New("invalid range: from must be before to")
+	}
+
+	history, err := s.priceRepo.GetAppliedPrices(ctx, categoryID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		// categories without history have only ever had their current price
+		history = []model.TrashCategoryPrice{{
+			Price:         category.EstimatedPrice,
+			EffectiveFrom: category.CreatedAt,
+			Source:        PriceSourceInitial,
+		}}
+	}
+
+	points := make([]ResponseTrashPricePointDTO, len(history))
+	for i, entry := range history {
+		points[i] = ResponseTrashPricePointDTO{
+			Price:         entry.Price,
+			EffectiveFrom: entry.EffectiveFrom.Format(time.RFC3339),
+			Source:        entry.Source,
+		}
+		if i+1 < len(history) {
+			points[i].EffectiveTo = history[i+1].EffectiveFrom.Format(time.RFC3339)
+		}
+	}
+
+	scheduled, err := s.GetScheduledPrices(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResponseTrashPriceCurveDTO{
+		CategoryID:   category.ID,
+		CategoryName: category.Name,
+		CurrentPrice: category.EstimatedPrice,
+		From:         start.Format(time.RFC3339),
+		To:           end.Format(time.RFC3339),
+		Points:       points,
+		Scheduled:    scheduled,
+	}, nil
+}
+
+func (s *TrashPriceService) GetPriceAt(ctx context.Context, categoryID, at string) (*ResponseTrashPriceAtDTO, error) {
+	category, err := s.trashRepo.GetTrashCategoryByID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	moment := time.Now()
+	if at != "" {
+		if moment, err = parsePriceTime(at); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &ResponseTrashPriceAtDTO{
+		CategoryID: categoryID,
+		At:         moment.Format(time.RFC3339),
+	}
+
+	entry, err := s.priceRepo.GetPriceAt(ctx, categoryID, moment)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		response.Price = category.EstimatedPrice
+		response.Source = "current"
+		return response, nil
+	}
+
+	response.Price = entry.Price
+	response.EffectiveFrom = entry.EffectiveFrom.Format(time.RFC3339)
+	response.Source = entry.Source
+	return response, nil
+}
+
+func (s *TrashPriceService) ApplyDuePriceChanges(ctx context.Context) (int, error) {
+	applied, err := s.priceRepo.ApplyDuePrices(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return len(applied), nil
+}
+
+// parsePriceTime accepts RFC3339, or a WIB "YYYY-MM-DD HH:MM" or "YYYY-MM-DD".
+func parsePriceTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, utils.IndonesianLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339, YYYY-MM-DD HH:MM or YYYY-MM-DD", value)
+}
+
+func convertTrashPriceChangeToResponseDTO(price *model.TrashCategoryPrice) *ResponseTrashPriceChangeDTO {
+	response := &ResponseTrashPriceChangeDTO{
+		ID:            price.ID,
+		CategoryID:    price.TrashCategoryID,
+		Price:         price.Price,
+		EffectiveFrom: price.EffectiveFrom.Format(time.RFC3339),
+		Status:        price.Status,
+		Source:        price.Source,
+		Note:          price.Note,
+		CreatedBy:     price.CreatedBy,
+		CreatedAt:     price.CreatedAt.Format(time.RFC3339),
+	}
+	if price.AppliedAt != nil {
+		response.AppliedAt = price.AppliedAt.Format(time.RFC3339)
+	}
+	return response
+}
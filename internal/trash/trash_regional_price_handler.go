@@ -0,0 +1,61 @@
+package trash
+
+import (
+	"rijig/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TrashRegionalPriceHandler struct {
+	regionalService TrashRegionalPriceServiceInterface
+}
+
+func NewTrashRegionalPriceHandler(regionalService TrashRegionalPriceServiceInterface) *TrashRegionalPriceHandler {
+	return &TrashRegionalPriceHandler{
+		regionalService: regionalService,
+	}
+}
+
+func (h *TrashRegionalPriceHandler) UpsertRegionalPrice(c *fiber.Ctx) error {
+	var req RequestRegionalPriceDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequest(c, "Invalid request body")
+	}
+
+	response, err := h.regionalService.UpsertRegionalPrice(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return handleRegionalPriceError(c, err, "Failed to save regional price")
+	}
+
+	return utils.SuccessWithData(c, "Regional price saved successfully", response)
+}
+
+func (h *TrashRegionalPriceHandler) GetRegionalPrices(c *fiber.Ctx) error {
+	response, err := h.regionalService.GetRegionalPrices(c.Context(), c.Params("id"))
+	if err != nil {
+		return handleRegionalPriceError(c, err, "Failed to get regional prices")
+	}
+
+	return utils.SuccessWithData(c, "Regional prices retrieved successfully", response)
+}
+
+func (h *TrashRegionalPriceHandler) DeleteRegionalPrice(c *fiber.Ctx) error {
+	if err := h.regionalService.DeleteRegionalPrice(c.Context(), c.Params("regionalPriceId")); err != nil {
+		return handleRegionalPriceError(c, err, "Failed to delete regional price")
+	}
+
+	return utils.Success(c, "Regional price deleted successfully")
+}
+
+func handleRegionalPriceError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "validation failed"):
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validation failed", extractValidationErrors(msg))
+	case strings.Contains(msg, "not found"):
+		return utils.NotFound(c, msg)
+	default:
+		return utils.InternalServerError(c, fallback)
+	}
+}
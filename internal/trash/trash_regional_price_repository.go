@@ -0,0 +1,94 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rijig/model"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RegionLevelProvince = "province"
+	RegionLevelRegency  = "regency"
+)
+
+type TrashRegionalPriceRepository interface {
+	UpsertRegionalPrice(ctx context.Context, price *model.TrashCategoryRegionalPrice) error
+	GetRegionalPricesByCategory(ctx context.Context, categoryID string) ([]model.TrashCategoryRegionalPrice, error)
+	DeleteRegionalPrice(ctx context.Context, id string) error
+	FindRegionalPricesForRegion(ctx context.Context, provinceName, regencyName string) ([]model.TrashCategoryRegionalPrice, error)
+}
+
+type trashRegionalPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRegionalPriceRepository(db *gorm.DB) TrashRegionalPriceRepository {
+	return &trashRegionalPriceRepository{db: db}
+}
+
+func (r *trashRegionalPriceRepository) UpsertRegionalPrice(ctx context.Context, price *model.TrashCategoryRegionalPrice) error {
+	var existing model.TrashCategoryRegionalPrice
+	err := r.db.WithContext(ctx).
+		Where("trash_category_id = ? AND region_level = ? AND region_id = ?", price.TrashCategoryID, price.RegionLevel, price.RegionID).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.db.WithContext(ctx).Create(price).Error; err != nil {
+			return fmt.Errorf("failed to create regional price: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get regional price: %w", err)
+	}
+
+	price.ID = existing.ID
+	price.CreatedAt = existing.CreatedAt
+	if err := r.db.WithContext(ctx).Save(price).Error; err != nil {
+		return fmt.Errorf("failed to update regional price: %w", err)
+	}
+	return nil
+}
+
+func (r *trashRegionalPriceRepository) GetRegionalPricesByCategory(ctx context.Context, categoryID string) ([]model.TrashCategoryRegionalPrice, error) {
+	var prices []model.TrashCategoryRegionalPrice
+	err := r.db.WithContext(ctx).
+		Where("trash_category_id = ?", categoryID).
+		Order("region_level ASC, region_name ASC").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regional prices: %w", err)
+	}
+	return prices, nil
+}
+
+func (r *trashRegionalPriceRepository) DeleteRegionalPrice(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.TrashCategoryRegionalPrice{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete regional price: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("regional price not found")
+	}
+	return nil
+}
+
+// FindRegionalPricesForRegion returns every override that applies to an address in the
+// given province and regency, at either level.
+func (r *trashRegionalPriceRepository) FindRegionalPricesForRegion(ctx context.Context, provinceName, regencyName string) ([]model.TrashCategoryRegionalPrice, error) {
+	province := strings.ToLower(strings.TrimSpace(provinceName))
+	regency := strings.ToLower(strings.TrimSpace(regencyName))
+
+	var prices []model.TrashCategoryRegionalPrice
+	err := r.db.WithContext(ctx).
+		Where("(region_level = ? AND LOWER(region_name) = ?) OR (region_level = ? AND LOWER(region_name) = ? AND LOWER(province_name) = ?)",
+			RegionLevelProvince, province, RegionLevelRegency, regency, province).
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regional prices: %w", err)
+	}
+	return prices, nil
+}
@@ -0,0 +1,225 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rijig/internal/address"
+	"rijig/internal/wilayahindo"
+	"rijig/model"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrPriceAddressNotFound = errors.New("address not found")
+
+// RegionalPrices holds the overrides that apply to one address. A nil *RegionalPrices
+// resolves every category to its national price.
+type RegionalPrices struct {
+	AddressID  string
+	Province   string
+	Regency    string
+	byRegency  map[string]float64
+	byProvince map[string]float64
+}
+
+// PriceFor returns the effective price of category and the region it came from, or an
+// empty region for the national price. Regency overrides beat province overrides.
+func (p *RegionalPrices) PriceFor(category *model.TrashCategory) (float64, string) {
+	if p == nil {
+		return category.EstimatedPrice, ""
+	}
+	if price, ok := p.byRegency[category.ID]; ok {
+		return price, p.Regency
+	}
+	if price, ok := p.byProvince[category.ID]; ok {
+		return price, p.Province
+	}
+	return category.EstimatedPrice, ""
+}
+
+type TrashRegionalPriceServiceInterface interface {
+	UpsertRegionalPrice(ctx context.Context, categoryID string, req RequestRegionalPriceDTO) (*ResponseRegionalPriceDTO, error)
+	GetRegionalPrices(ctx context.Context, categoryID string) ([]ResponseRegionalPriceDTO, error)
+	DeleteRegionalPrice(ctx context.Context, id string) error
+	ResolveForAddress(ctx context.Context, userID, addressID string) (*RegionalPrices, error)
+	ResolveForRegion(ctx context.Context, province, regency string) (*RegionalPrices, error)
+	ApplyToCategories(ctx context.Context, userID, addressID string, categories []ResponseTrashCategoryDTO) error
+}
+
+type TrashRegionalPriceService struct {
+	trashRepo    TrashRepositoryInterface
+	regionalRepo TrashRegionalPriceRepository
+	addressRepo  address.AddressRepository
+	wilayahRepo  wilayahindo.WilayahIndonesiaRepository
+}
+
+func NewTrashRegionalPriceService(trashRepo TrashRepositoryInterface, regionalRepo TrashRegionalPriceRepository,
+	addressRepo address.AddressRepository, wilayahRepo wilayahindo.WilayahIndonesiaRepository) TrashRegionalPriceServiceInterface {
+	return &TrashRegionalPriceService{
+		trashRepo:    trashRepo,
+		regionalRepo: regionalRepo,
+		addressRepo:  addressRepo,
+		wilayahRepo:  wilayahRepo,
+	}
+}
+
+// NewDefaultTrashRegionalPriceService wires the service from db, for the cart and pickup
+// packages that only need price resolution.
+func NewDefaultTrashRegionalPriceService(db *gorm.DB) TrashRegionalPriceServiceInterface {
+	return NewTrashRegionalPriceService(NewTrashRepository(db), NewTrashRegionalPriceRepository(db),
+		address.NewAddressRepository(db), wilayahindo.NewWilayahIndonesiaRepository(db))
+}
+
+func (s *TrashRegionalPriceService) UpsertRegionalPrice(ctx context.Context, categoryID string, req RequestRegionalPriceDTO) (*ResponseRegionalPriceDTO, error) {
+	if errors, valid := req.ValidateRequestRegionalPriceDTO(); !valid {
+		return nil, fmt.Errorf("validation failed: %v", errors)
+	}
+
+	if _, err := s.trashRepo.GetTrashCategoryByID(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	price := &model.TrashCategoryRegionalPrice{
+		TrashCategoryID: categoryID,
+		RegionLevel:     req.RegionLevel,
+		RegionID:        req.RegionID,
+		Price:           req.Price,
+	}
+
+	if req.RegionLevel == RegionLevelProvince {
+		province, _, err := s.wilayahRepo.FindProvinceByID(ctx, req.RegionID, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("province with id %s not found", req.RegionID)
+		}
+		price.RegionName = province.Name
+		price.ProvinceName = province.Name
+	} else {
+		regency, _, err := s.wilayahRepo.FindRegencyByID(ctx, req.RegionID, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("regency with id %s not found", req.RegionID)
+		}
+		province, _, err := s.wilayahRepo.FindProvinceByID(ctx, regency.ProvinceID, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("province with id %s not found", regency.ProvinceID)
+		}
+		price.RegionName = regency.Name
+		price.ProvinceName = province.Name
+	}
+
+	if err := s.regionalRepo.UpsertRegionalPrice(ctx, price); err != nil {
+		return nil, err
+	}
+
+	return convertRegionalPriceToResponseDTO(price), nil
+}
+
+func (s *TrashRegionalPriceService) GetRegionalPrices(ctx context.Context, categoryID string) ([]ResponseRegionalPriceDTO, error) {
+	if _, err := s.trashRepo.GetTrashCategoryByID(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	prices, err := s.regionalRepo.GetRegionalPricesByCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]ResponseRegionalPriceDTO, len(prices))
+	for i := range prices {
+		responses[i] = *convertRegionalPriceToResponseDTO(&prices[i])
+	}
+	return responses, nil
+}
+
+func (s *TrashRegionalPriceService) DeleteRegionalPrice(ctx context.Context, id string) error {
+	return s.regionalRepo.DeleteRegionalPrice(ctx, id)
+}
+
+// ResolveForAddress loads the overrides for one of the user's addresses. An empty
+// addressID means national prices and returns nil.
+func (s *TrashRegionalPriceService) ResolveForAddress(ctx context.Context, userID, addressID string) (*RegionalPrices, error) {
+	if addressID == "" {
+		return nil, nil
+	}
+
+	addr, err := s.addressRepo.FindAddressByID(ctx, addressID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPriceAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if addr.UserID != userID {
+		return nil, ErrPriceAddressNotFound
+	}
+
+	prices, err := s.ResolveForRegion(ctx, addr.Province, addr.Regency)
+	if err != nil {
+		return nil, err
+	}
+	prices.AddressID = addr.ID
+	return prices, nil
+}
+
+// ResolveForRegion loads the overrides that apply in a regency of a province, for
+// callers that already hold the address.
+func (s *TrashRegionalPriceService) ResolveForRegion(ctx context.Context, province, regency string) (*RegionalPrices, error) {
+	overrides, err := s.regionalRepo.FindRegionalPricesForRegion(ctx, province, regency)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := &RegionalPrices{
+		Province:   province,
+		Regency:    regency,
+		byRegency:  make(map[string]float64),
+		byProvince: make(map[string]float64),
+	}
+	for _, override := range overrides {
+		if override.RegionLevel == RegionLevelRegency && strings.EqualFold(override.RegionName, regency) {
+			prices.byRegency[override.TrashCategoryID] = override.Price
+		} else if override.RegionLevel == RegionLevelProvince {
+			prices.byProvince[override.TrashCategoryID] = override.Price
+		}
+	}
+	return prices, nil
+}
+
+// ApplyToCategories rewrites EstimatedPrice in categories to the price in force at the
+// address, keeping the national price alongside when they differ.
+func (s *TrashRegionalPriceService) ApplyToCategories(ctx context.Context, userID, addressID string, categories []ResponseTrashCategoryDTO) error {
+	prices, err := s.ResolveForAddress(ctx, userID, addressID)
+	if err != nil || prices == nil {
+		return err
+	}
+
+	for i := range categories {
+		category := &model.TrashCategory{ID: categories[i].ID, EstimatedPrice: categories[i].EstimatedPrice}
+		price, region := prices.PriceFor(category)
+		if region == "" {
+			continue
+		}
+		categories[i].NationalPrice = categories[i].EstimatedPrice
+		categories[i].EstimatedPrice = price
+		categories[i].PriceRegion = region
+	}
+	return nil
+}
+
+func convertRegionalPriceToResponseDTO(price *model.TrashCategoryRegionalPrice) *ResponseRegionalPriceDTO {
+	response := &ResponseRegionalPriceDTO{
+		ID:          price.ID,
+		CategoryID:  price.TrashCategoryID,
+		RegionLevel: price.RegionLevel,
+		RegionID:    price.RegionID,
+		RegionName:  price.RegionName,
+		Price:       price.Price,
+		UpdatedAt:   price.UpdatedAt.Format(time.RFC3339),
+	}
+	if price.RegionLevel == RegionLevelRegency {
+		response.ProvinceName = price.ProvinceName
+	}
+	return response
+}
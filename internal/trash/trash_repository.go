@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TrashRepositoryInterface interface {
@@ -19,6 +20,7 @@ type TrashRepositoryInterface interface {
 	GetTrashCategoryByID(ctx context.Context, id string) (*model.TrashCategory, error)
 	GetTrashCategoryByIDWithDetails(ctx context.Context, id string) (*model.TrashCategory, error)
 	DeleteTrashCategory(ctx context.Context, id string) error
+	CountChildCategories(ctx context.Context, id string) (int64, error)
 
 	CreateTrashDetail(ctx context.Context, detail *model.TrashDetail) error
 	AddTrashDetailToCategory(ctx context.Context, categoryID string, detail *model.TrashDetail) error
@@ -43,10 +45,12 @@ func NewTrashRepository(db *gorm.DB) TrashRepositoryInterface {
 }
 
 func (r *trashRepository) CreateTrashCategory(ctx context.Context, category *model.TrashCategory) error {
-	if err := r.db.WithContext(ctx).Create(category).Error; err != nil {
-		return fmt.Errorf("failed to create trash category: %w", err)
-	}
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(category).Error; err != nil {
+			return fmt.Errorf("failed to create trash category: %w", err)
+		}
+		return recordPriceChange(tx, category, category.EstimatedPrice, category.CreatedAt, PriceSourceInitial)
+	})
 }
 
 func (r *trashRepository) CreateTrashCategoryWithDetails(ctx context.Context, category *model.TrashCategory, details []model.TrashDetail) error {
@@ -56,6 +60,10 @@ func (r *trashRepository) CreateTrashCategoryWithDetails(ctx context.Context, ca
 			return fmt.Errorf("failed to create trash category: %w", err)
 		}
 
+		if err := recordPriceChange(tx, category, category.EstimatedPrice, category.CreatedAt, PriceSourceInitial); err != nil {
+			return err
+		}
+
 		if len(details) > 0 {
 
 			for i := range details {
@@ -85,18 +93,30 @@ func (r *trashRepository) UpdateTrashCategory(ctx context.Context, id string, up
 		return errors.New("trash category not found")
 	}
 
-	updates["updated_at"] = time.Now()
+	now := time.Now()
+	updates["updated_at"] = now
 
-	result := r.db.WithContext(ctx).Model(&model.TrashCategory{}).Where("id = ?", id).Updates(updates)
-	if result.Error != nil {
-		return fmt.Errorf("failed to update trash category: %w", result.Error)
-	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var category model.TrashCategory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&category).Error; err != nil {
+			return fmt.Errorf("failed to lock trash category: %w", err)
+		}
 
-	if result.RowsAffected == 0 {
-		return errors.New("no rows affected during update")
-	}
+		result := tx.Model(&model.TrashCategory{}).Where("id = ?", id).Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update trash category: %w", result.Error)
+		}
 
-	return nil
+		if result.RowsAffected == 0 {
+			return errors.New("no rows affected during update")
+		}
+
+		// keep the old price in the history instead of losing it to the overwrite
+		if price, ok := updates["estimated_price"].(float64); ok && price != category.EstimatedPrice {
+			return recordPriceChange(tx, &category, price, now, PriceSourceUpdate)
+		}
+		return nil
+	})
 }
 
 func (r *trashRepository) GetAllTrashCategories(ctx context.Context) ([]model.TrashCategory, error) {
@@ -171,6 +191,16 @@ func (r *trashRepository) DeleteTrashCategory(ctx context.Context, id string) er
 	return nil
 }
 
+func (r *trashRepository) CountChildCategories(ctx context.Context, id string) (int64, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).Model(&model.TrashCategory{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count subcategories: %w", err)
+	}
+
+	return count, nil
+}
+
 func (r *trashRepository) CreateTrashDetail(ctx context.Context, detail *model.TrashDetail) error {
 
 	exists, err := r.CheckTrashCategoryExists(ctx, detail.TrashCategoryID)
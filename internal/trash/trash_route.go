@@ -4,6 +4,7 @@ package trash
 import (
 	"rijig/config"
 	"rijig/middleware"
+	"rijig/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -11,7 +12,11 @@ import (
 func TrashRouter(api fiber.Router) {
 	trashRepo := NewTrashRepository(config.DB)
 	trashService := NewTrashService(trashRepo)
-	trashHandler := NewTrashHandler(trashService)
+	regionalService := NewDefaultTrashRegionalPriceService(config.DB)
+	trashHandler := NewTrashHandler(trashService, regionalService)
+	regionalHandler := NewTrashRegionalPriceHandler(regionalService)
+	priceService := NewTrashPriceService(trashRepo, NewTrashPriceRepository(config.DB))
+	priceHandler := NewTrashPriceHandler(priceService)
 
 	trashAPI := api.Group("/trash")
 	trashAPI.Use(middleware.AuthMiddleware())
@@ -27,9 +32,15 @@ func TrashRouter(api fiber.Router) {
 	// Create trash category with details (JSON)
 	trashAPI.Post("/category/with-details", trashHandler.CreateTrashCategoryWithDetails)
 
-	// Get all trash categories (with optional query param: ?with_details=true)
+	// Get all trash categories (with optional query params: ?with_details=true&address_id=...)
 	trashAPI.Get("/category", trashHandler.GetAllTrashCategories)
 
+	// Get categories as a parent/child tree
+	trashAPI.Get("/category/tree", trashHandler.GetTrashCategoryTree)
+
+	// Search categories by name, including the names of their parents (?q=...)
+	trashAPI.Get("/category/search", trashHandler.SearchTrashCategories)
+
 	// Get trash category by ID (with optional query param: ?with_details=true)
 	trashAPI.Get("/category/:id", trashHandler.GetTrashCategoryByID)
 
@@ -42,6 +53,34 @@ func TrashRouter(api fiber.Router) {
 	// Delete trash category
 	trashAPI.Delete("/category/:id", trashHandler.DeleteTrashCategory)
 
+	// ============= TRASH PRICE ROUTES =============
+
+	// Get price curve of a category (optional query params: ?from=...&to=...)
+	trashAPI.Get("/category/:id/prices", priceHandler.GetPriceCurve)
+
+	// Get the price of a category at a given moment (?at=...)
+	trashAPI.Get("/category/:id/price-at", priceHandler.GetPriceAt)
+
+	// List scheduled price changes of a category
+	trashAPI.Get("/category/:id/prices/scheduled", middleware.RequireRoles(utils.RoleAdministrator), priceHandler.GetScheduledPrices)
+
+	// Schedule a future price change
+	trashAPI.Post("/category/:id/prices/scheduled", middleware.RequireRoles(utils.RoleAdministrator), priceHandler.SchedulePriceChange)
+
+	// Cancel a scheduled price change
+	trashAPI.Delete("/prices/scheduled/:priceId", middleware.RequireRoles(utils.RoleAdministrator), priceHandler.CancelScheduledPrice)
+
+	// ============= REGIONAL PRICE ROUTES =============
+
+	// List province and regency price overrides of a category
+	trashAPI.Get("/category/:id/regional-prices", middleware.RequireRoles(utils.RoleAdministrator), regionalHandler.GetRegionalPrices)
+
+	// Create or update a province or regency price override
+	trashAPI.Put("/category/:id/regional-prices", middleware.RequireRoles(utils.RoleAdministrator), regionalHandler.UpsertRegionalPrice)
+
+	// Delete a price override
+	trashAPI.Delete("/regional-prices/:regionalPriceId", middleware.RequireRoles(utils.RoleAdministrator), regionalHandler.DeleteRegionalPrice)
+
 	// ============= TRASH DETAIL ROUTES =============
 
 	// Create trash detail (JSON)
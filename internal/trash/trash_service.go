@@ -23,6 +23,8 @@ type TrashServiceInterface interface {
 	UpdateTrashCategoryWithIcon(ctx context.Context, id string, req RequestTrashCategoryDTO, iconFile *multipart.FileHeader) (*ResponseTrashCategoryDTO, error)
 	GetAllTrashCategories(ctx context.Context) ([]ResponseTrashCategoryDTO, error)
 	GetAllTrashCategoriesWithDetails(ctx context.Context) ([]ResponseTrashCategoryDTO, error)
+	GetTrashCategoryTree(ctx context.Context) ([]ResponseTrashCategoryDTO, error)
+	SearchTrashCategories(ctx context.Context, query string) ([]ResponseTrashCategoryDTO, error)
 	GetTrashCategoryByID(ctx context.Context, id string) (*ResponseTrashCategoryDTO, error)
 	GetTrashCategoryByIDWithDetails(ctx context.Context, id string) (*ResponseTrashCategoryDTO, error)
 	DeleteTrashCategory(ctx context.Context, id string) error
@@ -177,6 +179,10 @@ func (s *TrashService) CreateTrashCategoryWithIcon(ctx context.Context, req Requ
 		return nil, fmt.Errorf("validation failed: %v", errors)
 	}
 
+	if err := s.validateParent(ctx, "", req.ParentID); err != nil {
+		return nil, err
+	}
+
 	var iconUrl string
 	var err error
 
@@ -187,12 +193,7 @@ func (s *TrashService) CreateTrashCategoryWithIcon(ctx context.Context, req Requ
 		}
 	}
 
-	category := &model.TrashCategory{
-		Name:           req.Name,
-		IconTrash:      iconUrl,
-		EstimatedPrice: req.EstimatedPrice,
-		Variety:        req.Variety,
-	}
+	category := newTrashCategory(req, iconUrl)
 
 	if err := s.trashRepo.CreateTrashCategory(ctx, category); err != nil {
 
@@ -216,6 +217,10 @@ func (s *TrashService) UpdateTrashCategoryWithIcon(ctx context.Context, id strin
 		return nil, fmt.Errorf("failed to get existing category: %w", err)
 	}
 
+	if err := s.validateParent(ctx, id, req.ParentID); err != nil {
+		return nil, err
+	}
+
 	var iconUrl string = existingCategory.IconTrash
 
 	if iconFile != nil {
@@ -226,12 +231,7 @@ func (s *TrashService) UpdateTrashCategoryWithIcon(ctx context.Context, id strin
 		iconUrl = newIconUrl
 	}
 
-	updates := map[string]interface{}{
-		"name":            req.Name,
-		"icon_trash":      iconUrl,
-		"estimated_price": req.EstimatedPrice,
-		"variety":         req.Variety,
-	}
+	updates := trashCategoryUpdates(req, iconUrl)
 
 	if err := s.trashRepo.UpdateTrashCategory(ctx, id, updates); err != nil {
 
@@ -377,13 +377,12 @@ func (s *TrashService) CreateTrashCategory(ctx context.Context, req RequestTrash
 		return nil, fmt.Errorf("validation failed: %v", errors)
 	}
 
-	category := &model.TrashCategory{
-		Name:           req.Name,
-		IconTrash:      req.IconTrash,
-		EstimatedPrice: req.EstimatedPrice,
-		Variety:        req.Variety,
+	if err := s.validateParent(ctx, "", req.ParentID); err != nil {
+		return nil, err
 	}
 
+	category := newTrashCategory(req, req.IconTrash)
+
 	if err := s.trashRepo.CreateTrashCategory(ctx, category); err != nil {
 		return nil, fmt.Errorf("failed to create trash category: %w", err)
 	}
@@ -403,13 +402,12 @@ func (s *TrashService) CreateTrashCategoryWithDetails(ctx context.Context, categ
 		}
 	}
 
-	category := &model.TrashCategory{
-		Name:           categoryReq.Name,
-		IconTrash:      categoryReq.IconTrash,
-		EstimatedPrice: categoryReq.EstimatedPrice,
-		Variety:        categoryReq.Variety,
+	if err := s.validateParent(ctx, "", categoryReq.ParentID); err != nil {
+		return nil, err
 	}
 
+	category := newTrashCategory(categoryReq, categoryReq.IconTrash)
+
 	details := make([]model.TrashDetail, len(detailsReq))
 	for i, detailReq := range detailsReq {
 		details[i] = model.TrashDetail{
@@ -445,13 +443,12 @@ func (s *TrashService) UpdateTrashCategory(ctx context.Context, id string, req R
 		return nil, errors.New("trash category not found")
 	}
 
-	updates := map[string]interface{}{
-		"name":            req.Name,
-		"icon_trash":      req.IconTrash,
-		"estimated_price": req.EstimatedPrice,
-		"variety":         req.Variety,
+	if err := s.validateParent(ctx, id, req.ParentID); err != nil {
+		return nil, err
 	}
 
+	updates := trashCategoryUpdates(req, req.IconTrash)
+
 	if err := s.trashRepo.UpdateTrashCategory(ctx, id, updates); err != nil {
 		return nil, fmt.Errorf("failed to update trash category: %w", err)
 	}
@@ -471,9 +468,11 @@ func (s *TrashService) GetAllTrashCategories(ctx context.Context) ([]ResponseTra
 		return nil, fmt.Errorf("failed to get trash categories: %w", err)
 	}
 
+	tree := newCategoryTree(categories)
 	responses := make([]ResponseTrashCategoryDTO, len(categories))
-	for i, category := range categories {
-		responses[i] = *s.convertTrashCategoryToResponseDTO(&category)
+	for i := range categories {
+		responses[i] = *s.convertTrashCategoryToResponseDTO(&categories[i])
+		responses[i].Path = tree.path(&categories[i])
 	}
 
 	return responses, nil
@@ -485,14 +484,79 @@ func (s *TrashService) GetAllTrashCategoriesWithDetails(ctx context.Context) ([]
 		return nil, fmt.Errorf("failed to get trash categories with details: %w", err)
 	}
 
+	tree := newCategoryTree(categories)
 	responses := make([]ResponseTrashCategoryDTO, len(categories))
-	for i, category := range categories {
-		responses[i] = *s.convertTrashCategoryToResponseDTOWithDetails(&category)
+	for i := range categories {
+		responses[i] = *s.convertTrashCategoryToResponseDTOWithDetails(&categories[i])
+		responses[i].Path = tree.path(&categories[i])
 	}
 
 	return responses, nil
 }
 
+func (s *TrashService) GetTrashCategoryTree(ctx context.Context) ([]ResponseTrashCategoryDTO, error) {
+	tree, err := s.loadCategoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var build func(list []*model.TrashCategory) []ResponseTrashCategoryDTO
+	build = func(list []*model.TrashCategory) []ResponseTrashCategoryDTO {
+		nodes := make([]ResponseTrashCategoryDTO, len(list))
+		for i, category := range list {
+			nodes[i] = *s.convertTrashCategoryToResponseDTO(category)
+			nodes[i].Children = build(tree.children[category.ID])
+		}
+		return nodes
+	}
+
+	return build(tree.roots), nil
+}
+
+func (s *TrashService) SearchTrashCategories(ctx context.Context, query string) ([]ResponseTrashCategoryDTO, error) {
+	if len(strings.TrimSpace(query)) < 2 {
+		errors := map[string][]string{"q": {"query must be at least 2 characters"}}
+		return nil, fmt.Errorf("validation failed: %v", errors)
+	}
+
+	tree, err := s.loadCategoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := tree.search(query)
+	responses := make([]ResponseTrashCategoryDTO, len(matches))
+	for i, match := range matches {
+		responses[i] = *s.convertTrashCategoryToResponseDTO(match.Category)
+		responses[i].Path = tree.path(match.Category)
+		responses[i].MatchedOn = match.MatchedOn
+	}
+
+	return responses, nil
+}
+
+func (s *TrashService) loadCategoryTree(ctx context.Context) (*categoryTree, error) {
+	categories, err := s.trashRepo.GetAllTrashCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash categories: %w", err)
+	}
+	return newCategoryTree(categories), nil
+}
+
+// validateParent checks that the parent exists and, for an existing category, that the
+// move does not make the category its own ancestor.
+func (s *TrashService) validateParent(ctx context.Context, categoryID string, parentID *string) error {
+	if parentID == nil || *parentID == "" {
+		return nil
+	}
+
+	tree, err := s.loadCategoryTree(ctx)
+	if err != nil {
+		return err
+	}
+	return tree.checkParent(categoryID, *parentID)
+}
+
 func (s *TrashService) GetTrashCategoryByID(ctx context.Context, id string) (*ResponseTrashCategoryDTO, error) {
 	category, err := s.trashRepo.GetTrashCategoryByID(ctx, id)
 	if err != nil {
@@ -500,6 +564,9 @@ func (s *TrashService) GetTrashCategoryByID(ctx context.Context, id string) (*Re
 	}
 
 	response := s.convertTrashCategoryToResponseDTO(category)
+	if tree, err := s.loadCategoryTree(ctx); err == nil {
+		response.Path = tree.path(category)
+	}
 	return response, nil
 }
 
@@ -520,6 +587,14 @@ func (s *TrashService) DeleteTrashCategory(ctx context.Context, id string) error
 		return fmt.Errorf("failed to get category: %w", err)
 	}
 
+	children, err := s.trashRepo.CountChildCategories(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to check subcategories: %w", err)
+	}
+	if children > 0 {
+		return errors.New("trash category still has subcategories")
+	}
+
 	if err := s.trashRepo.DeleteTrashCategory(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete trash category: %w", err)
 	}
@@ -713,16 +788,66 @@ func (s *TrashService) ReorderTrashDetails(ctx context.Context, categoryID strin
 	return nil
 }
 
+func newTrashCategory(req RequestTrashCategoryDTO, iconUrl string) *model.TrashCategory {
+	return &model.TrashCategory{
+		ParentID:       normalizeParentID(req.ParentID),
+		Name:           req.Name,
+		IconTrash:      iconUrl,
+		EstimatedPrice: req.EstimatedPrice,
+		Unit:           requestUnit(req.Unit),
+		MinQuantity:    req.MinQuantity,
+		MaxQuantity:    req.MaxQuantity,
+		EmissionFactor: req.EmissionFactor,
+		Variety:        req.Variety,
+	}
+}
+
+func trashCategoryUpdates(req RequestTrashCategoryDTO, iconUrl string) map[string]interface{} {
+	return map[string]interface{}{
+		"parent_id":       normalizeParentID(req.ParentID),
+		"name":            req.Name,
+		"icon_trash":      iconUrl,
+		"estimated_price": req.EstimatedPrice,
+		"unit":            requestUnit(req.Unit),
+		"min_quantity":    req.MinQuantity,
+		"max_quantity":    req.MaxQuantity,
+		"emission_factor": req.EmissionFactor,
+		"variety":         req.Variety,
+	}
+}
+
+func normalizeParentID(parentID *string) *string {
+	if parentID == nil || strings.TrimSpace(*parentID) == "" {
+		return nil
+	}
+	return parentID
+}
+
+func requestUnit(unit string) string {
+	if unit == "" {
+		return UnitKilogram
+	}
+	return unit
+}
+
 func (s *TrashService) convertTrashCategoryToResponseDTO(category *model.TrashCategory) *ResponseTrashCategoryDTO {
-	return &ResponseTrashCategoryDTO{
+	response := &ResponseTrashCategoryDTO{
 		ID:             category.ID,
 		TrashName:      category.Name,
 		TrashIcon:      category.IconTrash,
 		EstimatedPrice: category.EstimatedPrice,
+		Unit:           UnitOf(category),
+		MinQuantity:    category.MinQuantity,
+		MaxQuantity:    category.MaxQuantity,
+		EmissionFactor: category.EmissionFactor,
 		Variety:        category.Variety,
 		CreatedAt:      category.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:      category.UpdatedAt.Format(time.RFC3339),
 	}
+	if category.ParentID != nil {
+		response.ParentID = *category.ParentID
+	}
+	return response
 }
 
 func (s *TrashService) convertTrashCategoryToResponseDTOWithDetails(category *model.TrashCategory) *ResponseTrashCategoryDTO {
@@ -0,0 +1,65 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"rijig/model"
+)
+
+const (
+	UnitKilogram = "kg"
+	UnitPiece    = "piece"
+	UnitLitre    = "litre"
+)
+
+// ErrInvalidQuantity is wrapped by every quantity that does not fit its category.
+var ErrInvalidQuantity = errors.New("jumlah tidak valid")
+
+func IsValidUnit(unit string) bool {
+	switch unit {
+	case UnitKilogram, UnitPiece, UnitLitre:
+		return true
+	}
+	return false
+}
+
+// UnitOf returns the category's unit, treating categories created before units existed
+// as kilograms.
+func UnitOf(category *model.TrashCategory) string {
+	if category.Unit == "" {
+		return UnitKilogram
+	}
+	return category.Unit
+}
+
+// ValidateWholeUnits rejects fractional amounts of countable units.
+func ValidateWholeUnits(unit string, amount float64) error {
+	if unit == UnitPiece && amount != math.Trunc(amount) {
+		return fmt.Errorf("%w: jumlah dalam %s harus bilangan bulat", ErrInvalidQuantity, unit)
+	}
+	return nil
+}
+
+// ValidateQuantity checks an amount a user asks for against the category's unit and
+// its minimum and maximum quantity.
+func ValidateQuantity(category *model.TrashCategory, amount float64) error {
+	unit := UnitOf(category)
+	if amount <= 0 {
+		return fmt.Errorf("%w: jumlah harus lebih dari 0", ErrInvalidQuantity)
+	}
+	if err := ValidateWholeUnits(unit, amount); err != nil {
+		return err
+	}
+	if amount < category.MinQuantity {
+		return fmt.Errorf("%w: minimal %s %s untuk %s", ErrInvalidQuantity, formatQuantity(category.MinQuantity), unit, category.Name)
+	}
+	if category.MaxQuantity != nil && amount > *category.MaxQuantity {
+		return fmt.Errorf("%w: maksimal %s %s untuk %s", ErrInvalidQuantity, formatQuantity(*category.MaxQuantity), unit, category.Name)
+	}
+	return nil
+}
+
+func formatQuantity(amount float64) string {
+	return fmt.Sprintf("%g", amount)
+}
@@ -0,0 +1,227 @@
+package wallet
+
+import (
+	"strings"
+	"time"
+
+	"rijig/utils"
+)
+
+const (
+	PaymentCash     = "cash"
+	PaymentTransfer = "transfer"
+	// PaymentGateway marks payments confirmed by a payment gateway; collectors cannot
+	// record it by hand.
+	PaymentGateway = "gateway"
+
+	DecisionAccept = "accept"
+	DecisionReject = "reject"
+)
+
+type WalletBalanceDTO struct {
+	WalletID string  `json:"wallet_id,omitempty"`
+	UserID   string  `json:"user_id"`
+	Role     string  `json:"role,omitempty"`
+	Balance  float64 `json:"balance"`
+}
+
+type WalletEntryDTO struct {
+	EntryID          string  `json:"entry_id"`
+	TransactionID    string  `json:"transaction_id"`
+	Kind             string  `json:"kind"`
+	EntryType        string  `json:"entry_type"`
+	PickupID         *string `json:"pickup_id,omitempty"`
+	PaymentMethod    string  `json:"payment_method,omitempty"`
+	PaymentReference string  `json:"payment_reference,omitempty"`
+	ReversalOfID     *string `json:"reversal_of_id,omitempty"`
+	Description      string  `json:"description"`
+	Amount           float64 `json:"amount"`
+	BalanceAfter     float64 `json:"balance_after"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// WalletStatementDTO covers [From, To]; OpeningBalance is the balance before From and
+// ClosingBalance the balance at the end of To, regardless of the page returned.
+type WalletStatementDTO struct {
+	UserID         string           `json:"user_id"`
+	From           string           `json:"from"`
+	To             string           `json:"to"`
+	OpeningBalance float64          `json:"opening_balance"`
+	ClosingBalance float64          `json:"closing_balance"`
+	Entries        []WalletEntryDTO `json:"entries"`
+	Total          int64            `json:"total"`
+	Page           int              `json:"page"`
+	Limit          int              `json:"limit"`
+}
+
+type WalletTransactionDTO struct {
+	ID               string             `json:"id"`
+	Kind             string             `json:"kind"`
+	PickupID         *string            `json:"pickup_id,omitempty"`
+	PaymentMethod    string             `json:"payment_method,omitempty"`
+	PaymentReference string             `json:"payment_reference,omitempty"`
+	ReversalOfID     *string            `json:"reversal_of_id,omitempty"`
+	Description      string             `json:"description"`
+	Entries          []WalletPostingDTO `json:"entries"`
+	Outstanding      *float64           `json:"outstanding,omitempty"`
+	CreatedAt        string             `json:"created_at"`
+}
+
+type WalletPostingDTO struct {
+	EntryID   string  `json:"entry_id"`
+	WalletID  string  `json:"wallet_id"`
+	EntryType string  `json:"entry_type"`
+	Amount    float64 `json:"amount"`
+}
+
+// RecordPaymentDTO.Amount is optional; zero pays whatever is still outstanding.
+type RecordPaymentDTO struct {
+	Method    string  `json:"method"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+}
+
+func (r *RecordPaymentDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Method = strings.ToLower(strings.TrimSpace(r.Method))
+	r.Reference = strings.TrimSpace(r.Reference)
+
+	switch r.Method {
+	case PaymentCash:
+	case PaymentTransfer:
+		if r.Reference == "" {
+			errors["reference"] = append(errors["reference"], "nomor referensi wajib diisi untuk pembayaran transfer")
+		}
+	case "":
+		errors["method"] = append(errors["method"], "metode pembayaran wajib diisi")
+	default:
+		errors["method"] = append(errors["method"], "metode pembayaran harus cash atau transfer")
+	}
+
+	if r.Amount < 0 {
+		errors["amount"] = append(errors["amount"], "jumlah pembayaran tidak boleh negatif")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type RaiseDisputeDTO struct {
+	Reason string `json:"reason"`
+}
+
+func (r *RaiseDisputeDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	if strings.TrimSpace(r.Reason) == "" {
+		errors["reason"] = append(errors["reason"], "alasan sanggahan wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type ResolveDisputeDTO struct {
+	Decision string `json:"decision"`
+	Note     string `json:"note"`
+}
+
+func (r *ResolveDisputeDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Decision = strings.ToLower(strings.TrimSpace(r.Decision))
+	if r.Decision != DecisionAccept && r.Decision != DecisionReject {
+		errors["decision"] = append(errors["decision"], "keputusan harus accept atau reject")
+	}
+	if strings.TrimSpace(r.Note) == "" {
+		errors["note"] = append(errors["note"], "catatan keputusan wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type WalletDisputeDTO struct {
+	ID         string  `json:"id"`
+	EntryID    string  `json:"entry_id"`
+	RaisedBy   string  `json:"raised_by"`
+	Reason     string  `json:"reason"`
+	Status     string  `json:"status"`
+	Resolution string  `json:"resolution,omitempty"`
+	ResolvedBy *string `json:"resolved_by,omitempty"`
+	ResolvedAt string  `json:"resolved_at,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// StatementQuery holds the optional from/to dates (YYYY-MM-DD, Asia/Jakarta) of a
+// statement request. Missing dates default to the last 30 days.
+type StatementQuery struct {
+	From string
+	To   string
+}
+
+func (q StatementQuery) Range(now time.Time) (time.Time, time.Time, map[string][]string) {
+	errors := make(map[string][]string)
+	loc := utils.IndonesianLocation()
+
+	today := now.In(loc)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	if q.To != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", q.To, loc)
+		if err != nil {
+			errors["to"] = append(errors["to"], "format tanggal harus YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if q.From != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", q.From, loc)
+		if err != nil {
+			errors["from"] = append(errors["from"], "format tanggal harus YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if len(errors) == 0 && from.After(to) {
+		errors["from"] = append(errors["from"], "tanggal awal tidak boleh setelah tanggal akhir")
+	}
+	if len(errors) > 0 {
+		return time.Time{}, time.Time{}, errors
+	}
+
+	// to is inclusive, so the range ends at the start of the following day.
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// DisburseDTO records the transfer the platform made to the household.
+type DisburseDTO struct {
+	Reference string `json:"reference"`
+}
+
+func (r *DisburseDTO) Validate() (map[string][]string, bool) {
+	errors := make(map[string][]string)
+
+	r.Reference = strings.TrimSpace(r.Reference)
+	if r.Reference == "" {
+		errors["reference"] = append(errors["reference"], "nomor referensi transfer wajib diisi")
+	}
+
+	if len(errors) > 0 {
+		return errors, false
+	}
+	return nil, true
+}
+
+type HeldPickupDTO struct {
+	PickupID  string  `json:"pickup_id"`
+	Amount    float64 `json:"amount"`
+	HeldSince string  `json:"held_since"`
+}
@@ -0,0 +1,240 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WalletHandler struct {
+	service WalletService
+}
+
+func NewWalletHandler(service WalletService) *WalletHandler {
+	return &WalletHandler{service: service}
+}
+
+func (h *WalletHandler) GetMyBalance(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	balance, err := h.service.GetBalance(context.Background(), claims.UserID)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Saldo dompet berhasil diambil", balance)
+}
+
+func (h *WalletHandler) GetMyStatement(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respondStatement(c, claims.UserID)
+}
+
+func (h *WalletHandler) GetUserStatement(c *fiber.Ctx) error {
+	userID := c.Params("user_id")
+	if userID == "" {
+		return utils.BadRequest(c, "user_id wajib diisi")
+	}
+
+	return h.respondStatement(c, userID)
+}
+
+func (h *WalletHandler) respondStatement(c *fiber.Ctx, userID string) error {
+	query := StatementQuery{From: c.Query("from"), To: c.Query("to")}
+	from, to, errs := query.Range(time.Now())
+	if errs != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	limit, offset, _ := utils.ParsePagination(c, 20)
+	statement, err := h.service.GetStatement(context.Background(), userID, from, to, limit, offset)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Mutasi dompet berhasil diambil", statement)
+}
+
+func (h *WalletHandler) RecordPayment(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("pickup_id")
+	if pickupID == "" {
+		return utils.BadRequest(c, "ID pickup wajib diisi")
+	}
+
+	var req RecordPaymentDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	payment, err := h.service.RecordPayment(context.Background(), claims.UserID, pickupID, req)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Pembayaran berhasil dicatat", payment)
+}
+
+func (h *WalletHandler) DisburseHeldFunds(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pickupID := c.Params("pickup_id")
+	if pickupID == "" {
+		return utils.BadRequest(c, "ID pickup wajib diisi")
+	}
+
+	var req DisburseDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	disbursement, err := h.service.DisburseHeldFunds(context.Background(), claims.UserID, pickupID, req)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Penyaluran dana berhasil dicatat", disbursement)
+}
+
+func (h *WalletHandler) GetHeldPickups(c *fiber.Ctx) error {
+	limit, offset, page := utils.ParsePagination(c, 20)
+	held, total, err := h.service.ListHeldPickups(context.Background(), limit, offset)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Dana yang ditahan berhasil diambil", held, page, limit, int(total))
+}
+
+func (h *WalletHandler) RaiseDispute(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	entryID := c.Params("entry_id")
+	if entryID == "" {
+		return utils.BadRequest(c, "ID entri wajib diisi")
+	}
+
+	var req RaiseDisputeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	dispute, err := h.service.RaiseDispute(context.Background(), claims.UserID, entryID, req)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.CreateSuccessWithData(c, "Sanggahan berhasil diajukan", dispute)
+}
+
+func (h *WalletHandler) GetMyDisputes(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return h.respondDisputes(c, claims.UserID)
+}
+
+func (h *WalletHandler) GetDisputes(c *fiber.Ctx) error {
+	return h.respondDisputes(c, "")
+}
+
+func (h *WalletHandler) respondDisputes(c *fiber.Ctx, raisedBy string) error {
+	status := c.Query("status")
+	switch status {
+	case "", DisputeOpen, DisputeAccepted, DisputeRejected:
+	default:
+		return utils.BadRequest(c, "status harus open, accepted, atau rejected")
+	}
+
+	limit, offset, page := utils.ParsePagination(c, 20)
+	disputes, total, err := h.service.ListDisputes(context.Background(), raisedBy, status, limit, offset)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.SuccessWithPaginationAndTotal(c, "Daftar sanggahan berhasil diambil", disputes, page, limit, int(total))
+}
+
+func (h *WalletHandler) ResolveDispute(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	disputeID := c.Params("id")
+	if disputeID == "" {
+		return utils.BadRequest(c, "ID sanggahan wajib diisi")
+	}
+
+	var req ResolveDisputeDTO
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", map[string][]string{
+			"body": {"format JSON tidak valid"},
+		})
+	}
+	if errs, ok := req.Validate(); !ok {
+		return utils.ResponseErrorData(c, fiber.StatusBadRequest, "Validasi gagal", errs)
+	}
+
+	dispute, err := h.service.ResolveDispute(context.Background(), claims.UserID, disputeID, req)
+	if err != nil {
+		return handleWalletError(c, err)
+	}
+
+	return utils.SuccessWithData(c, "Sanggahan berhasil diputuskan", dispute)
+}
+
+func handleWalletError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrSettlementNotFound), errors.Is(err, ErrEntryNotFound), errors.Is(err, ErrDisputeNotFound):
+		return utils.NotFound(c, err.Error())
+	case errors.Is(err, ErrNotPickupCollector):
+		return utils.Forbidden(c, err.Error())
+	case errors.Is(err, ErrPaymentExceeds), errors.Is(err, ErrDisputeWindowClosed), errors.Is(err, ErrReversalNotDisputable):
+		return utils.BadRequest(c, err.Error())
+	case errors.Is(err, ErrNothingOutstanding), errors.Is(err, ErrNothingHeld), errors.Is(err, ErrDisputeAlreadyOpen),
+		errors.Is(err, ErrAlreadyReversed), errors.Is(err, ErrDisputeResolved):
+		return utils.ResponseErrorData(c, fiber.StatusConflict, err.Error(), nil)
+	default:
+		return utils.InternalServerError(c, err.Error())
+	}
+}
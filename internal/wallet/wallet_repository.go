@@ -0,0 +1,317 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"rijig/config"
+	"rijig/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WalletEntryRow is an entry joined with its transaction and the balance right after it.
+type WalletEntryRow struct {
+	EntryID          string
+	TransactionID    string
+	Kind             string
+	EntryType        string
+	PickupID         *string
+	PaymentMethod    string
+	PaymentReference string
+	ReversalOfID     *string
+	Description      string
+	Amount           float64
+	RunningBalance   float64
+	CreatedAt        time.Time
+}
+
+// HeldPickupRow is money the platform holds for a pickup's household.
+type HeldPickupRow struct {
+	PickupID  string
+	Amount    float64
+	HeldSince time.Time
+}
+
+type WalletRepository interface {
+	GetOrCreateWallet(ctx context.Context, userID, role string) (*model.Wallet, error)
+	GetWalletByUserID(ctx context.Context, userID string) (*model.Wallet, error)
+	GetOrCreateSystemWallet(ctx context.Context, code string) (*model.Wallet, error)
+	GetHeldPickups(ctx context.Context, walletID string, limit, offset int) ([]HeldPickupRow, int64, error)
+	LockWallets(ctx context.Context, walletIDs []string) error
+	GetBalance(ctx context.Context, walletID string, before *time.Time) (float64, error)
+	GetPickupBalance(ctx context.Context, walletID, pickupID string) (float64, error)
+	GetStatementEntries(ctx context.Context, walletID string, from, to time.Time, limit, offset int) ([]WalletEntryRow, int64, error)
+
+	CreateTransaction(ctx context.Context, txn *model.WalletTransaction) error
+	GetTransactionForUpdate(ctx context.Context, id string) (*model.WalletTransaction, error)
+	GetTransactionWithEntries(ctx context.Context, id string) (*model.WalletTransaction, error)
+	GetTransactionByIdempotencyKey(ctx context.Context, key string) (*model.WalletTransaction, error)
+	IsReversed(ctx context.Context, transactionID string) (bool, error)
+	GetEntryByID(ctx context.Context, id string) (*model.WalletEntry, error)
+
+	CreateDispute(ctx context.Context, dispute *model.WalletDispute) error
+	HasOpenDispute(ctx context.Context, entryID string) (bool, error)
+	GetDisputeForUpdate(ctx context.Context, id string) (*model.WalletDispute, error)
+	UpdateDisputeFields(ctx context.Context, id string, fields map[string]interface{}) error
+	ListDisputes(ctx context.Context, raisedBy, status string, limit, offset int) ([]model.WalletDispute, int64, error)
+
+	GetCollectorUserID(ctx context.Context, collectorID string) (string, error)
+
+	WithTx(tx *gorm.DB) WalletRepository
+}
+
+type walletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository() WalletRepository {
+	return &walletRepository{db: config.DB}
+}
+
+func (r *walletRepository) WithTx(tx *gorm.DB) WalletRepository {
+	return &walletRepository{db: tx}
+}
+
+func (r *walletRepository) GetOrCreateWallet(ctx context.Context, userID, role string) (*model.Wallet, error) {
+	wallet := model.Wallet{UserID: &userID, Role: role}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(&wallet).Error; err != nil {
+		return nil, err
+	}
+
+	var stored model.Wallet
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *walletRepository) GetOrCreateSystemWallet(ctx context.Context, code string) (*model.Wallet, error) {
+	wallet := model.Wallet{Code: &code, Role: WalletRoleSystem}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+		Create(&wallet).Error; err != nil {
+		return nil, err
+	}
+
+	var stored model.Wallet
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// GetHeldPickups returns the pickups the wallet still holds money for, oldest first.
+func (r *walletRepository) GetHeldPickups(ctx context.Context, walletID string, limit, offset int) ([]HeldPickupRow, int64, error) {
+	held := r.db.WithContext(ctx).
+		Table("wallet_entries e").
+		Joins("JOIN wallet_transactions t ON t.id = e.transaction_id").
+		Where("e.wallet_id = ? AND t.pickup_id IS NOT NULL", walletID).
+		Group("t.pickup_id").
+		Having("SUM(e.amount) < 0").
+		Select("t.pickup_id AS pickup_id, -SUM(e.amount) AS amount, MIN(e.created_at) AS held_since")
+
+	var total int64
+	if err := r.db.WithContext(ctx).Table("(?) AS held", held).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []HeldPickupRow
+	err := r.db.WithContext(ctx).Table("(?) AS held", held).
+		Order("held_since ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+	return rows, total, err
+}
+
+func (r *walletRepository) GetWalletByUserID(ctx context.Context, userID string) (*model.Wallet, error) {
+	var wallet model.Wallet
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// LockWallets locks in id order so postings between the same wallets cannot deadlock.
+func (r *walletRepository) LockWallets(ctx context.Context, walletIDs []string) error {
+	var locked []model.Wallet
+	return r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", walletIDs).
+		Order("id").
+		Find(&locked).Error
+}
+
+// GetBalance sums the wallet's entries, optionally only those created before a moment.
+func (r *walletRepository) GetBalance(ctx context.Context, walletID string, before *time.Time) (float64, error) {
+	query := r.db.WithContext(ctx).Model(&model.WalletEntry{}).Where("wallet_id = ?", walletID)
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+
+	var balance float64
+	err := query.Select("COALESCE(SUM(amount), 0)").Scan(&balance).Error
+	return balance, err
+}
+
+func (r *walletRepository) GetPickupBalance(ctx context.Context, walletID, pickupID string) (float64, error) {
+	var balance float64
+	err := r.db.WithContext(ctx).
+		Table("wallet_entries e").
+		Joins("JOIN wallet_transactions t ON t.id = e.transaction_id").
+		Where("e.wallet_id = ? AND t.pickup_id = ?", walletID, pickupID).
+		Select("COALESCE(SUM(e.amount), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+// GetStatementEntries returns the entries of [from, to) oldest first, each with the
+// running balance of the whole wallet after it.
+func (r *walletRepository) GetStatementEntries(ctx context.Context, walletID string, from, to time.Time, limit, offset int) ([]WalletEntryRow, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&model.WalletEntry{}).
+		Where("wallet_id = ? AND created_at >= ? AND created_at < ?", walletID, from, to).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []WalletEntryRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT e.id AS entry_id, e.transaction_id, t.kind, e.entry_type, t.pickup_id,
+				t.payment_method, t.payment_reference, t.reversal_of_id, t.description,
+				e.amount, e.created_at,
+				SUM(e.amount) OVER (ORDER BY e.created_at, e.id) AS running_balance
+			FROM wallet_entries e
+			JOIN wallet_transactions t ON t.id = e.transaction_id
+			WHERE e.wallet_id = ? AND e.created_at < ?
+		) statement
+		WHERE created_at >= ?
+		ORDER BY created_at ASC, entry_id ASC
+		LIMIT ? OFFSET ?`, walletID, to, from, limit, offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *walletRepository) CreateTransaction(ctx context.Context, txn *model.WalletTransaction) error {
+	return r.db.WithContext(ctx).Create(txn).Error
+}
+
+func (r *walletRepository) GetTransactionForUpdate(ctx context.Context, id string) (*model.WalletTransaction, error) {
+	var txn model.WalletTransaction
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&txn).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("transaction_id = ?", id).Find(&txn.Entries).Error; err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *walletRepository) GetTransactionWithEntries(ctx context.Context, id string) (*model.WalletTransaction, error) {
+	var txn model.WalletTransaction
+	if err := r.db.WithContext(ctx).Preload("Entries").Where("id = ?", id).First(&txn).Error; err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *walletRepository) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*model.WalletTransaction, error) {
+	var txn model.WalletTransaction
+	err := r.db.WithContext(ctx).Where("idempotency_key = ?", key).First(&txn).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *walletRepository) IsReversed(ctx context.Context, transactionID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.WalletTransaction{}).
+		Where("reversal_of_id = ?", transactionID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *walletRepository) GetEntryByID(ctx context.Context, id string) (*model.WalletEntry, error) {
+	var entry model.WalletEntry
+	if err := r.db.WithContext(ctx).Preload("Wallet").Preload("Transaction").Where("id = ?", id).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *walletRepository) CreateDispute(ctx context.Context, dispute *model.WalletDispute) error {
+	return r.db.WithContext(ctx).Create(dispute).Error
+}
+
+func (r *walletRepository) HasOpenDispute(ctx context.Context, entryID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.WalletDispute{}).
+		Where("entry_id = ? AND status = ?", entryID, DisputeOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *walletRepository) GetDisputeForUpdate(ctx context.Context, id string) (*model.WalletDispute, error) {
+	var dispute model.WalletDispute
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&dispute).Error; err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *walletRepository) UpdateDisputeFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.WalletDispute{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *walletRepository) ListDisputes(ctx context.Context, raisedBy, status string, limit, offset int) ([]model.WalletDispute, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.WalletDispute{})
+	if raisedBy != "" {
+		query = query.Where("raised_by = ?", raisedBy)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var disputes []model.WalletDispute
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&disputes).Error; err != nil {
+		return nil, 0, err
+	}
+	return disputes, total, nil
+}
+
+func (r *walletRepository) GetCollectorUserID(ctx context.Context, collectorID string) (string, error) {
+	var collector model.Collector
+	if err := r.db.WithContext(ctx).Select("id", "user_id").Where("id = ?", collectorID).First(&collector).Error; err != nil {
+		return "", err
+	}
+	return collector.UserID, nil
+}
@@ -0,0 +1,31 @@
+package wallet
+
+import (
+	"rijig/config"
+	"rijig/middleware"
+	"rijig/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WalletRouter(api fiber.Router) {
+	service := NewWalletService(config.DB, NewWalletRepository())
+	handler := NewWalletHandler(service)
+
+	wallet := api.Group("/wallet")
+	wallet.Use(middleware.AuthMiddleware())
+
+	members := middleware.RequireRoles(utils.RoleMasyarakat, utils.RolePengepul)
+	wallet.Get("/balance", members, handler.GetMyBalance)
+	wallet.Get("/statement", members, handler.GetMyStatement)
+	wallet.Post("/entries/:entry_id/dispute", members, handler.RaiseDispute)
+	wallet.Get("/disputes/me", members, handler.GetMyDisputes)
+
+	wallet.Post("/pickups/:pickup_id/payment", middleware.RequireRoles(utils.RolePengepul), handler.RecordPayment)
+
+	wallet.Get("/disputes", middleware.RequireRoles(utils.RoleAdministrator), handler.GetDisputes)
+	wallet.Patch("/disputes/:id/resolve", middleware.RequireRoles(utils.RoleAdministrator), handler.ResolveDispute)
+	wallet.Get("/users/:user_id/statement", middleware.RequireRoles(utils.RoleAdministrator), handler.GetUserStatement)
+	wallet.Get("/clearing/pickups", middleware.RequireRoles(utils.RoleAdministrator), handler.GetHeldPickups)
+	wallet.Post("/clearing/pickups/:pickup_id/disbursement", middleware.RequireRoles(utils.RoleAdministrator), handler.DisburseHeldFunds)
+}
@@ -0,0 +1,681 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"rijig/model"
+	"rijig/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	KindPickupSettlement = "pickup_settlement"
+	KindPayout           = "payout"
+	KindDisbursement     = "disbursement"
+	KindReversal         = "reversal"
+
+	EntryReceivable      = "receivable"
+	EntryPayable         = "payable"
+	EntryPaymentSent     = "payment_sent"
+	EntryPaymentReceived = "payment_received"
+	EntryGatewayHeld     = "gateway_held"
+	EntryGatewayReleased = "gateway_released"
+	EntryReversal        = "reversal"
+
+	// ClearingWalletCode is the system wallet holding gateway payments until the
+	// platform disburses them to the household.
+	ClearingWalletCode = "gateway_clearing"
+	WalletRoleSystem   = "system"
+
+	DisputeOpen     = "open"
+	DisputeAccepted = "accepted"
+	DisputeRejected = "rejected"
+
+	defaultDisputeWindow = 72 * time.Hour
+)
+
+var (
+	ErrSettlementNotFound    = errors.New("tagihan pickup tidak ditemukan")
+	ErrNotPickupCollector    = errors.New("pickup ini bukan milik pengepul tersebut")
+	ErrNothingOutstanding    = errors.New("tagihan pickup sudah lunas")
+	ErrPaymentExceeds        = errors.New("jumlah pembayaran melebihi sisa tagihan")
+	ErrEntryNotFound         = errors.New("entri dompet tidak ditemukan")
+	ErrDisputeWindowClosed   = errors.New("batas waktu sanggahan untuk entri ini sudah lewat")
+	ErrDisputeAlreadyOpen    = errors.New("entri ini sudah memiliki sanggahan yang sedang diproses")
+	ErrReversalNotDisputable = errors.New("entri koreksi tidak dapat disanggah")
+	ErrAlreadyReversed       = errors.New("transaksi dompet sudah pernah dikoreksi")
+	ErrDisputeNotFound       = errors.New("sanggahan tidak ditemukan")
+	ErrDisputeResolved       = errors.New("sanggahan sudah diputuskan")
+	ErrUnbalancedPosting     = errors.New("entri transaksi dompet tidak seimbang")
+	ErrNothingHeld           = errors.New("tidak ada dana pickup ini yang ditahan platform")
+)
+
+type WalletService interface {
+	RecordPickupSettlement(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup) (*model.WalletTransaction, error)
+	RecordPayment(ctx context.Context, collectorUserID, pickupID string, req RecordPaymentDTO) (*WalletTransactionDTO, error)
+	RecordGatewayPayment(ctx context.Context, tx *gorm.DB, collectorUserID, pickupID string, amount float64, reference string) error
+	DisburseHeldFunds(ctx context.Context, adminID, pickupID string, req DisburseDTO) (*WalletTransactionDTO, error)
+	ListHeldPickups(ctx context.Context, limit, offset int) ([]HeldPickupDTO, int64, error)
+	GetOutstanding(ctx context.Context, collectorUserID, pickupID string) (float64, error)
+	GetBalance(ctx context.Context, userID string) (*WalletBalanceDTO, error)
+	GetStatement(ctx context.Context, userID string, from, to time.Time, limit, offset int) (*WalletStatementDTO, error)
+	RaiseDispute(ctx context.Context, userID, entryID string, req RaiseDisputeDTO) (*WalletDisputeDTO, error)
+	ListDisputes(ctx context.Context, raisedBy, status string, limit, offset int) ([]WalletDisputeDTO, int64, error)
+	ResolveDispute(ctx context.Context, adminID, disputeID string, req ResolveDisputeDTO) (*WalletDisputeDTO, error)
+}
+
+type walletService struct {
+	db   *gorm.DB
+	repo WalletRepository
+}
+
+func NewWalletService(db *gorm.DB, repo WalletRepository) WalletService {
+	return &walletService{db: db, repo: repo}
+}
+
+// walletLeg is one side of a posting before it becomes a WalletEntry.
+type walletLeg struct {
+	WalletID  string
+	EntryType string
+	Amount    float64
+}
+
+// disputeWindow reads WALLET_DISPUTE_WINDOW, how long after posting an entry can be disputed.
+func disputeWindow() time.Duration {
+	raw := os.Getenv("WALLET_DISPUTE_WINDOW")
+	if raw == "" {
+		return defaultDisputeWindow
+	}
+
+	window, err := time.ParseDuration(raw)
+	if err != nil || window <= 0 {
+		log.Printf("[WALLET] invalid WALLET_DISPUTE_WINDOW %q, using %s", raw, defaultDisputeWindow)
+		return defaultDisputeWindow
+	}
+	return window
+}
+
+// roundAmount keeps amounts at the two decimals the entries are stored with.
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// post writes a balanced transaction after locking every wallet it touches.
+func (s *walletService) post(ctx context.Context, repo WalletRepository, txn *model.WalletTransaction, legs []walletLeg) error {
+	if len(legs) < 2 {
+		return ErrUnbalancedPosting
+	}
+
+	var sum float64
+	walletIDs := make([]string, 0, len(legs))
+	for _, leg := range legs {
+		sum += leg.Amount
+		walletIDs = append(walletIDs, leg.WalletID)
+	}
+	if math.Abs(sum) >= 0.005 {
+		return ErrUnbalancedPosting
+	}
+
+	if err := repo.LockWallets(ctx, walletIDs); err != nil {
+		return fmt.Errorf("failed to lock wallets: %w", err)
+	}
+
+	txn.Entries = make([]model.WalletEntry, 0, len(legs))
+	for _, leg := range legs {
+		txn.Entries = append(txn.Entries, model.WalletEntry{
+			WalletID:  leg.WalletID,
+			EntryType: leg.EntryType,
+			Amount:    leg.Amount,
+		})
+	}
+	return repo.CreateTransaction(ctx, txn)
+}
+
+// RecordPickupSettlement books the settled price of a completed pickup: the household
+// is owed FinalPrice and the collector owes it. It runs inside the completion
+// transaction and is idempotent per pickup.
+func (s *walletService) RecordPickupSettlement(ctx context.Context, tx *gorm.DB, pickup *model.RequestPickup) (*model.WalletTransaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	amount := roundAmount(pickup.FinalPrice)
+	if pickup.CollectorID == nil || amount <= 0 {
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("%s:%s", KindPickupSettlement, pickup.ID)
+	existing, err := repo.GetTransactionByIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	collectorUserID, err := repo.GetCollectorUserID(ctx, *pickup.CollectorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find collector user: %w", err)
+	}
+
+	household, err := repo.GetOrCreateWallet(ctx, pickup.UserId, utils.RoleMasyarakat)
+	if err != nil {
+		return nil, err
+	}
+	collector, err := repo.GetOrCreateWallet(ctx, collectorUserID, utils.RolePengepul)
+	if err != nil {
+		return nil, err
+	}
+
+	pickupID := pickup.ID
+	txn := &model.WalletTransaction{
+		Kind:           KindPickupSettlement,
+		PickupID:       &pickupID,
+		IdempotencyKey: &key,
+		Description:    fmt.Sprintf("Tagihan pickup %s sebesar Rp%.2f", pickup.ID, amount),
+	}
+	err = s.post(ctx, repo, txn, []walletLeg{
+		{WalletID: household.ID, EntryType: EntryReceivable, Amount: amount},
+		{WalletID: collector.ID, EntryType: EntryPayable, Amount: -amount},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record pickup settlement: %w", err)
+	}
+	return txn, nil
+}
+
+// RecordPayment records money the collector has handed to the household for a pickup.
+func (s *walletService) RecordPayment(ctx context.Context, collectorUserID, pickupID string, req RecordPaymentDTO) (*WalletTransactionDTO, error) {
+	var payment *model.WalletTransaction
+	var outstanding float64
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		payment, outstanding, err = s.recordPayment(ctx, s.repo.WithTx(tx), collectorUserID, pickupID, req.Method, req.Reference, req.Amount, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toWalletTransactionDTO(payment)
+	response.Outstanding = &outstanding
+	return response, nil
+}
+
+// RecordGatewayPayment books a payment that a payment gateway has confirmed. The
+// gateway pays the platform, not the household, so the collector's debt moves to the
+// clearing wallet and the household stays owed until DisburseHeldFunds. It runs
+// inside the caller's transaction.
+func (s *walletService) RecordGatewayPayment(ctx context.Context, tx *gorm.DB, collectorUserID, pickupID string, amount float64, reference string) error {
+	_, _, err := s.recordPayment(ctx, s.repo.WithTx(tx), collectorUserID, pickupID, PaymentGateway, reference, amount, true)
+	return err
+}
+
+// DisburseHeldFunds records that the platform paid the household what it holds for a
+// pickup, closing the household's receivable.
+func (s *walletService) DisburseHeldFunds(ctx context.Context, adminID, pickupID string, req DisburseDTO) (*WalletTransactionDTO, error) {
+	var disbursement *model.WalletTransaction
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		settlement, err := repo.GetTransactionByIdempotencyKey(ctx, fmt.Sprintf("%s:%s", KindPickupSettlement, pickupID))
+		if err != nil {
+			return err
+		}
+		if settlement == nil {
+			return ErrSettlementNotFound
+		}
+		settlement, err = repo.GetTransactionForUpdate(ctx, settlement.ID)
+		if err != nil {
+			return err
+		}
+
+		var householdWalletID string
+		for _, entry := range settlement.Entries {
+			if entry.EntryType == EntryReceivable {
+				householdWalletID = entry.WalletID
+			}
+		}
+		if householdWalletID == "" {
+			return ErrSettlementNotFound
+		}
+
+		clearing, err := repo.GetOrCreateSystemWallet(ctx, ClearingWalletCode)
+		if err != nil {
+			return err
+		}
+		balance, err := repo.GetPickupBalance(ctx, clearing.ID, pickupID)
+		if err != nil {
+			return err
+		}
+		held := roundAmount(-balance)
+		if held <= 0 {
+			return ErrNothingHeld
+		}
+
+		disbursement = &model.WalletTransaction{
+			Kind:             KindDisbursement,
+			PickupID:         &pickupID,
+			PaymentMethod:    PaymentTransfer,
+			PaymentReference: req.Reference,
+			Description:      fmt.Sprintf("Penyaluran dana pickup %s sebesar Rp%.2f", pickupID, held),
+			CreatedBy:        &adminID,
+		}
+		return s.post(ctx, repo, disbursement, []walletLeg{
+			{WalletID: clearing.ID, EntryType: EntryGatewayReleased, Amount: held},
+			{WalletID: householdWalletID, EntryType: EntryPaymentReceived, Amount: -held},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toWalletTransactionDTO(disbursement), nil
+}
+
+func (s *walletService) ListHeldPickups(ctx context.Context, limit, offset int) ([]HeldPickupDTO, int64, error) {
+	clearing, err := s.repo.GetOrCreateSystemWallet(ctx, ClearingWalletCode)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, total, err := s.repo.GetHeldPickups(ctx, clearing.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	held := make([]HeldPickupDTO, 0, len(rows))
+	for _, row := range rows {
+		held = append(held, HeldPickupDTO{
+			PickupID:  row.PickupID,
+			Amount:    roundAmount(row.Amount),
+			HeldSince: row.HeldSince.Format(time.RFC3339),
+		})
+	}
+	return held, total, nil
+}
+
+// GetOutstanding returns what the collector still owes for a pickup.
+func (s *walletService) GetOutstanding(ctx context.Context, collectorUserID, pickupID string) (float64, error) {
+	_, collector, err := s.pickupParties(ctx, s.repo, collectorUserID, pickupID, false)
+	if err != nil {
+		return 0, err
+	}
+
+	balance, err := s.repo.GetPickupBalance(ctx, collector.ID, pickupID)
+	if err != nil {
+		return 0, err
+	}
+	return roundAmount(-balance), nil
+}
+
+// pickupParties finds the settlement of a pickup and returns the household wallet and
+// the collector's wallet, failing when the caller is not the pickup's collector. With
+// lock set the settlement row is locked, so concurrent payments cannot overpay it.
+func (s *walletService) pickupParties(ctx context.Context, repo WalletRepository, collectorUserID, pickupID string, lock bool) (string, *model.Wallet, error) {
+	settlement, err := repo.GetTransactionByIdempotencyKey(ctx, fmt.Sprintf("%s:%s", KindPickupSettlement, pickupID))
+	if err != nil {
+		return "", nil, err
+	}
+	if settlement == nil {
+		return "", nil, ErrSettlementNotFound
+	}
+	if lock {
+		settlement, err = repo.GetTransactionForUpdate(ctx, settlement.ID)
+	} else {
+		settlement, err = repo.GetTransactionWithEntries(ctx, settlement.ID)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	collector, err := repo.GetWalletByUserID(ctx, collectorUserID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var householdWalletID string
+	isCollector := false
+	for _, entry := range settlement.Entries {
+		switch {
+		case collector != nil && entry.WalletID == collector.ID:
+			isCollector = true
+		case entry.EntryType == EntryReceivable:
+			householdWalletID = entry.WalletID
+		}
+	}
+	if !isCollector || householdWalletID == "" {
+		return "", nil, ErrNotPickupCollector
+	}
+	return householdWalletID, collector, nil
+}
+
+// recordPayment books a payment of the collector for a pickup. It goes to the
+// household, or with viaClearing to the platform's clearing wallet.
+func (s *walletService) recordPayment(ctx context.Context, repo WalletRepository, collectorUserID, pickupID, method, reference string,
+	amount float64, viaClearing bool) (*model.WalletTransaction, float64, error) {
+	householdWalletID, collector, err := s.pickupParties(ctx, repo, collectorUserID, pickupID, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	balance, err := repo.GetPickupBalance(ctx, collector.ID, pickupID)
+	if err != nil {
+		return nil, 0, err
+	}
+	outstanding := roundAmount(-balance)
+	if outstanding <= 0 {
+		return nil, 0, ErrNothingOutstanding
+	}
+
+	amount = roundAmount(amount)
+	if amount == 0 {
+		amount = outstanding
+	}
+	if amount > outstanding {
+		return nil, 0, ErrPaymentExceeds
+	}
+
+	payment := &model.WalletTransaction{
+		Kind:             KindPayout,
+		PickupID:         &pickupID,
+		PaymentMethod:    method,
+		PaymentReference: reference,
+		Description:      fmt.Sprintf("Pembayaran %s pickup %s sebesar Rp%.2f", method, pickupID, amount),
+		CreatedBy:        &collectorUserID,
+	}
+	counterparty := walletLeg{WalletID: householdWalletID, EntryType: EntryPaymentReceived, Amount: -amount}
+	if viaClearing {
+		clearing, err := repo.GetOrCreateSystemWallet(ctx, ClearingWalletCode)
+		if err != nil {
+			return nil, 0, err
+		}
+		counterparty = walletLeg{WalletID: clearing.ID, EntryType: EntryGatewayHeld, Amount: -amount}
+	}
+
+	err = s.post(ctx, repo, payment, []walletLeg{
+		{WalletID: collector.ID, EntryType: EntryPaymentSent, Amount: amount},
+		counterparty,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return payment, roundAmount(outstanding - amount), nil
+}
+
+func (s *walletService) GetBalance(ctx context.Context, userID string) (*WalletBalanceDTO, error) {
+	response := &WalletBalanceDTO{UserID: userID}
+
+	wallet, err := s.repo.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return response, nil
+	}
+
+	response.WalletID = wallet.ID
+	response.Role = wallet.Role
+	balance, err := s.repo.GetBalance(ctx, wallet.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	response.Balance = roundAmount(balance)
+	return response, nil
+}
+
+func (s *walletService) GetStatement(ctx context.Context, userID string, from, to time.Time, limit, offset int) (*WalletStatementDTO, error) {
+	statement := &WalletStatementDTO{
+		UserID:  userID,
+		From:    from.Format("2006-01-02"),
+		To:      to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Entries: []WalletEntryDTO{},
+		Page:    offset/limit + 1,
+		Limit:   limit,
+	}
+
+	wallet, err := s.repo.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return statement, nil
+	}
+
+	opening, err := s.repo.GetBalance(ctx, wallet.ID, &from)
+	if err != nil {
+		return nil, err
+	}
+	closing, err := s.repo.GetBalance(ctx, wallet.ID, &to)
+	if err != nil {
+		return nil, err
+	}
+	rows, total, err := s.repo.GetStatementEntries(ctx, wallet.ID, from, to, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	statement.OpeningBalance = roundAmount(opening)
+	statement.ClosingBalance = roundAmount(closing)
+	statement.Total = total
+	for _, row := range rows {
+		statement.Entries = append(statement.Entries, WalletEntryDTO{
+			EntryID:          row.EntryID,
+			TransactionID:    row.TransactionID,
+			Kind:             row.Kind,
+			EntryType:        row.EntryType,
+			PickupID:         row.PickupID,
+			PaymentMethod:    row.PaymentMethod,
+			PaymentReference: row.PaymentReference,
+			ReversalOfID:     row.ReversalOfID,
+			Description:      row.Description,
+			Amount:           roundAmount(row.Amount),
+			BalanceAfter:     roundAmount(row.RunningBalance),
+			CreatedAt:        row.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return statement, nil
+}
+
+// RaiseDispute lets the owner of an entry contest it within the dispute window. The
+// owner's wallet is locked so two requests cannot both open a dispute on the entry.
+func (s *walletService) RaiseDispute(ctx context.Context, userID, entryID string, req RaiseDisputeDTO) (*WalletDisputeDTO, error) {
+	var dispute *model.WalletDispute
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		entry, err := repo.GetEntryByID(ctx, entryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntryNotFound
+			}
+			return err
+		}
+		if entry.Wallet == nil || entry.Wallet.UserID == nil || *entry.Wallet.UserID != userID {
+			return ErrEntryNotFound
+		}
+		if entry.Transaction != nil && entry.Transaction.ReversalOfID != nil {
+			return ErrReversalNotDisputable
+		}
+		if time.Since(entry.CreatedAt) > disputeWindow() {
+			return ErrDisputeWindowClosed
+		}
+
+		if err := repo.LockWallets(ctx, []string{entry.WalletID}); err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+
+		reversed, err := repo.IsReversed(ctx, entry.TransactionID)
+		if err != nil {
+			return err
+		}
+		if reversed {
+			return ErrAlreadyReversed
+		}
+		open, err := repo.HasOpenDispute(ctx, entry.ID)
+		if err != nil {
+			return err
+		}
+		if open {
+			return ErrDisputeAlreadyOpen
+		}
+
+		dispute = &model.WalletDispute{
+			EntryID:  entry.ID,
+			RaisedBy: userID,
+			Reason:   strings.TrimSpace(req.Reason),
+			Status:   DisputeOpen,
+		}
+		return repo.CreateDispute(ctx, dispute)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toWalletDisputeDTO(dispute), nil
+}
+
+func (s *walletService) ListDisputes(ctx context.Context, raisedBy, status string, limit, offset int) ([]WalletDisputeDTO, int64, error) {
+	disputes, total, err := s.repo.ListDisputes(ctx, raisedBy, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]WalletDisputeDTO, 0, len(disputes))
+	for i := range disputes {
+		response = append(response, *toWalletDisputeDTO(&disputes[i]))
+	}
+	return response, total, nil
+}
+
+// ResolveDispute closes an open dispute. Accepting it reverses the whole disputed
+// transaction, so both wallets involved return to where they were before it.
+func (s *walletService) ResolveDispute(ctx context.Context, adminID, disputeID string, req ResolveDisputeDTO) (*WalletDisputeDTO, error) {
+	var dispute *model.WalletDispute
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		dispute, err = repo.GetDisputeForUpdate(ctx, disputeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDisputeNotFound
+			}
+			return err
+		}
+		if dispute.Status != DisputeOpen {
+			return ErrDisputeResolved
+		}
+
+		status := DisputeRejected
+		if req.Decision == DecisionAccept {
+			status = DisputeAccepted
+			if err := s.reverseDisputedEntry(ctx, repo, adminID, dispute); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		note := strings.TrimSpace(req.Note)
+		if err := repo.UpdateDisputeFields(ctx, dispute.ID, map[string]interface{}{
+			"status":      status,
+			"resolution":  note,
+			"resolved_by": adminID,
+			"resolved_at": now,
+		}); err != nil {
+			return err
+		}
+
+		dispute.Status = status
+		dispute.Resolution = note
+		dispute.ResolvedBy = &adminID
+		dispute.ResolvedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toWalletDisputeDTO(dispute), nil
+}
+
+func (s *walletService) reverseDisputedEntry(ctx context.Context, repo WalletRepository, adminID string, dispute *model.WalletDispute) error {
+	entry, err := repo.GetEntryByID(ctx, dispute.EntryID)
+	if err != nil {
+		return err
+	}
+	original, err := repo.GetTransactionForUpdate(ctx, entry.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	reversed, err := repo.IsReversed(ctx, original.ID)
+	if err != nil {
+		return err
+	}
+	if reversed {
+		return ErrAlreadyReversed
+	}
+
+	legs := make([]walletLeg, 0, len(original.Entries))
+	for _, e := range original.Entries {
+		legs = append(legs, walletLeg{WalletID: e.WalletID, EntryType: EntryReversal, Amount: -e.Amount})
+	}
+
+	reversal := &model.WalletTransaction{
+		Kind:         KindReversal,
+		PickupID:     original.PickupID,
+		ReversalOfID: &original.ID,
+		Description:  fmt.Sprintf("Koreksi atas sanggahan: %s", strings.TrimSpace(dispute.Reason)),
+		CreatedBy:    &adminID,
+	}
+	return s.post(ctx, repo, reversal, legs)
+}
+
+func toWalletTransactionDTO(txn *model.WalletTransaction) *WalletTransactionDTO {
+	response := &WalletTransactionDTO{
+		ID:               txn.ID,
+		Kind:             txn.Kind,
+		PickupID:         txn.PickupID,
+		PaymentMethod:    txn.PaymentMethod,
+		PaymentReference: txn.PaymentReference,
+		ReversalOfID:     txn.ReversalOfID,
+		Description:      txn.Description,
+		CreatedAt:        txn.CreatedAt.Format(time.RFC3339),
+	}
+	for _, entry := range txn.Entries {
+		response.Entries = append(response.Entries, WalletPostingDTO{
+			EntryID:   entry.ID,
+			WalletID:  entry.WalletID,
+			EntryType: entry.EntryType,
+			Amount:    entry.Amount,
+		})
+	}
+	return response
+}
+
+func toWalletDisputeDTO(dispute *model.WalletDispute) *WalletDisputeDTO {
+	response := &WalletDisputeDTO{
+		ID:         dispute.ID,
+		EntryID:    dispute.EntryID,
+		RaisedBy:   dispute.RaisedBy,
+		Reason:     dispute.Reason,
+		Status:     dispute.Status,
+		Resolution: dispute.Resolution,
+		ResolvedBy: dispute.ResolvedBy,
+		CreatedAt:  dispute.CreatedAt.Format(time.RFC3339),
+	}
+	if dispute.ResolvedAt != nil {
+		response.ResolvedAt = dispute.ResolvedAt.Format(time.RFC3339)
+	}
+	return response
+}
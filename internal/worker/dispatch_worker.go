@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"rijig/internal/requestpickup"
+)
+
+const dispatchLockKey = "worker:dispatch:lock"
+
+type DispatchWorker struct {
+	dispatchService requestpickup.PickupDispatchService
+	lockTTL         time.Duration
+}
+
+func NewDispatchWorker(dispatchService requestpickup.PickupDispatchService) *DispatchWorker {
+	return &DispatchWorker{
+		dispatchService: dispatchService,
+		lockTTL:         25 * time.Second,
+	}
+}
+
+// DispatchPendingPickups runs one dispatch pass. The Redis lock keeps several API
+// instances from offering the same pickup twice in the same tick.
+func (w *DispatchWorker) DispatchPendingPickups() error {
+	ctx := context.Background()
+
+	release, err := acquireLock(ctx, dispatchLockKey, w.lockTTL)
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	summary, err := w.dispatchService.ProcessPendingDispatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	if summary.Expired > 0 || summary.Offered > 0 || summary.Escalated > 0 {
+		log.Printf("[DISPATCH-WORKER] expired=%d offered=%d escalated=%d skipped=%d",
+			summary.Expired, summary.Offered, summary.Escalated, summary.Skipped)
+	}
+	return nil
+}
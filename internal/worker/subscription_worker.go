@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"rijig/internal/requestpickup"
+)
+
+const subscriptionLockKey = "worker:subscription:lock"
+
+type SubscriptionWorker struct {
+	subscriptionService requestpickup.PickupSubscriptionService
+	lockTTL             time.Duration
+}
+
+func NewSubscriptionWorker(subscriptionService requestpickup.PickupSubscriptionService) *SubscriptionWorker {
+	return &SubscriptionWorker{
+		subscriptionService: subscriptionService,
+		lockTTL:             50 * time.Second,
+	}
+}
+
+// MaterializeSubscriptions creates the pickups for subscription occurrences entering
+// the lead window. Only one instance runs a pass at a time.
+func (w *SubscriptionWorker) MaterializeSubscriptions() error {
+	ctx := context.Background()
+
+	release, err := acquireLock(ctx, subscriptionLockKey, w.lockTTL)
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	created, err := w.subscriptionService.MaterializeDueSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if created > 0 {
+		log.Printf("[SUBSCRIPTION-WORKER] materialised %d pickup(s)", created)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"rijig/internal/trash"
+)
+
+const trashPriceLockKey = "worker:trash-price:lock"
+
+type TrashPriceWorker struct {
+	priceService trash.TrashPriceServiceInterface
+	lockTTL      time.Duration
+}
+
+func NewTrashPriceWorker(priceService trash.TrashPriceServiceInterface) *TrashPriceWorker {
+	return &TrashPriceWorker{
+		priceService: priceService,
+		lockTTL:      50 * time.Second,
+	}
+}
+
+// ApplyScheduledPrices puts scheduled trash category prices into effect once their
+// effective time has passed.
+func (w *TrashPriceWorker) ApplyScheduledPrices() error {
+	ctx := context.Background()
+
+	release, err := acquireLock(ctx, trashPriceLockKey, w.lockTTL)
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	applied, err := w.priceService.ApplyDuePriceChanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	if applied > 0 {
+		log.Printf("[TRASH-PRICE-WORKER] applied %d scheduled price change(s)", applied)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"rijig/config"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// releaseLockScript deletes the lock only while it still holds the caller's token, so a
+// pass that outlived its TTL cannot remove a lock another instance has taken since.
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// acquireLock takes the Redis lock at key for ttl under a random token. It returns the
+// func that releases it, or nil when another instance holds the lock.
+func acquireLock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	token := uuid.NewString()
+
+	acquired, err := config.RedisClient.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, nil
+	}
+
+	return func() {
+		if err := releaseLockScript.Run(ctx, config.RedisClient, []string{key}, token).Err(); err != nil {
+			log.Printf("[WORKER] failed to release lock %s: %v", key, err)
+		}
+	}, nil
+}
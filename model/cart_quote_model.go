@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// CartQuote freezes the per-category prices of a user's cart for a short time, so the
+// pickup request is created with the estimate the user actually saw.
+type CartQuote struct {
+	ID                  string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	UserID              string          `gorm:"not null;index" json:"user_id"`
+	User                User            `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	AddressID           *string         `gorm:"type:uuid" json:"address_id,omitempty"`
+	Items               []CartQuoteItem `gorm:"foreignKey:QuoteID;constraint:OnDelete:CASCADE;" json:"items"`
+	TotalAmount         float64         `json:"total_amount"`
+	EstimatedTotalPrice float64         `json:"estimated_total_price"`
+	ExpiresAt           time.Time       `gorm:"not null" json:"expires_at"`
+	UsedAt              *time.Time      `json:"used_at,omitempty"`
+	CreatedAt           time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type CartQuoteItem struct {
+	ID                     string        `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	QuoteID                string        `gorm:"not null;index" json:"quote_id"`
+	TrashCategoryID        string        `gorm:"not null" json:"trash_id"`
+	TrashCategory          TrashCategory `gorm:"foreignKey:TrashCategoryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"trash_category"`
+	Amount                 float64       `json:"amount"`
+	Unit                   string        `gorm:"type:varchar(10);not null;default:'kg'" json:"unit"`
+	PricePerKg             float64       `json:"price_per_kg"`
+	PriceRegion            string        `json:"price_region,omitempty"`
+	SubTotalEstimatedPrice float64       `json:"subtotal_estimated_price"`
+}
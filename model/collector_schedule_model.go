@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// CollectorAvailabilitySlot is a recurring weekly window in which a collector takes
+// pickups. Weekday follows time.Weekday (0 = Minggu) and times are "HH:MM" in WIB.
+type CollectorAvailabilitySlot struct {
+	ID          string     `gorm:"primaryKey;type:uuid;default:uuid_generate_v4();unique;not null" json:"id"`
+	CollectorID string     `gorm:"type:uuid;not null;index" json:"collector_id"`
+	Collector   *Collector `gorm:"foreignKey:CollectorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Weekday     int        `gorm:"not null;index" json:"weekday"`
+	StartTime   string     `gorm:"type:varchar(5);not null" json:"start_time"`
+	EndTime     string     `gorm:"type:varchar(5);not null" json:"end_time"`
+	Capacity    int        `gorm:"not null;default:1" json:"capacity"`
+	IsActive    bool       `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt   time.Time  `gorm:"default:current_timestamp" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"default:current_timestamp" json:"updated_at"`
+}
+
+// CollectorSlotBooking reserves one unit of a slot's capacity on a specific date for a pickup.
+type CollectorSlotBooking struct {
+	ID          string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4();unique;not null" json:"id"`
+	SlotID      string    `gorm:"type:uuid;not null;index:idx_slot_booking_date" json:"slot_id"`
+	CollectorID string    `gorm:"type:uuid;not null;index" json:"collector_id"`
+	RequestID   string    `gorm:"type:uuid;not null;uniqueIndex" json:"request_id"`
+	SlotDate    time.Time `gorm:"type:date;not null;index:idx_slot_booking_date" json:"slot_date"`
+	WindowStart time.Time `gorm:"not null" json:"window_start"`
+	WindowEnd   time.Time `gorm:"not null" json:"window_end"`
+	CreatedAt   time.Time `gorm:"default:current_timestamp" json:"created_at"`
+}
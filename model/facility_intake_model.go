@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// FacilityIntake is one load a collector delivers to a pengelola facility, weighed on
+// the weighbridge loaded (gross) and empty (tare). NetWeight and TotalPrice are only
+// set once the tare weight is recorded.
+type FacilityIntake struct {
+	ID               string                 `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	CompanyProfileID string                 `gorm:"type:uuid;not null;index;uniqueIndex:idx_facility_intake_ticket" json:"company_profile_id"`
+	CompanyProfile   *CompanyProfile        `gorm:"foreignKey:CompanyProfileID;constraint:OnDelete:RESTRICT;" json:"-"`
+	RecordedBy       string                 `gorm:"type:uuid;not null" json:"recorded_by"`
+	CollectorID      string                 `gorm:"type:uuid;not null;index" json:"collector_id"`
+	Collector        *Collector             `gorm:"foreignKey:CollectorID;constraint:OnDelete:RESTRICT;" json:"-"`
+	TrashCategoryID  string                 `gorm:"type:uuid;not null;index" json:"trash_category_id"`
+	TrashCategory    *TrashCategory         `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:RESTRICT;" json:"trash_category,omitempty"`
+	TicketNumber     *string                `gorm:"type:varchar(50);uniqueIndex:idx_facility_intake_ticket" json:"ticket_number,omitempty"`
+	Status           string                 `gorm:"type:varchar(20);not null;index" json:"status"`
+	GrossWeight      float64                `gorm:"type:numeric(12,2);not null" json:"gross_weight"`
+	TareWeight       *float64               `gorm:"type:numeric(12,2)" json:"tare_weight,omitempty"`
+	NetWeight        float64                `gorm:"type:numeric(12,2);not null;default:0" json:"net_weight"`
+	Grade            string                 `gorm:"type:varchar(10);not null" json:"grade"`
+	PricePerKg       float64                `gorm:"type:numeric(14,2);not null" json:"price_per_kg"`
+	TotalPrice       float64                `gorm:"type:numeric(14,2);not null;default:0" json:"total_price"`
+	Notes            string                 `json:"notes,omitempty"`
+	Pickups          []FacilityIntakePickup `gorm:"foreignKey:IntakeID;constraint:OnDelete:CASCADE;" json:"pickups,omitempty"`
+	WeighedInAt      time.Time              `gorm:"not null" json:"weighed_in_at"`
+	WeighedOutAt     *time.Time             `json:"weighed_out_at,omitempty"`
+	CreatedAt        time.Time              `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt        time.Time              `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// FacilityIntakePickup links an intake to a request pickup whose load it contains.
+type FacilityIntakePickup struct {
+	ID              string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	IntakeID        string         `gorm:"type:uuid;not null;uniqueIndex:idx_facility_intake_pickup" json:"intake_id"`
+	RequestPickupID string         `gorm:"type:uuid;not null;index;uniqueIndex:idx_facility_intake_pickup" json:"request_pickup_id"`
+	RequestPickup   *RequestPickup `gorm:"foreignKey:RequestPickupID;constraint:OnDelete:RESTRICT;" json:"-"`
+}
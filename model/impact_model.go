@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// ImpactRecord is the environmental impact of one settled pickup item: the weight
+// recycled and the CO2e avoided at the category's emission factor when the pickup was
+// completed. The factor is copied so later changes to the category don't rewrite history.
+type ImpactRecord struct {
+	ID                  string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	RequestPickupID     string         `gorm:"type:uuid;not null;index" json:"request_pickup_id"`
+	RequestPickup       *RequestPickup `gorm:"foreignKey:RequestPickupID;constraint:OnDelete:CASCADE;" json:"-"`
+	RequestPickupItemID string         `gorm:"type:uuid;not null;uniqueIndex" json:"request_pickup_item_id"`
+	UserID              string         `gorm:"type:uuid;not null;index" json:"user_id"`
+	CollectorID         *string        `gorm:"type:uuid;index" json:"collector_id,omitempty"`
+	Regency             string         `gorm:"type:varchar(100);not null;index" json:"regency"`
+	TrashCategoryID     string         `gorm:"type:uuid;not null;index" json:"trash_category_id"`
+	TrashCategory       *TrashCategory `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:RESTRICT;" json:"trash_category,omitempty"`
+	WeightKg            float64        `gorm:"type:numeric(12,2);not null" json:"weight_kg"`
+	EmissionFactor      float64        `gorm:"type:numeric(10,4);not null" json:"emission_factor"`
+	CO2eAvoided         float64        `gorm:"column:co2e_avoided;type:numeric(14,4);not null" json:"co2e_avoided"`
+	SettledAt           time.Time      `gorm:"not null;index" json:"settled_at"`
+	CreatedAt           time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ImpactRollup is the running total of impact records for one scope (a user, a
+// collector, a regency or the whole platform) in one calendar month or year, in
+// Asia/Jakarta time. Rollups are incremented as pickups complete.
+type ImpactRollup struct {
+	ID          string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	ScopeType   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_impact_rollup" json:"scope_type"`
+	ScopeID     string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_impact_rollup" json:"scope_id"`
+	PeriodType  string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_impact_rollup" json:"period_type"`
+	PeriodStart time.Time `gorm:"type:date;not null;uniqueIndex:idx_impact_rollup" json:"period_start"`
+	WeightKg    float64   `gorm:"type:numeric(14,2);not null;default:0" json:"weight_kg"`
+	CO2eAvoided float64   `gorm:"column:co2e_avoided;type:numeric(16,4);not null;default:0" json:"co2e_avoided"`
+	PickupCount int64     `gorm:"not null;default:0" json:"pickup_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
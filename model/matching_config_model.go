@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type MatchingConfig struct {
+	ID             string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	RegencyID      string    `gorm:"type:varchar(255);not null;unique" json:"regency_id"`
+	RegencyName    string    `gorm:"not null;index" json:"regency_name"`
+	RadiusKm       float64   `gorm:"not null;default:10" json:"radius_km"`
+	MaxWorkload    int       `gorm:"not null;default:5" json:"max_workload"`
+	WeightDistance float64   `gorm:"not null" json:"weight_distance"`
+	WeightCategory float64   `gorm:"not null" json:"weight_category"`
+	WeightRating   float64   `gorm:"not null" json:"weight_rating"`
+	WeightPrice    float64   `gorm:"not null" json:"weight_price"`
+	WeightWorkload float64   `gorm:"not null" json:"weight_workload"`
+	CreatedAt      time.Time `gorm:"default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"default:current_timestamp" json:"updated_at"`
+}
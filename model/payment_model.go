@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Payment is one attempt to collect money through a payment provider for a pickup
+// settlement or a store order. ProviderChargeID is the provider's id for the charge.
+type Payment struct {
+	ID               string     `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Provider         string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_payment_provider_charge" json:"provider"`
+	ProviderChargeID *string    `gorm:"type:varchar(100);uniqueIndex:idx_payment_provider_charge" json:"provider_charge_id,omitempty"`
+	Channel          string     `gorm:"type:varchar(30);not null" json:"channel"`
+	Purpose          string     `gorm:"type:varchar(30);not null;index:idx_payment_reference" json:"purpose"`
+	ReferenceID      string     `gorm:"type:uuid;not null;index:idx_payment_reference" json:"reference_id"`
+	PayerID          string     `gorm:"type:uuid;not null;index" json:"payer_id"`
+	Payer            *User      `gorm:"foreignKey:PayerID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Amount           float64    `gorm:"type:numeric(14,2);not null" json:"amount"`
+	Status           string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	PaymentURL       string     `json:"payment_url,omitempty"`
+	QRString         string     `json:"qr_string,omitempty"`
+	VANumber         string     `gorm:"type:varchar(50)" json:"va_number,omitempty"`
+	FailureReason    string     `json:"failure_reason,omitempty"`
+	SettlementError  string     `json:"settlement_error,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	PaidAt           *time.Time `json:"paid_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PaymentEvent stores every webhook a provider delivered; the unique event id makes
+// redelivered webhooks harmless.
+type PaymentEvent struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	PaymentID string    `gorm:"type:uuid;not null;index" json:"payment_id"`
+	Payment   *Payment  `gorm:"foreignKey:PaymentID;constraint:OnDelete:CASCADE;" json:"-"`
+	Provider  string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_payment_event_provider" json:"provider"`
+	EventID   string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_payment_event_provider" json:"event_id"`
+	Status    string    `gorm:"type:varchar(20);not null" json:"status"`
+	Payload   string    `gorm:"type:text" json:"payload"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PaymentSimulatorCharge is a charge held by the offline payment simulator. It is kept
+// in the database so pending simulated payments survive a restart.
+type PaymentSimulatorCharge struct {
+	ChargeID   string     `gorm:"primaryKey;type:varchar(100)" json:"charge_id"`
+	Status     string     `gorm:"type:varchar(20);not null" json:"status"`
+	Amount     float64    `gorm:"type:numeric(14,2);not null" json:"amount"`
+	PaymentURL string     `json:"payment_url,omitempty"`
+	QRString   string     `json:"qr_string,omitempty"`
+	VANumber   string     `gorm:"type:varchar(50)" json:"va_number,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type PickupDispatchOffer struct {
+	ID          string     `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	RequestID   string     `gorm:"type:uuid;not null;index" json:"request_id"`
+	CollectorID string     `gorm:"type:uuid;not null;index" json:"collector_id"`
+	Status      string     `gorm:"not null;index" json:"status"`
+	Score       float64    `json:"score"`
+	DistanceKm  float64    `json:"distance_km"`
+	OfferedAt   time.Time  `gorm:"not null" json:"offered_at"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
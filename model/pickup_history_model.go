@@ -9,4 +9,6 @@ type PickupStatusHistory struct {
 	ChangedAt     time.Time `gorm:"not null" json:"changed_at"`
 	ChangedByID   string    `gorm:"not null" json:"changed_by_id"`
 	ChangedByRole string    `gorm:"not null" json:"changed_by_role"`
+	ReasonCode    string    `json:"reason_code,omitempty"`
+	Notes         string    `json:"notes,omitempty"`
 }
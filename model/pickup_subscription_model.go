@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// PickupSubscription repeats the same pickup on a fixed rhythm. NextOccurrence is the
+// start of the next window that has not been materialised or skipped yet.
+type PickupSubscription struct {
+	ID             string                   `gorm:"primaryKey;type:uuid;default:uuid_generate_v4();unique;not null" json:"id"`
+	UserID         string                   `gorm:"type:uuid;not null;index" json:"user_id"`
+	User           *User                    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	AddressID      string                   `gorm:"type:uuid;not null" json:"address_id"`
+	Address        *Address                 `gorm:"foreignKey:AddressID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"address,omitempty"`
+	RequestMethod  string                   `gorm:"not null" json:"request_method"`
+	Notes          string                   `json:"notes"`
+	Frequency      string                   `gorm:"not null" json:"frequency"`
+	Weekday        int                      `json:"weekday"`
+	DayOfMonth     int                      `json:"day_of_month"`
+	WindowStart    string                   `gorm:"type:varchar(5);not null" json:"window_start"`
+	WindowEnd      string                   `gorm:"type:varchar(5);not null" json:"window_end"`
+	Status         string                   `gorm:"not null;default:'active';index" json:"status"`
+	NextOccurrence time.Time                `gorm:"not null;index" json:"next_occurrence"`
+	LastPickupID   *string                  `gorm:"type:uuid" json:"last_pickup_id,omitempty"`
+	EndedAt        *time.Time               `json:"ended_at,omitempty"`
+	Items          []PickupSubscriptionItem `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE;" json:"items"`
+	Skips          []PickupSubscriptionSkip `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE;" json:"skips,omitempty"`
+	CreatedAt      time.Time                `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time                `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type PickupSubscriptionItem struct {
+	ID              string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4();unique;not null" json:"id"`
+	SubscriptionID  string         `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	TrashCategoryID string         `gorm:"type:uuid;not null" json:"trash_category_id"`
+	TrashCategory   *TrashCategory `gorm:"foreignKey:TrashCategoryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"trash_category,omitempty"`
+	EstimatedAmount float64        `gorm:"not null" json:"estimated_amount"`
+}
+
+// PickupSubscriptionSkip marks a single occurrence that should not become a pickup.
+type PickupSubscriptionSkip struct {
+	ID             string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4();unique;not null" json:"id"`
+	SubscriptionID string    `gorm:"type:uuid;not null;uniqueIndex:idx_subscription_skip" json:"subscription_id"`
+	OccurrenceDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_subscription_skip" json:"occurrence_date"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
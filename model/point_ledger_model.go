@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// PointAccount is one side of the points ledger: a user's wallet or a system account
+// points are issued from. Its balance is never stored; it is the sum of its entries.
+type PointAccount struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Code      string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"code"`
+	Type      string    `gorm:"type:varchar(20);not null" json:"type"`
+	UserID    *string   `gorm:"type:uuid;uniqueIndex" json:"user_id,omitempty"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT;" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PointTransaction groups the entries of one posting; its entries always sum to zero.
+// Transactions are never edited: a correction is a new transaction that reverses the
+// original through ReversalOfID.
+type PointTransaction struct {
+	ID             string       `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Kind           string       `gorm:"type:varchar(30);not null;index" json:"kind"`
+	ReferenceType  string       `gorm:"type:varchar(30)" json:"reference_type,omitempty"`
+	ReferenceID    string       `gorm:"type:varchar(64);index" json:"reference_id,omitempty"`
+	IdempotencyKey *string      `gorm:"type:varchar(120);uniqueIndex" json:"-"`
+	ReversalOfID   *string      `gorm:"type:uuid;uniqueIndex" json:"reversal_of_id,omitempty"`
+	Description    string       `json:"description"`
+	CreatedBy      *string      `gorm:"type:uuid" json:"created_by,omitempty"`
+	Entries        []PointEntry `gorm:"foreignKey:TransactionID;constraint:OnDelete:RESTRICT;" json:"entries,omitempty"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// PointEntry moves Amount points into (positive) or out of (negative) an account.
+type PointEntry struct {
+	ID            string            `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TransactionID string            `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   *PointTransaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	AccountID     string            `gorm:"type:uuid;not null;index" json:"account_id"`
+	Account       *PointAccount     `gorm:"foreignKey:AccountID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Amount        int64             `gorm:"not null" json:"amount"`
+	CreatedAt     time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
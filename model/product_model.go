@@ -2,14 +2,21 @@ package model
 
 import "time"
 
+// Product is sold for Price, redeemed for PointPrice points, or both; a zero price
+// means the product is not offered that way. Quantity is the stock still available and
+// Saled the units reserved by orders that were not cancelled.
 type Product struct {
 	ID            string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4();unique;not null;column:id" json:"id"`
 	StoreID       string         `gorm:"type:uuid;not null;column:store_id" json:"storeId"`
 	Store         Store          `gorm:"foreignKey:StoreID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	ProductName   string         `gorm:"not null;column:product_name;index" json:"productName"`
+	Description   string         `gorm:"column:description" json:"description"`
 	ProductImages []ProductImage `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"productImages,omitempty"`
+	PointPrice    int64          `gorm:"default:0;column:point_price" json:"pointPrice"`
+	Price         float64        `gorm:"default:0;column:price" json:"price"`
 	Quantity      int            `gorm:"not null;column:quantity" json:"quantity"`
 	Saled         int            `gorm:"default:0;column:saled" json:"saled"`
+	IsActive      bool           `gorm:"default:true;column:is_active" json:"isActive"`
 	CreatedAt     time.Time      `gorm:"default:current_timestamp;column:created_at" json:"createdAt"`
 	UpdatedAt     time.Time      `gorm:"default:current_timestamp;column:updated_at" json:"updatedAt"`
 }
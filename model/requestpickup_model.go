@@ -17,7 +17,13 @@ type RequestPickup struct {
 	Collector              *Collector          `gorm:"foreignKey:CollectorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"collector,omitempty"`
 	ConfirmedByCollectorAt *time.Time          `json:"confirmed_by_collector_at,omitempty"`
 	RequestMethod          string              `gorm:"not null" json:"request_method"`
+	ScheduledStart         *time.Time          `gorm:"uniqueIndex:idx_subscription_occurrence" json:"scheduled_start,omitempty"`
+	SubscriptionID         *string             `gorm:"type:uuid;uniqueIndex:idx_subscription_occurrence" json:"subscription_id,omitempty"`
+	ScheduledEnd           *time.Time          `json:"scheduled_end,omitempty"`
+	QuoteID                *string             `gorm:"type:uuid;uniqueIndex" json:"quote_id,omitempty"`
 	FinalPrice             float64             `json:"final_price"`
+	CompletedAt            *time.Time          `json:"completed_at,omitempty"`
+	DispatchEscalatedAt    *time.Time          `json:"dispatch_escalated_at,omitempty"`
 	CreatedAt              time.Time           `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt              time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -29,6 +35,10 @@ type RequestPickupItem struct {
 	TrashCategoryId        string         `gorm:"not null" json:"trash_category_id"`
 	TrashCategory          *TrashCategory `gorm:"foreignKey:TrashCategoryId;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"trash_category"`
 	EstimatedAmount        float64        `gorm:"not null" json:"estimated_amount"`
+	Unit                   string         `gorm:"type:varchar(10);not null;default:'kg'" json:"unit"`
 	EstimatedPricePerKg    float64        `gorm:"not null" json:"estimated_price_per_kg"`
 	EstimatedSubtotalPrice float64        `gorm:"not null" json:"estimated_subtotal_price"`
+	ActualAmount           *float64       `json:"actual_amount,omitempty"`
+	ActualPricePerKg       float64        `json:"actual_price_per_kg"`
+	ActualSubtotalPrice    float64        `json:"actual_subtotal_price"`
 }
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// StoreOrder is a purchase or point redemption at a single store. Stock is reserved
+// and points are held when the order is placed; both are given back if it is cancelled.
+// Money paid online cannot be given back the same way, so cancelling a paid order
+// leaves a pending refund that an admin settles with the buyer.
+type StoreOrder struct {
+	ID            string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	StoreID       string  `gorm:"type:uuid;not null;index" json:"store_id"`
+	Store         *Store  `gorm:"foreignKey:StoreID;constraint:OnDelete:RESTRICT;" json:"store,omitempty"`
+	UserID        string  `gorm:"type:uuid;not null;index" json:"user_id"`
+	User          *User   `gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Status        string  `gorm:"type:varchar(20);not null;index" json:"status"`
+	PaymentMethod string  `gorm:"type:varchar(20);not null" json:"payment_method"`
+	TotalPoints   int64   `gorm:"not null;default:0" json:"total_points"`
+	TotalPrice    float64 `gorm:"not null;default:0" json:"total_price"`
+	Notes         string  `json:"notes,omitempty"`
+	// PaidAt and PaymentReference are set once an online payment is confirmed.
+	PaidAt           *time.Time       `json:"paid_at,omitempty"`
+	PaymentReference string           `json:"payment_reference,omitempty"`
+	RefundStatus     string           `gorm:"type:varchar(20);index" json:"refund_status,omitempty"`
+	RefundAmount     float64          `gorm:"not null;default:0" json:"refund_amount,omitempty"`
+	RefundReference  string           `json:"refund_reference,omitempty"`
+	RefundedBy       *string          `gorm:"type:uuid" json:"refunded_by,omitempty"`
+	RefundedAt       *time.Time       `json:"refunded_at,omitempty"`
+	Items            []StoreOrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"items"`
+	CancelReason     string           `json:"cancel_reason,omitempty"`
+	CancelledBy      *string          `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	ReadyAt          *time.Time       `json:"ready_at,omitempty"`
+	HandedOverAt     *time.Time       `json:"handed_over_at,omitempty"`
+	CancelledAt      *time.Time       `json:"cancelled_at,omitempty"`
+	CreatedAt        time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StoreOrderItem copies the product name and prices at the time of the order.
+type StoreOrderItem struct {
+	ID             string   `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	OrderID        string   `gorm:"type:uuid;not null;index" json:"order_id"`
+	ProductID      string   `gorm:"type:uuid;not null;index" json:"product_id"`
+	Product        *Product `gorm:"foreignKey:ProductID;constraint:OnDelete:RESTRICT;" json:"-"`
+	ProductName    string   `gorm:"not null" json:"product_name"`
+	Quantity       int      `gorm:"not null" json:"quantity"`
+	PointPrice     int64    `gorm:"not null;default:0" json:"point_price"`
+	Price          float64  `gorm:"not null;default:0" json:"price"`
+	SubtotalPoints int64    `gorm:"not null;default:0" json:"subtotal_points"`
+	SubtotalPrice  float64  `gorm:"not null;default:0" json:"subtotal_price"`
+}
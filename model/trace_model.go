@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// TraceLot is a quantity of one trash category at one hop of its journey: a settled
+// pickup item (source), a collector's combined load (aggregated), a load received at a
+// facility (intake) or the output of final processing (processed). A lot is consumed
+// once it becomes a parent of a lot at a later hop.
+type TraceLot struct {
+	ID               string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Code             string         `gorm:"type:varchar(40);uniqueIndex;not null" json:"code"`
+	Stage            string         `gorm:"type:varchar(20);not null;index" json:"stage"`
+	Status           string         `gorm:"type:varchar(20);not null;index" json:"status"`
+	TrashCategoryID  string         `gorm:"type:uuid;not null;index" json:"trash_category_id"`
+	TrashCategory    *TrashCategory `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:RESTRICT;" json:"trash_category,omitempty"`
+	Quantity         float64        `gorm:"type:numeric(12,2);not null" json:"quantity"`
+	Unit             string         `gorm:"type:varchar(10);not null" json:"unit"`
+	ReferenceType    string         `gorm:"type:varchar(30);not null;uniqueIndex:idx_trace_lot_reference" json:"reference_type"`
+	ReferenceID      *string        `gorm:"type:uuid;uniqueIndex:idx_trace_lot_reference" json:"reference_id,omitempty"`
+	CollectorID      *string        `gorm:"type:uuid;index" json:"collector_id,omitempty"`
+	CompanyProfileID *string        `gorm:"type:uuid;index" json:"company_profile_id,omitempty"`
+	ProcessMethod    string         `gorm:"type:varchar(30)" json:"process_method,omitempty"`
+	CreatedBy        *string        `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TraceLink says that the child lot was made from Quantity of the parent lot.
+type TraceLink struct {
+	ID          string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	ParentLotID string    `gorm:"type:uuid;not null;uniqueIndex:idx_trace_link" json:"parent_lot_id"`
+	ParentLot   *TraceLot `gorm:"foreignKey:ParentLotID;constraint:OnDelete:RESTRICT;" json:"-"`
+	ChildLotID  string    `gorm:"type:uuid;not null;index;uniqueIndex:idx_trace_link" json:"child_lot_id"`
+	ChildLot    *TraceLot `gorm:"foreignKey:ChildLotID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Quantity    float64   `gorm:"type:numeric(12,2);not null" json:"quantity"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TraceRecord is one entry of the append-only trace ledger. Hash covers the previous
+// record's hash, the sequence and the payload, so editing or removing any record
+// breaks every hash after it.
+type TraceRecord struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Sequence  int64     `gorm:"uniqueIndex;not null" json:"sequence"`
+	LotID     string    `gorm:"type:uuid;not null;index" json:"lot_id"`
+	Lot       *TraceLot `gorm:"foreignKey:LotID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Event     string    `gorm:"type:varchar(30);not null" json:"event"`
+	Payload   string    `gorm:"type:text;not null" json:"payload"`
+	PrevHash  string    `gorm:"type:char(64);not null" json:"prev_hash"`
+	Hash      string    `gorm:"type:char(64);uniqueIndex;not null" json:"hash"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
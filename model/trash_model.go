@@ -2,15 +2,26 @@ package model
 
 import "time"
 
+// TrashCategory is a node in the category tree (Plastic → PET bottle → clear PET).
+// EstimatedPrice is per Unit, and quantities of the category are bounded by
+// MinQuantity and, when set, MaxQuantity. EmissionFactor is the kg CO2e avoided
+// per kg of the category recycled.
 type TrashCategory struct {
-	ID             string        `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
-	Name           string        `gorm:"not null" json:"trash_name"`
-	IconTrash      string        `json:"trash_icon,omitempty"`
-	EstimatedPrice float64       `gorm:"not null" json:"estimated_price"`
-	Variety        string        `gorm:"not null" json:"variety"`
-	Details        []TrashDetail `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:CASCADE;" json:"trash_detail"`
-	CreatedAt      time.Time     `gorm:"default:current_timestamp" json:"createdAt"`
-	UpdatedAt      time.Time     `gorm:"default:current_timestamp" json:"updatedAt"`
+	ID             string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	ParentID       *string         `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	Parent         *TrashCategory  `gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Children       []TrashCategory `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+	Name           string          `gorm:"not null" json:"trash_name"`
+	IconTrash      string          `json:"trash_icon,omitempty"`
+	EstimatedPrice float64         `gorm:"not null" json:"estimated_price"`
+	Unit           string          `gorm:"type:varchar(10);not null;default:'kg'" json:"unit"`
+	MinQuantity    float64         `gorm:"not null;default:0" json:"min_quantity"`
+	MaxQuantity    *float64        `json:"max_quantity,omitempty"`
+	EmissionFactor float64         `gorm:"not null;default:0" json:"emission_factor"`
+	Variety        string          `gorm:"not null" json:"variety"`
+	Details        []TrashDetail   `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:CASCADE;" json:"trash_detail"`
+	CreatedAt      time.Time       `gorm:"default:current_timestamp" json:"createdAt"`
+	UpdatedAt      time.Time       `gorm:"default:current_timestamp" json:"updatedAt"`
 }
 
 type TrashDetail struct {
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// TrashCategoryPrice is one entry in a category's price history. Applied entries form
+// the price curve; scheduled entries are future changes waiting for the price worker.
+type TrashCategoryPrice struct {
+	ID              string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TrashCategoryID string         `gorm:"type:uuid;not null;index:idx_trash_price_curve,priority:1" json:"trash_category_id"`
+	TrashCategory   *TrashCategory `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:CASCADE;" json:"-"`
+	Price           float64        `gorm:"not null" json:"price"`
+	EffectiveFrom   time.Time      `gorm:"not null;index:idx_trash_price_curve,priority:2" json:"effective_from"`
+	Status          string         `gorm:"not null;index" json:"status"`
+	Source          string         `gorm:"not null" json:"source"`
+	Note            string         `json:"note,omitempty"`
+	CreatedBy       string         `json:"created_by,omitempty"`
+	AppliedAt       *time.Time     `json:"applied_at,omitempty"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
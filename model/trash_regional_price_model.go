@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TrashCategoryRegionalPrice overrides a category's national EstimatedPrice inside one
+// province or regency. Region names are kept next to the wilayah IDs because addresses
+// store names, not IDs.
+type TrashCategoryRegionalPrice struct {
+	ID              string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TrashCategoryID string         `gorm:"type:uuid;not null;uniqueIndex:idx_trash_regional_price" json:"trash_category_id"`
+	TrashCategory   *TrashCategory `gorm:"foreignKey:TrashCategoryID;constraint:OnDelete:CASCADE;" json:"-"`
+	RegionLevel     string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_trash_regional_price" json:"region_level"`
+	RegionID        string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_trash_regional_price" json:"region_id"`
+	RegionName      string         `gorm:"not null;index" json:"region_name"`
+	ProvinceName    string         `gorm:"not null" json:"province_name"`
+	Price           float64        `gorm:"not null" json:"price"`
+	CreatedAt       time.Time      `gorm:"default:current_timestamp" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"default:current_timestamp" json:"updated_at"`
+}
@@ -6,11 +6,12 @@ import (
 
 type Cart struct {
 	ID                  string     `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
-	UserID              string     `gorm:"not null" json:"user_id"`
+	UserID              string     `gorm:"not null;uniqueIndex" json:"user_id"`
 	User                User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
 	CartItems           []CartItem `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE;" json:"cart_items"`
 	TotalAmount         float64    `json:"total_amount"`
 	EstimatedTotalPrice float64    `json:"estimated_total_price"`
+	Version             int64      `gorm:"not null;default:0" json:"version"`
 	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Wallet tracks money between households and collectors. A household's positive
+// balance is what it is still owed, a collector's negative balance is what it still
+// has to pay out. Money collected through a payment gateway is held by the platform
+// in a system wallet, identified by Code instead of UserID, until it is disbursed.
+type Wallet struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	UserID    *string   `gorm:"type:uuid;uniqueIndex" json:"user_id,omitempty"`
+	Code      *string   `gorm:"type:varchar(40);uniqueIndex" json:"code,omitempty"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Role      string    `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// WalletTransaction groups the entries of one posting; its entries sum to zero across
+// the two wallets involved. Corrections reverse a transaction instead of editing it.
+type WalletTransaction struct {
+	ID               string        `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Kind             string        `gorm:"type:varchar(30);not null;index" json:"kind"`
+	PickupID         *string       `gorm:"type:uuid;index" json:"pickup_id,omitempty"`
+	PaymentMethod    string        `gorm:"type:varchar(20)" json:"payment_method,omitempty"`
+	PaymentReference string        `json:"payment_reference,omitempty"`
+	IdempotencyKey   *string       `gorm:"type:varchar(120);uniqueIndex" json:"-"`
+	ReversalOfID     *string       `gorm:"type:uuid;uniqueIndex" json:"reversal_of_id,omitempty"`
+	Description      string        `json:"description"`
+	CreatedBy        *string       `gorm:"type:uuid" json:"created_by,omitempty"`
+	Entries          []WalletEntry `gorm:"foreignKey:TransactionID;constraint:OnDelete:RESTRICT;" json:"entries,omitempty"`
+	CreatedAt        time.Time     `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+type WalletEntry struct {
+	ID            string             `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	TransactionID string             `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   *WalletTransaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	WalletID      string             `gorm:"type:uuid;not null;index" json:"wallet_id"`
+	Wallet        *Wallet            `gorm:"foreignKey:WalletID;constraint:OnDelete:RESTRICT;" json:"-"`
+	EntryType     string             `gorm:"type:varchar(30);not null" json:"entry_type"`
+	Amount        float64            `gorm:"type:numeric(14,2);not null" json:"amount"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// WalletDispute is raised by the owner of an entry. An accepted dispute reverses the
+// disputed transaction; a rejected one leaves the ledger as it is.
+type WalletDispute struct {
+	ID         string       `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	EntryID    string       `gorm:"type:uuid;not null;index" json:"entry_id"`
+	Entry      *WalletEntry `gorm:"foreignKey:EntryID;constraint:OnDelete:RESTRICT;" json:"-"`
+	RaisedBy   string       `gorm:"type:uuid;not null;index" json:"raised_by"`
+	Reason     string       `gorm:"not null" json:"reason"`
+	Status     string       `gorm:"type:varchar(20);not null;index" json:"status"`
+	Resolution string       `json:"resolution,omitempty"`
+	ResolvedBy *string      `gorm:"type:uuid" json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time   `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -9,13 +9,21 @@ import (
 	"rijig/internal/article"
 	"rijig/internal/authentication"
 	"rijig/internal/cart"
+	"rijig/internal/collector"
 	"rijig/internal/company"
+	"rijig/internal/facility"
 	"rijig/internal/identitycart"
+	"rijig/internal/impact"
+	"rijig/internal/payment"
+	"rijig/internal/points"
 	"rijig/internal/requestpickup"
 	"rijig/internal/role"
+	"rijig/internal/store"
+	"rijig/internal/trace"
 	"rijig/internal/trash"
 	"rijig/internal/userpin"
 	"rijig/internal/userprofile"
+	"rijig/internal/wallet"
 	"rijig/internal/whatsapp"
 	"rijig/internal/wilayahindo"
 	"rijig/middleware"
@@ -28,6 +36,8 @@ import (
 func SetupRoutes(app *fiber.App) {
 	apa := app.Group(os.Getenv("BASE_URL"))
 	apa.Static("/uploads", "./public"+os.Getenv("BASE_URL")+"/uploads")
+	payment.PaymentWebhookRouter(apa)
+	requestpickup.PickupTrackingRouter(apa)
 	// a := app.Group(os.Getenv("BASE_URL"))
 	// whatsapp.WhatsAppRouter(a)
 
@@ -53,11 +63,21 @@ func SetupRoutes(app *fiber.App) {
 	// presentation.IdentityCardRouter(api)
 	// presentation.CompanyProfileRouter(api)
 	requestpickup.RequestPickupRouter(api)
-	// presentation.PickupMatchingRouter(api)
-	// presentation.PickupRatingRouter(api)
+	requestpickup.PickupMatchingRouter(api)
+	requestpickup.PickupRatingRouter(api)
+	requestpickup.PickupDispatchRouter(api)
+	requestpickup.PickupSubscriptionRouter(api)
+	requestpickup.PickupRoutePlanRouter(api)
 
-	// presentation.CollectorRouter(api)
+	collector.CollectorRouter(api)
 	cart.TrashCartRouter(api)
+	points.PointsRouter(api)
+	store.StoreRouter(api, payment.StoreOrderPayments())
+	wallet.WalletRouter(api)
+	payment.PaymentRouter(api)
+	facility.FacilityRouter(api)
+	trace.TraceRouter(api)
+	impact.ImpactRouter(api)
 
 	// presentation.UserProfileRouter(api)
 	// presentation.UserPinRouter(api)
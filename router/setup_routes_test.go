@@ -0,0 +1,58 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestTrackingHandshakeSkipsAPIKey opens the tracking socket the way a browser does,
+// without x-api-key or Authorization, and checks the request reaches the JWT check.
+func TestTrackingHandshakeSkipsAPIKey(t *testing.T) {
+	t.Setenv("PAYMENT_SIMULATOR_ENABLED", "true")
+
+	app := fiber.New()
+	SetupRoutes(app)
+
+	tests := []struct {
+		name     string
+		target   string
+		protocol string
+		wantCode string
+	}{
+		{name: "no token", target: "/pickup/tracking/pickup-1/ws", wantCode: "MISSING_TOKEN"},
+		{name: "query token", target: "/pickup/tracking/pickup-1/ws?token=not-a-jwt", wantCode: "INVALID_TOKEN"},
+		{name: "subprotocol token", target: "/pickup/tracking/pickup-1/ws", protocol: "bearer, not-a-jwt", wantCode: "INVALID_TOKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			req.Header.Set(fiber.HeaderConnection, "Upgrade")
+			req.Header.Set(fiber.HeaderUpgrade, "websocket")
+			req.Header.Set(fiber.HeaderSecWebSocketVersion, "13")
+			req.Header.Set(fiber.HeaderSecWebSocketKey, "dGhlIHNhbXBsZSBub25jZQ==")
+			if tt.protocol != "" {
+				req.Header.Set(fiber.HeaderSecWebSocketProtocol, tt.protocol)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("handshake: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusUnauthorized || body.Error != tt.wantCode {
+				t.Fatalf("got %d %q, want 401 %q", resp.StatusCode, body.Error, tt.wantCode)
+			}
+		})
+	}
+}
@@ -6,6 +6,16 @@ import (
 	"time"
 )
 
+// IndonesianLocation is the zone pickup schedules are written in. It falls back to a
+// fixed UTC+7 zone when the tz database is missing from the container.
+func IndonesianLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
 func FormatDateToIndonesianFormat(t time.Time) (string, error) {
 
 	utcTime := t.UTC()
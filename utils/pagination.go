@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxPageLimit = 100
+
+// ParsePagination reads the limit and page query parameters. A missing or invalid
+// limit falls back to defaultLimit and is capped at 100; the page starts at 1.
+func ParsePagination(c *fiber.Ctx, defaultLimit int) (limit, offset, page int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	page, err = strconv.Atoi(c.Query("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	return limit, (page - 1) * limit, page
+}